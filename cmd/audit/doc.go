@@ -0,0 +1,75 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file was auto-generated via go generate.
+// DO NOT UPDATE MANUALLY
+
+/*
+Command audit inspects the audit logs written by the auditors in
+v.io/x/ref/lib/security/audit.
+
+Usage:
+   audit [flags] <command>
+
+The audit commands are:
+   verify      Verify a hash-chained audit log
+   help        Display help for commands or topics
+
+The global flags are:
+ -metadata=<just specify -metadata to activate>
+   Displays metadata for the program and exits.
+ -time=false
+   Dump timing information to stderr before exiting the program.
+
+Audit verify - Verify a hash-chained audit log
+
+Verify checks that a log written by audit.NewChainAuditor has not been modified
+since it was written: that every entry is signed, and that no entry was
+altered, inserted, removed or reordered.
+
+Entries removed from the end of the log are not detected; compare the number of
+entries printed with one recorded elsewhere to detect them.
+
+Unless the -public-key flag is set, the log is verified with the public key
+recorded in it.
+
+Usage:
+   audit verify [flags] <file>
+
+<file> is the audit log to verify, or - for STDIN.
+
+The audit verify flags are:
+ -public-key=
+   If set, the base64url-encoded, DER-encoded public key, such as that printed
+   by "principal get publickey", that the log must be signed by.
+ -v=false
+   If true, prints each verified entry.
+
+Audit help - Display help for commands or topics
+
+Help with no args displays the usage of the parent command.
+
+Help with args displays the usage of the specified sub-command or help topic.
+
+"help ..." recursively displays help for all commands and topics.
+
+Usage:
+   audit help [flags] [command/topic ...]
+
+[command/topic ...] optionally identifies a specific sub-command or help topic.
+
+The audit help flags are:
+ -style=compact
+   The formatting style for help output:
+      compact   - Good for compact cmdline output.
+      full      - Good for cmdline output, shows all global flags.
+      godoc     - Good for godoc processing.
+      shortonly - Only output short description.
+   Override the default by setting the CMDLINE_STYLE environment variable.
+ -width=<terminal width>
+   Format output to this target width in runes, or unlimited if width < 0.
+   Defaults to the terminal width if available.  Override the default by setting
+   the CMDLINE_WIDTH environment variable.
+*/
+package main
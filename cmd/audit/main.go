@@ -0,0 +1,104 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The following enables go generate to generate the doc.go file.
+//go:generate go run $JIRI_ROOT/release/go/src/v.io/x/lib/cmdline/testdata/gendoc.go .
+
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"v.io/v23/security"
+	"v.io/x/lib/cmdline"
+	"v.io/x/ref/lib/security/audit"
+)
+
+var (
+	flagVerifyPublicKey string
+	flagVerifyVerbose   bool
+)
+
+func main() {
+	cmdline.Main(cmdAudit)
+}
+
+func init() {
+	cmdVerify.Flags.StringVar(&flagVerifyPublicKey, "public-key", "", "If set, the base64url-encoded, DER-encoded public key, such as that printed by \"principal get publickey\", that the log must be signed by.")
+	cmdVerify.Flags.BoolVar(&flagVerifyVerbose, "v", false, "If true, prints each verified entry.")
+}
+
+var cmdAudit = &cmdline.Command{
+	Name:  "audit",
+	Short: "inspects audit logs",
+	Long: `
+Command audit inspects the audit logs written by the auditors in
+v.io/x/ref/lib/security/audit.
+`,
+	Children: []*cmdline.Command{cmdVerify},
+}
+
+var cmdVerify = &cmdline.Command{
+	Runner: cmdline.RunnerFunc(runVerify),
+	Name:   "verify",
+	Short:  "Verify a hash-chained audit log",
+	Long: `
+Verify checks that a log written by audit.NewChainAuditor has not been
+modified since it was written: that every entry is signed, and that no entry
+was altered, inserted, removed or reordered.
+
+Entries removed from the end of the log are not detected; compare the number of
+entries printed with one recorded elsewhere to detect them.
+
+Unless the -public-key flag is set, the log is verified with the public key
+recorded in it.
+`,
+	ArgsName: "<file>",
+	ArgsLong: "<file> is the audit log to verify, or - for STDIN.",
+}
+
+func runVerify(env *cmdline.Env, args []string) error {
+	if len(args) != 1 {
+		return env.UsageErrorf("expected exactly one argument, got %d", len(args))
+	}
+	var key security.PublicKey
+	if flagVerifyPublicKey != "" {
+		der, err := base64.URLEncoding.DecodeString(flagVerifyPublicKey)
+		if err != nil {
+			return fmt.Errorf("invalid base64url encoding of public key: %v", err)
+		}
+		if key, err = security.UnmarshalPublicKey(der); err != nil {
+			return fmt.Errorf("invalid public key: %v", err)
+		}
+	}
+	in := env.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	var printRecord func(audit.Record)
+	if flagVerifyVerbose {
+		printRecord = func(r audit.Record) {
+			fmt.Fprintf(env.Stdout, "%v: %s(%s)", r.Timestamp.Format(time.RFC3339), r.Method, strings.Join(r.Arguments, ", "))
+			if len(r.Results) > 0 {
+				fmt.Fprintf(env.Stdout, " = (%s)", strings.Join(r.Results, ", "))
+			}
+			fmt.Fprintln(env.Stdout)
+		}
+	}
+	signer, n, err := audit.VerifyChain(in, key, printRecord)
+	if err != nil {
+		return fmt.Errorf("verification failed after %d entries: %v", n, err)
+	}
+	fmt.Fprintf(env.Stdout, "Verified %d entries signed by %v\n", n, signer)
+	return nil
+}
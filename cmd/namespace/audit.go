@@ -0,0 +1,252 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"v.io/x/lib/cmdline"
+
+	"v.io/v23"
+	"v.io/v23/context"
+	"v.io/v23/naming"
+	"v.io/v23/security"
+	"v.io/v23/security/access"
+	"v.io/v23/services/permissions"
+	"v.io/v23/verror"
+
+	"v.io/x/ref/lib/v23cmd"
+)
+
+var (
+	flagAuditBlessing string
+	flagAuditServers  bool
+	flagAuditApply    string
+	flagAuditDryRun   bool
+)
+
+func init() {
+	cmdPermissionsAudit.Flags.StringVar(&flagAuditBlessing, "blessing", "", "If provided, report the access that a principal with this blessing name has to each name.")
+	cmdPermissionsAudit.Flags.BoolVar(&flagAuditServers, "servers", false, "Also audit the permissions of the servers mounted in the subtree.")
+	cmdPermissionsAudit.Flags.StringVar(&flagAuditApply, "apply", "", `If provided, the path to a file containing a JSON-encoded Permissions object, or "-" for STDIN, that is set on every name in the subtree.`)
+	cmdPermissionsAudit.Flags.BoolVar(&flagAuditDryRun, "n", false, "With -apply, report the names whose permissions would change without changing them.")
+}
+
+var cmdPermissionsAudit = &cmdline.Command{
+	Runner:   v23cmd.RunnerFunc(runPermissionsAudit),
+	Name:     "audit",
+	Short:    "Audits the permissions of a subtree of the namespace",
+	ArgsName: "<name>",
+	ArgsLong: `
+<name> is the root of the subtree to audit.
+`,
+	Long: `
+Audit retrieves the permissions of every name in the subtree rooted at <name>,
+and optionally of every server mounted in it, and reports:
+
+  world-writable  a tag other than Read or Resolve is granted to all principals
+  no-admin        no one is granted the Admin tag
+  orphaned        nothing is mounted on the name and it has no children
+
+With -blessing, the tags granted to a principal with that blessing name are
+reported for each name.  With -apply, the given permissions are set on every
+name in the subtree whose permissions differ from them.
+`,
+}
+
+// auditEntry is the result of auditing a single name or mounted server.
+type auditEntry struct {
+	name     string
+	server   bool
+	perms    access.Permissions
+	version  string
+	err      error
+	findings []string
+}
+
+// readOnlyTags are the tags that may safely be granted to all principals.
+var readOnlyTags = map[string]bool{
+	string(access.Read):    true,
+	string(access.Resolve): true,
+}
+
+// audit fills in the findings for e.  children is true if the name has
+// children in the namespace and mounted is true if something is mounted on
+// it.
+func (e *auditEntry) audit(children, mounted bool) {
+	if e.err != nil {
+		return
+	}
+	var open []string
+	for tag, acl := range e.perms {
+		if !readOnlyTags[tag] && grantsAll(acl) {
+			open = append(open, tag)
+		}
+	}
+	if len(open) > 0 {
+		sort.Strings(open)
+		e.findings = append(e.findings, fmt.Sprintf("world-writable(%s)", strings.Join(open, ",")))
+	}
+	if acl, ok := e.perms[string(access.Admin)]; !ok || len(acl.In) == 0 {
+		e.findings = append(e.findings, "no-admin")
+	}
+	if !e.server && !children && !mounted {
+		e.findings = append(e.findings, "orphaned")
+	}
+}
+
+// grantsAll returns true if acl grants access to all principals.
+func grantsAll(acl access.AccessList) bool {
+	for _, p := range acl.In {
+		if p == security.AllPrincipals && len(acl.NotIn) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// grantedTags returns the tags that perms grant to a principal with blessing.
+func grantedTags(perms access.Permissions, blessing string) []string {
+	var tags []string
+	for tag, acl := range perms {
+		if acl.Includes(blessing) {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func runPermissionsAudit(ctx *context.T, env *cmdline.Env, args []string) error {
+	if expected, got := 1, len(args); expected != got {
+		return env.UsageErrorf("audit: incorrect number of arguments, expected %d, got %d", expected, got)
+	}
+	var template access.Permissions
+	if flagAuditApply != "" {
+		file := os.Stdin
+		if flagAuditApply != "-" {
+			var err error
+			if file, err = os.Open(flagAuditApply); err != nil {
+				return err
+			}
+			defer file.Close()
+		}
+		if err := json.NewDecoder(file).Decode(&template); err != nil {
+			return err
+		}
+	}
+	root := args[0]
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	ns := v23.GetNamespace(ctx)
+	c, err := ns.Glob(ctx, naming.Join(root, "..."))
+	if err != nil {
+		ctx.Infof("ns.Glob(%q) failed: %v", root, err)
+		return err
+	}
+	mounted := make(map[string]bool)
+	var servers []naming.MountedServer
+	var serverNames []string
+	for res := range c {
+		switch v := res.(type) {
+		case *naming.GlobReplyEntry:
+			// A server that is not a mount table may reply with its own
+			// name again, without any servers.
+			name := v.Value.Name
+			mounted[name] = mounted[name] || len(v.Value.Servers) > 0
+			if flagAuditServers && !v.Value.ServesMountTable {
+				for _, s := range v.Value.Servers {
+					servers = append(servers, s)
+					serverNames = append(serverNames, name)
+				}
+			}
+		case *naming.GlobReplyError:
+			fmt.Fprintf(env.Stderr, "Error: %s: %v\n", v.Value.Name, v.Value.Error)
+		}
+	}
+	names := make([]string, 0, len(mounted))
+	children := make(map[string]bool)
+	for name := range mounted {
+		names = append(names, name)
+		if i := strings.LastIndex(name, "/"); i > 0 {
+			children[name[:i]] = true
+		}
+	}
+	sort.Strings(names)
+
+	var entries []*auditEntry
+	for _, name := range names {
+		e := &auditEntry{name: name}
+		e.perms, e.version, e.err = ns.GetPermissions(ctx, name)
+		e.audit(children[name], mounted[name])
+		entries = append(entries, e)
+	}
+	for i, s := range servers {
+		e := &auditEntry{name: serverNames[i] + " @ " + s.Server, server: true}
+		e.perms, e.version, e.err = permissions.ObjectClient(s.Server).GetPermissions(ctx)
+		e.audit(false, true)
+		entries = append(entries, e)
+	}
+
+	var flagged int
+	for _, e := range entries {
+		if e.err != nil {
+			fmt.Fprintf(env.Stdout, "%s: error: %v\n", e.name, e.err)
+			continue
+		}
+		line := e.name
+		if flagAuditBlessing != "" {
+			line += fmt.Sprintf(" access=[%s]", strings.Join(grantedTags(e.perms, flagAuditBlessing), ","))
+		}
+		if len(e.findings) > 0 {
+			flagged++
+			line += " " + strings.Join(e.findings, " ")
+		}
+		fmt.Fprintln(env.Stdout, line)
+	}
+	fmt.Fprintf(env.Stdout, "%d names, %d servers audited, %d flagged\n", len(names), len(servers), flagged)
+
+	if template == nil {
+		return nil
+	}
+	for _, e := range entries {
+		if e.server || e.err != nil || reflect.DeepEqual(e.perms, template) {
+			continue
+		}
+		if flagAuditDryRun {
+			fmt.Fprintf(env.Stdout, "Would set permissions on %s\n", e.name)
+			continue
+		}
+		if err := setPermissions(ctx, e.name, template, e.version); err != nil {
+			fmt.Fprintf(env.Stderr, "Error: %s: %v\n", e.name, err)
+			continue
+		}
+		fmt.Fprintf(env.Stdout, "Set permissions on %s\n", e.name)
+	}
+	return nil
+}
+
+// setPermissions sets perms on name, retrying if the permissions changed
+// since they were read at version.
+func setPermissions(ctx *context.T, name string, perms access.Permissions, version string) error {
+	ns := v23.GetNamespace(ctx)
+	for {
+		err := ns.SetPermissions(ctx, name, perms, version)
+		if verror.ErrorID(err) != verror.ErrBadVersion.ID {
+			return err
+		}
+		ctx.Infof("SetPermissions(%q, %q) failed: %v, retrying...", name, version, err)
+		if _, version, err = ns.GetPermissions(ctx, name); err != nil {
+			return err
+		}
+	}
+}
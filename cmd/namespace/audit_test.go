@@ -0,0 +1,160 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"v.io/v23"
+	"v.io/v23/context"
+	"v.io/v23/options"
+	"v.io/v23/rpc"
+	"v.io/v23/security"
+	"v.io/v23/security/access"
+	"v.io/v23/services/permissions"
+	"v.io/x/lib/cmdline"
+	"v.io/x/ref/lib/v23cmd"
+	_ "v.io/x/ref/runtime/factories/generic"
+	"v.io/x/ref/services/mounttable/mounttablelib"
+	"v.io/x/ref/test"
+)
+
+// object is a server whose permissions grant Write to everyone.
+type object struct{}
+
+func (object) GetPermissions(*context.T, rpc.ServerCall) (access.Permissions, string, error) {
+	perms := access.Permissions{}.Add(test.TestBlessing, string(access.Admin))
+	perms.Add(security.AllPrincipals, string(access.Read), string(access.Write))
+	return perms, "0", nil
+}
+
+func (object) SetPermissions(*context.T, rpc.ServerCall, access.Permissions, string) error {
+	return nil
+}
+
+func runAudit(t *testing.T, ctx *context.T, args ...string) string {
+	flagAuditBlessing, flagAuditServers, flagAuditApply, flagAuditDryRun = "", false, "", false
+	var stdout, stderr bytes.Buffer
+	env := &cmdline.Env{Stdout: &stdout, Stderr: &stderr}
+	args = append([]string{"permissions", "audit"}, args...)
+	if err := v23cmd.ParseAndRunForTest(cmdRoot, ctx, env, args); err != nil {
+		t.Fatalf("%v failed: %v\n%s", args, err, stderr.String())
+	}
+	return stdout.String()
+}
+
+func TestPermissionsAudit(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+
+	mt, err := mounttablelib.NewMountTableDispatcher(ctx, "", "", "mounttable")
+	if err != nil {
+		t.Fatalf("NewMountTableDispatcher failed: %v", err)
+	}
+	ctx, mtServer, err := v23.WithNewDispatchingServer(ctx, "", mt, options.ServesMountTable(true))
+	if err != nil {
+		t.Fatalf("WithNewDispatchingServer failed: %v", err)
+	}
+	ns := v23.GetNamespace(ctx)
+	ns.SetRoots(mtServer.Status().Endpoints[0].Name())
+
+	_, server, err := v23.WithNewServer(ctx, "", permissions.ObjectServer(object{}), security.AllowEveryone())
+	if err != nil {
+		t.Fatalf("WithNewServer failed: %v", err)
+	}
+	serverName := server.Status().Endpoints[0].Name()
+	if err := ns.Mount(ctx, "x/a/b", serverName, time.Minute); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+	open := access.Permissions{}.Add(test.TestBlessing, string(access.Admin))
+	open.Add(security.AllPrincipals, string(access.Read), string(access.Write))
+	if err := ns.SetPermissions(ctx, "x/c", open, ""); err != nil {
+		t.Fatalf("SetPermissions failed: %v", err)
+	}
+
+	// The intermediate nodes have no permissions, and x/c is world-writable
+	// and has nothing mounted on it.
+	got := runAudit(t, ctx, "x")
+	want := `x no-admin
+x/a no-admin
+x/a/b no-admin
+x/c world-writable(Write) orphaned
+4 names, 0 servers audited, 4 flagged
+`
+	if got != want {
+		t.Errorf("Unexpected output. Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	// With -servers, the server mounted on x/a/b is audited too.
+	got = runAudit(t, ctx, "-servers", "x")
+	if want := "x/a/b @ " + serverName + " world-writable(Write)\n"; !strings.Contains(got, want) {
+		t.Errorf("Got %q, want it to contain %q", got, want)
+	}
+	if want := "4 names, 1 servers audited, 5 flagged\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("Got %q, want it to end with %q", got, want)
+	}
+
+	// With -blessing, the tags granted to the blessing are reported.
+	got = runAudit(t, ctx, "-blessing", test.TestBlessing+":alice", "x/c")
+	if want := "x/c access=[Admin,Read,Write] world-writable(Write) orphaned\n"; !strings.HasPrefix(got, want) {
+		t.Errorf("Got %q, want it to start with %q", got, want)
+	}
+	got = runAudit(t, ctx, "-blessing", "bob", "x/c")
+	if want := "x/c access=[Read,Write] world-writable(Write) orphaned\n"; !strings.HasPrefix(got, want) {
+		t.Errorf("Got %q, want it to start with %q", got, want)
+	}
+
+	// With -apply, the permissions are set on the names whose permissions
+	// differ.
+	fixed := access.Permissions{}.Add(test.TestBlessing, string(access.Admin), string(access.Write))
+	fixed.Add(security.AllPrincipals, string(access.Read), string(access.Resolve))
+	file, err := ioutil.TempFile("", "perms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	if err := json.NewEncoder(file).Encode(fixed); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	got = runAudit(t, ctx, "-apply", file.Name(), "-n", "x")
+	for _, name := range []string{"x", "x/a", "x/a/b", "x/c"} {
+		if want := "Would set permissions on " + name + "\n"; !strings.Contains(got, want) {
+			t.Errorf("Got %q, want it to contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "Set permissions on") {
+		t.Errorf("Permissions were set with -n: %q", got)
+	}
+	if perms, _, err := ns.GetPermissions(ctx, "x/c"); err != nil || !reflect.DeepEqual(perms.Normalize(), open.Normalize()) {
+		t.Errorf("Got (%v, %v), want (%v, nil)", perms, err, open)
+	}
+
+	got = runAudit(t, ctx, "-apply", file.Name(), "x/c")
+	if want := "Set permissions on x/c\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("Got %q, want it to end with %q", got, want)
+	}
+	if perms, _, err := ns.GetPermissions(ctx, "x/c"); err != nil || !reflect.DeepEqual(perms.Normalize(), fixed.Normalize()) {
+		t.Errorf("Got (%v, %v), want (%v, nil)", perms, err, fixed)
+	}
+	got = runAudit(t, ctx, "x/c")
+	if want := "x/c orphaned\n1 names, 0 servers audited, 1 flagged\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+
+	// Names that are not in the namespace are not audited.
+	got = runAudit(t, ctx, "y")
+	if want := "0 names, 0 servers audited, 0 flagged\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
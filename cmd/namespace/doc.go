@@ -24,6 +24,7 @@ The namespace commands are:
    resolvetomt Finds the address of the mounttable that holds an object name
    permissions Manipulates permissions on an entry in the namespace
    delete      Deletes a name from the namespace
+   dnszone     Exports parts of the namespace as a DNS zone
    help        Display help for commands or topics
 
 The global flags are:
@@ -55,6 +56,14 @@ The global flags are:
  -v23.proxy=
    object name of proxy service to use to export services across network
    boundaries
+ -v23.revocation.max-staleness=24h0m0s
+   Maximum age of the revocation snapshots against which discharges past their
+   freshness are validated.
+ -v23.revocation.refresh=1h0m0s
+   Interval at which revocation snapshots are fetched.
+ -v23.revocation.snapshots=
+   Names of the revocation list services to fetch revocation snapshots from,
+   comma separated.
  -v23.tcp.address=
    address to listen on
  -v23.tcp.protocol=wsh
@@ -159,6 +168,7 @@ Usage:
 The namespace permissions commands are:
    get         Gets permissions on a mount name
    set         Sets permissions on a mount name
+   audit       Audits the permissions of a subtree of the namespace
 
 Namespace permissions get - Gets permissions on a mount name
 
@@ -184,6 +194,37 @@ Usage:
 <permissions> is the path to a file containing a JSON-encoded Permissions object
 (defined in v.io/v23/security/access/types.vdl), or "-" for STDIN.
 
+Namespace permissions audit - Audits the permissions of a subtree of the namespace
+
+Audit retrieves the permissions of every name in the subtree rooted at <name>,
+and optionally of every server mounted in it, and reports:
+
+  world-writable  a tag other than Read or Resolve is granted to all principals
+  no-admin        no one is granted the Admin tag
+  orphaned        nothing is mounted on the name and it has no children
+
+With -blessing, the tags granted to a principal with that blessing name are
+reported for each name.  With -apply, the given permissions are set on every
+name in the subtree whose permissions differ from them.
+
+Usage:
+   namespace permissions audit [flags] <name>
+
+<name> is the root of the subtree to audit.
+
+The namespace permissions audit flags are:
+ -apply=
+   If provided, the path to a file containing a JSON-encoded Permissions
+   object, or "-" for STDIN, that is set on every name in the subtree.
+ -blessing=
+   If provided, report the access that a principal with this blessing name has
+   to each name.
+ -n=false
+   With -apply, report the names whose permissions would change without
+   changing them.
+ -servers=false
+   Also audit the permissions of the servers mounted in the subtree.
+
 Namespace delete - Deletes a name from the namespace
 
 Deletes a name from the namespace.
@@ -197,6 +238,31 @@ The namespace delete flags are:
  -r=false
    Delete all children of the name in addition to the name itself.
 
+Namespace dnszone - Exports parts of the namespace as a DNS zone
+
+Exports the mounted servers in the given subtrees of the namespace as a DNS zone
+file, which is written to stdout.
+
+The servers mounted on a name are published in TXT records of the form
+"v23=<object address>" for the DNS name formed by reversing the elements of the
+name, e.g. the servers mounted on apps/foo are published as foo.apps.<domain>.
+Names rooted at an address or at a fully qualified DNS name, e.g.
+mt.example.com./apps/foo, are published relative to their root.  Names with
+elements that are not valid DNS labels are skipped.
+
+Processes with V23_NS_DNS_SUFFIXES set to <domain> resolve these DNS names
+without the help of the namespace roots.
+
+Usage:
+   namespace dnszone [flags] <domain> <name> ...
+
+<domain> is the DNS domain of the zone. <name> is the root of a subtree of the
+namespace to export.
+
+The namespace dnszone flags are:
+ -ttl=5m0s
+   The TTL of the exported DNS records.
+
 Namespace help - Display help for commands or topics
 
 Help with no args displays the usage of the parent command.
@@ -13,6 +13,8 @@ import (
 	"os"
 	"regexp"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"v.io/x/lib/cmdline"
@@ -41,6 +43,7 @@ var (
 	flagInsecureResolveToMT bool
 	flagDeleteSubtree       bool
 	flagShallowResolve      bool
+	flagDNSZoneTTL          time.Duration
 )
 
 func init() {
@@ -49,6 +52,7 @@ func init() {
 	cmdResolve.Flags.BoolVar(&flagShallowResolve, "s", false, "True to perform a shallow resolution")
 	cmdResolveToMT.Flags.BoolVar(&flagInsecureResolveToMT, "insecure", false, "Insecure mode: May return results from untrusted servers and invoke Resolve on untrusted mounttables")
 	cmdDelete.Flags.BoolVar(&flagDeleteSubtree, "r", false, "Delete all children of the name in addition to the name itself.")
+	cmdDNSZone.Flags.DurationVar(&flagDNSZoneTTL, "ttl", 5*time.Minute, "The TTL of the exported DNS records.")
 }
 
 var cmdGlob = &cmdline.Command{
@@ -276,7 +280,7 @@ names required to resolve the name.
 The permissions are provided as an JSON-encoded version of the Permissions type
 defined in v.io/v23/security/access/types.vdl.
 `,
-	Children: []*cmdline.Command{cmdPermissionsGet, cmdPermissionsSet},
+	Children: []*cmdline.Command{cmdPermissionsGet, cmdPermissionsSet, cmdPermissionsAudit},
 }
 
 var cmdPermissionsSet = &cmdline.Command{
@@ -379,6 +383,126 @@ func runDelete(ctx *context.T, env *cmdline.Env, args []string) error {
 	return v23.GetNamespace(ctx).Delete(ctx, name, flagDeleteSubtree)
 }
 
+var cmdDNSZone = &cmdline.Command{
+	Runner:   v23cmd.RunnerFunc(runDNSZone),
+	Name:     "dnszone",
+	Short:    "Exports parts of the namespace as a DNS zone",
+	ArgsName: "<domain> <name> ...",
+	ArgsLong: `
+<domain> is the DNS domain of the zone.
+<name> is the root of a subtree of the namespace to export.
+`,
+	Long: `
+Exports the mounted servers in the given subtrees of the namespace as a DNS
+zone file, which is written to stdout.
+
+The servers mounted on a name are published in TXT records of the form
+"v23=<object address>" for the DNS name formed by reversing the elements of the
+name, e.g. the servers mounted on apps/foo are published as foo.apps.<domain>.
+Names rooted at an address or at a fully qualified DNS name, e.g.
+mt.example.com./apps/foo, are published relative to their root.  Names with elements that are not valid DNS
+labels are skipped.
+
+Processes with V23_NS_DNS_SUFFIXES set to <domain> resolve these DNS names
+without the help of the namespace roots.
+`,
+}
+
+var dnsLabel = regexp.MustCompile(`^[a-zA-Z0-9_]([-a-zA-Z0-9_]{0,61}[a-zA-Z0-9])?$`)
+
+// dnsOwner returns the DNS name, relative to the zone's origin, for a name
+// in the namespace.  Names rooted at an address, or at a fully qualified DNS
+// name such as "mt.example.com.", are exported relative to their root, and the
+// root itself is the origin of the zone.  Other dotted first elements are
+// ordinary mount names.
+func dnsOwner(name string) (string, bool) {
+	if naming.Rooted(name) {
+		_, name = naming.SplitAddressName(name)
+	} else if elems := strings.SplitN(name, "/", 2); len(elems[0]) > 1 && strings.HasSuffix(elems[0], ".") {
+		name = ""
+		if len(elems) > 1 {
+			name = elems[1]
+		}
+	}
+	if name = strings.Trim(name, "/"); name == "" {
+		return "@", true
+	}
+	elems := strings.Split(name, "/")
+	labels := make([]string, len(elems))
+	for i, e := range elems {
+		if !dnsLabel.MatchString(e) {
+			return "", false
+		}
+		labels[len(elems)-1-i] = e
+	}
+	return strings.Join(labels, "."), true
+}
+
+// txtRecord quotes s as the character strings of a TXT record, splitting it
+// into strings of at most 255 bytes.
+func txtRecord(s string) string {
+	var parts []string
+	for len(s) > 255 {
+		parts = append(parts, strconv.Quote(s[:255]))
+		s = s[255:]
+	}
+	parts = append(parts, strconv.Quote(s))
+	return strings.Join(parts, " ")
+}
+
+func runDNSZone(ctx *context.T, env *cmdline.Env, args []string) error {
+	if len(args) < 2 {
+		return env.UsageErrorf("dnszone: incorrect number of arguments, expected at least 2, got %d", len(args))
+	}
+	domain := strings.Trim(args[0], ".")
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	ns := v23.GetNamespace(ctx)
+	records := make(map[string][]string)
+	for _, name := range args[1:] {
+		c, err := ns.Glob(ctx, naming.Join(name, "..."))
+		if err != nil {
+			ctx.Infof("ns.Glob(%q) failed: %v", name, err)
+			return err
+		}
+		for res := range c {
+			switch v := res.(type) {
+			case *naming.GlobReplyEntry:
+				if len(v.Value.Servers) == 0 {
+					continue
+				}
+				owner, ok := dnsOwner(v.Value.Name)
+				if !ok {
+					fmt.Fprintf(env.Stderr, "Skipping %s: not a valid DNS name\n", v.Value.Name)
+					continue
+				}
+				for _, s := range v.Value.Servers {
+					records[owner] = append(records[owner], txtRecord("v23="+s.Server))
+				}
+				if !v.Value.ServesMountTable {
+					records[owner] = append(records[owner], txtRecord("v23mt=false"))
+				}
+			case *naming.GlobReplyError:
+				fmt.Fprintf(env.Stderr, "Error: %s: %v\n", v.Value.Name, v.Value.Error)
+			}
+		}
+	}
+	owners := make([]string, 0, len(records))
+	for owner := range records {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+	fmt.Fprintf(env.Stdout, "$ORIGIN %s.\n", domain)
+	fmt.Fprintf(env.Stdout, "$TTL %d\n", int(flagDNSZoneTTL.Seconds()))
+	for _, owner := range owners {
+		for _, r := range records[owner] {
+			fmt.Fprintf(env.Stdout, "%s\tIN\tTXT\t%s\n", owner, r)
+		}
+	}
+	return nil
+}
+
 var cmdRoot = &cmdline.Command{
 	Name:  "namespace",
 	Short: "resolves and manages names in the Vanadium namespace",
@@ -390,5 +514,5 @@ command line option or from environment variables that have a name starting
 with V23_NAMESPACE, e.g.  V23_NAMESPACE, V23_NAMESPACE_2, V23_NAMESPACE_GOOGLE,
 etc.  The command line options override the environment.
 `,
-	Children: []*cmdline.Command{cmdGlob, cmdMount, cmdUnmount, cmdResolve, cmdResolveToMT, cmdPermissions, cmdDelete},
+	Children: []*cmdline.Command{cmdGlob, cmdMount, cmdUnmount, cmdResolve, cmdResolveToMT, cmdPermissions, cmdDelete, cmdDNSZone},
 }
@@ -0,0 +1,62 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDNSOwner(t *testing.T) {
+	testcases := []struct {
+		name, owner string
+		ok          bool
+	}{
+		{"apps", "apps", true},
+		{"apps/foo", "foo.apps", true},
+		{"/apps/foo/", "foo.apps", true},
+		{"apps/foo_1/bar-2", "bar-2.foo_1.apps", true},
+		// Names rooted at an address are relative to the address.
+		{"/@6@tcp@10.0.0.1:8101@@@@@@/apps/foo", "foo.apps", true},
+		{"/10.0.0.1:8101/apps", "apps", true},
+		{"/@6@tcp@10.0.0.1:8101@@@@@@", "@", true},
+		// Names rooted at a fully qualified DNS name are relative to the
+		// DNS name.
+		{"mt.example.com./apps/foo", "foo.apps", true},
+		{"mt.example.com.", "@", true},
+		// Other dotted elements are ordinary mount names.
+		{"mt.example.com/apps/foo", "", false},
+		{"v1.2/apps", "", false},
+		// Elements that are not DNS labels.
+		{"apps/foo bar", "", false},
+		{"apps/-foo", "", false},
+		{"apps/foo.", "", false},
+		{"apps/" + strings.Repeat("a", 64), "", false},
+	}
+	for _, tc := range testcases {
+		owner, ok := dnsOwner(tc.name)
+		if owner != tc.owner || ok != tc.ok {
+			t.Errorf("dnsOwner(%q): got (%q, %v), want (%q, %v)", tc.name, owner, ok, tc.owner, tc.ok)
+		}
+	}
+}
+
+func TestTxtRecord(t *testing.T) {
+	long := strings.Repeat("a", 255)
+	testcases := []struct {
+		s, record string
+	}{
+		{"", `""`},
+		{"v23=/@6@tcp@10.0.0.1:8101@@@@@@", `"v23=/@6@tcp@10.0.0.1:8101@@@@@@"`},
+		{`v23="x"`, `"v23=\"x\""`},
+		{long, `"` + long + `"`},
+		{long + "b", `"` + long + `" "b"`},
+	}
+	for _, tc := range testcases {
+		if got := txtRecord(tc.s); got != tc.record {
+			t.Errorf("txtRecord(%q): got %s, want %s", tc.s, got, tc.record)
+		}
+	}
+}
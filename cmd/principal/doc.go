@@ -17,6 +17,8 @@ The principal commands are:
    create        Create a new principal and persist it into a directory
    fork          Fork a new principal from the principal that this tool is
                  running as and persist it into a directory
+   rotate        Rotate the key of the principal that this tool is running as
+                 into a new principal persisted in a directory
    seekblessings Seek blessings from a web-based Vanadium blessing service
    recvblessings Receive blessings sent by another principal and use them as the
                  default
@@ -65,6 +67,14 @@ The global flags are:
  -v23.proxy=
    object name of proxy service to use to export services across network
    boundaries
+ -v23.revocation.max-staleness=24h0m0s
+   Maximum age of the revocation snapshots against which discharges past their
+   freshness are validated.
+ -v23.revocation.refresh=1h0m0s
+   Interval at which revocation snapshots are fetched.
+ -v23.revocation.snapshots=
+   Names of the revocation list services to fetch revocation snapshots from,
+   comma separated.
  -v23.tcp.address=
    address to listen on
  -v23.tcp.protocol=wsh
@@ -99,6 +109,12 @@ The operation fails if the directory already contains a principal. In this case
 the --overwrite flag can be provided to clear the directory and write out the
 new principal.
 
+If --pkcs11-library is provided, the private key of the principal is held by a
+PKCS#11 token, e.g. a hardware security module, instead of being written out to
+the directory.  The user is prompted for the PIN of the token, and the key
+labeled --pkcs11-key is generated on the token labeled --pkcs11-token unless it
+already exists.  The PIN has to be provided whenever the principal is loaded.
+
 Usage:
    principal create [flags] <directory> [<blessing>]
 
@@ -111,6 +127,13 @@ principal will have no blessings.
 The principal create flags are:
  -overwrite=false
    If true, any existing principal data in the directory will be overwritten
+ -pkcs11-key=principal
+   The label of the private key of the principal on the PKCS#11 token.
+ -pkcs11-library=
+   If non-empty, the path of the PKCS#11 library of the token that holds the
+   private key of the principal. The --with-passphrase flag is ignored.
+ -pkcs11-token=
+   The label of the PKCS#11 token that holds the private key of the principal.
  -with-passphrase=true
    If true, the user is prompted for a passphrase to encrypt the principal.
    Otherwise, the principal is stored unencrypted.
@@ -153,6 +176,55 @@ The principal fork flags are:
    If true, the user is prompted for a passphrase to encrypt the principal.
    Otherwise, the principal is stored unencrypted.
 
+Principal rotate - Rotate the key of the principal that this tool is running as into a new principal persisted in a directory
+
+Creates a new principal with a new key and writes it out to the provided
+directory, as the successor of the principal specified by the environment that
+this tool is running in.
+
+The principal that this tool is running as signs a statement that the new key
+succeeds its key, which the new principal presents, along with the blessings of
+the principal, to each of the blessers provided by the --rebless-from flag.
+Blessers that support key succession, e.g. the identity server, the role server
+and the cluster agent, re-issue the blessings they granted to the old key for
+the new key, with the same names and caveats.  Blessers that only re-issue
+blessings to principals they still authorize, e.g. the role server, are asked
+again once the other blessings are re-issued.
+
+The re-issued blessings are stored in the blessing store of the new principal
+for the same peer patterns as in the store of the old principal, and the new
+principal recognizes the same blessing roots as the old one.  Blessings not
+granted by any of the blessers, e.g. self-blessings, are not carried over.
+
+Once the new principal is in use, the old one should be discarded.
+
+The operation fails if the directory already contains a principal. In this case
+the --overwrite flag can be provided to clear the directory and write out the
+new principal.  As with the create command, the private key of the new
+principal may be held by a PKCS#11 token.
+
+Usage:
+   principal rotate [flags] <directory>
+
+<directory> is the directory to which the new principal will be persisted.
+
+The principal rotate flags are:
+ -overwrite=false
+   If true, any existing principal data in the directory will be overwritten
+ -pkcs11-key=principal
+   The label of the private key of the principal on the PKCS#11 token.
+ -pkcs11-library=
+   If non-empty, the path of the PKCS#11 library of the token that holds the
+   private key of the principal. The --with-passphrase flag is ignored.
+ -pkcs11-token=
+   The label of the PKCS#11 token that holds the private key of the principal.
+ -rebless-from=
+   Comma-separated list of the object names of the blessers from which the
+   blessings of the new principal are obtained.
+ -with-passphrase=true
+   If true, the user is prompted for a passphrase to encrypt the principal.
+   Otherwise, the principal is stored unencrypted.
+
 Principal seekblessings - Seek blessings from a web-based Vanadium blessing service
 
 Seeks blessings from a web-based Vanadium blesser which requires the caller to
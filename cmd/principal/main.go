@@ -26,13 +26,16 @@ import (
 	"v.io/v23/options"
 	"v.io/v23/rpc"
 	"v.io/v23/security"
+	"v.io/v23/verror"
 	"v.io/v23/vom"
 	"v.io/x/lib/cmdline"
 	"v.io/x/ref"
 	vsecurity "v.io/x/ref/lib/security"
 	"v.io/x/ref/lib/security/passphrase"
+	"v.io/x/ref/lib/security/pkcs11"
 	"v.io/x/ref/lib/v23cmd"
 	_ "v.io/x/ref/runtime/factories/roaming"
+	"v.io/x/ref/services/succession"
 )
 
 var (
@@ -69,6 +72,14 @@ var (
 	flagWithPassphrase  bool
 	flagRemoteArgFile   string
 
+	// Flags for the "create" and "rotate" commands
+	flagCreatePKCS11Library string
+	flagCreatePKCS11Token   string
+	flagCreatePKCS11Key     string
+
+	// Flags for the "rotate" command
+	flagRotateReblessFrom string
+
 	// Flags for the "recvblessings" command
 	flagRecvBlessingsSetDefault bool
 	flagRecvBlessingsForPeer    string
@@ -634,6 +645,12 @@ V23_CREDENTIALS environment variable for other vanadium applications.
 The operation fails if the directory already contains a principal. In this case
 the --overwrite flag can be provided to clear the directory and write out the
 new principal.
+
+If --pkcs11-library is provided, the private key of the principal is held by a
+PKCS#11 token, e.g. a hardware security module, instead of being written out to
+the directory.  The user is prompted for the PIN of the token, and the key
+labeled --pkcs11-key is generated on the token labeled --pkcs11-token unless it
+already exists.  The PIN has to be provided whenever the principal is loaded.
 `,
 		ArgsName: "<directory> [<blessing>]",
 		ArgsLong: `
@@ -653,14 +670,7 @@ principal will have no blessings.
 					return err
 				}
 			}
-			var pass []byte
-			if flagWithPassphrase {
-				var err error
-				if pass, err = passphrase.Get("Enter passphrase (entering nothing will store the principal key unencrypted): "); err != nil {
-					return err
-				}
-			}
-			p, err := vsecurity.CreatePersistentPrincipal(dir, pass)
+			p, err := createPersistentPrincipal(dir)
 			if err != nil {
 				return err
 			}
@@ -754,6 +764,121 @@ forked principal.
 		}),
 	}
 
+	cmdRotate = &cmdline.Command{
+		Name:  "rotate",
+		Short: "Rotate the key of the principal that this tool is running as into a new principal persisted in a directory",
+		Long: `
+Creates a new principal with a new key and writes it out to the provided
+directory, as the successor of the principal specified by the environment that
+this tool is running in.
+
+The principal that this tool is running as signs a statement that the new key
+succeeds its key, which the new principal presents, along with the blessings of
+the principal, to each of the blessers provided by the --rebless-from flag.
+Blessers that support key succession, e.g. the identity server, the role server
+and the cluster agent, re-issue the blessings they granted to the old key for
+the new key, with the same names and caveats.  Blessers that only re-issue
+blessings to principals they still authorize, e.g. the role server, are asked
+again once the other blessings are re-issued.
+
+The re-issued blessings are stored in the blessing store of the new principal
+for the same peer patterns as in the store of the old principal, and the new
+principal recognizes the same blessing roots as the old one.  Blessings not
+granted by any of the blessers, e.g. self-blessings, are not carried over.
+
+Once the new principal is in use, the old one should be discarded.
+
+The operation fails if the directory already contains a principal. In this case
+the --overwrite flag can be provided to clear the directory and write out the
+new principal.  As with the create command, the private key of the new
+principal may be held by a PKCS#11 token.
+`,
+		ArgsName: "<directory>",
+		ArgsLong: `
+<directory> is the directory to which the new principal will be persisted.
+	`,
+		Runner: v23cmd.RunnerFunc(func(ctx *context.T, env *cmdline.Env, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("requires exactly one argument: <directory>, provided %d", len(args))
+			}
+			dir := args[0]
+			var blessers []string
+			for _, name := range strings.Split(flagRotateReblessFrom, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					blessers = append(blessers, name)
+				}
+			}
+			if flagCreateOverwrite {
+				if err := os.RemoveAll(dir); err != nil {
+					return err
+				}
+			}
+			p, err := createPersistentPrincipal(dir)
+			if err != nil {
+				return err
+			}
+			old := v23.GetPrincipal(ctx)
+			statement, err := vsecurity.NewKeySuccession(old, p.PublicKey())
+			if err != nil {
+				return fmt.Errorf("failed to create the key succession: %v", err)
+			}
+			for pattern, keys := range old.Roots().Dump() {
+				for _, key := range keys {
+					der, err := key.MarshalBinary()
+					if err != nil {
+						return err
+					}
+					if err := p.Roots().Add(der, pattern); err != nil {
+						return fmt.Errorf("failed to add root %v for %v: %v", key, pattern, err)
+					}
+				}
+			}
+			if ctx, err = v23.WithPrincipal(ctx, p); err != nil {
+				return err
+			}
+			r := &reblesser{ctx: ctx, succession: statement, blessers: blessers}
+			// Blessers such as the role server only re-issue blessings to
+			// principals that they still authorize, which the new principal
+			// may only be once the blessings it is authorized with, e.g.
+			// those of the identity server, are re-issued.  The blessings
+			// that were refused are then re-issued in a second pass.
+			for _, final := range []bool{false, true} {
+				complete := true
+				for pattern, blessings := range old.BlessingStore().PeerBlessings() {
+					reissued, ok, err := r.rebless(blessings, final)
+					if err != nil {
+						return err
+					}
+					complete = complete && ok
+					if reissued.IsZero() {
+						continue
+					}
+					if _, err := p.BlessingStore().Set(reissued, pattern); err != nil {
+						return fmt.Errorf("failed to set blessings %v for peers %v: %v", reissued, pattern, err)
+					}
+				}
+				def, _ := old.BlessingStore().Default()
+				reissued, ok, err := r.rebless(def, final)
+				if err != nil {
+					return err
+				}
+				complete = complete && ok
+				if !reissued.IsZero() {
+					if err := p.BlessingStore().SetDefault(reissued); err != nil {
+						return fmt.Errorf("failed to set blessings %v as default: %v", reissued, err)
+					}
+				}
+				if complete {
+					break
+				}
+			}
+			for _, b := range r.reissued {
+				fmt.Fprintf(env.Stdout, "Re-issued %v\n", security.BlessingNames(p, b))
+			}
+			return nil
+		}),
+	}
+
 	cmdSeekBlessings = &cmdline.Command{
 		Name:  "seekblessings",
 		Short: "Seek blessings from a web-based Vanadium blessing service",
@@ -1050,6 +1175,16 @@ func main() {
 
 	cmdCreate.Flags.BoolVar(&flagCreateOverwrite, "overwrite", false, "If true, any existing principal data in the directory will be overwritten")
 	cmdCreate.Flags.BoolVar(&flagWithPassphrase, "with-passphrase", true, "If true, the user is prompted for a passphrase to encrypt the principal. Otherwise, the principal is stored unencrypted.")
+	cmdCreate.Flags.StringVar(&flagCreatePKCS11Library, "pkcs11-library", "", "If non-empty, the path of the PKCS#11 library of the token that holds the private key of the principal. The --with-passphrase flag is ignored.")
+	cmdCreate.Flags.StringVar(&flagCreatePKCS11Token, "pkcs11-token", "", "The label of the PKCS#11 token that holds the private key of the principal.")
+	cmdCreate.Flags.StringVar(&flagCreatePKCS11Key, "pkcs11-key", "principal", "The label of the private key of the principal on the PKCS#11 token.")
+
+	cmdRotate.Flags.BoolVar(&flagCreateOverwrite, "overwrite", false, "If true, any existing principal data in the directory will be overwritten")
+	cmdRotate.Flags.BoolVar(&flagWithPassphrase, "with-passphrase", true, "If true, the user is prompted for a passphrase to encrypt the principal. Otherwise, the principal is stored unencrypted.")
+	cmdRotate.Flags.StringVar(&flagCreatePKCS11Library, "pkcs11-library", "", "If non-empty, the path of the PKCS#11 library of the token that holds the private key of the principal. The --with-passphrase flag is ignored.")
+	cmdRotate.Flags.StringVar(&flagCreatePKCS11Token, "pkcs11-token", "", "The label of the PKCS#11 token that holds the private key of the principal.")
+	cmdRotate.Flags.StringVar(&flagCreatePKCS11Key, "pkcs11-key", "principal", "The label of the private key of the principal on the PKCS#11 token.")
+	cmdRotate.Flags.StringVar(&flagRotateReblessFrom, "rebless-from", "", "Comma-separated list of the object names of the blessers from which the blessings of the new principal are obtained.")
 
 	cmdRecvBlessings.Flags.BoolVar(&flagRecvBlessingsSetDefault, "set-default", true, "If true, the blessings received will be set as the default blessing in the store")
 	cmdRecvBlessings.Flags.StringVar(&flagRecvBlessingsForPeer, "for-peer", string(security.AllPrincipals), "If non-empty, the blessings received will be marked for peers matching this pattern in the store")
@@ -1096,11 +1231,104 @@ Command principal creates and manages Vanadium principals and blessings.
 
 All objects are printed using base64url-vom-encoding.
 `,
-		Children: []*cmdline.Command{cmdCreate, cmdFork, cmdSeekBlessings, cmdRecvBlessings, cmdDump, cmdDumpBlessings, cmdDumpRoots, cmdBlessSelf, cmdBless, cmdSet, cmdGet, cmdRecognize, cmdUnion},
+		Children: []*cmdline.Command{cmdCreate, cmdFork, cmdRotate, cmdSeekBlessings, cmdRecvBlessings, cmdDump, cmdDumpBlessings, cmdDumpRoots, cmdBlessSelf, cmdBless, cmdSet, cmdGet, cmdRecognize, cmdUnion},
 	}
 	cmdline.Main(root)
 }
 
+// createPKCS11Principal creates a principal in dir whose private key is held
+// by the PKCS#11 token specified by the --pkcs11-* flags, generating the key
+// if it does not exist yet.
+func createPKCS11Principal(dir string) (security.Principal, error) {
+	if flagCreatePKCS11Token == "" {
+		return nil, fmt.Errorf("--pkcs11-token must be provided with --pkcs11-library")
+	}
+	pin, err := passphrase.Get(fmt.Sprintf("Enter the PIN of token %q: ", flagCreatePKCS11Token))
+	if err != nil {
+		return nil, err
+	}
+	config := pkcs11.Config(flagCreatePKCS11Library, flagCreatePKCS11Token, flagCreatePKCS11Key)
+	// The signer is only used to check that the key exists, the principal
+	// opens its own session with the token.
+	signer, err := pkcs11.NewSigner(config.Params, pin)
+	switch {
+	case err == nil:
+		signer.(io.Closer).Close()
+	case verror.ErrorID(err) != pkcs11.ErrNoKey.ID:
+		return nil, err
+	default:
+		if err := pkcs11.GenerateKey(config.Params, pin); err != nil {
+			return nil, err
+		}
+	}
+	return vsecurity.CreatePersistentPrincipalWithSigner(dir, config, pin)
+}
+
+// createPersistentPrincipal creates a principal in dir, whose private key is
+// either held by the PKCS#11 token specified by the --pkcs11-* flags, or
+// stored in dir and encrypted with a passphrase if --with-passphrase is set.
+func createPersistentPrincipal(dir string) (security.Principal, error) {
+	if flagCreatePKCS11Library != "" {
+		return createPKCS11Principal(dir)
+	}
+	var pass []byte
+	if flagWithPassphrase {
+		var err error
+		if pass, err = passphrase.Get("Enter passphrase (entering nothing will store the principal key unencrypted): "); err != nil {
+			return nil, err
+		}
+	}
+	return vsecurity.CreatePersistentPrincipal(dir, pass)
+}
+
+// reblesser obtains from blessers the blessings of the successor of a key.
+type reblesser struct {
+	ctx        *context.T
+	succession vsecurity.KeySuccession
+	blessers   []string
+	// done and reissued are the blessings already re-issued, and the
+	// blessings they were re-issued as.
+	done, reissued []security.Blessings
+}
+
+// rebless returns the union of the blessings re-issued by the blessers for
+// the successor, in place of the blessings of the old key.  Unless final is
+// set, the blessers that deny access to the successor are skipped, in which
+// case complete is false.
+func (r *reblesser) rebless(blessings security.Blessings, final bool) (reissued security.Blessings, complete bool, err error) {
+	if blessings.IsZero() {
+		return security.Blessings{}, true, nil
+	}
+	for i, b := range r.done {
+		if b.Equivalent(blessings) {
+			return r.reissued[i], true, nil
+		}
+	}
+	var union []security.Blessings
+	complete = true
+	for _, name := range r.blessers {
+		b, err := succession.SuccessorBlesserClient(name).BlessSuccessor(r.ctx, r.succession, blessings)
+		switch id := verror.ErrorID(err); {
+		case id == vsecurity.ErrNotGrantedByBlesser.ID:
+			continue
+		case !final && (id == verror.ErrNoAccess.ID || id == verror.ErrNoExistOrNoAccess.ID):
+			complete = false
+			continue
+		case err != nil:
+			return security.Blessings{}, false, fmt.Errorf("failed to re-issue %v from %v: %v", blessings, name, err)
+		}
+		union = append(union, b)
+	}
+	if reissued, err = security.UnionOfBlessings(union...); err != nil {
+		return security.Blessings{}, false, err
+	}
+	if complete {
+		r.done = append(r.done, blessings)
+		r.reissued = append(r.reissued, reissued)
+	}
+	return reissued, complete, nil
+}
+
 func decodeBlessings(fname string) (security.Blessings, error) {
 	var b security.Blessings
 	err := decode(fname, &b)
@@ -51,6 +51,14 @@ The global flags are:
  -v23.proxy=
    object name of proxy service to use to export services across network
    boundaries
+ -v23.revocation.max-staleness=24h0m0s
+   Maximum age of the revocation snapshots against which discharges past their
+   freshness are validated.
+ -v23.revocation.refresh=1h0m0s
+   Interval at which revocation snapshots are fetched.
+ -v23.revocation.snapshots=
+   Names of the revocation list services to fetch revocation snapshots from,
+   comma separated.
  -v23.tcp.address=
    address to listen on
  -v23.tcp.protocol=wsh
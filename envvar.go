@@ -65,6 +65,14 @@ const (
 
 	// When set and non-empty, the namespace client will not use caching.
 	EnvDisableNamespaceCache = "V23_DISABLE_NS_CACHE"
+
+	// A comma-separated list of DNS domains.  When set, the namespace
+	// client resolves names whose first element is within one of these
+	// domains using DNS SRV and TXT records rather than the namespace
+	// roots.
+	//
+	// Note that this must not start with EnvNamespacePrefix.
+	EnvNamespaceDNSSuffixes = "V23_NS_DNS_SUFFIXES"
 )
 
 // EnvNamespaceRoots returns the set of namespace roots to be used by the
@@ -19,6 +19,10 @@ import (
 const (
 	msPerSec = int64(time.Second / time.Millisecond)
 	nsPerMs  = int64(time.Millisecond / time.Nanosecond)
+
+	// defaultAdTTL is how long scanners keep an advertisement once they no
+	// longer see it.
+	defaultAdTTL = 30 * time.Second
 )
 
 func (d *idiscovery) advertise(ctx *context.T, session sessionId, ad *discovery.Advertisement, visibility []security.BlessingPattern) (<-chan struct{}, error) {
@@ -36,8 +40,12 @@ func (d *idiscovery) advertise(ctx *context.T, session sessionId, ad *discovery.
 	if err := encrypt(ctx, adinfo, visibility); err != nil {
 		return nil, err
 	}
+	if err := encryptFields(ctx, adinfo, getFieldVisibility(ctx)); err != nil {
+		return nil, err
+	}
 	hashAd(adinfo)
 	adinfo.TimestampNs = d.newAdTimestampNs()
+	adinfo.TtlNs = int64(defaultAdTTL)
 
 	ctx, cancel, err := d.addTask(ctx)
 	if err != nil {
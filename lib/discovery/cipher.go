@@ -9,10 +9,13 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"golang.org/x/crypto/nacl/secretbox"
 
 	"v.io/v23/context"
+	"v.io/v23/discovery"
 	"v.io/v23/security"
 
 	"v.io/x/ref/lib/security/bcrypter"
@@ -112,6 +115,208 @@ func decrypt(ctx *context.T, adinfo *AdInfo) error {
 	return nil
 }
 
+type fieldVisibilityKey struct{}
+
+// WithFieldVisibility returns a context that restricts the visibility of the
+// attributes and attachments with the given names in advertisements, so that
+// only users who possess blessings matching one of the blessing patterns of a
+// name can see the attribute or attachment of the name. The visibility of the
+// rest of the advertisement is not changed.
+func WithFieldVisibility(ctx *context.T, visibility map[string][]security.BlessingPattern) *context.T {
+	return context.WithValue(ctx, fieldVisibilityKey{}, visibility)
+}
+
+func getFieldVisibility(ctx *context.T) map[string][]security.BlessingPattern {
+	visibility, _ := ctx.Value(fieldVisibilityKey{}).(map[string][]security.BlessingPattern)
+	return visibility
+}
+
+const (
+	attributeField  = 0
+	attachmentField = 1
+)
+
+func fieldNonce(kind byte, i int) *[24]byte {
+	var n [24]byte
+	binary.LittleEndian.PutUint64(n[:], uint64(i))
+	n[8] = kind
+	return &n
+}
+
+// encryptFields encrypts the attributes and attachments of the advertisement
+// that have their own visibility. The attributes and attachments that are
+// visible to the same blessing patterns are encrypted with the same key.
+func encryptFields(ctx *context.T, adinfo *AdInfo, visibility map[string][]security.BlessingPattern) error {
+	groups := make(map[string]*FieldEncryption)
+	patterns := make(map[string][]security.BlessingPattern)
+	for name, vis := range visibility {
+		_, isAttr := adinfo.Ad.Attributes[name]
+		_, isAttachment := adinfo.Ad.Attachments[name]
+		if len(vis) == 0 || !(isAttr || isAttachment) {
+			continue
+		}
+		sorted := make([]string, len(vis))
+		for i, pattern := range vis {
+			sorted[i] = string(pattern)
+		}
+		sort.Strings(sorted)
+		key := strings.Join(sorted, ",")
+		group := groups[key]
+		if group == nil {
+			group = &FieldEncryption{}
+			groups[key] = group
+			patterns[key] = vis
+		}
+		if isAttr {
+			group.Attributes = append(group.Attributes, name)
+		}
+		if isAttachment {
+			group.Attachments = append(group.Attachments, name)
+		}
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+
+	// Note that we should not modify the maps of the advertisement directly
+	// here since they are owned by the caller.
+	attrs := make(discovery.Attributes, len(adinfo.Ad.Attributes))
+	for k, v := range adinfo.Ad.Attributes {
+		attrs[k] = v
+	}
+	attachments := make(discovery.Attachments, len(adinfo.Ad.Attachments))
+	for k, v := range adinfo.Ad.Attachments {
+		attachments[k] = v
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	encryptions := make([]FieldEncryption, 0, len(groups))
+	for _, key := range keys {
+		var sharedKey [32]byte
+		if _, err := rand.Read(sharedKey[:]); err != nil {
+			return err
+		}
+		group := groups[key]
+		for _, pattern := range patterns[key] {
+			wrapped, err := wrapSharedKey(ctx, sharedKey, pattern)
+			if err != nil {
+				return err
+			}
+			group.Keys = append(group.Keys, wrapped)
+		}
+		sort.Strings(group.Attributes)
+		for i, name := range group.Attributes {
+			attrs[name] = string(secretbox.Seal(nil, []byte(attrs[name]), fieldNonce(attributeField, i), &sharedKey))
+		}
+		sort.Strings(group.Attachments)
+		for i, name := range group.Attachments {
+			attachments[name] = secretbox.Seal(nil, attachments[name], fieldNonce(attachmentField, i), &sharedKey)
+		}
+		encryptions = append(encryptions, *group)
+	}
+	adinfo.Ad.Attributes = attrs
+	adinfo.Ad.Attachments = attachments
+	adinfo.FieldEncryptions = encryptions
+	return nil
+}
+
+// decryptFields decrypts the attributes and attachments of the advertisement
+// that have their own visibility. The attributes and attachments that cannot
+// be decrypted are removed from the advertisement.
+//
+// The attachments that are not included in the advertisement are left to be
+// decrypted by decryptAttachment when they are fetched.
+func decryptFields(ctx *context.T, adinfo *AdInfo) error {
+	if len(adinfo.FieldEncryptions) == 0 {
+		return nil
+	}
+
+	// Note that we should not modify the maps directly here since the underlying
+	// plugins may cache advertisements.
+	attrs := make(discovery.Attributes, len(adinfo.Ad.Attributes))
+	for k, v := range adinfo.Ad.Attributes {
+		attrs[k] = v
+	}
+	attachments := make(discovery.Attachments, len(adinfo.Ad.Attachments))
+	for k, v := range adinfo.Ad.Attachments {
+		attachments[k] = v
+	}
+
+	for _, group := range adinfo.FieldEncryptions {
+		sharedKey := unwrapFieldKey(ctx, group)
+		for i, name := range group.Attributes {
+			encrypted, ok := attrs[name]
+			if !ok {
+				continue
+			}
+			delete(attrs, name)
+			if sharedKey == nil {
+				continue
+			}
+			attr, ok := secretbox.Open(nil, []byte(encrypted), fieldNonce(attributeField, i), sharedKey)
+			if !ok {
+				return errors.New("decryption error")
+			}
+			attrs[name] = string(attr)
+		}
+		for i, name := range group.Attachments {
+			encrypted, ok := attachments[name]
+			if !ok {
+				continue
+			}
+			delete(attachments, name)
+			if sharedKey == nil {
+				continue
+			}
+			data, ok := secretbox.Open(nil, encrypted, fieldNonce(attachmentField, i), sharedKey)
+			if !ok {
+				return errors.New("decryption error")
+			}
+			attachments[name] = data
+		}
+	}
+	adinfo.Ad.Attributes = attrs
+	adinfo.Ad.Attachments = attachments
+	return nil
+}
+
+// decryptAttachment decrypts an attachment that is fetched separately from
+// its advertisement. It returns errNoPermission if the attachment cannot be
+// decrypted.
+func decryptAttachment(ctx *context.T, encryptions []FieldEncryption, name string, data []byte) ([]byte, error) {
+	for _, group := range encryptions {
+		for i, n := range group.Attachments {
+			if n != name {
+				continue
+			}
+			sharedKey := unwrapFieldKey(ctx, group)
+			if sharedKey == nil {
+				return nil, errNoPermission
+			}
+			decrypted, ok := secretbox.Open(nil, data, fieldNonce(attachmentField, i), sharedKey)
+			if !ok {
+				return nil, errors.New("decryption error")
+			}
+			return decrypted, nil
+		}
+	}
+	// Not encrypted.
+	return data, nil
+}
+
+func unwrapFieldKey(ctx *context.T, group FieldEncryption) *[32]byte {
+	for _, key := range group.Keys {
+		if sharedKey, err := unwrapSharedKey(ctx, key); err == nil {
+			return sharedKey
+		}
+	}
+	return nil
+}
+
 func wrapSharedKey(ctx *context.T, sharedKey [32]byte, pattern security.BlessingPattern) (EncryptionKey, error) {
 	crypter := bcrypter.GetCrypter(ctx)
 	if crypter == nil {
@@ -133,6 +133,34 @@ func (x *AdStatus) VDLRead(dec vdl.Decoder) error {
 	return nil
 }
 
+type LostReason byte
+
+func (LostReason) __VDLReflect(struct {
+	Name string `vdl:"v.io/x/ref/lib/discovery.LostReason"`
+}) {
+}
+
+func (x LostReason) VDLIsZero() bool {
+	return x == 0
+}
+
+func (x LostReason) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.WriteValueUint(__VDLType_byte_10, uint64(x)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (x *LostReason) VDLRead(dec vdl.Decoder) error {
+	switch value, err := dec.ReadValueUint(8); {
+	case err != nil:
+		return err
+	default:
+		*x = LostReason(value)
+	}
+	return nil
+}
+
 // An AdHash is a hash of an advertisement.
 type AdHash [8]byte
 
@@ -160,6 +188,110 @@ func (x *AdHash) VDLRead(dec vdl.Decoder) error {
 	return nil
 }
 
+// FieldEncryption represents attributes and attachments of an advertisement
+// that are encrypted with the same key.
+type FieldEncryption struct {
+	// Names of the encrypted attributes and attachments.
+	Attributes  []string
+	Attachments []string
+	// The key that the attributes and attachments are encrypted with,
+	// encrypted with IBE for each of the blessing patterns that they are
+	// visible to.
+	Keys []EncryptionKey
+}
+
+func (FieldEncryption) __VDLReflect(struct {
+	Name string `vdl:"v.io/x/ref/lib/discovery.FieldEncryption"`
+}) {
+}
+
+func (x FieldEncryption) VDLIsZero() bool {
+	if len(x.Attributes) != 0 {
+		return false
+	}
+	if len(x.Attachments) != 0 {
+		return false
+	}
+	if len(x.Keys) != 0 {
+		return false
+	}
+	return true
+}
+
+func (x FieldEncryption) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_struct_11); err != nil {
+		return err
+	}
+	if len(x.Attributes) != 0 {
+		if err := enc.NextField(0); err != nil {
+			return err
+		}
+		if err := __VDLWriteAnon_list_2(enc, x.Attributes); err != nil {
+			return err
+		}
+	}
+	if len(x.Attachments) != 0 {
+		if err := enc.NextField(1); err != nil {
+			return err
+		}
+		if err := __VDLWriteAnon_list_2(enc, x.Attachments); err != nil {
+			return err
+		}
+	}
+	if len(x.Keys) != 0 {
+		if err := enc.NextField(2); err != nil {
+			return err
+		}
+		if err := __VDLWriteAnon_list_1(enc, x.Keys); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x *FieldEncryption) VDLRead(dec vdl.Decoder) error {
+	*x = FieldEncryption{}
+	if err := dec.StartValue(__VDLType_struct_11); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	for {
+		index, err := dec.NextField()
+		switch {
+		case err != nil:
+			return err
+		case index == -1:
+			return dec.FinishValue()
+		}
+		if decType != __VDLType_struct_11 {
+			index = __VDLType_struct_11.FieldIndexByName(decType.Field(index).Name)
+			if index == -1 {
+				if err := dec.SkipValue(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		switch index {
+		case 0:
+			if err := __VDLReadAnon_list_2(dec, &x.Attributes); err != nil {
+				return err
+			}
+		case 1:
+			if err := __VDLReadAnon_list_2(dec, &x.Attachments); err != nil {
+				return err
+			}
+		case 2:
+			if err := __VDLReadAnon_list_1(dec, &x.Keys); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 // AdInfo represents advertisement information for discovery.
 type AdInfo struct {
 	Ad discovery.Advertisement
@@ -169,6 +301,10 @@ type AdInfo struct {
 	// If the advertisement is encrypted, then the data required to
 	// decrypt it. The format of this data is a function of the algorithm.
 	EncryptionKeys []EncryptionKey
+	// Attributes and attachments that are encrypted separately, so that they
+	// are only revealed to some of the principals that can see the
+	// advertisement.
+	FieldEncryptions []FieldEncryption
 	// Hash of the current advertisement. This does not include the fields below.
 	Hash AdHash
 	// Unix time in nanoseconds at which the advertisement was created.
@@ -181,6 +317,12 @@ type AdInfo struct {
 	// TODO(jhahn): Add proximity.
 	// TODO(jhahn): Use proximity for Lost.
 	Lost bool
+	// Time-to-live of the advertisement in nanoseconds. Scanners consider the
+	// advertisement lost if it is not seen again within its TTL. Zero means
+	// that the advertisement is lost only when a plugin reports it lost.
+	TtlNs int64
+	// Reason why the advertisement was lost. Valid for lost advertisements.
+	LostReason LostReason
 }
 
 func (AdInfo) __VDLReflect(struct {
@@ -198,6 +340,9 @@ func (x AdInfo) VDLIsZero() bool {
 	if len(x.EncryptionKeys) != 0 {
 		return false
 	}
+	if len(x.FieldEncryptions) != 0 {
+		return false
+	}
 	if x.Hash != (AdHash{}) {
 		return false
 	}
@@ -213,6 +358,12 @@ func (x AdInfo) VDLIsZero() bool {
 	if x.Lost {
 		return false
 	}
+	if x.TtlNs != 0 {
+		return false
+	}
+	if x.LostReason != 0 {
+		return false
+	}
 	return true
 }
 
@@ -241,18 +392,26 @@ func (x AdInfo) VDLWrite(enc vdl.Encoder) error {
 			return err
 		}
 	}
+	if len(x.FieldEncryptions) != 0 {
+		if err := enc.NextField(3); err != nil {
+			return err
+		}
+		if err := __VDLWriteAnon_list_3(enc, x.FieldEncryptions); err != nil {
+			return err
+		}
+	}
 	if x.Hash != (AdHash{}) {
-		if err := enc.NextFieldValueBytes(3, __VDLType_array_5, x.Hash[:]); err != nil {
+		if err := enc.NextFieldValueBytes(4, __VDLType_array_5, x.Hash[:]); err != nil {
 			return err
 		}
 	}
 	if x.TimestampNs != 0 {
-		if err := enc.NextFieldValueInt(4, vdl.Int64Type, x.TimestampNs); err != nil {
+		if err := enc.NextFieldValueInt(5, vdl.Int64Type, x.TimestampNs); err != nil {
 			return err
 		}
 	}
 	if len(x.DirAddrs) != 0 {
-		if err := enc.NextField(5); err != nil {
+		if err := enc.NextField(6); err != nil {
 			return err
 		}
 		if err := __VDLWriteAnon_list_2(enc, x.DirAddrs); err != nil {
@@ -260,12 +419,22 @@ func (x AdInfo) VDLWrite(enc vdl.Encoder) error {
 		}
 	}
 	if x.Status != 0 {
-		if err := enc.NextFieldValueUint(6, __VDLType_byte_4, uint64(x.Status)); err != nil {
+		if err := enc.NextFieldValueUint(7, __VDLType_byte_4, uint64(x.Status)); err != nil {
 			return err
 		}
 	}
 	if x.Lost {
-		if err := enc.NextFieldValueBool(7, vdl.BoolType, x.Lost); err != nil {
+		if err := enc.NextFieldValueBool(8, vdl.BoolType, x.Lost); err != nil {
+			return err
+		}
+	}
+	if x.TtlNs != 0 {
+		if err := enc.NextFieldValueInt(9, vdl.Int64Type, x.TtlNs); err != nil {
+			return err
+		}
+	}
+	if x.LostReason != 0 {
+		if err := enc.NextFieldValueUint(10, __VDLType_byte_10, uint64(x.LostReason)); err != nil {
 			return err
 		}
 	}
@@ -311,6 +480,27 @@ func __VDLWriteAnon_list_2(enc vdl.Encoder, x []string) error {
 	return enc.FinishValue()
 }
 
+func __VDLWriteAnon_list_3(enc vdl.Encoder, x []FieldEncryption) error {
+	if err := enc.StartValue(__VDLType_list_12); err != nil {
+		return err
+	}
+	if err := enc.SetLenHint(len(x)); err != nil {
+		return err
+	}
+	for _, elem := range x {
+		if err := enc.NextEntry(false); err != nil {
+			return err
+		}
+		if err := elem.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextEntry(true); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
 func (x *AdInfo) VDLRead(dec vdl.Decoder) error {
 	*x = AdInfo{}
 	if err := dec.StartValue(__VDLType_struct_6); err != nil {
@@ -351,35 +541,53 @@ func (x *AdInfo) VDLRead(dec vdl.Decoder) error {
 				return err
 			}
 		case 3:
+			if err := __VDLReadAnon_list_3(dec, &x.FieldEncryptions); err != nil {
+				return err
+			}
+		case 4:
 			bytes := x.Hash[:]
 			if err := dec.ReadValueBytes(8, &bytes); err != nil {
 				return err
 			}
-		case 4:
+		case 5:
 			switch value, err := dec.ReadValueInt(64); {
 			case err != nil:
 				return err
 			default:
 				x.TimestampNs = value
 			}
-		case 5:
+		case 6:
 			if err := __VDLReadAnon_list_2(dec, &x.DirAddrs); err != nil {
 				return err
 			}
-		case 6:
+		case 7:
 			switch value, err := dec.ReadValueUint(8); {
 			case err != nil:
 				return err
 			default:
 				x.Status = AdStatus(value)
 			}
-		case 7:
+		case 8:
 			switch value, err := dec.ReadValueBool(); {
 			case err != nil:
 				return err
 			default:
 				x.Lost = value
 			}
+		case 9:
+			switch value, err := dec.ReadValueInt(64); {
+			case err != nil:
+				return err
+			default:
+				x.TtlNs = value
+			}
+		case 10:
+			switch value, err := dec.ReadValueUint(8); {
+			case err != nil:
+				return err
+			default:
+				x.LostReason = LostReason(value)
+			}
 		}
 	}
 }
@@ -432,15 +640,43 @@ func __VDLReadAnon_list_2(dec vdl.Decoder, x *[]string) error {
 	}
 }
 
+func __VDLReadAnon_list_3(dec vdl.Decoder, x *[]FieldEncryption) error {
+	if err := dec.StartValue(__VDLType_list_12); err != nil {
+		return err
+	}
+	if len := dec.LenHint(); len > 0 {
+		*x = make([]FieldEncryption, 0, len)
+	} else {
+		*x = nil
+	}
+	for {
+		switch done, err := dec.NextEntry(); {
+		case err != nil:
+			return err
+		case done:
+			return dec.FinishValue()
+		default:
+			var elem FieldEncryption
+			if err := elem.VDLRead(dec); err != nil {
+				return err
+			}
+			*x = append(*x, elem)
+		}
+	}
+}
+
 //////////////////////////////////////////////////
 // Const definitions
 
 const NoEncryption = EncryptionAlgorithm(0)
 const TestEncryption = EncryptionAlgorithm(1)
 const IbeEncryption = EncryptionAlgorithm(2)
-const AdReady = AdStatus(0)          // All information is available
-const AdNotReady = AdStatus(1)       // Not all information is available for querying against it
-const AdPartiallyReady = AdStatus(2) // All information except attachments is available
+const AdReady = AdStatus(0)             // All information is available
+const AdNotReady = AdStatus(1)          // Not all information is available for querying against it
+const AdPartiallyReady = AdStatus(2)    // All information except attachments is available
+const LostWithdrawn = LostReason(0)     // The advertisement was withdrawn or is no longer seen by a plugin
+const LostExpired = LostReason(1)       // The advertisement was not seen again within its TTL
+const LostPluginStopped = LostReason(2) // The plugins that saw the advertisement stopped scanning
 
 //////////////////////////////////////////////////
 // Error definitions
@@ -638,15 +874,18 @@ var descDirectory = rpc.InterfaceDesc{
 
 // Hold type definitions in package-level variables, for better performance.
 var (
-	__VDLType_list_1   *vdl.Type
-	__VDLType_int32_2  *vdl.Type
-	__VDLType_list_3   *vdl.Type
-	__VDLType_byte_4   *vdl.Type
-	__VDLType_array_5  *vdl.Type
-	__VDLType_struct_6 *vdl.Type
-	__VDLType_struct_7 *vdl.Type
-	__VDLType_list_8   *vdl.Type
-	__VDLType_list_9   *vdl.Type
+	__VDLType_list_1    *vdl.Type
+	__VDLType_int32_2   *vdl.Type
+	__VDLType_list_3    *vdl.Type
+	__VDLType_byte_4    *vdl.Type
+	__VDLType_array_5   *vdl.Type
+	__VDLType_struct_6  *vdl.Type
+	__VDLType_struct_7  *vdl.Type
+	__VDLType_list_8    *vdl.Type
+	__VDLType_list_9    *vdl.Type
+	__VDLType_byte_10   *vdl.Type
+	__VDLType_struct_11 *vdl.Type
+	__VDLType_list_12   *vdl.Type
 )
 
 var __VDLInitCalled bool
@@ -655,7 +894,7 @@ var __VDLInitCalled bool
 // If you have an init ordering issue, just insert the following line verbatim
 // into your source files in this package, right after the "package foo" clause:
 //
-//    var _ = __VDLInit()
+//	var _ = __VDLInit()
 //
 // The purpose of this function is to ensure that vdl initialization occurs in
 // the right order, and very early in the init sequence.  In particular, vdl
@@ -675,7 +914,9 @@ func __VDLInit() struct{} {
 	vdl.Register((*EncryptionAlgorithm)(nil))
 	vdl.Register((*EncryptionKey)(nil))
 	vdl.Register((*AdStatus)(nil))
+	vdl.Register((*LostReason)(nil))
 	vdl.Register((*AdHash)(nil))
+	vdl.Register((*FieldEncryption)(nil))
 	vdl.Register((*AdInfo)(nil))
 
 	// Initialize type definitions.
@@ -688,6 +929,9 @@ func __VDLInit() struct{} {
 	__VDLType_struct_7 = vdl.TypeOf((*discovery.Advertisement)(nil)).Elem()
 	__VDLType_list_8 = vdl.TypeOf((*[]EncryptionKey)(nil))
 	__VDLType_list_9 = vdl.TypeOf((*[]string)(nil))
+	__VDLType_byte_10 = vdl.TypeOf((*LostReason)(nil))
+	__VDLType_struct_11 = vdl.TypeOf((*FieldEncryption)(nil)).Elem()
+	__VDLType_list_12 = vdl.TypeOf((*[]FieldEncryption)(nil))
 
 	// Set error format strings.
 	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrAdvertisementNotFound.ID), "{1:}{2:} advertisement not found: {3}")
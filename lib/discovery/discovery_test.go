@@ -183,6 +183,76 @@ func TestVisibility(t *testing.T) {
 	}
 }
 
+func TestFieldVisibility(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+
+	df, _ := idiscovery.NewFactory(ctx, mock.New())
+	defer df.Shutdown()
+
+	master, err := ibe.SetupBB2()
+	if err != nil {
+		ctx.Fatalf("ibe.SetupBB2 failed: %v", err)
+	}
+	root := bcrypter.NewRoot("v.io", master)
+	crypter := bcrypter.NewCrypter()
+	if err := crypter.AddParams(ctx, root.Params()); err != nil {
+		ctx.Fatalf("bcrypter.AddParams failed: %v", err)
+	}
+
+	ad := discovery.Advertisement{
+		InterfaceName: "v.io/v23/a",
+		Addresses:     []string{"/h1:123/x"},
+		Attributes:    map[string]string{"a1": "v1", "a2": "v2"},
+		Attachments:   map[string][]byte{"contact": []byte{1, 2, 3}},
+	}
+	visibility := map[string][]security.BlessingPattern{
+		"a2":      []security.BlessingPattern{"v.io:bob"},
+		"contact": []security.BlessingPattern{"v.io:bob"},
+	}
+
+	d1, _ := df.New(ctx)
+
+	sctx := idiscovery.WithFieldVisibility(bcrypter.WithCrypter(ctx, crypter), visibility)
+	stop, err := testutil.Advertise(sctx, d1, nil, &ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	d2, _ := df.New(ctx)
+
+	// Bob should discover the whole advertisement.
+	bobctx, _ := testutil.WithPrivateKey(ctx, root, "v.io:bob")
+	if err := testutil.ScanAndMatch(bobctx, d2, ``, ad); err != nil {
+		t.Error(err)
+	}
+	if err := testutil.ScanAndMatch(bobctx, d2, `v.Attributes["a2"]="v2"`, ad); err != nil {
+		t.Error(err)
+	}
+
+	// Others should discover the advertisement without the restricted
+	// attribute and attachment.
+	public := ad
+	public.Attributes = map[string]string{"a1": "v1"}
+	public.Attachments = nil
+	carolctx, _ := testutil.WithPrivateKey(ctx, root, "v.io:carol")
+	if err := testutil.ScanAndMatch(carolctx, d2, ``, public); err != nil {
+		t.Error(err)
+	}
+	if err := testutil.ScanAndMatch(carolctx, d2, `v.Attributes["a2"]="v2"`); err != nil {
+		t.Error(err)
+	}
+	scanCh, scanStop, err := testutil.Scan(carolctx, d2, ``)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scanStop()
+	if got := (<-scanCh).Advertisement(); len(got.Attributes) != 1 || len(got.Attachments) != 0 {
+		t.Errorf("got %v, but want the restricted attribute and attachment removed", got)
+	}
+}
+
 func TestDuplicates(t *testing.T) {
 	ctx, shutdown := test.V23Init()
 	defer shutdown()
@@ -413,6 +483,77 @@ func TestTimestamp(t *testing.T) {
 	}
 }
 
+func TestExpiration(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+
+	p1, p2 := mock.New(), mock.New()
+	df, _ := idiscovery.NewFactory(ctx, p1, p2)
+	defer df.Shutdown()
+
+	const ttl = 100 * time.Millisecond
+	adinfo := idiscovery.AdInfo{
+		Ad: discovery.Advertisement{
+			Id:            discovery.AdId{1, 2, 3},
+			InterfaceName: "v.io/v23/a",
+			Addresses:     []string{"/h1:123/x"},
+		},
+		Hash:        idiscovery.AdHash{1, 2, 3},
+		TimestampNs: time.Now().UnixNano(),
+		TtlNs:       int64(ttl),
+	}
+
+	d, _ := df.New(ctx)
+	scanCh, scanStop, err := testutil.Scan(ctx, d, ``)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scanStop()
+
+	// The mock plugin does not see the advertisement again, so it should
+	// expire after its TTL.
+	p1.RegisterAd(&adinfo)
+	if update := <-scanCh; !testutil.MatchFound(ctx, []discovery.Update{update}, adinfo.Ad) {
+		t.Errorf("unexpected scan: %v", update)
+	}
+	select {
+	case update := <-scanCh:
+		if !testutil.MatchLost(ctx, []discovery.Update{update}, adinfo.Ad) {
+			t.Errorf("unexpected scan: %v", update)
+		}
+		if got, want := idiscovery.GetLostReason(update), idiscovery.LostExpired; got != want {
+			t.Errorf("got lost reason %v, but want %v", got, want)
+		}
+	case <-time.After(10 * time.Second):
+		t.Errorf("advertisement not expired")
+	}
+	p1.UnregisterAd(&adinfo)
+
+	// An advertisement seen by a plugin without a TTL should not expire.
+	noTTLAdinfo := adinfo
+	noTTLAdinfo.TtlNs = 0
+	p1.RegisterAd(&adinfo)
+	p2.RegisterAd(&noTTLAdinfo)
+	if update := <-scanCh; !testutil.MatchFound(ctx, []discovery.Update{update}, adinfo.Ad) {
+		t.Errorf("unexpected scan: %v", update)
+	}
+	select {
+	case update := <-scanCh:
+		t.Errorf("unexpected scan: %v", update)
+	case <-time.After(3 * ttl):
+	}
+
+	// Until the plugin withdraws it.
+	p2.UnregisterAd(&noTTLAdinfo)
+	update := <-scanCh
+	if !testutil.MatchLost(ctx, []discovery.Update{update}, adinfo.Ad) {
+		t.Errorf("unexpected scan: %v", update)
+	}
+	if got, want := idiscovery.GetLostReason(update), idiscovery.LostWithdrawn; got != want {
+		t.Errorf("got lost reason %v, but want %v", got, want)
+	}
+}
+
 func TestLargeAdvertisement(t *testing.T) {
 	ctx, shutdown := test.V23Init()
 	defer shutdown()
@@ -201,6 +201,70 @@ func UnpackEncryptionKeys(data []byte) (EncryptionAlgorithm, []EncryptionKey, er
 	return EncryptionAlgorithm(algo), keys, nil
 }
 
+// PackFieldEncryptions packs field encryptions into a byte slice.
+func PackFieldEncryptions(encryptions []FieldEncryption) []byte {
+	buf := NewEncodingBuffer(nil)
+	for _, fe := range encryptions {
+		buf.WriteInt(len(fe.Attributes))
+		for _, name := range fe.Attributes {
+			buf.WriteString(name)
+		}
+		buf.WriteInt(len(fe.Attachments))
+		for _, name := range fe.Attachments {
+			buf.WriteString(name)
+		}
+		buf.WriteInt(len(fe.Keys))
+		for _, k := range fe.Keys {
+			buf.WriteBytes(k)
+		}
+	}
+	return buf.Bytes()
+}
+
+// UnpackFieldEncryptions unpacks field encryptions from a byte slice.
+func UnpackFieldEncryptions(data []byte) ([]FieldEncryption, error) {
+	buf := NewEncodingBuffer(data)
+	readStrings := func() ([]string, error) {
+		n, err := buf.ReadInt()
+		if err != nil {
+			return nil, err
+		}
+		var strs []string
+		for i := 0; i < n; i++ {
+			s, err := buf.ReadString()
+			if err != nil {
+				return nil, err
+			}
+			strs = append(strs, s)
+		}
+		return strs, nil
+	}
+	var encryptions []FieldEncryption
+	for buf.Len() > 0 {
+		var fe FieldEncryption
+		var err error
+		if fe.Attributes, err = readStrings(); err != nil {
+			return nil, err
+		}
+		if fe.Attachments, err = readStrings(); err != nil {
+			return nil, err
+		}
+		n, err := buf.ReadInt()
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < n; i++ {
+			key, err := buf.ReadBytes()
+			if err != nil {
+				return nil, err
+			}
+			fe.Keys = append(fe.Keys, EncryptionKey(key))
+		}
+		encryptions = append(encryptions, fe)
+	}
+	return encryptions, nil
+}
+
 // EncodeCiphertext encodes the cipher text into a byte slice.
 func EncodeWireCiphertext(wctext *bcrypter.WireCiphertext) []byte {
 	buf := NewEncodingBuffer(nil)
@@ -108,6 +108,30 @@ func TestPackEncryptionKeys(t *testing.T) {
 	}
 }
 
+func TestPackFieldEncryptions(t *testing.T) {
+	tests := [][]idiscovery.FieldEncryption{
+		nil,
+		{
+			{Attributes: []string{"a", "b"}, Keys: []idiscovery.EncryptionKey{idiscovery.EncryptionKey("k1")}},
+		},
+		{
+			{Attachments: []string{"c"}, Keys: []idiscovery.EncryptionKey{idiscovery.EncryptionKey("k1"), idiscovery.EncryptionKey("k2")}},
+			{Attributes: []string{"d"}, Attachments: []string{"d", "e"}, Keys: []idiscovery.EncryptionKey{idiscovery.EncryptionKey("k3")}},
+		},
+	}
+	for _, test := range tests {
+		pack := idiscovery.PackFieldEncryptions(test)
+		unpack, err := idiscovery.UnpackFieldEncryptions(pack)
+		if err != nil {
+			t.Errorf("unpack error: %v", err)
+			continue
+		}
+		if !reflect.DeepEqual(unpack, test) {
+			t.Errorf("unpacked to %v, but want %v", unpack, test)
+		}
+	}
+}
+
 func TestEncodeWireCiphertext(t *testing.T) {
 	rand := rand.New(rand.NewSource(0))
 	for i := 0; i < 1; i++ {
@@ -32,6 +32,12 @@ type Plugin interface {
 	// The callback takes ownership of the provided AdInfo, and the plugin
 	// should not use the advertisement after invoking the callback.
 	//
+	// Scanned advertisements with a non-zero TtlNs are considered lost once
+	// the TTL elapses, so the plugin should invoke the callback again with
+	// them while it still sees them, at least once per TTL.  Plugins that
+	// cannot do so should clear TtlNs, and report the advertisements as
+	// lost themselves, with the appropriate LostReason.
+	//
 	// Scanning should continue until the context is canceled or exceeds its
 	// deadline. done should be called once when scanning is done or canceled.
 	Scan(ctx *context.T, interfaceName string, callback func(*AdInfo), done func()) error
@@ -58,6 +58,9 @@ func (p *plugin) Scan(ctx *context.T, interfaceName string, callback func(*idisc
 		defer p.scanner.removeListener(interfaceName, listener)
 
 		seen := make(map[discovery.AdId]*idiscovery.AdInfo)
+		// delivered tracks when we delivered each advertisement last, so
+		// that we can deliver the ones with a TTL again before they expire.
+		delivered := make(map[discovery.AdId]time.Time)
 
 		// TODO(ashankar,jhahn): To prevent plugins from stepping over
 		// each other (e.g., a Lost even from one undoing a Found event
@@ -88,14 +91,22 @@ func (p *plugin) Scan(ctx *context.T, interfaceName string, callback func(*idisc
 					seenMu.Lock()
 					delete(seen, adinfo.Ad.Id)
 					seenMu.Unlock()
+					delete(delivered, adinfo.Ad.Id)
 				} else if prev := seen[adinfo.Ad.Id]; prev != nil && (prev.Hash == adinfo.Hash || prev.TimestampNs >= adinfo.TimestampNs) {
-					continue
+					if ttl := p.scanTTL(prev); ttl == 0 || time.Since(delivered[prev.Ad.Id]) < ttl/2 {
+						continue
+					}
+					adinfo = prev
 				} else {
 					seenMu.Lock()
 					seen[adinfo.Ad.Id] = adinfo
 					seenMu.Unlock()
 				}
 				copied := *adinfo
+				if !copied.Lost {
+					copied.TtlNs = int64(p.scanTTL(adinfo))
+					delivered[copied.Ad.Id] = time.Now()
+				}
 				callback(&copied)
 			case <-ctx.Done():
 				return
@@ -105,6 +116,17 @@ func (p *plugin) Scan(ctx *context.T, interfaceName string, callback func(*idisc
 	return nil
 }
 
+// scanTTL returns the TTL of a scanned advertisement. We may not see the
+// advertisement again before the scanner TTL elapses, so it is never shorter
+// than that.
+func (p *plugin) scanTTL(adinfo *idiscovery.AdInfo) time.Duration {
+	ttl := time.Duration(adinfo.TtlNs)
+	if ttl > 0 && ttl < p.scanner.ttl {
+		ttl = p.scanner.ttl
+	}
+	return ttl
+}
+
 func (p *plugin) Close() {
 	p.scanner.shutdown()
 }
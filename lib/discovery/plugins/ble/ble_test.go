@@ -524,3 +524,90 @@ func TestTimestamp(t *testing.T) {
 		t.Errorf("Unexpected scan: %v, but want %v", *got, adinfo)
 	}
 }
+
+func TestExpiration(t *testing.T) {
+	ctx, shutdown := test.TestContext()
+	defer shutdown()
+
+	adinfos := []idiscovery.AdInfo{
+		{
+			Ad: discovery.Advertisement{
+				Id:            discovery.AdId{1, 2, 3},
+				InterfaceName: "v.io/x",
+				Addresses:     []string{"/@6@wsh@foo.com:1234@@/x"},
+			},
+			Hash:        idiscovery.AdHash{1, 2, 3},
+			TimestampNs: 1001,
+			TtlNs:       int64(50 * time.Millisecond),
+		},
+		{
+			Ad: discovery.Advertisement{
+				Id:            discovery.AdId{4, 5, 6},
+				InterfaceName: "v.io/y",
+				Addresses:     []string{"/@6@wsh@bar.com:1234@@/y"},
+			},
+			Hash:        idiscovery.AdHash{4, 5, 6},
+			TimestampNs: 1002,
+			TtlNs:       int64(time.Millisecond),
+		},
+	}
+
+	neighborhood := newNeighborhood()
+	defer neighborhood.shutdown()
+
+	p1, err := newWithTTL(ctx, "h1", defaultTTL)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer p1.Close()
+
+	stop, err := testutil.Advertise(ctx, p1, &adinfos[0], &adinfos[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p2, err := newWithTTL(ctx, "h2", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer p2.Close()
+
+	scanCh, scanStop, err := testutil.Scan(ctx, p2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scanStop()
+
+	// Make sure the advertisements are discovered with their TTLs, which are
+	// never shorter than the scanner TTL, and delivered again while they are
+	// still advertised.
+	wants := []idiscovery.AdInfo{adinfos[0], adinfos[1]}
+	wants[1].TtlNs = int64(10 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		for _, want := range wants {
+			if err := testutil.WaitUntilMatchFound(scanCh, want); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Make sure scan returns the expired advertisements when advertising is stopped.
+	stop()
+
+	lost := make(map[discovery.AdId]bool)
+	timeout := time.After(10 * time.Second)
+	for len(lost) < len(adinfos) {
+		select {
+		case got := <-scanCh:
+			if !got.Lost {
+				continue
+			}
+			if got.LostReason != idiscovery.LostExpired {
+				t.Errorf("Unexpected scan: %v, but want it as expired", *got)
+			}
+			lost[got.Ad.Id] = true
+		case <-timeout:
+			t.Fatalf("Match failed; got %v lost, but wanted %v", lost, adinfos)
+		}
+	}
+}
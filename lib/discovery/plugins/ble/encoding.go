@@ -67,6 +67,8 @@ func encodeAdInfo(adinfo *idiscovery.AdInfo) ([]byte, error) {
 	//	<TimestampNs>
 	//      <DirAddrs encoded using idiscovery.PackAddresses>
 	//	<Status>
+	//	[<FieldEncryptions encoded using idiscovery.PackFieldEncryptions>
+	//	[<TtlNs>]]
 	//
 	// Any change of this format (except appending new fields) would break decoding.
 	// We can handle any versioning through different characteristic uuids if needed.
@@ -102,6 +104,13 @@ func encodeAdInfo(adinfo *idiscovery.AdInfo) ([]byte, error) {
 		buf.WriteInt(int(idiscovery.AdPartiallyReady))
 	}
 
+	if len(adinfo.FieldEncryptions) > 0 || adinfo.TtlNs != 0 {
+		buf.WriteBytes(idiscovery.PackFieldEncryptions(adinfo.FieldEncryptions))
+	}
+	if adinfo.TtlNs != 0 {
+		buf.Write(idiscovery.EncodeTimestamp(adinfo.TtlNs))
+	}
+
 	if buf.Len() > maxCharacteristicValueLen*maxNumPackedCharacteristicsPerService {
 		return nil, errors.New("max advertisement size exceeded")
 	}
@@ -221,6 +230,14 @@ func decodeAdInfo(encoded []byte) (*idiscovery.AdInfo, error) {
 	adinfo.DirAddrs, err = idiscovery.UnpackAddresses(readBytes())
 	adinfo.Status = idiscovery.AdStatus(readInt())
 
+	// Field encryptions and TTL are optional.
+	if err == nil && buf.Len() > 0 {
+		adinfo.FieldEncryptions, err = idiscovery.UnpackFieldEncryptions(readBytes())
+	}
+	if err == nil && buf.Len() > 0 {
+		adinfo.TtlNs = readTimestamp()
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -62,8 +62,22 @@ func TestEncode(t *testing.T) {
 				}
 			}
 
+			if n := rand.Intn(3); n > 0 {
+				adinfo.FieldEncryptions = make([]idiscovery.FieldEncryption, n)
+				for i, _ := range adinfo.FieldEncryptions {
+					adinfo.FieldEncryptions[i] = idiscovery.FieldEncryption{
+						Attributes:  []string{randString(16)},
+						Attachments: []string{randString(16)},
+						Keys:        []idiscovery.EncryptionKey{randBytes(128)},
+					}
+				}
+			}
+
 			copy(adinfo.Hash[:], randBytes(16))
 			adinfo.TimestampNs = rand.Int63()
+			if rand.Intn(2) > 0 {
+				adinfo.TtlNs = rand.Int63()
+			}
 
 			adinfo.DirAddrs = make([]string, rand.Intn(3)+1)
 			for i, _ := range adinfo.DirAddrs {
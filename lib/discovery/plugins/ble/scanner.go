@@ -185,7 +185,7 @@ func (s *scanner) gc(wg *sync.WaitGroup) {
 		}
 
 		delete(s.scanRecords, id)
-		adinfo := &idiscovery.AdInfo{Ad: discovery.Advertisement{Id: id}, Lost: true}
+		adinfo := &idiscovery.AdInfo{Ad: discovery.Advertisement{Id: id}, Lost: true, LostReason: idiscovery.LostExpired}
 		for _, ch := range append(s.listeners[rec.interfaceName], s.listeners[""]...) {
 			select {
 			case ch <- adinfo:
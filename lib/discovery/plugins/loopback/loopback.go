@@ -115,7 +115,11 @@ func (p *plugin) getAds(interfaceName string) map[discovery.AdId]idiscovery.AdIn
 	adinfos := make(map[discovery.AdId]idiscovery.AdInfo)
 	p.mu.Lock()
 	for id, adinfo := range p.adinfoMap[interfaceName] {
-		adinfos[id] = *adinfo
+		copied := *adinfo
+		// Loopback advertisements are seen until they are withdrawn, so
+		// they never expire.
+		copied.TtlNs = 0
+		adinfos[id] = copied
 	}
 	p.mu.Unlock()
 	return adinfos
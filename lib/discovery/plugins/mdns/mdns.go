@@ -42,8 +42,10 @@ const (
 	attrInterface  = "_i"
 	attrAddresses  = "_a"
 	attrEncryption = "_e"
+	attrFieldEncs  = "_f"
 	attrHash       = "_h"
 	attrTimestamp  = "_t"
+	attrTtl        = "_l"
 	attrDirAddrs   = "_d"
 	attrStatus     = "_s"
 
@@ -135,27 +137,46 @@ func (p *plugin) Scan(ctx *context.T, interfaceName string, callback func(*idisc
 
 		p.subscribeToService(serviceName)
 		watcher, stopWatcher := p.mdns.ServiceMemberWatch(serviceName)
+		refresh := time.NewTicker(p.subscriptionRefreshTime)
 		defer func() {
+			refresh.Stop()
 			stopWatcher()
 			p.unsubscribeFromService(serviceName)
 		}()
 
+		// The watcher notifies us of changes only, so we re-deliver the
+		// advertisements with a TTL from the mDNS cache before they expire.
+		var (
+			redeliveryPeriod time.Duration
+			redelivery       <-chan time.Time
+		)
+		deliver := func(service mdns.ServiceInstance) {
+			adinfo, err := newAdInfo(service)
+			if err != nil {
+				ctx.Error(err)
+				return
+			}
+			if period := time.Duration(adinfo.TtlNs) / 2; period > 0 && (redeliveryPeriod == 0 || period < redeliveryPeriod) {
+				redeliveryPeriod = period
+				redelivery = time.After(redeliveryPeriod)
+			}
+			callback(adinfo)
+		}
+
 		for {
-			var service mdns.ServiceInstance
 			select {
-			case service = <-watcher:
-			case <-time.After(p.subscriptionRefreshTime):
+			case service := <-watcher:
+				deliver(service)
+			case <-redelivery:
+				for _, service := range p.mdns.ServiceDiscovery(serviceName) {
+					deliver(service)
+				}
+				redelivery = time.After(redeliveryPeriod)
+			case <-refresh.C:
 				p.refreshSubscription(serviceName)
-				continue
 			case <-ctx.Done():
 				return
 			}
-			adinfo, err := newAdInfo(service)
-			if err != nil {
-				ctx.Error(err)
-				continue
-			}
-			callback(adinfo)
 		}
 	}()
 	return nil
@@ -278,6 +299,9 @@ func newTxtRecords(adinfo *idiscovery.AdInfo) ([]string, error) {
 	core, xseq := appendTxtRecord(nil, attrInterface, adinfo.Ad.InterfaceName, 0)
 	core, xseq = appendTxtRecord(core, attrHash, adinfo.Hash[:], xseq)
 	core, xseq = appendTxtRecord(core, attrTimestamp, idiscovery.EncodeTimestamp(adinfo.TimestampNs), xseq)
+	if adinfo.TtlNs != 0 {
+		core, xseq = appendTxtRecord(core, attrTtl, idiscovery.EncodeTimestamp(adinfo.TtlNs), xseq)
+	}
 	coreLen := sizeOfTxtRecords(core)
 
 	dir, xseq := appendTxtRecord(nil, attrDirAddrs, idiscovery.PackAddresses(adinfo.DirAddrs), xseq)
@@ -291,6 +315,10 @@ func newTxtRecords(adinfo *idiscovery.AdInfo) ([]string, error) {
 		enc := idiscovery.PackEncryptionKeys(adinfo.EncryptionAlgorithm, adinfo.EncryptionKeys)
 		required, xseq = appendTxtRecord(required, attrEncryption, enc, xseq)
 	}
+	if len(adinfo.FieldEncryptions) > 0 {
+		fenc := idiscovery.PackFieldEncryptions(adinfo.FieldEncryptions)
+		required, xseq = appendTxtRecord(required, attrFieldEncs, fenc, xseq)
+	}
 	requiredLen := sizeOfTxtRecords(required)
 
 	remainingLen := maxTotalTxtRecordsLen - coreLen - requiredLen
@@ -360,7 +388,11 @@ func newAdInfo(service mdns.ServiceInstance) (*idiscovery.AdInfo, error) {
 	}
 
 	if len(service.SrvRRs) == 0 && len(service.TxtRRs) == 0 {
+		// mDNS removes a service instance either on its goodbye announcement
+		// or when its records expire, and we can't tell which one happened.
+		// Either way, we no longer see the advertisement.
 		adinfo.Lost = true
+		adinfo.LostReason = idiscovery.LostWithdrawn
 		return adinfo, nil
 	}
 
@@ -388,12 +420,20 @@ func newAdInfo(service mdns.ServiceInstance) (*idiscovery.AdInfo, error) {
 				if adinfo.EncryptionAlgorithm, adinfo.EncryptionKeys, err = idiscovery.UnpackEncryptionKeys([]byte(v)); err != nil {
 					return nil, err
 				}
+			case attrFieldEncs:
+				if adinfo.FieldEncryptions, err = idiscovery.UnpackFieldEncryptions([]byte(v)); err != nil {
+					return nil, err
+				}
 			case attrHash:
 				copy(adinfo.Hash[:], []byte(v))
 			case attrTimestamp:
 				if adinfo.TimestampNs, err = idiscovery.DecodeTimestamp([]byte(v)); err != nil {
 					return nil, err
 				}
+			case attrTtl:
+				if adinfo.TtlNs, err = idiscovery.DecodeTimestamp([]byte(v)); err != nil {
+					return nil, err
+				}
 			case attrDirAddrs:
 				if adinfo.DirAddrs, err = idiscovery.UnpackAddresses([]byte(v)); err != nil {
 					return nil, err
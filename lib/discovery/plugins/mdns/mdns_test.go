@@ -13,6 +13,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"v.io/v23/discovery"
 
@@ -457,3 +458,66 @@ func TestMultipleLargeAdvertisements(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestExpiration(t *testing.T) {
+	ctx, shutdown := test.TestContext()
+	defer shutdown()
+
+	adinfo := idiscovery.AdInfo{
+		Ad: discovery.Advertisement{
+			Id:            discovery.AdId{1, 2, 3},
+			InterfaceName: "v.io/x",
+			Addresses:     []string{"/@6@wsh@foo.com:1234@@/x"},
+		},
+		Hash:        idiscovery.AdHash{1, 2, 3},
+		TimestampNs: 1001,
+		TtlNs:       int64(100 * time.Millisecond),
+	}
+
+	p1, err := newMDNS("m1")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	stop, err := testutil.Advertise(ctx, p1, &adinfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p2, err := newMDNS("m2")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	scanCh, scanStop, err := testutil.Scan(ctx, p2, "v.io/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scanStop()
+
+	// Make sure the advertisement is discovered with its TTL, and delivered
+	// again while it is still advertised.
+	want := withAdReady(adinfo)[0]
+	for i := 0; i < 3; i++ {
+		if err := testutil.WaitUntilMatchFound(scanCh, want); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Make sure scan returns the lost advertisement when advertising is stopped.
+	stop()
+
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case got := <-scanCh:
+			if !got.Lost {
+				continue
+			}
+			if got.Ad.Id != adinfo.Ad.Id || got.LostReason != idiscovery.LostWithdrawn {
+				t.Errorf("Unexpected scan: %v, but want %v as withdrawn", *got, adinfo)
+			}
+			return
+		case <-timeout:
+			t.Fatalf("Match failed; got none, but wanted %v as lost", adinfo)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package relay contains a discovery plugin that relays advertisements
+// through a rendezvous server.
+//
+// Unlike the global discovery, which polls a mounttable, the plugin keeps
+// long-lived RPCs to the rendezvous server, so that advertisements are relayed
+// between sites, such as the LANs of two offices, as soon as they change.
+// Advertisements are relayed as they are, so that encrypted advertisements can
+// only be decrypted by the principals that they are visible to.
+//
+// Usage:
+//
+//	import (
+//		"v.io/x/ref/lib/discovery/factory"
+//		"v.io/x/ref/lib/discovery/plugins/relay"
+//	)
+//
+//	// The plugin factory should be set before v23.NewDiscovery() is called.
+//	factory.SetPluginFactory("relay", func(ctx *context.T, _ string) (idiscovery.Plugin, error) {
+//		return relay.New(ctx, "/ns.example.com:8101/rendezvous")
+//	})
+//
+//	d, err := v23.NewDiscovery(ctx)
+//	...
+package relay
+
+import (
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/discovery"
+
+	idiscovery "v.io/x/ref/lib/discovery"
+)
+
+const (
+	minRetryDelay = time.Second
+	maxRetryDelay = time.Minute
+)
+
+type plugin struct {
+	rendezvous string
+}
+
+func (p *plugin) Advertise(ctx *context.T, adinfo *idiscovery.AdInfo, done func()) error {
+	// Clear DirAddrs since we relay a full advertisement information.
+	relayed := *adinfo
+	relayed.DirAddrs = nil
+
+	go func() {
+		defer done()
+
+		retry(ctx, func() error {
+			return RendezvousClient(p.rendezvous).Advertise(ctx, relayed)
+		})
+	}()
+	return nil
+}
+
+func (p *plugin) Scan(ctx *context.T, interfaceName string, callback func(*idiscovery.AdInfo), done func()) error {
+	go func() {
+		defer done()
+
+		seen := make(map[discovery.AdId]idiscovery.AdInfo)
+		retry(ctx, func() error {
+			err := p.scan(ctx, interfaceName, seen, callback)
+			// The advertisements are no longer seen until we reconnect to
+			// the rendezvous server.
+			for id, adinfo := range seen {
+				delete(seen, id)
+				lost := adinfo
+				lost.Lost = true
+				callback(&lost)
+			}
+			return err
+		})
+	}()
+	return nil
+}
+
+func (p *plugin) scan(ctx *context.T, interfaceName string, seen map[discovery.AdId]idiscovery.AdInfo, callback func(*idiscovery.AdInfo)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	call, err := RendezvousClient(p.rendezvous).Scan(ctx, interfaceName)
+	if err != nil {
+		return err
+	}
+	stream := call.RecvStream()
+	for stream.Advance() {
+		adinfo := stream.Value()
+		// The rendezvous server reports the advertisements as lost as
+		// soon as they are withdrawn, so they never expire.
+		adinfo.TtlNs = 0
+		if adinfo.Lost {
+			if _, ok := seen[adinfo.Ad.Id]; !ok {
+				continue
+			}
+			delete(seen, adinfo.Ad.Id)
+		} else {
+			seen[adinfo.Ad.Id] = adinfo
+		}
+		callback(&adinfo)
+	}
+	if err := stream.Err(); err != nil {
+		return err
+	}
+	return call.Finish()
+}
+
+func (p *plugin) Close() {}
+
+// retry calls f until ctx is canceled, waiting with an exponential backoff
+// between the calls that fail quickly.
+func retry(ctx *context.T, f func() error) {
+	delay := minRetryDelay
+	for {
+		start := time.Now()
+		err := f()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			ctx.Error(err)
+		}
+		if time.Since(start) > maxRetryDelay {
+			delay = minRetryDelay
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		if delay *= 2; delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
+
+// New returns a new relay plugin instance that relays advertisements through
+// the rendezvous server with the given name.
+func New(ctx *context.T, rendezvous string) (idiscovery.Plugin, error) {
+	return &plugin{rendezvous: rendezvous}, nil
+}
@@ -0,0 +1,311 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file was auto-generated by the vanadium vdl tool.
+// Package: relay
+
+package relay
+
+import (
+	"io"
+	"v.io/v23"
+	"v.io/v23/context"
+	"v.io/v23/rpc"
+	"v.io/v23/security/access"
+	"v.io/v23/vdl"
+	"v.io/x/ref/lib/discovery"
+)
+
+var _ = __VDLInit() // Must be first; see __VDLInit comments for details.
+
+//////////////////////////////////////////////////
+// Interface definitions
+
+// RendezvousClientMethods is the client interface
+// containing Rendezvous methods.
+//
+// Rendezvous is the interface for relaying advertisements between relay
+// plugins through a rendezvous server.
+type RendezvousClientMethods interface {
+	// Advertise publishes an advertisement until the call is canceled.
+	// An advertisement can only be replaced by an advertiser with the same
+	// blessing names as its first advertiser.
+	Advertise(_ *context.T, adinfo discovery.AdInfo, _ ...rpc.CallOpt) error
+	// Scan streams the advertisements with the given interface name, or all
+	// of them if the interface name is empty, that are currently published,
+	// followed by the changes to them as they happen.  Withdrawn
+	// advertisements are streamed as lost.
+	Scan(_ *context.T, interfaceName string, _ ...rpc.CallOpt) (RendezvousScanClientCall, error)
+}
+
+// RendezvousClientStub adds universal methods to RendezvousClientMethods.
+type RendezvousClientStub interface {
+	RendezvousClientMethods
+	rpc.UniversalServiceMethods
+}
+
+// RendezvousClient returns a client stub for Rendezvous.
+func RendezvousClient(name string) RendezvousClientStub {
+	return implRendezvousClientStub{name}
+}
+
+type implRendezvousClientStub struct {
+	name string
+}
+
+func (c implRendezvousClientStub) Advertise(ctx *context.T, i0 discovery.AdInfo, opts ...rpc.CallOpt) (err error) {
+	err = v23.GetClient(ctx).Call(ctx, c.name, "Advertise", []interface{}{i0}, nil, opts...)
+	return
+}
+
+func (c implRendezvousClientStub) Scan(ctx *context.T, i0 string, opts ...rpc.CallOpt) (ocall RendezvousScanClientCall, err error) {
+	var call rpc.ClientCall
+	if call, err = v23.GetClient(ctx).StartCall(ctx, c.name, "Scan", []interface{}{i0}, opts...); err != nil {
+		return
+	}
+	ocall = &implRendezvousScanClientCall{ClientCall: call}
+	return
+}
+
+// RendezvousScanClientStream is the client stream for Rendezvous.Scan.
+type RendezvousScanClientStream interface {
+	// RecvStream returns the receiver side of the Rendezvous.Scan client stream.
+	RecvStream() interface {
+		// Advance stages an item so that it may be retrieved via Value.  Returns
+		// true iff there is an item to retrieve.  Advance must be called before
+		// Value is called.  May block if an item is not available.
+		Advance() bool
+		// Value returns the item that was staged by Advance.  May panic if Advance
+		// returned false or was not called.  Never blocks.
+		Value() discovery.AdInfo
+		// Err returns any error encountered by Advance.  Never blocks.
+		Err() error
+	}
+}
+
+// RendezvousScanClientCall represents the call returned from Rendezvous.Scan.
+type RendezvousScanClientCall interface {
+	RendezvousScanClientStream
+	// Finish blocks until the server is done, and returns the positional return
+	// values for call.
+	//
+	// Finish returns immediately if the call has been canceled; depending on the
+	// timing the output could either be an error signaling cancelation, or the
+	// valid positional return values from the server.
+	//
+	// Calling Finish is mandatory for releasing stream resources, unless the call
+	// has been canceled or any of the other methods return an error.  Finish should
+	// be called at most once.
+	Finish() error
+}
+
+type implRendezvousScanClientCall struct {
+	rpc.ClientCall
+	valRecv discovery.AdInfo
+	errRecv error
+}
+
+func (c *implRendezvousScanClientCall) RecvStream() interface {
+	Advance() bool
+	Value() discovery.AdInfo
+	Err() error
+} {
+	return implRendezvousScanClientCallRecv{c}
+}
+
+type implRendezvousScanClientCallRecv struct {
+	c *implRendezvousScanClientCall
+}
+
+func (c implRendezvousScanClientCallRecv) Advance() bool {
+	c.c.valRecv = discovery.AdInfo{}
+	c.c.errRecv = c.c.Recv(&c.c.valRecv)
+	return c.c.errRecv == nil
+}
+func (c implRendezvousScanClientCallRecv) Value() discovery.AdInfo {
+	return c.c.valRecv
+}
+func (c implRendezvousScanClientCallRecv) Err() error {
+	if c.c.errRecv == io.EOF {
+		return nil
+	}
+	return c.c.errRecv
+}
+func (c *implRendezvousScanClientCall) Finish() (err error) {
+	err = c.ClientCall.Finish()
+	return
+}
+
+// RendezvousServerMethods is the interface a server writer
+// implements for Rendezvous.
+//
+// Rendezvous is the interface for relaying advertisements between relay
+// plugins through a rendezvous server.
+type RendezvousServerMethods interface {
+	// Advertise publishes an advertisement until the call is canceled.
+	// An advertisement can only be replaced by an advertiser with the same
+	// blessing names as its first advertiser.
+	Advertise(_ *context.T, _ rpc.ServerCall, adinfo discovery.AdInfo) error
+	// Scan streams the advertisements with the given interface name, or all
+	// of them if the interface name is empty, that are currently published,
+	// followed by the changes to them as they happen.  Withdrawn
+	// advertisements are streamed as lost.
+	Scan(_ *context.T, _ RendezvousScanServerCall, interfaceName string) error
+}
+
+// RendezvousServerStubMethods is the server interface containing
+// Rendezvous methods, as expected by rpc.Server.
+// The only difference between this interface and RendezvousServerMethods
+// is the streaming methods.
+type RendezvousServerStubMethods interface {
+	// Advertise publishes an advertisement until the call is canceled.
+	// An advertisement can only be replaced by an advertiser with the same
+	// blessing names as its first advertiser.
+	Advertise(_ *context.T, _ rpc.ServerCall, adinfo discovery.AdInfo) error
+	// Scan streams the advertisements with the given interface name, or all
+	// of them if the interface name is empty, that are currently published,
+	// followed by the changes to them as they happen.  Withdrawn
+	// advertisements are streamed as lost.
+	Scan(_ *context.T, _ *RendezvousScanServerCallStub, interfaceName string) error
+}
+
+// RendezvousServerStub adds universal methods to RendezvousServerStubMethods.
+type RendezvousServerStub interface {
+	RendezvousServerStubMethods
+	// Describe the Rendezvous interfaces.
+	Describe__() []rpc.InterfaceDesc
+}
+
+// RendezvousServer returns a server stub for Rendezvous.
+// It converts an implementation of RendezvousServerMethods into
+// an object that may be used by rpc.Server.
+func RendezvousServer(impl RendezvousServerMethods) RendezvousServerStub {
+	stub := implRendezvousServerStub{
+		impl: impl,
+	}
+	// Initialize GlobState; always check the stub itself first, to handle the
+	// case where the user has the Glob method defined in their VDL source.
+	if gs := rpc.NewGlobState(stub); gs != nil {
+		stub.gs = gs
+	} else if gs := rpc.NewGlobState(impl); gs != nil {
+		stub.gs = gs
+	}
+	return stub
+}
+
+type implRendezvousServerStub struct {
+	impl RendezvousServerMethods
+	gs   *rpc.GlobState
+}
+
+func (s implRendezvousServerStub) Advertise(ctx *context.T, call rpc.ServerCall, i0 discovery.AdInfo) error {
+	return s.impl.Advertise(ctx, call, i0)
+}
+
+func (s implRendezvousServerStub) Scan(ctx *context.T, call *RendezvousScanServerCallStub, i0 string) error {
+	return s.impl.Scan(ctx, call, i0)
+}
+
+func (s implRendezvousServerStub) Globber() *rpc.GlobState {
+	return s.gs
+}
+
+func (s implRendezvousServerStub) Describe__() []rpc.InterfaceDesc {
+	return []rpc.InterfaceDesc{RendezvousDesc}
+}
+
+// RendezvousDesc describes the Rendezvous interface.
+var RendezvousDesc rpc.InterfaceDesc = descRendezvous
+
+// descRendezvous hides the desc to keep godoc clean.
+var descRendezvous = rpc.InterfaceDesc{
+	Name:    "Rendezvous",
+	PkgPath: "v.io/x/ref/lib/discovery/plugins/relay",
+	Doc:     "// Rendezvous is the interface for relaying advertisements between relay\n// plugins through a rendezvous server.",
+	Methods: []rpc.MethodDesc{
+		{
+			Name: "Advertise",
+			Doc:  "// Advertise publishes an advertisement until the call is canceled.\n// An advertisement can only be replaced by an advertiser with the same\n// blessing names as its first advertiser.",
+			InArgs: []rpc.ArgDesc{
+				{"adinfo", ``}, // discovery.AdInfo
+			},
+			Tags: []*vdl.Value{vdl.ValueOf(access.Tag("Write"))},
+		},
+		{
+			Name: "Scan",
+			Doc:  "// Scan streams the advertisements with the given interface name, or all\n// of them if the interface name is empty, that are currently published,\n// followed by the changes to them as they happen.  Withdrawn\n// advertisements are streamed as lost.",
+			InArgs: []rpc.ArgDesc{
+				{"interfaceName", ``}, // string
+			},
+			Tags: []*vdl.Value{vdl.ValueOf(access.Tag("Read"))},
+		},
+	},
+}
+
+// RendezvousScanServerStream is the server stream for Rendezvous.Scan.
+type RendezvousScanServerStream interface {
+	// SendStream returns the send side of the Rendezvous.Scan server stream.
+	SendStream() interface {
+		// Send places the item onto the output stream.  Returns errors encountered
+		// while sending.  Blocks if there is no buffer space; will unblock when
+		// buffer space is available.
+		Send(item discovery.AdInfo) error
+	}
+}
+
+// RendezvousScanServerCall represents the context passed to Rendezvous.Scan.
+type RendezvousScanServerCall interface {
+	rpc.ServerCall
+	RendezvousScanServerStream
+}
+
+// RendezvousScanServerCallStub is a wrapper that converts rpc.StreamServerCall into
+// a typesafe stub that implements RendezvousScanServerCall.
+type RendezvousScanServerCallStub struct {
+	rpc.StreamServerCall
+}
+
+// Init initializes RendezvousScanServerCallStub from rpc.StreamServerCall.
+func (s *RendezvousScanServerCallStub) Init(call rpc.StreamServerCall) {
+	s.StreamServerCall = call
+}
+
+// SendStream returns the send side of the Rendezvous.Scan server stream.
+func (s *RendezvousScanServerCallStub) SendStream() interface {
+	Send(item discovery.AdInfo) error
+} {
+	return implRendezvousScanServerCallSend{s}
+}
+
+type implRendezvousScanServerCallSend struct {
+	s *RendezvousScanServerCallStub
+}
+
+func (s implRendezvousScanServerCallSend) Send(item discovery.AdInfo) error {
+	return s.s.Send(item)
+}
+
+var __VDLInitCalled bool
+
+// __VDLInit performs vdl initialization.  It is safe to call multiple times.
+// If you have an init ordering issue, just insert the following line verbatim
+// into your source files in this package, right after the "package foo" clause:
+//
+//    var _ = __VDLInit()
+//
+// The purpose of this function is to ensure that vdl initialization occurs in
+// the right order, and very early in the init sequence.  In particular, vdl
+// registration and package variable initialization needs to occur before
+// functions like vdl.TypeOf will work properly.
+//
+// This function returns a dummy value, so that it can be used to initialize the
+// first var in the file, to take advantage of Go's defined init order.
+func __VDLInit() struct{} {
+	if __VDLInitCalled {
+		return struct{}{}
+	}
+	__VDLInitCalled = true
+
+	return struct{}{}
+}
@@ -0,0 +1,198 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package relay
+
+import (
+	"testing"
+
+	"v.io/v23"
+	"v.io/v23/context"
+	"v.io/v23/discovery"
+	"v.io/v23/security"
+	"v.io/v23/verror"
+
+	idiscovery "v.io/x/ref/lib/discovery"
+	"v.io/x/ref/lib/discovery/plugins/testutil"
+	_ "v.io/x/ref/runtime/factories/generic"
+	"v.io/x/ref/test"
+	vtestutil "v.io/x/ref/test/testutil"
+)
+
+func TestBasic(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+
+	_, server, err := v23.WithNewServer(ctx, "", RendezvousServer(NewRendezvous()), security.AllowEveryone())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendezvous := server.Status().Endpoints[0].Name()
+
+	adinfos := []idiscovery.AdInfo{
+		{
+			Ad: discovery.Advertisement{
+				Id:            discovery.AdId{1, 2, 3},
+				InterfaceName: "v.io/x",
+				Addresses:     []string{"/@6@wsh@v1.com@@/x"},
+				Attributes:    discovery.Attributes{"a": "123"},
+				Attachments:   discovery.Attachments{"a": []byte{1, 2, 3}},
+			},
+			Hash: idiscovery.AdHash{1, 2, 3},
+		},
+		{
+			Ad: discovery.Advertisement{
+				Id:            discovery.AdId{4, 5, 6},
+				InterfaceName: "v.io/y",
+				Addresses:     []string{"/@6@wsh@v2.com@@/y"},
+			},
+			EncryptionAlgorithm: idiscovery.TestEncryption,
+			EncryptionKeys:      []idiscovery.EncryptionKey{idiscovery.EncryptionKey("k")},
+			Hash:                idiscovery.AdHash{4, 5, 6},
+		},
+	}
+
+	// Each plugin stands for a site that relays its advertisements.
+	plugins := make([]idiscovery.Plugin, len(adinfos))
+	var stops []func()
+	for i, _ := range plugins {
+		if plugins[i], err = New(ctx, rendezvous); err != nil {
+			t.Fatal(err)
+		}
+		defer plugins[i].Close()
+
+		stop, err := testutil.Advertise(ctx, plugins[i], &adinfos[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		stops = append(stops, stop)
+	}
+
+	// Make sure all advertisements are discovered by all sites, including
+	// their encryption.
+	for _, p := range plugins {
+		if err := testutil.ScanAndMatch(ctx, p, "", adinfos...); err != nil {
+			t.Error(err)
+		}
+		if err := testutil.ScanAndMatch(ctx, p, "v.io/y", adinfos[1]); err != nil {
+			t.Error(err)
+		}
+		if err := testutil.ScanAndMatch(ctx, p, "v.io/z"); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// Open a new scan channel and consume expected advertisements first.
+	scanCh, scanStop, err := testutil.Scan(ctx, plugins[1], "v.io/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scanStop()
+
+	adinfo := *<-scanCh
+	if !testutil.MatchFound([]idiscovery.AdInfo{adinfo}, adinfos[0]) {
+		t.Errorf("Unexpected scan: %v, but want %v", adinfo, adinfos[0])
+	}
+
+	// Make sure scan returns the lost advertisement as soon as advertising
+	// is stopped.
+	stops[0]()
+
+	adinfo = *<-scanCh
+	if !testutil.MatchLost([]idiscovery.AdInfo{adinfo}, adinfos[0]) {
+		t.Errorf("Unexpected scan: %v, but want %v as lost", adinfo, adinfos[0])
+	}
+
+	// And that the new advertisements are relayed right away.
+	newAdinfo := adinfos[0]
+	newAdinfo.Ad.Id = discovery.AdId{7, 8, 9}
+	newAdinfo.Hash = idiscovery.AdHash{7, 8, 9}
+	stop, err := testutil.Advertise(ctx, plugins[0], &newAdinfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	adinfo = *<-scanCh
+	if !testutil.MatchFound([]idiscovery.AdInfo{adinfo}, newAdinfo) {
+		t.Errorf("Unexpected scan: %v, but want %v", adinfo, newAdinfo)
+	}
+	stops[1]()
+}
+
+func TestReplaceAdvertisement(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+
+	_, server, err := v23.WithNewServer(ctx, "", RendezvousServer(NewRendezvous()), security.AllowEveryone())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendezvous := RendezvousClient(server.Status().Endpoints[0].Name())
+
+	idp := vtestutil.IDProviderFromPrincipal(v23.GetPrincipal(ctx))
+	newCtx := func(extension string) *context.T {
+		principal := vtestutil.NewPrincipal()
+		if err := idp.Bless(principal, extension); err != nil {
+			t.Fatal(err)
+		}
+		ctx, err := v23.WithPrincipal(ctx, principal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ctx
+	}
+	advertise := func(ctx *context.T, adinfo idiscovery.AdInfo) (func(), <-chan error) {
+		ctx, cancel := context.WithCancel(ctx)
+		errCh := make(chan error, 1)
+		go func() { errCh <- rendezvous.Advertise(ctx, adinfo) }()
+		return cancel, errCh
+	}
+
+	scanCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	call, err := rendezvous.Scan(scanCtx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := func() idiscovery.AdInfo {
+		stream := call.RecvStream()
+		if !stream.Advance() {
+			t.Fatalf("scan ended: %v", stream.Err())
+		}
+		return stream.Value()
+	}
+
+	adinfo := idiscovery.AdInfo{
+		Ad: discovery.Advertisement{
+			Id:            discovery.AdId{1, 2, 3},
+			InterfaceName: "v.io/x",
+			Addresses:     []string{"/@6@wsh@v1.com@@/x"},
+		},
+		Hash: idiscovery.AdHash{1},
+	}
+	alice := newCtx("alice")
+	stop, _ := advertise(alice, adinfo)
+	defer stop()
+	if got := next(); !testutil.MatchFound([]idiscovery.AdInfo{got}, adinfo) {
+		t.Errorf("Unexpected scan: %v, but want %v", got, adinfo)
+	}
+
+	// Another publisher can not replace the advertisement.
+	replaced := adinfo
+	replaced.Ad.Addresses = []string{"/@6@wsh@v2.com@@/x"}
+	replaced.Hash = idiscovery.AdHash{2}
+	stop, errCh := advertise(newCtx("bob"), replaced)
+	defer stop()
+	if err := <-errCh; verror.ErrorID(err) != errNotPublisher.ID {
+		t.Errorf("unexpected error ID. Got %v, expected %v", err, errNotPublisher.ID)
+	}
+
+	// But its publisher can.
+	stop, _ = advertise(alice, replaced)
+	defer stop()
+	if got := next(); !testutil.MatchFound([]idiscovery.AdInfo{got}, replaced) {
+		t.Errorf("Unexpected scan: %v, but want %v", got, replaced)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package relay
+
+import (
+	"sort"
+	"sync"
+
+	"v.io/v23/context"
+	"v.io/v23/discovery"
+	"v.io/v23/rpc"
+	"v.io/v23/security"
+	"v.io/v23/verror"
+
+	idiscovery "v.io/x/ref/lib/discovery"
+)
+
+const pkgPath = "v.io/x/ref/lib/discovery/plugins/relay"
+
+var (
+	errScanTooSlow  = verror.Register(pkgPath+".errScanTooSlow", verror.RetryBackoff, "{1:}{2:} scan does not keep up with the advertisements{:_}")
+	errNotPublisher = verror.Register(pkgPath+".errNotPublisher", verror.NoRetry, "{1:}{2:} advertisement {3} is published by {4}{:_}")
+)
+
+// scanBufferSize is the number of changes that are buffered for each scan
+// before the scan is considered too slow, and is ended.
+const scanBufferSize = 100
+
+type advertisement struct {
+	adinfo idiscovery.AdInfo
+	// publisher is the sorted blessing names of the first advertiser of
+	// the advertisement, which only advertisers with the same names can
+	// replace.
+	publisher []string
+}
+
+type scanner struct {
+	interfaceName string
+	ch            chan idiscovery.AdInfo
+	tooSlow       bool // GUARDED_BY(rendezvous.mu)
+}
+
+type rendezvous struct {
+	mu       sync.Mutex
+	ads      map[discovery.AdId]*advertisement // GUARDED_BY(mu)
+	scanners map[*scanner]struct{}             // GUARDED_BY(mu)
+}
+
+func (r *rendezvous) Advertise(ctx *context.T, call rpc.ServerCall, adinfo idiscovery.AdInfo) error {
+	publisher, _ := security.RemoteBlessingNames(ctx, call.Security())
+	sort.Strings(publisher)
+	adinfo.Lost = false
+	ad := &advertisement{adinfo, publisher}
+
+	r.mu.Lock()
+	if old, ok := r.ads[adinfo.Ad.Id]; ok && !sameNames(old.publisher, publisher) {
+		r.mu.Unlock()
+		return verror.New(errNotPublisher, ctx, adinfo.Ad.Id, old.publisher)
+	}
+	r.ads[adinfo.Ad.Id] = ad
+	r.notifyLocked(adinfo)
+	r.mu.Unlock()
+
+	<-ctx.Done()
+
+	r.mu.Lock()
+	// The advertisement may have been replaced by a newer one.
+	if r.ads[adinfo.Ad.Id] == ad {
+		delete(r.ads, adinfo.Ad.Id)
+		adinfo.Lost = true
+		r.notifyLocked(adinfo)
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *rendezvous) Scan(ctx *context.T, call RendezvousScanServerCall, interfaceName string) error {
+	s := &scanner{
+		interfaceName: interfaceName,
+		ch:            make(chan idiscovery.AdInfo, scanBufferSize),
+	}
+
+	r.mu.Lock()
+	current := make([]idiscovery.AdInfo, 0, len(r.ads))
+	for _, ad := range r.ads {
+		if matches(s, ad.adinfo) {
+			current = append(current, ad.adinfo)
+		}
+	}
+	r.scanners[s] = struct{}{}
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		delete(r.scanners, s)
+		r.mu.Unlock()
+	}()
+
+	stream := call.SendStream()
+	for _, adinfo := range current {
+		if err := stream.Send(adinfo); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case adinfo, ok := <-s.ch:
+			if !ok {
+				return verror.New(errScanTooSlow, ctx)
+			}
+			if err := stream.Send(adinfo); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// notifyLocked sends a change of advertisement to the matching scanners.
+// Scanners whose buffer is full are closed, so that their scan is ended, and
+// their client scans again from the current advertisements.
+func (r *rendezvous) notifyLocked(adinfo idiscovery.AdInfo) {
+	for s := range r.scanners {
+		if s.tooSlow || !matches(s, adinfo) {
+			continue
+		}
+		select {
+		case s.ch <- adinfo:
+		default:
+			s.tooSlow = true
+			close(s.ch)
+		}
+	}
+}
+
+func matches(s *scanner, adinfo idiscovery.AdInfo) bool {
+	return len(s.interfaceName) == 0 || s.interfaceName == adinfo.Ad.InterfaceName
+}
+
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// NewRendezvous returns a new rendezvous server, which relays advertisements
+// between relay plugins.
+func NewRendezvous() RendezvousServerMethods {
+	return &rendezvous{
+		ads:      make(map[discovery.AdId]*advertisement),
+		scanners: make(map[*scanner]struct{}),
+	}
+}
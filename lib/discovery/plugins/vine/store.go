@@ -59,15 +59,19 @@ func (s *store) Delete(_ *context.T, _ rpc.ServerCall, id discovery.AdId) error
 	return nil
 }
 
-func (s *store) lookup(interfaceName string) map[discovery.AdId]idiscovery.AdInfo {
+// lookup returns the advertisements with the given interface name, and the
+// times at which they expire unless they are added again.
+func (s *store) lookup(interfaceName string) (map[discovery.AdId]idiscovery.AdInfo, map[discovery.AdId]time.Time) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	adinfos := make(map[discovery.AdId]idiscovery.AdInfo, len(s.adinfoMap[interfaceName]))
+	expirations := make(map[discovery.AdId]time.Time, len(s.adinfoMap[interfaceName]))
 	for id, adinfo := range s.adinfoMap[interfaceName] {
 		adinfos[id] = *adinfo
+		expirations[id] = s.expirations[id]
 	}
-	return adinfos
+	return adinfos, expirations
 }
 
 func (s *store) deleteLocked(id discovery.AdId) {
@@ -64,11 +64,17 @@ func (p *plugin) Advertise(ctx *context.T, adinfo *idiscovery.AdInfo, done func(
 		defer cancel()
 		defer p.unpublish(rctx, adinfo.Ad.Id)
 
+		// Publish the advertisement again before scanners consider it
+		// expired, if it expires before the store entries do.
+		interval := p.ttl
+		if ttl := time.Duration(adinfo.TtlNs) / 2; ttl > 0 && ttl < interval {
+			interval = ttl
+		}
 		for {
 			p.publish(ctx, *adinfo)
 
 			select {
-			case <-p.clock.After(p.ttl):
+			case <-p.clock.After(interval):
 			case <-ctx.Done():
 				return
 			}
@@ -84,18 +90,29 @@ func (p *plugin) Scan(ctx *context.T, interfaceName string, callback func(*idisc
 		defer done()
 
 		seen := make(map[discovery.AdId]idiscovery.AdInfo)
+		var seenExpirations map[discovery.AdId]time.Time
 		for {
-			current := p.store.lookup(interfaceName)
+			current, expirations := p.store.lookup(interfaceName)
 
 			changed := make([]idiscovery.AdInfo, 0, len(current))
 			for id, adinfo := range current {
-				if old, ok := seen[id]; !ok || old.Hash != adinfo.Hash {
+				old, ok := seen[id]
+				switch {
+				case !ok || old.Hash != adinfo.Hash:
+					changed = append(changed, adinfo)
+				case adinfo.TtlNs > 0 && !expirations[id].Equal(seenExpirations[id]):
+					// The advertisement was published again, which
+					// refreshes it for scanners.
 					changed = append(changed, adinfo)
 				}
 			}
+			now := p.clock.Now()
 			for id, adinfo := range seen {
 				if _, ok := current[id]; !ok {
 					adinfo.Lost = true
+					if !seenExpirations[id].After(now) {
+						adinfo.LostReason = idiscovery.LostExpired
+					}
 					changed = append(changed, adinfo)
 				}
 			}
@@ -103,7 +120,7 @@ func (p *plugin) Scan(ctx *context.T, interfaceName string, callback func(*idisc
 			for i := range changed {
 				callback(&changed[i])
 			}
-			seen = current
+			seen, seenExpirations = current, expirations
 
 			// Wait the next update.
 			select {
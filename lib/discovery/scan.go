@@ -7,14 +7,16 @@ package discovery
 import (
 	"sort"
 	"sync"
+	"time"
 
 	"v.io/v23/context"
 	"v.io/v23/discovery"
 )
 
 type scanChanElem struct {
-	src uint // index into idiscovery.plugins
-	val *AdInfo
+	src     uint // index into idiscovery.plugins
+	val     *AdInfo
+	stopped bool // true if the plugin stopped scanning
 }
 
 func (d *idiscovery) scan(ctx *context.T, session sessionId, query string) (<-chan discovery.Update, error) {
@@ -43,11 +45,24 @@ func (d *idiscovery) scan(ctx *context.T, session sessionId, query string) (<-ch
 		p := uint(idx) // https://golang.org/doc/faq#closures_and_goroutines
 		callback := func(ad *AdInfo) {
 			select {
-			case scanCh <- scanChanElem{p, ad}:
+			case scanCh <- scanChanElem{src: p, val: ad}:
 			case <-ctx.Done():
 			}
 		}
-		if err := plugin.Scan(ctx, matcher.TargetInterfaceName(), callback, barrier.Add()); err != nil {
+		pluginDone := barrier.Add()
+		stopped := func() {
+			// Let doScan know that the advertisements seen by the plugin
+			// will no longer be refreshed or lost, unless the whole scan
+			// is being stopped.
+			if ctx.Err() == nil {
+				select {
+				case scanCh <- scanChanElem{src: p, stopped: true}:
+				case <-ctx.Done():
+				}
+			}
+			pluginDone()
+		}
+		if err := plugin.Scan(ctx, matcher.TargetInterfaceName(), callback, stopped); err != nil {
 			cancel()
 			return nil, err
 		}
@@ -59,19 +74,56 @@ func (d *idiscovery) scan(ctx *context.T, session sessionId, query string) (<-ch
 type adref struct {
 	adinfo *AdInfo
 	refs   uint32 // Bitmap of plugin indices that saw the ad
+	// Times after which the plugins that saw the ad with a TTL consider it
+	// lost, unless they see it again.
+	expiries map[uint]time.Time
 }
 
-func (a *adref) set(plugin uint) {
+// set records that the plugin saw the ad at the given time. The ad expires
+// for the plugin after ttlNs, or never if ttlNs is zero.
+func (a *adref) set(plugin uint, ttlNs int64, now time.Time) {
 	mask := uint32(1) << plugin
 	a.refs = a.refs | mask
+	if ttlNs > 0 {
+		if a.expiries == nil {
+			a.expiries = make(map[uint]time.Time)
+		}
+		a.expiries[plugin] = now.Add(time.Duration(ttlNs))
+	} else {
+		delete(a.expiries, plugin)
+	}
 }
 
 func (a *adref) unset(plugin uint) bool {
 	mask := uint32(1) << plugin
 	a.refs = a.refs & (^mask)
+	delete(a.expiries, plugin)
 	return a.refs == 0
 }
 
+// expire unsets the plugins for which the ad has expired at the given time,
+// and returns true if no plugin sees the ad anymore.
+func (a *adref) expire(now time.Time) bool {
+	for plugin, expiry := range a.expiries {
+		if !expiry.After(now) && a.unset(plugin) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextExpiry returns the earliest time at which the ad expires for a plugin,
+// or the zero time if it never expires.
+func (a *adref) nextExpiry() time.Time {
+	var next time.Time
+	for _, expiry := range a.expiries {
+		if next.IsZero() || expiry.Before(next) {
+			next = expiry
+		}
+	}
+	return next
+}
+
 func (d *idiscovery) doScan(ctx *context.T, session sessionId, matcher Matcher, scanCh chan scanChanElem, updateCh chan<- discovery.Update, done func()) {
 	// Some plugins may not return a full advertisement information when it is lost.
 	// So we keep the advertisements that we've seen so that we can provide the
@@ -87,6 +139,21 @@ func (d *idiscovery) doScan(ctx *context.T, session sessionId, matcher Matcher,
 		}
 	}
 
+	// lost removes the advertisement from seen, and sends the lost
+	// notification for it.
+	lost := func(ref *adref, reason LostReason) bool {
+		delete(seen, ref.adinfo.Ad.Id)
+		ref.adinfo.Lost = true
+		ref.adinfo.LostReason = reason
+		return send(NewUpdate(ref.adinfo))
+	}
+
+	// expiry fires when the earliest advertisement with a TTL expires.
+	var (
+		expiryAt time.Time
+		expiry   <-chan time.Time
+	)
+
 	var wg sync.WaitGroup
 	defer func() {
 		wg.Wait()
@@ -94,11 +161,41 @@ func (d *idiscovery) doScan(ctx *context.T, session sessionId, matcher Matcher,
 	}()
 
 	for {
+		var next time.Time
+		for _, ref := range seen {
+			if t := ref.nextExpiry(); !t.IsZero() && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+		if !next.Equal(expiryAt) {
+			expiryAt, expiry = next, nil
+			if !next.IsZero() {
+				expiry = time.After(next.Sub(time.Now()))
+			}
+		}
+
 		select {
 		case <-ctx.Done():
 			return
+		case now := <-expiry:
+			expiryAt, expiry = time.Time{}, nil
+			for _, ref := range seen {
+				if ref.expire(now) && !lost(ref, LostExpired) {
+					return
+				}
+			}
 		case e := <-scanCh:
 			plugin, adinfo := e.src, e.val
+			if e.stopped {
+				// The advertisements seen only by the plugin will
+				// never be lost by it, so consider them lost now.
+				for _, ref := range seen {
+					if ref.refs&(uint32(1)<<plugin) != 0 && ref.unset(plugin) && !lost(ref, LostPluginStopped) {
+						return
+					}
+				}
+				continue
+			}
 			id := adinfo.Ad.Id
 			prev := seen[adinfo.Ad.Id]
 			if adinfo.Lost {
@@ -110,9 +207,7 @@ func (d *idiscovery) doScan(ctx *context.T, session sessionId, matcher Matcher,
 				if prev == nil || !prev.unset(plugin) {
 					continue
 				}
-				delete(seen, id)
-				prev.adinfo.Lost = true
-				if !send(NewUpdate(prev.adinfo)) {
+				if !lost(prev, adinfo.LostReason) {
 					return
 				}
 				continue
@@ -121,8 +216,9 @@ func (d *idiscovery) doScan(ctx *context.T, session sessionId, matcher Matcher,
 				// Ignore advertisements made within the same session.
 				continue
 			}
+			now := time.Now()
 			if prev != nil && prev.adinfo.Hash == adinfo.Hash {
-				prev.set(plugin)
+				prev.set(plugin, adinfo.TtlNs, now)
 				if prev.adinfo.Status == AdReady {
 					continue
 				}
@@ -136,7 +232,7 @@ func (d *idiscovery) doScan(ctx *context.T, session sessionId, matcher Matcher,
 			} else if adinfo.Status == AdNotReady {
 				// Fetch not-ready-to-serve advertisements from the directory server.
 				wg.Add(1)
-				go fetchAd(ctx, adinfo.DirAddrs, id, plugin, scanCh, wg.Done)
+				go fetchAd(ctx, adinfo.DirAddrs, id, adinfo.TtlNs, plugin, scanCh, wg.Done)
 				continue
 			}
 
@@ -150,6 +246,12 @@ func (d *idiscovery) doScan(ctx *context.T, session sessionId, matcher Matcher,
 				}
 				continue
 			}
+			// Only the attributes and attachments that we can decrypt are
+			// matched and reported.
+			if err := decryptFields(ctx, adinfo); err != nil {
+				ctx.Error(err)
+				continue
+			}
 
 			if matched, err := matcher.Match(&adinfo.Ad); err != nil {
 				ctx.Error(err)
@@ -161,7 +263,7 @@ func (d *idiscovery) doScan(ctx *context.T, session sessionId, matcher Matcher,
 			if prev == nil {
 				// Never seen before
 				ref := &adref{adinfo: adinfo}
-				ref.set(plugin)
+				ref.set(plugin, adinfo.TtlNs, now)
 				seen[id] = ref
 				if !send(NewUpdate(adinfo)) {
 					return
@@ -176,9 +278,10 @@ func (d *idiscovery) doScan(ctx *context.T, session sessionId, matcher Matcher,
 			if prev.adinfo.Hash != adinfo.Hash || (prev.adinfo.Status != AdReady && !sortedStringsEqual(prev.adinfo.DirAddrs, adinfo.DirAddrs)) {
 				// Changed contents of a previously seen ad. Treat it like a newly seen ad.
 				ref := &adref{adinfo: adinfo}
-				ref.set(plugin)
+				ref.set(plugin, adinfo.TtlNs, now)
 				seen[id] = ref
 				prev.adinfo.Lost = true
+				prev.adinfo.LostReason = LostWithdrawn
 				if !send(NewUpdate(prev.adinfo)) || !send(NewUpdate(adinfo)) {
 					return
 				}
@@ -187,7 +290,7 @@ func (d *idiscovery) doScan(ctx *context.T, session sessionId, matcher Matcher,
 	}
 }
 
-func fetchAd(ctx *context.T, dirAddrs []string, id discovery.AdId, plugin uint, scanCh chan<- scanChanElem, done func()) {
+func fetchAd(ctx *context.T, dirAddrs []string, id discovery.AdId, ttlNs int64, plugin uint, scanCh chan<- scanChanElem, done func()) {
 	defer done()
 
 	dir := newDirClient(dirAddrs)
@@ -200,8 +303,11 @@ func fetchAd(ctx *context.T, dirAddrs []string, id discovery.AdId, plugin uint,
 		}
 		return
 	}
+	// The advertisement expires as the plugin that saw it sees it, rather
+	// than as the directory server publishes it.
+	adinfo.TtlNs = ttlNs
 	select {
-	case scanCh <- scanChanElem{plugin, adinfo}:
+	case scanCh <- scanChanElem{src: plugin, val: adinfo}:
 	case <-ctx.Done():
 	}
 }
@@ -14,17 +14,20 @@ import (
 
 // update is an implementation of discovery.Update.
 type update struct {
-	ad        discovery.Advertisement
-	hash      AdHash
-	dirAddrs  []string
-	status    AdStatus
-	lost      bool
-	timestamp time.Time
+	ad               discovery.Advertisement
+	fieldEncryptions []FieldEncryption
+	hash             AdHash
+	dirAddrs         []string
+	status           AdStatus
+	lost             bool
+	lostReason       LostReason
+	timestamp        time.Time
 }
 
-func (u *update) IsLost() bool          { return u.lost }
-func (u *update) Id() discovery.AdId    { return u.ad.Id }
-func (u *update) InterfaceName() string { return u.ad.InterfaceName }
+func (u *update) IsLost() bool           { return u.lost }
+func (u *update) LostReason() LostReason { return u.lostReason }
+func (u *update) Id() discovery.AdId     { return u.ad.Id }
+func (u *update) InterfaceName() string  { return u.ad.InterfaceName }
 
 func (u *update) Addresses() []string {
 	addrs := make([]string, len(u.ad.Addresses))
@@ -55,6 +58,9 @@ func (u *update) fetchAttachment(ctx *context.T, name string, ch chan<- discover
 
 	dir := newDirClient(u.dirAddrs)
 	data, err := dir.GetAttachment(ctx, u.ad.Id, name)
+	if err == nil {
+		data, err = decryptAttachment(ctx, u.fieldEncryptions, name, data)
+	}
 	ch <- discovery.DataOrError{data, err}
 }
 
@@ -91,11 +97,36 @@ func (u *update) String() string {
 // NewUpdate returns a new update with the given advertisement information.
 func NewUpdate(adinfo *AdInfo) discovery.Update {
 	return &update{
-		ad:        adinfo.Ad,
-		hash:      adinfo.Hash,
-		dirAddrs:  adinfo.DirAddrs,
-		status:    adinfo.Status,
-		lost:      adinfo.Lost,
-		timestamp: time.Unix(adinfo.TimestampNs/1e6, adinfo.TimestampNs%1e6),
+		ad:               adinfo.Ad,
+		fieldEncryptions: adinfo.FieldEncryptions,
+		hash:             adinfo.Hash,
+		dirAddrs:         adinfo.DirAddrs,
+		status:           adinfo.Status,
+		lost:             adinfo.Lost,
+		lostReason:       adinfo.LostReason,
+		timestamp:        time.Unix(adinfo.TimestampNs/1e6, adinfo.TimestampNs%1e6),
+	}
+}
+
+// GetLostReason returns the reason why the advertisement of a lost update was
+// lost. Updates that do not come from this package are considered withdrawn.
+func GetLostReason(u discovery.Update) LostReason {
+	if u, ok := u.(interface {
+		LostReason() LostReason
+	}); ok {
+		return u.LostReason()
+	}
+	return LostWithdrawn
+}
+
+func (r LostReason) String() string {
+	switch r {
+	case LostWithdrawn:
+		return "withdrawn"
+	case LostExpired:
+		return "expired"
+	case LostPluginStopped:
+		return "plugin stopped"
 	}
+	return fmt.Sprintf("LostReason(%d)", r)
 }
@@ -25,6 +25,9 @@ func TestUpdateBasic(t *testing.T) {
 		if got, want := update.IsLost(), adinfo.Lost; got != want {
 			t.Errorf("IsLost: got %v, but want %v", got, want)
 		}
+		if got, want := GetLostReason(update), adinfo.LostReason; got != want {
+			t.Errorf("LostReason: got %v, but want %v", got, want)
+		}
 		if got, want := update.Id(), adinfo.Ad.Id; got != want {
 			t.Errorf("Id: got %v, but want %v", got, want)
 		}
@@ -69,6 +69,27 @@ func hashAd(adinfo *AdInfo) {
 	}
 	hasher.Write(field.Sum(nil))
 
+	// Field encryptions are hashed only when present to keep the hash of
+	// advertisements without them unchanged.
+	if len(adinfo.FieldEncryptions) > 0 {
+		field.Reset()
+		for _, fe := range adinfo.FieldEncryptions {
+			for _, name := range fe.Attributes {
+				w(field, []byte(name))
+			}
+			field.Write([]byte{0})
+			for _, name := range fe.Attachments {
+				w(field, []byte(name))
+			}
+			field.Write([]byte{0})
+			for _, key := range fe.Keys {
+				w(field, []byte(key))
+			}
+			field.Write([]byte{0})
+		}
+		hasher.Write(field.Sum(nil))
+	}
+
 	// We use the first 8 bytes to reduce the advertise packet size.
 	copy(adinfo.Hash[:], hasher.Sum(nil))
 }
@@ -10,6 +10,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"v.io/v23/verror"
 	"v.io/x/ref"
@@ -33,6 +34,9 @@ const (
 	// --v23.vtrace.dump-on-shutdown
 	// --v23.vtrace.cache-size
 	// --v23.vtrace.collect-regexp
+	// --v23.revocation.snapshots
+	// --v23.revocation.max-staleness
+	// --v23.revocation.refresh
 	Runtime FlagGroup = iota
 	// Listen identifies the flags typically required to configure
 	// rpc.ListenSpec. Namely:
@@ -138,6 +142,10 @@ type RuntimeFlags struct {
 	// Vtrace flags control various aspects of Vtrace.
 	Vtrace VtraceFlags
 
+	// Revocation flags control the revocation snapshots against which
+	// discharges are validated.
+	Revocation RevocationFlags
+
 	namespaceRootsFlag namespaceRootFlagVar
 }
 
@@ -164,6 +172,19 @@ type VtraceFlags struct {
 	CollectRegexp string
 }
 
+type RevocationFlags struct {
+	// Snapshots is a comma separated list of the names of the revocation
+	// list services from which the runtime fetches revocation snapshots.
+	Snapshots string
+
+	// MaxStaleness is the maximum age of the revocation snapshots against
+	// which discharges are validated.
+	MaxStaleness time.Duration
+
+	// Refresh is the interval at which revocation snapshots are fetched.
+	Refresh time.Duration
+}
+
 // PermissionsFlags contains the values of the PermissionsFlags flag group.
 type PermissionsFlags struct {
 	// List of named Permissions files.
@@ -290,6 +311,10 @@ func createAndRegisterRuntimeFlags(fs *flag.FlagSet) *RuntimeFlags {
 	fs.IntVar(&f.Vtrace.LogLevel, "v23.vtrace.v", 0, "The verbosity level of the log messages to be captured in traces")
 	fs.StringVar(&f.Vtrace.CollectRegexp, "v23.vtrace.collect-regexp", "", "Spans and annotations that match this regular expression will trigger trace collection.")
 
+	fs.StringVar(&f.Revocation.Snapshots, "v23.revocation.snapshots", "", "Names of the revocation list services to fetch revocation snapshots from, comma separated.")
+	fs.DurationVar(&f.Revocation.MaxStaleness, "v23.revocation.max-staleness", 24*time.Hour, "Maximum age of the revocation snapshots against which discharges past their freshness are validated.")
+	fs.DurationVar(&f.Revocation.Refresh, "v23.revocation.refresh", time.Hour, "Interval at which revocation snapshots are fetched.")
+
 	return f
 }
 
@@ -10,6 +10,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"v.io/x/ref"
 	"v.io/x/ref/lib/flags"
@@ -46,6 +47,25 @@ func TestFlags(t *testing.T) {
 	}
 }
 
+func TestRevocationFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fl := flags.CreateAndRegister(fs, flags.Runtime)
+	fl.Parse(nil, nil)
+	want := flags.RevocationFlags{MaxStaleness: 24 * time.Hour, Refresh: time.Hour}
+	if got := fl.RuntimeFlags().Revocation; got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fl = flags.CreateAndRegister(fs, flags.Runtime)
+	args := []string{"--v23.revocation.snapshots=a/revocations,b/revocations", "--v23.revocation.max-staleness=1h", "--v23.revocation.refresh=1m"}
+	fl.Parse(args, nil)
+	want = flags.RevocationFlags{Snapshots: "a/revocations,b/revocations", MaxStaleness: time.Hour, Refresh: time.Minute}
+	if got := fl.RuntimeFlags().Revocation; got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
 func TestPermissionsFlags(t *testing.T) {
 	fs := flag.NewFlagSet("test", flag.ContinueOnError)
 	fl := flags.CreateAndRegister(fs, flags.Runtime, flags.Permissions)
@@ -17,4 +17,16 @@ const (
 	PublisherBlessingPrefixesKey   = "MGMT_PUBLISHER_BLESSING_PREFIXES"
 	InstanceNameKey                = "MGMT_INSTANCE_NAME"
 	AppCycleBlessingsKey           = "MGMT_APP_CYCLE_BLESSINGS"
+	AppTrustedPublishersConfigKey  = "MGMT_APP_TRUSTED_PUBLISHERS"
+	AppSignaturePolicyConfigKey    = "MGMT_APP_SIGNATURE_POLICY"
+)
+
+// Values of AppSignaturePolicyConfigKey.
+const (
+	// LaxSignaturePolicy accepts binaries and packages that are not
+	// signed by a publisher.  It is the default.
+	LaxSignaturePolicy = "lax"
+	// StrictSignaturePolicy refuses binaries and packages that are not
+	// signed by a trusted publisher.
+	StrictSignaturePolicy = "strict"
 )
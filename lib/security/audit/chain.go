@@ -0,0 +1,313 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"v.io/v23/context"
+	"v.io/v23/security"
+	"v.io/v23/verror"
+	"v.io/x/ref/internal/logger"
+)
+
+var (
+	// ErrInvalidLog is returned by VerifyChain, and NewChainAuditor, if the
+	// log has been modified since it was written or is malformed.
+	ErrInvalidLog = verror.Register(pkgPath+".ErrInvalidLog", verror.NoRetry, "{1:}{2:} invalid audit log at line {3}{:_}")
+
+	errCantOpenLog = verror.Register(pkgPath+".errCantOpenLog", verror.NoRetry, "{1:}{2:} failed to open audit log {3}{:_}")
+	errWrongSigner = verror.Register(pkgPath+".errWrongSigner", verror.NoRetry, "{1:}{2:} audit log is signed by {3}, not {4}{:_}")
+)
+
+const (
+	chainVersion = 1
+	// chainSignaturePrefix separates the messages signed for audit log
+	// entries from any other message signed by a principal.
+	chainSignaturePrefix = "v.io/x/ref/lib/security/audit.ChainEntry\x00"
+)
+
+// chainHeader is the first line of a log written by a ChainAuditor.
+type chainHeader struct {
+	Version int
+	// PublicKey is the DER encoded public key that signs the entries.
+	PublicKey []byte
+}
+
+// chainEntry is an entry of a log written by a ChainAuditor.
+type chainEntry struct {
+	// Seq is the sequence number of the entry, starting at 1.
+	Seq uint64
+	// Prev is the SHA-256 hash of the previous line of the log, including
+	// the header line.
+	Prev []byte
+	Record
+}
+
+// chainLine is a line, after the header, of a log written by a ChainAuditor.
+type chainLine struct {
+	// Entry is the JSON encoded chainEntry, kept as written since it is
+	// what Signature is over.
+	Entry     json.RawMessage
+	Signature security.Signature
+}
+
+func chainMessage(entry []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(chainSignaturePrefix))
+	h.Write(entry)
+	return h.Sum(nil)
+}
+
+// ChainAuditor is an Auditor that appends entries to a tamper-evident log
+// file.
+//
+// Each line of the file after the first is an entry, signed by a principal and
+// holding the hash of the line before it.  Thus modifying, inserting, removing
+// or reordering entries is detected by VerifyChain without access to the
+// private key.  Removing entries from the end of the log can only be detected
+// by comparing with the number of entries, or the last line, recorded
+// elsewhere.
+type ChainAuditor struct {
+	mu     sync.Mutex
+	file   *os.File
+	signer security.Principal
+	seq    uint64
+	prev   []byte
+}
+
+// NewChainAuditor returns a ChainAuditor that appends to the log file at
+// path, signing entries with signer.  The file is created if it does not
+// exist; otherwise it must have been written with the same public key and
+// must pass VerifyChain, except that an incomplete last line, left by a crash
+// while writing it, is removed.
+//
+// The signer must not audit its signatures to the returned ChainAuditor,
+// e.g. by being a principal returned by NewPrincipal with it: Audit signs
+// while holding the lock of the ChainAuditor, so such a signer deadlocks.
+func NewChainAuditor(path string, signer security.Principal) (*ChainAuditor, error) {
+	der, err := signer.PublicKey().MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, verror.New(errCantOpenLog, nil, path, err)
+	}
+	a := &ChainAuditor{file: f, signer: signer}
+	if err := a.init(der); err != nil {
+		f.Close()
+		return nil, verror.New(errCantOpenLog, nil, path, err)
+	}
+	return a, nil
+}
+
+func (a *ChainAuditor) init(der []byte) error {
+	fi, err := a.file.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() == 0 {
+		line, err := json.Marshal(chainHeader{Version: chainVersion, PublicKey: der})
+		if err != nil {
+			return err
+		}
+		if err := a.writeLine(line); err != nil {
+			return err
+		}
+		a.prev = hashLine(line)
+		return nil
+	}
+	key, err := security.UnmarshalPublicKey(der)
+	if err != nil {
+		return err
+	}
+	state, err := verifyChain(a.file, key, nil)
+	if err != nil && !state.torn {
+		return err
+	}
+	if state.torn {
+		// The process died while writing the last line, so its operation
+		// was never audited.  Drop the line, rather than refuse to audit
+		// anything from now on.
+		logger.Global().Errorf("Truncating the incomplete last line of audit log %s after entry %d", a.file.Name(), state.seq)
+		if err := a.file.Truncate(state.offset); err != nil {
+			return err
+		}
+		if err := a.file.Sync(); err != nil {
+			return err
+		}
+		if state.offset == 0 {
+			// Even the header was incomplete.
+			return a.init(der)
+		}
+	}
+	a.seq, a.prev = state.seq, state.prev
+	return nil
+}
+
+// Audit implements Auditor.
+func (a *ChainAuditor) Audit(ctx *context.T, entry Entry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return verror.New(errCantWriteEntry, ctx, entry.Method, verror.New(verror.ErrAborted, ctx))
+	}
+	line, err := a.newLine(entry)
+	if err != nil {
+		return verror.New(errCantWriteEntry, ctx, entry.Method, err)
+	}
+	if err := a.writeLine(line); err != nil {
+		return verror.New(errCantWriteEntry, ctx, entry.Method, err)
+	}
+	a.seq++
+	a.prev = hashLine(line)
+	return nil
+}
+
+func (a *ChainAuditor) newLine(entry Entry) ([]byte, error) {
+	e, err := json.Marshal(chainEntry{Seq: a.seq + 1, Prev: a.prev, Record: NewRecord(entry)})
+	if err != nil {
+		return nil, err
+	}
+	sig, err := a.signer.Sign(chainMessage(e))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(chainLine{Entry: e, Signature: sig})
+}
+
+// writeLine appends line to the file and waits for it to reach stable
+// storage, so that audited operations are not lost on a crash.
+func (a *ChainAuditor) writeLine(line []byte) error {
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return a.file.Sync()
+}
+
+// Close closes the log file.  Subsequent calls to Audit fail.
+func (a *ChainAuditor) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
+
+func hashLine(line []byte) []byte {
+	h := sha256.Sum256(line)
+	return h[:]
+}
+
+// VerifyChain verifies the log, written by a ChainAuditor, read from r and
+// returns the public key that signed it and the number of entries in it.  If
+// verification fails, the number of entries verified before the failure is
+// returned with the error.
+//
+// If key is not nil, the log must have been signed by key; otherwise the key
+// recorded in the log is used, which only establishes that the log has not
+// been modified since it was written by the holder of that key.  If fn is not
+// nil, it is called with each record, in order, as it is verified.
+func VerifyChain(r io.Reader, key security.PublicKey, fn func(Record)) (security.PublicKey, int, error) {
+	state, err := verifyChain(r, key, fn)
+	return state.key, int(state.seq), err
+}
+
+type chainState struct {
+	key  security.PublicKey
+	seq  uint64
+	prev []byte
+	// offset is the length of the verified lines.
+	offset int64
+	// torn is true iff verification failed because the last line is
+	// incomplete.
+	torn bool
+}
+
+func verifyChain(r io.Reader, key security.PublicKey, fn func(Record)) (chainState, error) {
+	var (
+		state  chainState
+		br     = bufio.NewReader(r)
+		lineno = 0
+	)
+	invalid := func(args ...interface{}) (chainState, error) {
+		return state, verror.New(ErrInvalidLog, nil, append([]interface{}{lineno}, args...)...)
+	}
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			break
+		}
+		lineno++
+		if err == io.EOF {
+			state.torn = true
+			return invalid("incomplete line")
+		}
+		if err != nil {
+			return state, err
+		}
+		n := int64(len(line))
+		line = line[:len(line)-1]
+		if lineno == 1 {
+			var h chainHeader
+			if err := json.Unmarshal(line, &h); err != nil {
+				return invalid(err)
+			}
+			if h.Version != chainVersion {
+				return invalid("unsupported version", h.Version)
+			}
+			logKey, err := security.UnmarshalPublicKey(h.PublicKey)
+			if err != nil {
+				return invalid(err)
+			}
+			if key == nil {
+				key = logKey
+			} else if der, err := key.MarshalBinary(); err != nil || !bytes.Equal(der, h.PublicKey) {
+				return chainState{}, verror.New(errWrongSigner, nil, logKey, key)
+			}
+			state.key = key
+			state.prev = hashLine(line)
+			state.offset += n
+			continue
+		}
+		var l chainLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			return invalid(err)
+		}
+		if !bytes.Equal(l.Signature.Purpose, []byte(security.SignatureForMessageSigning)) || !l.Signature.Verify(key, chainMessage(l.Entry)) {
+			return invalid("signature verification failed")
+		}
+		var e chainEntry
+		if err := json.Unmarshal(l.Entry, &e); err != nil {
+			return invalid(err)
+		}
+		if e.Seq != state.seq+1 {
+			return invalid("got entry", e.Seq, "want entry", state.seq+1)
+		}
+		if !bytes.Equal(e.Prev, state.prev) {
+			return invalid("hash of the previous line does not match")
+		}
+		state.seq = e.Seq
+		state.prev = hashLine(line)
+		state.offset += n
+		if fn != nil {
+			fn(e.Record)
+		}
+	}
+	if lineno == 0 {
+		return invalid("missing header")
+	}
+	return state, nil
+}
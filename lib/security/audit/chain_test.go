@@ -0,0 +1,167 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package audit_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"v.io/v23/verror"
+	"v.io/x/ref/lib/security/audit"
+	"v.io/x/ref/test"
+	"v.io/x/ref/test/testutil"
+)
+
+func TestJSONLinesAuditor(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+	var buf bytes.Buffer
+	a := audit.NewJSONLinesAuditor(&buf)
+	now := time.Now().UTC()
+	entries := []audit.Entry{
+		{Method: "Sign", Arguments: []interface{}{[]byte{0xca, 0xfe}}, Timestamp: now},
+		{Method: "BlessSelf", Arguments: []interface{}{"self"}, Results: []interface{}{"blessing"}, Timestamp: now},
+	}
+	for _, e := range entries {
+		if err := a.Audit(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if got, want := len(lines), len(entries); got != want {
+		t.Fatalf("got %d lines, want %d: %q", got, want, buf.String())
+	}
+	want := []audit.Record{
+		{Timestamp: now, Method: "Sign", Arguments: []string{"cafe"}},
+		{Timestamp: now, Method: "BlessSelf", Arguments: []string{"self"}, Results: []string{"blessing"}},
+	}
+	for i, line := range lines {
+		var got audit.Record
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("got %#v, want %#v", got, want[i])
+		}
+	}
+}
+
+func TestChainAuditor(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+	dir, err := ioutil.TempDir("", "TestChainAuditor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var (
+		path   = filepath.Join(dir, "audit.log")
+		signer = testutil.NewPrincipal("signer")
+		other  = testutil.NewPrincipal("other")
+	)
+	write := func(methods ...string) {
+		a, err := audit.NewChainAuditor(path, signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer a.Close()
+		for _, m := range methods {
+			if err := a.Audit(ctx, audit.Entry{Method: m, Timestamp: time.Now()}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// Entries are appended when the log is reopened.
+	write("Sign", "Bless")
+	write("MintDischarge")
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var methods []string
+	key, n, err := audit.VerifyChain(bytes.NewReader(data), signer.PublicKey(), func(r audit.Record) {
+		methods = append(methods, r.Method)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := methods, []string{"Sign", "Bless", "MintDischarge"}; n != len(want) || !reflect.DeepEqual(got, want) {
+		t.Errorf("got %d entries %v, want %v", n, got, want)
+	}
+	if !reflect.DeepEqual(key, signer.PublicKey()) {
+		t.Errorf("got key %v, want %v", key, signer.PublicKey())
+	}
+	if _, _, err := audit.VerifyChain(bytes.NewReader(data), nil, nil); err != nil {
+		t.Errorf("VerifyChain with the key in the log failed: %v", err)
+	}
+	if _, _, err := audit.VerifyChain(bytes.NewReader(data), other.PublicKey(), nil); err == nil {
+		t.Errorf("VerifyChain with the wrong key succeeded")
+	}
+	if _, err := audit.NewChainAuditor(path, other); err == nil {
+		t.Errorf("NewChainAuditor with the wrong signer succeeded")
+	}
+
+	// Any modification of the entries must be detected.
+	lines := strings.SplitAfter(string(data), "\n")
+	lines = lines[:len(lines)-1]
+	tampered := map[string][]string{
+		"modified":  {lines[0], lines[1], strings.Replace(lines[2], "Bless", "Blesz", 1), lines[3]},
+		"removed":   {lines[0], lines[1], lines[3]},
+		"reordered": {lines[0], lines[2], lines[1], lines[3]},
+	}
+	for name, l := range tampered {
+		_, _, err := audit.VerifyChain(strings.NewReader(strings.Join(l, "")), nil, nil)
+		if verror.ErrorID(err) != audit.ErrInvalidLog.ID {
+			t.Errorf("%s: got %v, want %v", name, err, audit.ErrInvalidLog.ID)
+		}
+		if err := ioutil.WriteFile(path, []byte(strings.Join(l, "")), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := audit.NewChainAuditor(path, signer); err == nil {
+			t.Errorf("%s: NewChainAuditor succeeded", name)
+		}
+	}
+
+	// An incomplete last line, e.g. after a crash, fails verification but
+	// is dropped when the log is reopened.
+	torn := map[string]struct {
+		data    string
+		methods []string
+	}{
+		"entry":  {lines[0] + lines[1] + lines[2] + lines[3][:len(lines[3])/2], []string{"Sign", "Bless", "Revoke"}},
+		"header": {lines[0][:len(lines[0])/2], []string{"Revoke"}},
+	}
+	for name, tc := range torn {
+		_, _, err := audit.VerifyChain(strings.NewReader(tc.data), nil, nil)
+		if verror.ErrorID(err) != audit.ErrInvalidLog.ID {
+			t.Errorf("torn %s: got %v, want %v", name, err, audit.ErrInvalidLog.ID)
+		}
+		if err := ioutil.WriteFile(path, []byte(tc.data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		write("Revoke")
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var methods []string
+		if _, _, err := audit.VerifyChain(bytes.NewReader(data), signer.PublicKey(), func(r audit.Record) {
+			methods = append(methods, r.Method)
+		}); err != nil {
+			t.Errorf("torn %s: VerifyChain failed: %v", name, err)
+		}
+		if !reflect.DeepEqual(methods, tc.methods) {
+			t.Errorf("torn %s: got %v, want %v", name, methods, tc.methods)
+		}
+	}
+}
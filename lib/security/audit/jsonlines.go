@@ -0,0 +1,83 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/verror"
+)
+
+var (
+	errCantWriteEntry = verror.Register(pkgPath+".errCantWriteEntry", verror.NoRetry, "{1:}{2:} failed to write audit entry for {3}{:_}")
+)
+
+// Record is the serialized form of an Entry written by the Auditor
+// implementations in this package.
+type Record struct {
+	Timestamp time.Time
+	Method    string
+	// Arguments and Results are the string representations of the
+	// arguments and results of the Entry.  Byte slices are hex encoded.
+	Arguments []string `json:",omitempty"`
+	Results   []string `json:",omitempty"`
+}
+
+// NewRecord returns the Record for entry.
+func NewRecord(entry Entry) Record {
+	return Record{
+		Timestamp: entry.Timestamp,
+		Method:    entry.Method,
+		Arguments: formatValues(entry.Arguments),
+		Results:   formatValues(entry.Results),
+	}
+}
+
+func formatValues(vals []interface{}) []string {
+	if len(vals) == 0 {
+		return nil
+	}
+	strs := make([]string, len(vals))
+	for i, v := range vals {
+		if b, ok := v.([]byte); ok {
+			strs[i] = fmt.Sprintf("%x", b)
+		} else {
+			strs[i] = fmt.Sprintf("%v", v)
+		}
+	}
+	return strs
+}
+
+// NewJSONLinesAuditor returns an Auditor that writes the Record of each entry,
+// encoded in JSON, as a single line to w.
+func NewJSONLinesAuditor(w io.Writer) Auditor {
+	return &jsonLinesAuditor{w: w}
+}
+
+type jsonLinesAuditor struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (a *jsonLinesAuditor) Audit(ctx *context.T, entry Entry) error {
+	line, err := json.Marshal(NewRecord(entry))
+	if err != nil {
+		return verror.New(errCantWriteEntry, ctx, entry.Method, err)
+	}
+	// Write the line with a single call so that writers that delimit
+	// messages by writes (like syslog) see one entry per message.
+	line = append(line, '\n')
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.w.Write(line); err != nil {
+		return verror.New(errCantWriteEntry, ctx, entry.Method, err)
+	}
+	return nil
+}
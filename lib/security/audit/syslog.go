@@ -0,0 +1,21 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !windows,!nacl,!plan9
+
+package audit
+
+import (
+	"log/syslog"
+)
+
+// NewSyslogAuditor returns an Auditor that sends the Record of each entry,
+// encoded in JSON, to the system log daemon with the given priority and tag.
+func NewSyslogAuditor(priority syslog.Priority, tag string) (Auditor, error) {
+	w, err := syslog.New(priority, tag)
+	if err != nil {
+		return nil, err
+	}
+	return NewJSONLinesAuditor(w), nil
+}
@@ -0,0 +1,21 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pkcs11 implements security.Signer for ECDSA private keys held by a
+// PKCS#11 token, e.g. a hardware security module or a smart card, so that the
+// private key of a principal never leaves the token.
+//
+// Importing this package registers a factory for signers of type "pkcs11"
+// with v.io/x/ref/lib/security, so that principals created with
+// security.CreatePersistentPrincipalWithSigner may be loaded with
+// security.LoadPersistentPrincipal.  The PIN of the token is the passphrase of
+// the principal.  The parameters of the signer are:
+//
+//	library: the path of the shared library implementing PKCS#11 for the
+//	         token, e.g. /usr/lib/softhsm/libsofthsm2.so
+//	token:   the label of the token
+//	key:     the label of the private key and of its public key on the token
+//
+// The signer requires cgo; without it, creating a signer fails.
+package pkcs11
@@ -0,0 +1,53 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkcs11
+
+import (
+	"v.io/v23/verror"
+	vsecurity "v.io/x/ref/lib/security"
+)
+
+const (
+	pkgPath = "v.io/x/ref/lib/security/pkcs11"
+
+	// SignerType is the type of the signers in vsecurity.SignerConfig.
+	SignerType = "pkcs11"
+
+	// The keys of the parameters of the signers in vsecurity.SignerConfig.
+	LibraryParam = "library"
+	TokenParam   = "token"
+	KeyParam     = "key"
+)
+
+var (
+	// ErrNoKey is returned by NewSigner if the key is not on the token.
+	ErrNoKey = verror.Register(pkgPath+".ErrNoKey", verror.NoRetry, "{1:}{2:} no {3} labeled {4} on the token{:_}")
+
+	errNoCgo          = verror.Register(pkgPath+".errNoCgo", verror.NoRetry, "{1:}{2:} PKCS#11 support requires cgo{:_}")
+	errMissingParam   = verror.Register(pkgPath+".errMissingParam", verror.NoRetry, "{1:}{2:} missing parameter {3}{:_}")
+	errCantLoad       = verror.Register(pkgPath+".errCantLoad", verror.NoRetry, "{1:}{2:} failed to load PKCS#11 library {3}{:_}")
+	errNoToken        = verror.Register(pkgPath+".errNoToken", verror.NoRetry, "{1:}{2:} no token labeled {3}{:_}")
+	errKeyExists      = verror.Register(pkgPath+".errKeyExists", verror.NoRetry, "{1:}{2:} a key labeled {3} already exists on the token{:_}")
+	errUnsupportedKey = verror.Register(pkgPath+".errUnsupportedKey", verror.NoRetry, "{1:}{2:} unsupported public key{:_}")
+	errPKCS11         = verror.Register(pkgPath+".errPKCS11", verror.NoRetry, "{1:}{2:} {3} failed{:_}")
+	errClosed         = verror.Register(pkgPath+".errClosed", verror.NoRetry, "{1:}{2:} signer is closed{:_}")
+)
+
+func init() {
+	vsecurity.RegisterSignerFactory(SignerType, NewSigner)
+}
+
+// Config returns the configuration of the signer for the key labeled key on
+// the token labeled token, accessed with the PKCS#11 library at path library.
+func Config(library, token, key string) vsecurity.SignerConfig {
+	return vsecurity.SignerConfig{
+		Type: SignerType,
+		Params: map[string]string{
+			LibraryParam: library,
+			TokenParam:   token,
+			KeyParam:     key,
+		},
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !cgo
+// +build !cgo
+
+package pkcs11
+
+import (
+	"v.io/v23/security"
+	"v.io/v23/verror"
+)
+
+// NewSigner returns an error since PKCS#11 requires cgo.
+func NewSigner(params map[string]string, pin []byte) (security.Signer, error) {
+	return nil, verror.New(errNoCgo, nil)
+}
+
+// GenerateKey returns an error since PKCS#11 requires cgo.
+func GenerateKey(params map[string]string, pin []byte) error {
+	return verror.New(errNoCgo, nil)
+}
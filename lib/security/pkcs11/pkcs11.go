@@ -0,0 +1,323 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build cgo
+// +build cgo
+
+package pkcs11
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/asn1"
+	"math/big"
+	"runtime"
+	"strings"
+	"sync"
+
+	p11 "github.com/miekg/pkcs11"
+
+	"v.io/v23/security"
+	"v.io/v23/verror"
+	vsecurity "v.io/x/ref/lib/security"
+)
+
+var (
+	oidP256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+	oidP384 = asn1.ObjectIdentifier{1, 3, 132, 0, 34}
+	oidP521 = asn1.ObjectIdentifier{1, 3, 132, 0, 35}
+)
+
+var (
+	modulesMu sync.Mutex
+	// modules maps the path of a PKCS#11 library to its initialized
+	// context.  A library may only be initialized once per process.
+	modules = make(map[string]*p11.Ctx)
+)
+
+// NewSigner returns a security.Signer for the private key described by
+// params, see the package documentation.  pin is the PIN of the user of the
+// token, if it is nil ErrPassphraseRequired is returned.
+//
+// The signer holds a session with the token, which is closed when the signer
+// is garbage collected or, earlier, by its Close method: the signer
+// implements io.Closer.
+func NewSigner(params map[string]string, pin []byte) (security.Signer, error) {
+	t, err := openToken(params, pin)
+	if err != nil {
+		return nil, err
+	}
+	label := params[KeyParam]
+	priv, err := t.findObject(p11.CKO_PRIVATE_KEY, label)
+	if err != nil {
+		t.close()
+		return nil, err
+	}
+	pub, err := t.findObject(p11.CKO_PUBLIC_KEY, label)
+	if err != nil {
+		t.close()
+		return nil, err
+	}
+	key, err := t.publicKey(pub)
+	if err != nil {
+		t.close()
+		return nil, err
+	}
+	s := &signer{token: t, key: priv, size: (key.Curve.Params().BitSize + 7) / 8}
+	runtime.SetFinalizer(s, (*signer).Close)
+	return &closingSigner{security.NewECDSASigner(key, s.sign), s}, nil
+}
+
+// GenerateKey generates an ECDSA P-256 key pair, labeled as described by
+// params, on the token.  The private key is sensitive and can not be
+// extracted from the token.
+func GenerateKey(params map[string]string, pin []byte) error {
+	t, err := openToken(params, pin)
+	if err != nil {
+		return err
+	}
+	defer t.close()
+	label := params[KeyParam]
+	if _, err := t.findObject(p11.CKO_PRIVATE_KEY, label); err == nil {
+		return verror.New(errKeyExists, nil, label)
+	} else if verror.ErrorID(err) != ErrNoKey.ID {
+		return err
+	}
+	ecParams, err := asn1.Marshal(oidP256)
+	if err != nil {
+		return err
+	}
+	pub := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_TOKEN, true),
+		p11.NewAttribute(p11.CKA_VERIFY, true),
+		p11.NewAttribute(p11.CKA_EC_PARAMS, ecParams),
+		p11.NewAttribute(p11.CKA_LABEL, label),
+	}
+	priv := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_TOKEN, true),
+		p11.NewAttribute(p11.CKA_PRIVATE, true),
+		p11.NewAttribute(p11.CKA_SIGN, true),
+		p11.NewAttribute(p11.CKA_SENSITIVE, true),
+		p11.NewAttribute(p11.CKA_EXTRACTABLE, false),
+		p11.NewAttribute(p11.CKA_LABEL, label),
+	}
+	mech := []*p11.Mechanism{p11.NewMechanism(p11.CKM_EC_KEY_PAIR_GEN, nil)}
+	if _, _, err := t.ctx.GenerateKeyPair(t.session, mech, pub, priv); err != nil {
+		return verror.New(errPKCS11, nil, "C_GenerateKeyPair", err)
+	}
+	return nil
+}
+
+// token is a session, logged in as the user, with a PKCS#11 token.
+type token struct {
+	ctx     *p11.Ctx
+	session p11.SessionHandle
+}
+
+func openToken(params map[string]string, pin []byte) (*token, error) {
+	for _, p := range []string{LibraryParam, TokenParam, KeyParam} {
+		if params[p] == "" {
+			return nil, verror.New(errMissingParam, nil, p)
+		}
+	}
+	if pin == nil {
+		return nil, verror.New(vsecurity.ErrPassphraseRequired, nil)
+	}
+	ctx, err := loadModule(params[LibraryParam])
+	if err != nil {
+		return nil, err
+	}
+	slot, err := findSlot(ctx, params[TokenParam])
+	if err != nil {
+		return nil, err
+	}
+	session, err := ctx.OpenSession(slot, p11.CKF_SERIAL_SESSION|p11.CKF_RW_SESSION)
+	if err != nil {
+		return nil, verror.New(errPKCS11, nil, "C_OpenSession", err)
+	}
+	switch err := ctx.Login(session, p11.CKU_USER, string(pin)); err {
+	case nil, p11.Error(p11.CKR_USER_ALREADY_LOGGED_IN):
+	case p11.Error(p11.CKR_PIN_INCORRECT):
+		ctx.CloseSession(session)
+		return nil, verror.New(vsecurity.ErrBadPassphrase, nil)
+	default:
+		ctx.CloseSession(session)
+		return nil, verror.New(errPKCS11, nil, "C_Login", err)
+	}
+	return &token{ctx: ctx, session: session}, nil
+}
+
+// close closes the session with the token.  The module stays loaded since
+// other sessions may use it.
+func (t *token) close() error {
+	if err := t.ctx.CloseSession(t.session); err != nil {
+		return verror.New(errPKCS11, nil, "C_CloseSession", err)
+	}
+	return nil
+}
+
+func loadModule(library string) (*p11.Ctx, error) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	if ctx := modules[library]; ctx != nil {
+		return ctx, nil
+	}
+	ctx := p11.New(library)
+	if ctx == nil {
+		return nil, verror.New(errCantLoad, nil, library)
+	}
+	if err := ctx.Initialize(); err != nil && err != p11.Error(p11.CKR_CRYPTOKI_ALREADY_INITIALIZED) {
+		ctx.Destroy()
+		return nil, verror.New(errCantLoad, nil, library, err)
+	}
+	modules[library] = ctx
+	return ctx, nil
+}
+
+func findSlot(ctx *p11.Ctx, label string) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, verror.New(errPKCS11, nil, "C_GetSlotList", err)
+	}
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, verror.New(errPKCS11, nil, "C_GetTokenInfo", err)
+		}
+		// Token labels are padded with spaces.
+		if strings.TrimRight(info.Label, " \x00") == label {
+			return slot, nil
+		}
+	}
+	return 0, verror.New(errNoToken, nil, label)
+}
+
+func (t *token) findObject(class uint, label string) (p11.ObjectHandle, error) {
+	template := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, class),
+		p11.NewAttribute(p11.CKA_KEY_TYPE, p11.CKK_EC),
+		p11.NewAttribute(p11.CKA_LABEL, label),
+	}
+	if err := t.ctx.FindObjectsInit(t.session, template); err != nil {
+		return 0, verror.New(errPKCS11, nil, "C_FindObjectsInit", err)
+	}
+	objs, _, err := t.ctx.FindObjects(t.session, 1)
+	if ferr := t.ctx.FindObjectsFinal(t.session); err == nil && ferr != nil {
+		err = ferr
+	}
+	if err != nil {
+		return 0, verror.New(errPKCS11, nil, "C_FindObjects", err)
+	}
+	if len(objs) == 0 {
+		kind := "private key"
+		if class == p11.CKO_PUBLIC_KEY {
+			kind = "public key"
+		}
+		return 0, verror.New(ErrNoKey, nil, kind, label)
+	}
+	return objs[0], nil
+}
+
+// publicKey returns the ECDSA public key in the public key object obj.
+func (t *token) publicKey(obj p11.ObjectHandle) (*ecdsa.PublicKey, error) {
+	attrs, err := t.ctx.GetAttributeValue(t.session, obj, []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_EC_PARAMS, nil),
+		p11.NewAttribute(p11.CKA_EC_POINT, nil),
+	})
+	if err != nil {
+		return nil, verror.New(errPKCS11, nil, "C_GetAttributeValue", err)
+	}
+	var ecParams, ecPoint []byte
+	for _, a := range attrs {
+		switch a.Type {
+		case p11.CKA_EC_PARAMS:
+			ecParams = a.Value
+		case p11.CKA_EC_POINT:
+			ecPoint = a.Value
+		}
+	}
+	var oid asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(ecParams, &oid); err != nil {
+		return nil, verror.New(errUnsupportedKey, nil, err)
+	}
+	var curve elliptic.Curve
+	switch {
+	case oid.Equal(oidP256):
+		curve = elliptic.P256()
+	case oid.Equal(oidP384):
+		curve = elliptic.P384()
+	case oid.Equal(oidP521):
+		curve = elliptic.P521()
+	default:
+		return nil, verror.New(errUnsupportedKey, nil, oid)
+	}
+	// CKA_EC_POINT is the DER encoding of an OCTET STRING holding the
+	// uncompressed point.
+	var point []byte
+	if _, err := asn1.Unmarshal(ecPoint, &point); err != nil {
+		return nil, verror.New(errUnsupportedKey, nil, err)
+	}
+	x, y := elliptic.Unmarshal(curve, point)
+	if x == nil {
+		return nil, verror.New(errUnsupportedKey, nil)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// signer signs digests with a private key on a token.
+type signer struct {
+	token *token
+	key   p11.ObjectHandle
+	// size is the size in bytes of each of r and s in the signatures.
+	size int
+
+	// A session may only be used for one operation at a time.
+	mu     sync.Mutex
+	closed bool // GUARDED_BY(mu)
+}
+
+// closingSigner is the security.Signer returned by NewSigner.
+type closingSigner struct {
+	security.Signer
+	s *signer
+}
+
+// Close closes the session with the token.  The signer may not be used after
+// it is closed.
+func (c *closingSigner) Close() error {
+	return c.s.Close()
+}
+
+func (s *signer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	runtime.SetFinalizer(s, nil)
+	return s.token.close()
+}
+
+func (s *signer) sign(digest []byte) (r, ss *big.Int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, nil, verror.New(errClosed, nil)
+	}
+	ctx, session := s.token.ctx, s.token.session
+	if err := ctx.SignInit(session, []*p11.Mechanism{p11.NewMechanism(p11.CKM_ECDSA, nil)}, s.key); err != nil {
+		return nil, nil, verror.New(errPKCS11, nil, "C_SignInit", err)
+	}
+	sig, err := ctx.Sign(session, digest)
+	if err != nil {
+		return nil, nil, verror.New(errPKCS11, nil, "C_Sign", err)
+	}
+	// CKM_ECDSA signatures are r and s, each padded to the size of the
+	// order of the curve.
+	if len(sig) != 2*s.size {
+		return nil, nil, verror.New(errPKCS11, nil, "C_Sign", verror.New(verror.ErrInternal, nil, "unexpected signature length"))
+	}
+	return new(big.Int).SetBytes(sig[:s.size]), new(big.Int).SetBytes(sig[s.size:]), nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build cgo
+// +build cgo
+
+package pkcs11
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"v.io/v23/verror"
+	vsecurity "v.io/x/ref/lib/security"
+)
+
+// softHSMLibraries are the usual locations of the SoftHSM v2 library.
+var softHSMLibraries = []string{
+	"/usr/lib/softhsm/libsofthsm2.so",
+	"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/local/lib/softhsm/libsofthsm2.so",
+}
+
+// initSoftHSMToken initializes a SoftHSM token, labeled token, with the given
+// user PIN, and returns the path of the library.  It skips the test if
+// SoftHSM is not installed.
+func initSoftHSMToken(t *testing.T, dir, token, pin string) string {
+	util, err := exec.LookPath("softhsm2-util")
+	if err != nil {
+		t.Skip("softhsm2-util not found")
+	}
+	library := os.Getenv("SOFTHSM2_LIB")
+	for _, l := range softHSMLibraries {
+		if library != "" {
+			break
+		}
+		if _, err := os.Stat(l); err == nil {
+			library = l
+		}
+	}
+	if library == "" {
+		t.Skip("SoftHSM library not found, set SOFTHSM2_LIB")
+	}
+	tokenDir := filepath.Join(dir, "tokens")
+	if err := os.Mkdir(tokenDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	conf := filepath.Join(dir, "softhsm2.conf")
+	if err := ioutil.WriteFile(conf, []byte(fmt.Sprintf("directories.tokendir = %s\n", tokenDir)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// The library reads the configuration when it is initialized, i.e.
+	// the first time it is used by the test.
+	if err := os.Setenv("SOFTHSM2_CONF", conf); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command(util, "--init-token", "--free", "--label", token, "--pin", pin, "--so-pin", "so-"+pin).CombinedOutput(); err != nil {
+		t.Fatalf("softhsm2-util failed: %v: %s", err, out)
+	}
+	return library
+}
+
+func TestSoftHSM(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestSoftHSM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pin := []byte("1234")
+	library := initSoftHSMToken(t, dir, "vanadium", string(pin))
+
+	config := Config(library, "vanadium", "server")
+	if _, err := NewSigner(config.Params, pin); verror.ErrorID(err) != ErrNoKey.ID {
+		t.Errorf("got %v, want %v", err, ErrNoKey.ID)
+	}
+	if err := GenerateKey(config.Params, pin); err != nil {
+		t.Fatal(err)
+	}
+	if err := GenerateKey(config.Params, pin); verror.ErrorID(err) != errKeyExists.ID {
+		t.Errorf("got %v, want %v", err, errKeyExists.ID)
+	}
+	if _, err := NewSigner(Config(library, "bogus", "server").Params, pin); verror.ErrorID(err) != errNoToken.ID {
+		t.Errorf("got %v, want %v", err, errNoToken.ID)
+	}
+
+	// The session of a signer is closed by Close.
+	signer, err := NewSigner(config.Params, pin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signer.Sign([]byte("purpose"), []byte("message")); err != nil {
+		t.Error(err)
+	}
+	closer, ok := signer.(io.Closer)
+	if !ok {
+		t.Fatalf("signer %T does not implement io.Closer", signer)
+	}
+	if err := closer.Close(); err != nil {
+		t.Error(err)
+	}
+	if _, err := signer.Sign([]byte("purpose"), []byte("message")); verror.ErrorID(err) != errClosed.ID {
+		t.Errorf("got %v, want %v", err, errClosed.ID)
+	}
+	if err := closer.Close(); err != nil {
+		t.Errorf("second Close failed: %v", err)
+	}
+
+	// The principal is persisted without its private key, and loaded with
+	// the PIN of the token.
+	principalDir := filepath.Join(dir, "principal")
+	p, err := vsecurity.CreatePersistentPrincipalWithSigner(principalDir, config, pin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vsecurity.LoadPersistentPrincipal(principalDir, nil); verror.ErrorID(err) != vsecurity.ErrPassphraseRequired.ID {
+		t.Errorf("got %v, want %v", err, vsecurity.ErrPassphraseRequired.ID)
+	}
+	loaded, err := vsecurity.LoadPersistentPrincipal(principalDir, pin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := loaded.PublicKey().String(), p.PublicKey().String(); got != want {
+		t.Errorf("got public key %v, want %v", got, want)
+	}
+	for _, message := range []string{"", "message"} {
+		sig, err := loaded.Sign([]byte(message))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sig.Verify(p.PublicKey(), []byte(message)) {
+			t.Errorf("signature of %q failed verification", message)
+		}
+	}
+	if _, err := loaded.BlessSelf("server"); err != nil {
+		t.Error(err)
+	}
+}
@@ -104,8 +104,10 @@ func NewPrincipalFromSigner(signer security.Signer, state *PrincipalStateSeriali
 // os.IsNotExist(err) is true.
 // If private key file exists then 'passphrase' must be correct, otherwise
 // ErrBadPassphrase will be returned.
+// If the principal was created with CreatePersistentPrincipalWithSigner,
+// 'passphrase' is passed to the SignerFactory of the signer instead.
 func LoadPersistentPrincipal(dir string, passphrase []byte) (security.Principal, error) {
-	key, err := loadKeyFromDir(dir, passphrase)
+	signer, err := loadSignerFromDir(dir, passphrase)
 	if err != nil {
 		return nil, err
 	}
@@ -115,7 +117,7 @@ func LoadPersistentPrincipal(dir string, passphrase []byte) (security.Principal,
 	if err != nil {
 		return nil, err
 	}
-	return NewPrincipalFromSigner(security.NewInMemoryECDSASigner(key), state)
+	return NewPrincipalFromSigner(signer, state)
 }
 
 // CreatePersistentPrincipal creates a new principal (private key,
@@ -574,6 +574,132 @@ func __VDLReadAnon_map_4(dec vdl.Decoder, x *map[dischargeCacheKey]CachedDischar
 	}
 }
 
+// KeySuccession is a statement, signed with the private key of a principal,
+// that another public key succeeds the public key of the principal.  It is
+// presented to blessers to obtain blessings for the new public key that are
+// equivalent to those granted to the old one.
+//
+// See NewKeySuccession and BlessSuccessor.
+type KeySuccession struct {
+	// OldPublicKey is the DER encoding of the public key being succeeded.
+	OldPublicKey []byte
+	// NewPublicKey is the DER encoding of the public key of the successor.
+	NewPublicKey []byte
+	// Timestamp is the time at which the statement was made.
+	Timestamp time.Time
+	// Signature is the signature of the statement by OldPublicKey.
+	Signature security.Signature
+}
+
+func (KeySuccession) __VDLReflect(struct {
+	Name string `vdl:"v.io/x/ref/lib/security.KeySuccession"`
+}) {
+}
+
+func (x KeySuccession) VDLIsZero() bool {
+	if len(x.OldPublicKey) != 0 {
+		return false
+	}
+	if len(x.NewPublicKey) != 0 {
+		return false
+	}
+	if !x.Timestamp.IsZero() {
+		return false
+	}
+	if !x.Signature.VDLIsZero() {
+		return false
+	}
+	return true
+}
+
+func (x KeySuccession) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_struct_13); err != nil {
+		return err
+	}
+	if len(x.OldPublicKey) != 0 {
+		if err := enc.NextFieldValueBytes(0, __VDLType_list_14, x.OldPublicKey); err != nil {
+			return err
+		}
+	}
+	if len(x.NewPublicKey) != 0 {
+		if err := enc.NextFieldValueBytes(1, __VDLType_list_14, x.NewPublicKey); err != nil {
+			return err
+		}
+	}
+	if !x.Timestamp.IsZero() {
+		if err := enc.NextField(2); err != nil {
+			return err
+		}
+		var wire vdltime.Time
+		if err := vdltime.TimeFromNative(&wire, x.Timestamp); err != nil {
+			return err
+		}
+		if err := wire.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
+	if !x.Signature.VDLIsZero() {
+		if err := enc.NextField(3); err != nil {
+			return err
+		}
+		if err := x.Signature.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x *KeySuccession) VDLRead(dec vdl.Decoder) error {
+	*x = KeySuccession{}
+	if err := dec.StartValue(__VDLType_struct_13); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	for {
+		index, err := dec.NextField()
+		switch {
+		case err != nil:
+			return err
+		case index == -1:
+			return dec.FinishValue()
+		}
+		if decType != __VDLType_struct_13 {
+			index = __VDLType_struct_13.FieldIndexByName(decType.Field(index).Name)
+			if index == -1 {
+				if err := dec.SkipValue(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		switch index {
+		case 0:
+			if err := dec.ReadValueBytes(-1, &x.OldPublicKey); err != nil {
+				return err
+			}
+		case 1:
+			if err := dec.ReadValueBytes(-1, &x.NewPublicKey); err != nil {
+				return err
+			}
+		case 2:
+			var wire vdltime.Time
+			if err := wire.VDLRead(dec); err != nil {
+				return err
+			}
+			if err := vdltime.TimeToNative(wire, &x.Timestamp); err != nil {
+				return err
+			}
+		case 3:
+			if err := x.Signature.VDLRead(dec); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 // Hold type definitions in package-level variables, for better performance.
 var (
 	__VDLType_map_1     *vdl.Type
@@ -588,6 +714,9 @@ var (
 	__VDLType_struct_10 *vdl.Type
 	__VDLType_map_11    *vdl.Type
 	__VDLType_map_12    *vdl.Type
+	__VDLType_struct_13 *vdl.Type
+	__VDLType_list_14   *vdl.Type
+	__VDLType_struct_15 *vdl.Type
 )
 
 var __VDLInitCalled bool
@@ -616,6 +745,7 @@ func __VDLInit() struct{} {
 	vdl.Register((*dischargeCacheKey)(nil))
 	vdl.Register((*CachedDischarge)(nil))
 	vdl.Register((*blessingStoreState)(nil))
+	vdl.Register((*KeySuccession)(nil))
 
 	// Initialize type definitions.
 	__VDLType_map_1 = vdl.TypeOf((*blessingRootsState)(nil))
@@ -630,6 +760,9 @@ func __VDLInit() struct{} {
 	__VDLType_struct_10 = vdl.TypeOf((*security.WireBlessings)(nil)).Elem()
 	__VDLType_map_11 = vdl.TypeOf((*map[dischargeCacheKey]security.Discharge)(nil))
 	__VDLType_map_12 = vdl.TypeOf((*map[dischargeCacheKey]CachedDischarge)(nil))
+	__VDLType_struct_13 = vdl.TypeOf((*KeySuccession)(nil)).Elem()
+	__VDLType_list_14 = vdl.TypeOf((*[]byte)(nil))
+	__VDLType_struct_15 = vdl.TypeOf((*security.Signature)(nil)).Elem()
 
 	return struct{}{}
 }
@@ -0,0 +1,142 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package security
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"sync"
+
+	"v.io/v23/security"
+	"v.io/v23/verror"
+)
+
+var (
+	errUnknownSignerType = verror.Register(pkgPath+".errUnknownSignerType", verror.NoRetry, "{1:}{2:} no signer factory registered for type {3}{:_}")
+	errCantReadSigner    = verror.Register(pkgPath+".errCantReadSigner", verror.NoRetry, "{1:}{2:} failed to read signer configuration from {3}{:_}")
+	errCantSaveSigner    = verror.Register(pkgPath+".errCantSaveSigner", verror.NoRetry, "{1:}{2:} failed to save signer configuration to {3}{:_}")
+)
+
+const signerConfigFile = "signer.json"
+
+// SignerConfig describes a private key that is held outside of the principal
+// directory, e.g. in a hardware security module.  It is persisted in the
+// principal directory in place of the private key.
+type SignerConfig struct {
+	// Type selects the SignerFactory, registered with RegisterSignerFactory,
+	// that creates the signer.
+	Type string
+	// Params are interpreted by the SignerFactory, e.g. to locate the key.
+	Params map[string]string
+}
+
+// SignerFactory returns the signer for the key described by params.  The
+// passphrase, if not nil, authenticates the caller to the key store, e.g. it
+// is the PIN of a PKCS#11 token.  If the key store requires a passphrase and
+// none is provided, SignerFactory returns ErrPassphraseRequired.
+type SignerFactory func(params map[string]string, passphrase []byte) (security.Signer, error)
+
+var (
+	signerFactoriesMu sync.RWMutex
+	signerFactories   = make(map[string]SignerFactory)
+)
+
+// RegisterSignerFactory registers factory as the SignerFactory for signers of
+// type typ.  It is typically called from the init function of the package that
+// implements the signer.
+func RegisterSignerFactory(typ string, factory SignerFactory) {
+	signerFactoriesMu.Lock()
+	defer signerFactoriesMu.Unlock()
+	signerFactories[typ] = factory
+}
+
+// NewSignerFromConfig returns the signer described by config.
+func NewSignerFromConfig(config SignerConfig, passphrase []byte) (security.Signer, error) {
+	signerFactoriesMu.RLock()
+	factory := signerFactories[config.Type]
+	signerFactoriesMu.RUnlock()
+	if factory == nil {
+		return nil, verror.New(errUnknownSignerType, nil, config.Type)
+	}
+	return factory(config.Params, passphrase)
+}
+
+// CreatePersistentPrincipalWithSigner is like CreatePersistentPrincipal,
+// except that the principal uses the signer described by config, which is
+// persisted in dir, instead of a private key generated and stored in dir.
+// The passphrase is only used to create the signer, it is not stored.
+func CreatePersistentPrincipalWithSigner(dir string, config SignerConfig, passphrase []byte) (security.Principal, error) {
+	if err := mkDir(dir); err != nil {
+		return nil, err
+	}
+	signer, err := NewSignerFromConfig(config, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	p, err := createPrincipalWithSigner(dir, config, signer)
+	if err != nil {
+		// Release the resources held by the signer, e.g. a session with
+		// a PKCS#11 token.
+		if c, ok := signer.(io.Closer); ok {
+			c.Close()
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+func createPrincipalWithSigner(dir string, config SignerConfig, signer security.Signer) (security.Principal, error) {
+	configFile := path.Join(dir, signerConfigFile)
+	if _, err := os.Stat(path.Join(dir, privateKeyFile)); err == nil {
+		return nil, verror.New(errCantOpenForWriting, nil, configFile, verror.New(verror.ErrExist, nil, privateKeyFile))
+	}
+	f, err := os.OpenFile(configFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, verror.New(errCantOpenForWriting, nil, configFile, err)
+	}
+	err = json.NewEncoder(f).Encode(config)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, verror.New(errCantSaveSigner, nil, configFile, err)
+	}
+	state, err := NewPrincipalStateSerializer(dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewPrincipalFromSigner(signer, state)
+}
+
+// loadSignerFromDir returns the signer of the principal persisted in dir:
+// either for the private key in dir, or the signer described by the signer
+// configuration in dir.  If neither exists, it returns an error for which
+// os.IsNotExist is true.
+func loadSignerFromDir(dir string, passphrase []byte) (security.Signer, error) {
+	key, err := loadKeyFromDir(dir, passphrase)
+	if err == nil {
+		return security.NewInMemoryECDSASigner(key), nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	configFile := path.Join(dir, signerConfigFile)
+	f, cerr := os.Open(configFile)
+	if os.IsNotExist(cerr) {
+		// Report the missing private key, the common case.
+		return nil, err
+	}
+	if cerr != nil {
+		return nil, verror.New(errCantReadSigner, nil, configFile, cerr)
+	}
+	defer f.Close()
+	var config SignerConfig
+	if err := json.NewDecoder(f).Decode(&config); err != nil {
+		return nil, verror.New(errCantReadSigner, nil, configFile, err)
+	}
+	return NewSignerFromConfig(config, passphrase)
+}
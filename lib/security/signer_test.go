@@ -0,0 +1,86 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package security
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"v.io/v23/security"
+	"v.io/v23/verror"
+)
+
+func TestCreatePersistentPrincipalWithSigner(t *testing.T) {
+	keys := make(map[string]*ecdsa.PrivateKey)
+	pin := []byte("1234")
+	RegisterSignerFactory("test", func(params map[string]string, passphrase []byte) (security.Signer, error) {
+		if passphrase == nil {
+			return nil, verror.New(ErrPassphraseRequired, nil)
+		}
+		if !bytes.Equal(passphrase, pin) {
+			return nil, verror.New(ErrBadPassphrase, nil)
+		}
+		key, ok := keys[params["key"]]
+		if !ok {
+			var err error
+			if _, key, err = NewPrincipalKey(); err != nil {
+				return nil, err
+			}
+			keys[params["key"]] = key
+		}
+		return security.NewInMemoryECDSASigner(key), nil
+	})
+
+	dir, err := ioutil.TempDir("", "TestCreatePersistentPrincipalWithSigner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := SignerConfig{Type: "test", Params: map[string]string{"key": "server"}}
+	if _, err := CreatePersistentPrincipalWithSigner(dir, SignerConfig{Type: "bogus"}, pin); verror.ErrorID(err) != errUnknownSignerType.ID {
+		t.Errorf("got %v, want %v", err, errUnknownSignerType.ID)
+	}
+	p, err := CreatePersistentPrincipalWithSigner(dir, config, pin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreatePersistentPrincipalWithSigner(dir, config, pin); err == nil {
+		t.Error("CreatePersistentPrincipalWithSigner passed unexpectedly")
+	}
+	if _, err := CreatePersistentPrincipal(dir, nil); err == nil {
+		t.Error("CreatePersistentPrincipal passed unexpectedly")
+	}
+	if _, err := os.Stat(path.Join(dir, privateKeyFile)); !os.IsNotExist(err) {
+		t.Errorf("private key file should not exist: %v", err)
+	}
+
+	// The signer is created again when the principal is loaded.
+	if _, err := LoadPersistentPrincipal(dir, nil); verror.ErrorID(err) != ErrPassphraseRequired.ID {
+		t.Errorf("got %v, want %v", err, ErrPassphraseRequired.ID)
+	}
+	if _, err := LoadPersistentPrincipal(dir, []byte("bad")); verror.ErrorID(err) != ErrBadPassphrase.ID {
+		t.Errorf("got %v, want %v", err, ErrBadPassphrase.ID)
+	}
+	loaded, err := LoadPersistentPrincipal(dir, pin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := loaded.PublicKey().String(), p.PublicKey().String(); got != want {
+		t.Errorf("got public key %v, want %v", got, want)
+	}
+	message := []byte("message")
+	sig, err := loaded.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sig.Verify(p.PublicKey(), message) {
+		t.Errorf("signature of the loaded principal failed verification")
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package security
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+
+	"v.io/v23/security"
+	"v.io/v23/verror"
+)
+
+var (
+	// ErrNotGrantedByBlesser is returned by BlessSuccessor if none of the
+	// blessings were granted by the blesser.
+	ErrNotGrantedByBlesser = verror.Register(pkgPath+".ErrNotGrantedByBlesser", verror.NoRetry, "{1:}{2:} none of the blessings {3} were granted by the blesser{:_}")
+
+	errBadSuccession  = verror.Register(pkgPath+".errBadSuccession", verror.NoRetry, "{1:}{2:} invalid key succession{:_}")
+	errNotPredecessor = verror.Register(pkgPath+".errNotPredecessor", verror.NoRetry, "{1:}{2:} the blessings are not bound to the public key being succeeded{:_}")
+)
+
+// keySuccessionPrefix separates the messages signed for key successions from
+// any other message signed by a principal.
+const keySuccessionPrefix = "v.io/x/ref/lib/security.KeySuccession\x00"
+
+// NewKeySuccession returns a statement, signed by p, that newKey succeeds the
+// public key of p.
+//
+// The statement allows anyone who holds the private key of newKey to obtain,
+// from the blessers that implement BlessSuccessor, blessings equivalent to
+// those granted to p.  It should therefore only be made for keys under the
+// control of the owner of p, and p should not be used once its key is
+// succeeded.
+func NewKeySuccession(p security.Principal, newKey security.PublicKey) (KeySuccession, error) {
+	oldDER, err := p.PublicKey().MarshalBinary()
+	if err != nil {
+		return KeySuccession{}, err
+	}
+	newDER, err := newKey.MarshalBinary()
+	if err != nil {
+		return KeySuccession{}, err
+	}
+	s := KeySuccession{
+		OldPublicKey: oldDER,
+		NewPublicKey: newDER,
+		Timestamp:    time.Now(),
+	}
+	if s.Signature, err = p.Sign(s.message()); err != nil {
+		return KeySuccession{}, err
+	}
+	return s, nil
+}
+
+// message returns the message signed in s.
+func (s KeySuccession) message() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(keySuccessionPrefix)
+	for _, key := range [][]byte{s.OldPublicKey, s.NewPublicKey} {
+		binary.Write(&buf, binary.BigEndian, uint32(len(key)))
+		buf.Write(key)
+	}
+	binary.Write(&buf, binary.BigEndian, s.Timestamp.UnixNano())
+	digest := sha256.Sum256(buf.Bytes())
+	return digest[:]
+}
+
+// Verify returns the old and the new public keys of s if its signature is
+// valid, and an error otherwise.
+func (s KeySuccession) Verify() (oldKey, newKey security.PublicKey, err error) {
+	if oldKey, err = security.UnmarshalPublicKey(s.OldPublicKey); err != nil {
+		return nil, nil, verror.New(errBadSuccession, nil, err)
+	}
+	if newKey, err = security.UnmarshalPublicKey(s.NewPublicKey); err != nil {
+		return nil, nil, verror.New(errBadSuccession, nil, err)
+	}
+	if bytes.Equal(s.OldPublicKey, s.NewPublicKey) {
+		return nil, nil, verror.New(errBadSuccession, nil, "the new public key is the old one")
+	}
+	if !bytes.Equal(s.Signature.Purpose, []byte(security.SignatureForMessageSigning)) || !s.Signature.Verify(oldKey, s.message()) {
+		return nil, nil, verror.New(errBadSuccession, nil, "signature verification failed")
+	}
+	return oldKey, newKey, nil
+}
+
+// BlessSuccessor uses blesser to re-issue, to the successor in s, the
+// blessings in 'blessings' that blesser granted to the public key succeeded
+// in s.  Each re-issued blessing has the same extension and caveats as the
+// original one, and extends the same blessing of blesser.
+//
+// The blessings must be bound to the old public key of s, and s must be valid.
+// Blessings not granted by blesser (e.g., self-blessings, or those granted by
+// other blessers) are ignored; if none of the blessings were granted by
+// blesser, an error is returned.
+func BlessSuccessor(blesser security.Principal, s KeySuccession, blessings security.Blessings) (security.Blessings, error) {
+	_, newKey, err := s.Verify()
+	if err != nil {
+		return security.Blessings{}, err
+	}
+	if blessings.IsZero() {
+		return security.Blessings{}, verror.New(errNotPredecessor, nil)
+	}
+	if der, err := blessings.PublicKey().MarshalBinary(); err != nil || !bytes.Equal(der, s.OldPublicKey) {
+		return security.Blessings{}, verror.New(errNotPredecessor, nil)
+	}
+	blesserKey, err := blesser.PublicKey().MarshalBinary()
+	if err != nil {
+		return security.Blessings{}, err
+	}
+	var wire security.WireBlessings
+	if err := security.WireBlessingsFromNative(&wire, blessings); err != nil {
+		return security.Blessings{}, err
+	}
+	var reissued []security.Blessings
+	for _, chain := range wire.CertificateChains {
+		// The certificate preceding the last one in a blessing granted by
+		// blesser is bound to the public key of blesser.
+		if len(chain) < 2 || !bytes.Equal(chain[len(chain)-2].PublicKey, blesserKey) {
+			continue
+		}
+		var with security.Blessings
+		if err := security.WireBlessingsToNative(security.WireBlessings{CertificateChains: [][]security.Certificate{chain[:len(chain)-1]}}, &with); err != nil {
+			return security.Blessings{}, err
+		}
+		leaf := chain[len(chain)-1]
+		caveat, additional := security.UnconstrainedUse(), []security.Caveat(nil)
+		if len(leaf.Caveats) > 0 {
+			caveat, additional = leaf.Caveats[0], leaf.Caveats[1:]
+		}
+		b, err := blesser.Bless(newKey, with, leaf.Extension, caveat, additional...)
+		if err != nil {
+			return security.Blessings{}, err
+		}
+		reissued = append(reissued, b)
+	}
+	if len(reissued) == 0 {
+		return security.Blessings{}, verror.New(ErrNotGrantedByBlesser, nil, blessings)
+	}
+	return security.UnionOfBlessings(reissued...)
+}
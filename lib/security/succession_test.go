@@ -0,0 +1,89 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package security
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"v.io/v23/security"
+	"v.io/v23/verror"
+)
+
+func TestBlessSuccessor(t *testing.T) {
+	var (
+		blesser, blesserDefault = newPrincipal("blesser")
+		other, otherDefault     = newPrincipal("other")
+		oldP, oldSelf           = newPrincipal("self")
+		newP, _                 = newPrincipal()
+	)
+	expiry, err := security.NewExpiryCaveat(time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromBlesser, err := blesser.Bless(oldP.PublicKey(), blesserDefault, "alice", expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromOther, err := other.Bless(oldP.PublicKey(), otherDefault, "alice", security.UnconstrainedUse())
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := unionOfBlessings(oldSelf, fromBlesser, fromOther)
+
+	s, err := NewKeySuccession(oldP, newP.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := BlessSuccessor(blesser, s, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(b.PublicKey(), newP.PublicKey()) {
+		t.Errorf("got blessings for %v, want %v", b.PublicKey(), newP.PublicKey())
+	}
+	if err := security.AddToRoots(newP, b); err != nil {
+		t.Fatal(err)
+	}
+	names := security.BlessingNames(newP, b)
+	sort.Strings(names)
+	if want := []string{"blesser:alice"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+	var wire security.WireBlessings
+	if err := security.WireBlessingsFromNative(&wire, b); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := wire.CertificateChains[0][1].Caveats, []security.Caveat{expiry}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got caveats %v, want %v", got, want)
+	}
+
+	// Only blessings granted by the blesser are re-issued.
+	if _, err := BlessSuccessor(blesser, s, unionOfBlessings(oldSelf, fromOther)); verror.ErrorID(err) != ErrNotGrantedByBlesser.ID {
+		t.Errorf("got %v, want %v", err, ErrNotGrantedByBlesser.ID)
+	}
+	// The blessings must be bound to the old public key.
+	if _, err := BlessSuccessor(blesser, s, blesserDefault); verror.ErrorID(err) != errNotPredecessor.ID {
+		t.Errorf("got %v, want %v", err, errNotPredecessor.ID)
+	}
+	// The succession must be signed by the old public key.
+	forged, err := NewKeySuccession(newP, newP.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.OldPublicKey = s.OldPublicKey
+	tampered := s
+	tampered.Timestamp = s.Timestamp.Add(time.Hour)
+	for _, bad := range []KeySuccession{forged, tampered, {}} {
+		if _, err := BlessSuccessor(blesser, bad, old); verror.ErrorID(err) != errBadSuccession.ID {
+			t.Errorf("got %v, want %v", err, errBadSuccession.ID)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package serverlabels defines the labels that a server may attach to its
+// address when it is mounted, and that clients use to order and load balance
+// across the servers mounted under a single name.
+//
+// Labels are carried in the address portion of the mounted server's name,
+// separated from the endpoint by a '#', e.g.:
+//
+//	/@6@tcp@10.0.0.1:1234@@@@@@#weight=3,zone=us-west1/suffix
+//
+// Labels are stripped by the client before the endpoint is used.
+package serverlabels
+
+import (
+	"strconv"
+	"strings"
+
+	"v.io/v23/naming"
+)
+
+const (
+	separator = "#"
+	weightKey = "weight"
+	zoneKey   = "zone"
+)
+
+// DefaultWeight is the weight of a server that has not published one.
+const DefaultWeight = 1
+
+// Labels are published by a server along with its address.  The zero value
+// means no labels.
+//
+// Labels can be passed as an rpc.ServerOpt, in which case the server
+// publishes them with all of its endpoints.
+type Labels struct {
+	// Weight is the relative share of the load the server wishes to
+	// receive from clients when compared to the other servers mounted
+	// under the same name.  Zero means DefaultWeight.
+	Weight int
+	// Zone identifies the locality of the server, e.g. a datacenter or
+	// a cloud zone.  Clients prefer servers in their own Zone.
+	Zone string
+}
+
+func (Labels) RPCServerOpt() {}
+
+// Zone is the locality of a client.  It can be passed as an rpc.ClientOpt,
+// in which case the client prefers servers that published the same zone.
+type Zone string
+
+func (Zone) RPCClientOpt() {}
+
+// IsZero returns true if no labels are set.
+func (l Labels) IsZero() bool {
+	return l == Labels{}
+}
+
+// EffectiveWeight returns the weight of the server, or DefaultWeight if the
+// server did not publish one.
+func (l Labels) EffectiveWeight() int {
+	if l.Weight <= 0 {
+		return DefaultWeight
+	}
+	return l.Weight
+}
+
+// String returns the encoded form of the labels, e.g. "weight=3,zone=a".
+func (l Labels) String() string {
+	var kv []string
+	if l.Weight != 0 {
+		kv = append(kv, weightKey+"="+strconv.Itoa(l.Weight))
+	}
+	if l.Zone != "" {
+		kv = append(kv, zoneKey+"="+l.Zone)
+	}
+	return strings.Join(kv, ",")
+}
+
+// parse decodes the encoded form of labels.  Malformed and unknown labels are
+// ignored so that old clients can talk to new servers.
+func parse(s string) Labels {
+	var l Labels
+	for _, kv := range strings.Split(s, ",") {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch parts[0] {
+		case weightKey:
+			if w, err := strconv.Atoi(parts[1]); err == nil && w > 0 {
+				l.Weight = w
+			}
+		case zoneKey:
+			l.Zone = parts[1]
+		}
+	}
+	return l
+}
+
+// Attach returns server, which may be an endpoint or a rooted name, with the
+// labels attached.  Any labels already attached to server are replaced.
+func Attach(server string, l Labels) string {
+	server, _ = Split(server)
+	if l.IsZero() {
+		return server
+	}
+	if !naming.Rooted(server) {
+		return server + separator + l.String()
+	}
+	address, suffix := naming.SplitAddressName(server)
+	return naming.JoinAddressName(address+separator+l.String(), suffix)
+}
+
+// Split returns server with any labels removed, and the labels.  If server
+// has no labels, it is returned unchanged.
+func Split(server string) (string, Labels) {
+	if !strings.Contains(server, separator) {
+		return server, Labels{}
+	}
+	if !naming.Rooted(server) {
+		i := strings.LastIndex(server, separator)
+		return server[:i], parse(server[i+1:])
+	}
+	address, suffix := naming.SplitAddressName(server)
+	i := strings.LastIndex(address, separator)
+	if i < 0 {
+		return server, Labels{}
+	}
+	return naming.JoinAddressName(address[:i], suffix), parse(address[i+1:])
+}
+
+// Strip returns server with any labels removed.
+func Strip(server string) string {
+	s, _ := Split(server)
+	return s
+}
@@ -0,0 +1,51 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package serverlabels_test
+
+import (
+	"testing"
+
+	"v.io/x/ref/lib/serverlabels"
+)
+
+func TestAttachAndSplit(t *testing.T) {
+	const ep = "@6@tcp@127.0.0.1:1234@@@@@@"
+	labels := serverlabels.Labels{Weight: 3, Zone: "us-west1"}
+	testcases := []struct {
+		server, labeled string
+	}{
+		{ep, ep + "#weight=3,zone=us-west1"},
+		{"/" + ep, "/" + ep + "#weight=3,zone=us-west1"},
+		{"/" + ep + "/a/b", "/" + ep + "#weight=3,zone=us-west1/a/b"},
+		{"/127.0.0.1:1234", "/127.0.0.1:1234#weight=3,zone=us-west1"},
+	}
+	for _, tc := range testcases {
+		if got := serverlabels.Attach(tc.server, labels); got != tc.labeled {
+			t.Errorf("Attach(%q): got %q, want %q", tc.server, got, tc.labeled)
+		}
+		server, l := serverlabels.Split(tc.labeled)
+		if server != tc.server || l != labels {
+			t.Errorf("Split(%q): got (%q, %v), want (%q, %v)", tc.labeled, server, l, tc.server, labels)
+		}
+		// Attaching again replaces the labels.
+		if got, want := serverlabels.Attach(tc.labeled, serverlabels.Labels{Zone: "z"}), serverlabels.Attach(tc.server, serverlabels.Labels{Zone: "z"}); got != want {
+			t.Errorf("Attach(%q): got %q, want %q", tc.labeled, got, want)
+		}
+		// Attaching no labels removes them.
+		if got := serverlabels.Attach(tc.labeled, serverlabels.Labels{}); got != tc.server {
+			t.Errorf("Attach(%q): got %q, want %q", tc.labeled, got, tc.server)
+		}
+	}
+}
+
+func TestParseIgnoresUnknown(t *testing.T) {
+	_, l := serverlabels.Split("/127.0.0.1:1234#weight=x,color=red,zone=a,junk")
+	if want := (serverlabels.Labels{Zone: "a"}); l != want {
+		t.Errorf("got %v, want %v", l, want)
+	}
+	if got := l.EffectiveWeight(); got != serverlabels.DefaultWeight {
+		t.Errorf("got weight %d, want %d", got, serverlabels.DefaultWeight)
+	}
+}
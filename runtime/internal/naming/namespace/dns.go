@@ -0,0 +1,168 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package namespace
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/naming"
+	vdltime "v.io/v23/vdlroot/time"
+	"v.io/v23/verror"
+
+	"v.io/x/ref/lib/serverlabels"
+)
+
+const (
+	// DNSService and DNSProto name the SRV records consulted by the DNS
+	// resolver, i.e. _v23._tcp.<domain>.
+	DNSService = "v23"
+	DNSProto   = "tcp"
+
+	// DNSServerKey prefixes TXT records that carry the object address of
+	// a server, e.g. "v23=/@6@tcp@10.0.0.1:8101@@@@@@".
+	DNSServerKey = "v23="
+	// DNSMountTableKey prefixes the TXT record that says whether the
+	// servers are mount tables, e.g. "v23mt=0".  The default is that they
+	// are.
+	DNSMountTableKey = "v23mt="
+
+	// dnsTTL is how long entries resolved through DNS are valid.
+	dnsTTL = 5 * time.Minute
+)
+
+var (
+	errNoDNSRecords = verror.Register(pkgPath+".errNoDNSRecords", verror.NoRetry, "{1:}{2:} No v23 SRV or TXT records for {3}{:_}")
+)
+
+// dnsResolver is a Resolver that maps DNS names within a set of domains to
+// the servers listed in the SRV and TXT records of those names.
+type dnsResolver struct {
+	suffixes  []string
+	lookupSRV func(service, proto, name string) (string, []*net.SRV, error)
+	lookupTXT func(name string) ([]string, error)
+}
+
+// NewDNSResolver returns a Resolver for names whose first element is a DNS
+// name within one of the given domains, e.g. with suffix "v23.example.com"
+// the name "mt.v23.example.com/a/b" resolves "a/b" at the servers
+// listed for mt.v23.example.com.
+//
+// Servers are listed in SRV records for _v23._tcp.<name>, in which case they
+// are assumed to listen on tcp, or in TXT records of the form "v23=<object
+// address>".  The weight of SRV records is published as the weight label of
+// the server, see v.io/x/ref/lib/serverlabels.
+func NewDNSResolver(suffixes ...string) Resolver {
+	r := &dnsResolver{
+		lookupSRV: net.LookupSRV,
+		lookupTXT: net.LookupTXT,
+	}
+	for _, s := range suffixes {
+		if s = strings.Trim(strings.TrimSpace(s), "."); s != "" {
+			r.suffixes = append(r.suffixes, strings.ToLower(s))
+		}
+	}
+	return r
+}
+
+// handles returns true if the DNS name is within one of the resolver's
+// domains.
+func (r *dnsResolver) handles(host string) bool {
+	if host == "" || strings.ContainsAny(host, "*?[]") {
+		return false
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, s := range r.suffixes {
+		if host == s || strings.HasSuffix(host, "."+s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveRoot implements Resolver.ResolveRoot.
+func (r *dnsResolver) ResolveRoot(ctx *context.T, name string) (*naming.MountEntry, bool, error) {
+	elems := strings.SplitN(name, "/", 2)
+	host := elems[0]
+	if !r.handles(host) {
+		return nil, false, nil
+	}
+	e := &naming.MountEntry{ServesMountTable: true}
+	if len(elems) > 1 {
+		e.Name = elems[1]
+	}
+	// The lookups don't take the context, so run them in the background
+	// and give up on them once the context is done.
+	type srvResult struct {
+		srvs []*net.SRV
+		err  error
+	}
+	type txtResult struct {
+		txts []string
+		err  error
+	}
+	srvCh, txtCh := make(chan srvResult, 1), make(chan txtResult, 1)
+	go func() {
+		_, srvs, err := r.lookupSRV(DNSService, DNSProto, host)
+		srvCh <- srvResult{srvs, err}
+	}()
+	go func() {
+		txts, err := r.lookupTXT(host)
+		txtCh <- txtResult{txts, err}
+	}()
+	var (
+		srvRes srvResult
+		txtRes txtResult
+	)
+	for srvCh != nil || txtCh != nil {
+		select {
+		case srvRes = <-srvCh:
+			srvCh = nil
+		case txtRes = <-txtCh:
+			txtCh = nil
+		case <-ctx.Done():
+			return nil, true, ctx.Err()
+		}
+	}
+
+	deadline := vdltime.Deadline{Time: time.Now().Add(dnsTTL)}
+	// Both lookups may legitimately fail if there are no records of
+	// that type.
+	if srvRes.err == nil {
+		for _, srv := range srvRes.srvs {
+			address := net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port)))
+			server := naming.JoinAddressName(naming.FormatEndpoint("tcp", address), "")
+			server = serverlabels.Attach(server, serverlabels.Labels{Weight: int(srv.Weight)})
+			e.Servers = append(e.Servers, naming.MountedServer{Server: server, Deadline: deadline})
+		}
+	} else {
+		ctx.VI(2).Infof("LookupSRV(%s): %v", host, srvRes.err)
+	}
+	if txtRes.err == nil {
+		for _, txt := range txtRes.txts {
+			switch {
+			case strings.HasPrefix(txt, DNSServerKey):
+				server := strings.TrimPrefix(txt, DNSServerKey)
+				if !naming.Rooted(server) {
+					server = naming.JoinAddressName(server, "")
+				}
+				e.Servers = append(e.Servers, naming.MountedServer{Server: server, Deadline: deadline})
+			case strings.HasPrefix(txt, DNSMountTableKey):
+				if mt, err := strconv.ParseBool(strings.TrimPrefix(txt, DNSMountTableKey)); err == nil {
+					e.ServesMountTable = mt
+				}
+			}
+		}
+	} else {
+		ctx.VI(2).Infof("LookupTXT(%s): %v", host, txtRes.err)
+	}
+	if len(e.Servers) == 0 {
+		return nil, true, verror.New(errNoDNSRecords, ctx, host)
+	}
+	return e, true, nil
+}
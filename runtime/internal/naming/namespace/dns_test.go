@@ -0,0 +1,172 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package namespace
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+
+	"v.io/x/ref/test"
+)
+
+func fakeDNSResolver(srvs map[string][]*net.SRV, txts map[string][]string, suffixes ...string) *dnsResolver {
+	r := NewDNSResolver(suffixes...).(*dnsResolver)
+	r.lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+		if s, ok := srvs[name]; ok {
+			return "_" + service + "._" + proto + "." + name, s, nil
+		}
+		return "", nil, errors.New("no such host")
+	}
+	r.lookupTXT = func(name string) ([]string, error) {
+		if t, ok := txts[name]; ok {
+			return t, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	return r
+}
+
+func TestDNSResolver(t *testing.T) {
+	ctx, cancel := test.TestContext()
+	defer cancel()
+
+	srvs := map[string][]*net.SRV{
+		"mt.v23.example.com": {
+			{Target: "a.example.com.", Port: 8101, Priority: 1, Weight: 10},
+			{Target: "b.example.com.", Port: 8101, Priority: 2},
+		},
+		"w.v23.example.com": {
+			{Target: "c.example.com", Port: 1, Weight: 65535},
+			{Target: "d.example.com.", Port: 2, Weight: 1},
+		},
+	}
+	txts := map[string][]string{
+		"mt.v23.example.com":    {"v23=/@6@tcp@10.0.0.1:8101@@@@@@", "unrelated"},
+		"app.v23.example.com":   {"v23=@6@tcp@10.0.0.2:1234@@@@@@", "v23mt=false"},
+		"bad.v23.example.com":   {"unrelated"},
+		"fqdn.v23.example.com.": {"v23=/@6@tcp@10.0.0.3:1234@@@@@@"},
+	}
+	r := fakeDNSResolver(srvs, txts, "V23.example.com.")
+
+	testcases := []struct {
+		name     string
+		handled  bool
+		servers  []string
+		suffix   string
+		servesMT bool
+	}{
+		{"a/b", false, nil, "", false},
+		{"example.com/a", false, nil, "", false},
+		{"*.v23.example.com/a", false, nil, "", false},
+		{"mt.v23.example.com/a/b", true, []string{
+			"/@6@tcp@a.example.com:8101@@@@@@#weight=10",
+			"/@6@tcp@b.example.com:8101@@@@@@",
+			"/@6@tcp@10.0.0.1:8101@@@@@@",
+		}, "a/b", true},
+		{"app.v23.example.com", true, []string{"/@6@tcp@10.0.0.2:1234@@@@@@"}, "", false},
+		{"w.v23.example.com", true, []string{
+			"/@6@tcp@c.example.com:1@@@@@@#weight=65535",
+			"/@6@tcp@d.example.com:2@@@@@@#weight=1",
+		}, "", true},
+		{"fqdn.v23.example.com./a", true, []string{"/@6@tcp@10.0.0.3:1234@@@@@@"}, "a", true},
+		{"v23.example.com.", true, nil, "", false},
+		{"notv23.example.com./a", false, nil, "", false},
+		{"bad.v23.example.com/x", true, nil, "", false},
+		{"missing.v23.example.com/x", true, nil, "", false},
+	}
+	for _, tc := range testcases {
+		e, handled, err := r.ResolveRoot(ctx, tc.name)
+		if handled != tc.handled {
+			t.Errorf("%s: got handled %v, want %v", tc.name, handled, tc.handled)
+			continue
+		}
+		if !handled {
+			continue
+		}
+		if tc.servers == nil {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %v", tc.name, e)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		var got []string
+		for _, s := range e.Servers {
+			got = append(got, s.Server)
+		}
+		if !reflect.DeepEqual(got, tc.servers) {
+			t.Errorf("%s: got servers %v, want %v", tc.name, got, tc.servers)
+		}
+		if e.Name != tc.suffix || e.ServesMountTable != tc.servesMT {
+			t.Errorf("%s: got (%q, %v), want (%q, %v)", tc.name, e.Name, e.ServesMountTable, tc.suffix, tc.servesMT)
+		}
+	}
+}
+
+func TestDNSResolverDeadline(t *testing.T) {
+	ctx, cancel := test.TestContext()
+	defer cancel()
+
+	// Lookups that never return must not block past the deadline.
+	block := make(chan struct{})
+	defer close(block)
+	r := NewDNSResolver("v23.example.com").(*dnsResolver)
+	r.lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+		<-block
+		return "", nil, errors.New("no such host")
+	}
+	r.lookupTXT = func(name string) ([]string, error) {
+		<-block
+		return nil, errors.New("no such host")
+	}
+
+	ctx, cancel = context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, handled, err := r.ResolveRoot(ctx, "mt.v23.example.com/a"); !handled || err == nil {
+		t.Errorf("got (%v, %v), want a handled error", handled, err)
+	}
+}
+
+func TestRootMountEntryWithResolver(t *testing.T) {
+	ctx, cancel := test.TestContext()
+	defer cancel()
+
+	ns, err := New("/@6@tcp@10.0.0.9:8101@@@@@@")
+	if err != nil {
+		t.Fatal(err)
+	}
+	txts := map[string][]string{
+		"mt.v23.example.com":  {"v23=/@6@tcp@10.0.0.1:8101@@@@@@"},
+		"mt.v23.example.com.": {"v23=/@6@tcp@10.0.0.1:8101@@@@@@"},
+	}
+	ns.AddResolver(fakeDNSResolver(nil, txts, "v23.example.com"))
+
+	// The root of a DNS name is the DNS name, as the caller passed it, not
+	// one of its servers.
+	for _, root := range []string{"mt.v23.example.com", "mt.v23.example.com."} {
+		e, got := ns.rootMountEntry(ctx, root+"/a")
+		if got != root || len(e.Servers) != 1 || e.Servers[0].Server != "/@6@tcp@10.0.0.1:8101@@@@@@" || e.Name != "a" {
+			t.Errorf("got %v, %q", e, got)
+		}
+	}
+	// Rooted names are rooted at their address.
+	e, root := ns.rootMountEntry(ctx, "/@6@tcp@10.0.0.2:8101@@@@@@/a")
+	if root != "/@6@tcp@10.0.0.2:8101@@@@@@" || len(e.Servers) != 1 || e.Servers[0].Server != root || e.Name != "a" {
+		t.Errorf("got %v, %q", e, root)
+	}
+	// Other names still use the roots.
+	e, root = ns.rootMountEntry(ctx, "a/b")
+	if root != "" || len(e.Servers) != 1 || e.Servers[0].Server != "/@6@tcp@10.0.0.9:8101@@@@@@" || e.Name != "a/b" {
+		t.Errorf("got %v, %q", e, root)
+	}
+}
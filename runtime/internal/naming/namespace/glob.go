@@ -237,7 +237,7 @@ func (ns *namespace) globLoop(ctx *context.T, e *naming.MountEntry, prefix strin
 func (ns *namespace) Glob(ctx *context.T, pattern string, opts ...naming.NamespaceOpt) (<-chan naming.GlobReply, error) {
 	defer apilog.LogCallf(ctx, "pattern=%.10s...,opts...=%v", pattern, opts)(ctx, "") // gologcop: DO NOT EDIT, MUST BE FIRST STATEMENT
 	// Root the pattern.  If we have no servers to query, give up.
-	e, patternWasRooted := ns.rootMountEntry(pattern)
+	e, root := ns.rootMountEntry(ctx, pattern)
 	if len(e.Servers) == 0 {
 		return nil, verror.New(naming.ErrNoMountTable, ctx)
 	}
@@ -252,13 +252,10 @@ func (ns *namespace) Glob(ctx *context.T, pattern string, opts ...naming.Namespa
 
 	// If pattern was already rooted, make sure we tack that root
 	// onto all returned names.  Otherwise, just return the relative
-	// name.
-	var prefix string
-	if patternWasRooted {
-		prefix = e.Servers[0].Server
-	}
+	// name.  Note that the root of a name handled by a resolver is the
+	// name the caller passed, not one of the servers it resolved to.
 	e.Name = ""
 	reply := make(chan naming.GlobReply, 100)
-	go ns.globLoop(ctx, e, prefix, g, reply, tr, getCallOpts(opts))
+	go ns.globLoop(ctx, e, root, g, reply, tr, getCallOpts(opts))
 	return reply, nil
 }
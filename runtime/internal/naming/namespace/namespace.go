@@ -6,6 +6,7 @@ package namespace
 
 import (
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,6 +21,7 @@ import (
 
 	"v.io/x/ref"
 	"v.io/x/ref/lib/apilog"
+	"v.io/x/ref/lib/serverlabels"
 )
 
 const defaultMaxResolveDepth = 32
@@ -44,6 +46,19 @@ type namespace struct {
 
 	// cache for name resolutions
 	resolutionCache cache
+
+	// resolvers for names that are not served by the roots.
+	resolvers []Resolver
+}
+
+// Resolver maps names that are not served by mount tables, e.g. DNS names,
+// to the servers they refer to.
+type Resolver interface {
+	// ResolveRoot returns the mount entry for the unrooted name, and true,
+	// if the first element of name is handled by this Resolver.  The
+	// returned entry's Name is the remainder of name to be resolved by
+	// the returned servers.
+	ResolveRoot(ctx *context.T, name string) (*naming.MountEntry, bool, error)
 }
 
 // Factory creates a new namespace given a default namespace and a set
@@ -69,12 +84,24 @@ func New(roots ...string) (*namespace, error) {
 		return nil, badRoots(roots)
 	}
 	// A namespace with no roots can still be used for lookups of rooted names.
-	return &namespace{
+	ns := &namespace{
 		roots:                 roots,
 		maxResolveDepth:       defaultMaxResolveDepth,
 		maxRecursiveGlobDepth: defaultMaxRecursiveGlobDepth,
 		resolutionCache:       newCache(os.Getenv(ref.EnvDisableNamespaceCache) != ""),
-	}, nil
+	}
+	if suffixes := os.Getenv(ref.EnvNamespaceDNSSuffixes); suffixes != "" {
+		ns.AddResolver(NewDNSResolver(strings.Split(suffixes, ",")...))
+	}
+	return ns, nil
+}
+
+// AddResolver adds a Resolver that is consulted, in the order added, before
+// the roots for any unrooted name.
+func (ns *namespace) AddResolver(r Resolver) {
+	ns.Lock()
+	defer ns.Unlock()
+	ns.resolvers = append(ns.resolvers, r)
 }
 
 // SetRoots implements namespace.T.SetRoots
@@ -133,14 +160,35 @@ func (ns *namespace) rootName(name string) []string {
 //
 // Returns:
 // (1) MountEntry
-// (2) Whether "name" is a rooted name or not (if not, the namespace roots
-//     configured in "ns" will be used).
-func (ns *namespace) rootMountEntry(name string, opts ...naming.NamespaceOpt) (*naming.MountEntry, bool) {
+// (2) The root that the MountEntry's name is relative to, i.e. the address of
+//     a rooted name, or the first element of a name handled by one of the
+//     resolvers added with AddResolver.  It is empty if "name" is not rooted,
+//     in which case the namespace roots configured in "ns" will be used.
+func (ns *namespace) rootMountEntry(ctx *context.T, name string, opts ...naming.NamespaceOpt) (*naming.MountEntry, string) {
 	_, name = security.SplitPatternName(naming.Clean(name))
 	e := new(naming.MountEntry)
 	deadline := vdltime.Deadline{Time: time.Now().Add(time.Hour)} // plenty of time for a call
 	address, suffix := naming.SplitAddressName(name)
 	if len(address) == 0 {
+		ns.RLock()
+		resolvers := ns.resolvers
+		ns.RUnlock()
+		// The resolvers may block, so don't hold the lock.
+		for _, r := range resolvers {
+			re, ok, err := r.ResolveRoot(ctx, name)
+			if !ok {
+				continue
+			}
+			root := strings.SplitN(name, "/", 2)[0]
+			if err != nil {
+				// Return an entry with no servers, the callers
+				// will report the name as not found.
+				ctx.VI(1).Infof("rootMountEntry(%s): %v", name, err)
+				e.Name = name
+				return e, root
+			}
+			return re, root
+		}
 		e.ServesMountTable = true
 		e.Name = name
 		ns.RLock()
@@ -148,16 +196,17 @@ func (ns *namespace) rootMountEntry(name string, opts ...naming.NamespaceOpt) (*
 		for _, r := range ns.roots {
 			e.Servers = append(e.Servers, naming.MountedServer{Server: r, Deadline: deadline})
 		}
-		return e, false
+		return e, ""
 	}
 	servesMT := true
-	if ep, err := naming.ParseEndpoint(address); err == nil {
+	if ep, err := naming.ParseEndpoint(serverlabels.Strip(address)); err == nil {
 		servesMT = ep.ServesMountTable
 	}
 	e.ServesMountTable = servesMT
 	e.Name = suffix
-	e.Servers = []naming.MountedServer{{Server: naming.JoinAddressName(address, ""), Deadline: deadline}}
-	return e, true
+	root := naming.JoinAddressName(address, "")
+	e.Servers = []naming.MountedServer{{Server: root, Deadline: deadline}}
+	return e, root
 }
 
 // notAnMT returns true if the error indicates this isn't a mounttable server.
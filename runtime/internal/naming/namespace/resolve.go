@@ -129,7 +129,7 @@ func (ns *namespace) resolveInternal(ctx *context.T, name string, opts ...naming
 		return e, nil
 	}
 	// Expand any relative name.
-	e, _ = ns.rootMountEntry(name, opts...)
+	e, _ = ns.rootMountEntry(ctx, name, opts...)
 	if ctx.V(2) {
 		_, file, line, _ := runtime.Caller(1)
 		ctx.Infof("Resolve(%s) called from %s:%d", name, file, line)
@@ -198,7 +198,7 @@ func (ns *namespace) ShallowResolve(ctx *context.T, name string, opts ...naming.
 // ResolveToMountTable implements v.io/v23/naming.Namespace.
 func (ns *namespace) ResolveToMountTable(ctx *context.T, name string, opts ...naming.NamespaceOpt) (*naming.MountEntry, error) {
 	defer apilog.LogCallf(ctx, "name=%.10s...,opts...=%v", name, opts)(ctx, "") // gologcop: DO NOT EDIT, MUST BE FIRST STATEMENT
-	e, _ := ns.rootMountEntry(name, opts...)
+	e, _ := ns.rootMountEntry(ctx, name, opts...)
 	if ctx.V(2) {
 		_, file, line, _ := runtime.Caller(1)
 		ctx.Infof("ResolveToMountTable(%s) called from %s:%d", name, file, line)
@@ -0,0 +1,156 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rpc
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"v.io/v23/naming"
+)
+
+const (
+	// failureMemory is how long a failure to connect to a server counts
+	// against it.
+	failureMemory = 30 * time.Second
+	// latencyDecay is the weight of a new sample in the moving average of
+	// a server's latency.
+	latencyDecay = 0.25
+	// maxHealthEntries bounds the number of servers the balancer
+	// remembers.
+	maxHealthEntries = 1 << 11
+)
+
+// serverHealth is the client's recent experience with a single server.
+type serverHealth struct {
+	latency     time.Duration // moving average of the time to connect.
+	failures    int           // consecutive failures to connect.
+	lastFailure time.Time
+}
+
+// balancer orders servers for a client based on the labels they published
+// and on the client's own history of connecting to them.
+type balancer struct {
+	zone string
+	now  func() time.Time
+
+	mu     sync.Mutex
+	rand   *rand.Rand
+	health map[string]*serverHealth // keyed by server address.
+}
+
+func newBalancer(zone string) *balancer {
+	return &balancer{
+		zone:   zone,
+		now:    time.Now,
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		health: make(map[string]*serverHealth),
+	}
+}
+
+func serverAddress(server string) string {
+	if naming.Rooted(server) {
+		server, _ = naming.SplitAddressName(server)
+	}
+	return server
+}
+
+// entryLocked returns the health entry for server, creating it if needed.
+func (b *balancer) entryLocked(server string) *serverHealth {
+	addr := serverAddress(server)
+	h := b.health[addr]
+	if h == nil {
+		if len(b.health) >= maxHealthEntries {
+			for k := range b.health {
+				delete(b.health, k)
+				break
+			}
+		}
+		h = &serverHealth{}
+		b.health[addr] = h
+	}
+	return h
+}
+
+// succeeded records that connecting to server took latency.
+func (b *balancer) succeeded(server string, latency time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h := b.entryLocked(server)
+	if h.latency == 0 {
+		h.latency = latency
+	} else {
+		h.latency = time.Duration(latencyDecay*float64(latency) + (1-latencyDecay)*float64(h.latency))
+	}
+	h.failures = 0
+}
+
+// failed records that connecting to server failed.
+func (b *balancer) failed(server string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h := b.entryLocked(server)
+	h.failures++
+	h.lastFailure = b.now()
+}
+
+// rank fills in the per-call ordering fields of list.
+func (b *balancer) rank(list sortableServerList) {
+	now := b.now()
+	weighted := false
+	for _, s := range list {
+		if s.labels.Weight > 0 {
+			weighted = true
+			break
+		}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// Servers we have not connected to yet are assumed to have the
+	// average latency of those we have connected to.
+	var total time.Duration
+	var known int
+	latencies := make([]time.Duration, len(list))
+	for i := range list {
+		s := &list[i]
+		s.sameZone = b.zone != "" && s.labels.Zone == b.zone
+		s.failures, s.lbKey = 0, 0
+		h := b.health[serverAddress(s.server.Server)]
+		if h == nil {
+			continue
+		}
+		if h.failures > 0 && now.Sub(h.lastFailure) < failureMemory {
+			s.failures = h.failures
+		}
+		if h.latency > 0 {
+			latencies[i] = h.latency
+			total += h.latency
+			known++
+		}
+	}
+	for i := range list {
+		s := &list[i]
+		if !weighted {
+			// Unknown servers sort before known ones, so that
+			// they get explored.
+			s.lbKey = -latencies[i].Seconds()
+			continue
+		}
+		latency := latencies[i]
+		if latency == 0 && known > 0 {
+			latency = total / time.Duration(known)
+		}
+		w := float64(s.labels.EffectiveWeight())
+		if latency > 0 {
+			w /= latency.Seconds()
+		}
+		// Weighted random sampling: sorting by u^(1/w) yields an order
+		// in which each server is first with probability proportional
+		// to its weight.
+		s.lbKey = math.Pow(b.rand.Float64(), 1/w)
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"v.io/v23/vtrace"
 	"v.io/x/ref/lib/apilog"
 	slib "v.io/x/ref/lib/security"
+	"v.io/x/ref/lib/serverlabels"
 	"v.io/x/ref/runtime/internal/flow/conn"
 	"v.io/x/ref/runtime/internal/flow/manager"
 )
@@ -85,6 +86,7 @@ type client struct {
 	preferredProtocols []string
 	ctx                *context.T
 	outstanding        *outstandingStats
+	balancer           *balancer
 	// stop is kept for backward compatibilty to implement Close().
 	// TODO(mattr): deprecate Close.
 	stop func()
@@ -112,10 +114,13 @@ func NewClient(ctx *context.T, opts ...rpc.ClientOpt) rpc.Client {
 	}
 
 	connIdleExpiry := time.Duration(0)
+	zone := ""
 	for _, opt := range opts {
 		switch v := opt.(type) {
 		case PreferredProtocols:
 			c.preferredProtocols = v
+		case serverlabels.Zone:
+			zone = string(v)
 		case clientFlowManagerOpt:
 			c.flowMgr = v.mgr
 		case IdleConnectionExpiry:
@@ -125,6 +130,7 @@ func NewClient(ctx *context.T, opts ...rpc.ClientOpt) rpc.Client {
 	if c.flowMgr == nil {
 		c.flowMgr = manager.New(ctx, naming.NullRoutingID, nil, 0, connIdleExpiry, nil)
 	}
+	c.balancer = newBalancer(zone)
 
 	go func() {
 		<-ctx.Done()
@@ -327,12 +333,12 @@ func (c *client) tryConnectToName(ctx *context.T, name, method string, args []in
 		// This should never happen.
 		return nil, verror.NoRetry, true, verror.New(verror.ErrInternal, ctx, name)
 	}
-	if resolved.Servers, err = filterAndOrderServers(resolved.Servers, c.preferredProtocols); err != nil {
+	if resolved.Servers, err = balanceServers(resolved.Servers, c.preferredProtocols, c.balancer); err != nil {
 		return nil, verror.RetryRefetch, true, verror.New(verror.ErrNoServers, ctx, name, err)
 	}
 
 	// servers is now ordered by the priority heurestic implemented in
-	// filterAndOrderServers.
+	// balanceServers.
 	//
 	// Try to connect to all servers in parallel.  Provide sufficient
 	// buffering for all of the connections to finish instantaneously. This
@@ -404,7 +410,8 @@ func (c *client) tryConnectToName(ctx *context.T, name, method string, args []in
 // The server at the remote end of the flow is authorized using the provided
 // authorizer, both during creation of the VC underlying the flow and the
 // flow itself.
-// TODO(cnicolaou): implement real, configurable load balancing.
+//
+// The outcome of the attempt is recorded in the client's balancer.
 func (c *client) tryConnectToServer(
 	ctx *context.T,
 	index int,
@@ -450,12 +457,18 @@ func (c *client) tryConnectToServer(
 			return
 		}
 	} else {
+		start := time.Now()
 		flw, err = c.flowMgr.Dial(ctx, ep, auth, connOpts.channelTimeout)
 		if err != nil {
 			ctx.VI(2).Infof("rpc: failed to create Flow with %v: %v", server, err)
 			status.serverErr = suberr(err)
+			// Don't hold our own cancellation against the server.
+			if ctx.Err() == nil {
+				c.balancer.failed(server)
+			}
 			return
 		}
+		c.balancer.succeeded(server, time.Since(start))
 	}
 	if write := c.typeCache.writer(flw.Conn()); write != nil {
 		// Create the type flow with a root-cancellable context.
@@ -29,6 +29,7 @@ import (
 	"v.io/x/ref/lib/apilog"
 	"v.io/x/ref/lib/publisher"
 	"v.io/x/ref/lib/pubsub"
+	"v.io/x/ref/lib/serverlabels"
 	"v.io/x/ref/lib/stats"
 	"v.io/x/ref/runtime/internal/flow/conn"
 	"v.io/x/ref/runtime/internal/flow/manager"
@@ -76,7 +77,8 @@ type server struct {
 	preferredProtocols []string       // protocols to use when resolving proxy name to endpoint.
 	servesMountTable   bool
 	isLeaf             bool
-	lameDuckTimeout    time.Duration // the time to wait for inflight operations to finish on shutdown
+	labels             serverlabels.Labels // labels published with the endpoints.
+	lameDuckTimeout    time.Duration       // the time to wait for inflight operations to finish on shutdown
 
 	stats       *rpcStats // stats for this server.
 	outstanding *outstandingStats
@@ -140,6 +142,8 @@ func WithNewDispatchingServer(ctx *context.T,
 			s.dispReserved = opt.Dispatcher
 		case PreferredServerResolveProtocols:
 			s.preferredProtocols = []string(opt)
+		case serverlabels.Labels:
+			s.labels = opt
 		case options.ChannelTimeout:
 			channelTimeout = time.Duration(opt)
 		case options.LameDuckTimeout:
@@ -509,12 +513,12 @@ func (s *server) updateEndpointsLocked(ctx *context.T, leps []naming.Endpoint) {
 	s.Unlock()
 	for k, ep := range rmEps {
 		if ep.Addr().Network() != bidiProtocol {
-			s.publisher.RemoveServer(k)
+			s.publisher.RemoveServer(serverlabels.Attach(k, s.labels))
 		}
 	}
 	for k, ep := range addEps {
 		if ep.Addr().Network() != bidiProtocol {
-			s.publisher.AddServer(k)
+			s.publisher.AddServer(serverlabels.Attach(k, s.labels))
 		}
 	}
 	s.Lock()
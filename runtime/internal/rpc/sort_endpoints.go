@@ -15,6 +15,7 @@ import (
 	"v.io/v23/verror"
 
 	"v.io/x/lib/netstate"
+	"v.io/x/ref/lib/serverlabels"
 )
 
 var (
@@ -66,7 +67,22 @@ func init() {
 // will be used, but unlike the previous case, any servers that don't support
 // these protocols will be returned also, but following the default
 // preferences.
+//
+// Any labels that the servers published when mounting are removed from the
+// returned servers.
 func filterAndOrderServers(servers []naming.MountedServer, protocols []string, ipnets ...*net.IPNet) ([]naming.MountedServer, error) {
+	return balanceServers(servers, protocols, nil, ipnets...)
+}
+
+// balanceServers is like filterAndOrderServers, but if b is non-nil, servers
+// within the same protocol rank are additionally ordered by:
+// - whether they published the same zone as the client's.
+// - whether they have recently failed, fewer failures first.
+// - locality, as above.
+// - a weighted random choice, if any of the servers published a weight,
+// taking into account the latency observed by the client.  Otherwise,
+// servers with lower observed latency are preferred.
+func balanceServers(servers []naming.MountedServer, protocols []string, b *balancer, ipnets ...*net.IPNet) ([]naming.MountedServer, error) {
 	if ipnets == nil {
 		if err := refreshCache(); err != nil {
 			return nil, err
@@ -102,6 +118,9 @@ func filterAndOrderServers(servers []naming.MountedServer, protocols []string, i
 	if len(list) == 0 {
 		return nil, verror.AddSubErrs(verror.New(errNoCompatibleServers, nil), nil, errs...)
 	}
+	if b != nil {
+		b.rank(list)
+	}
 	// TODO(ashankar): Don't have to use stable sorting, could
 	// just use sort.Sort. The only problem with that is the
 	// unittest.
@@ -124,7 +143,9 @@ func mkSortableServer(server naming.MountedServer, protoRanks map[string]int, pr
 		return ss, nil
 	}
 
-	ep, err := name2endpoint(name)
+	stripped, labels := serverlabels.Split(name)
+	server.Server = stripped
+	ep, err := name2endpoint(stripped)
 	if err != nil {
 		return sortableServer{}, verror.New(errMalformedEndpoint, nil, err)
 	}
@@ -144,6 +165,7 @@ func mkSortableServer(server naming.MountedServer, protoRanks map[string]int, pr
 		server:       server,
 		protocolRank: rank,
 		locality:     locality(ep, ipnets),
+		labels:       labels,
 	}
 	serversCache[k] = ss
 	return ss, nil
@@ -248,6 +270,13 @@ type sortableServer struct {
 	server       naming.MountedServer
 	protocolRank int            // larger values are preferred.
 	locality     serverLocality // larger values are preferred.
+	labels       serverlabels.Labels
+
+	// The following are computed by the balancer for every call and
+	// are never cached.
+	sameZone bool    // true values are preferred.
+	failures int     // smaller values are preferred.
+	lbKey    float64 // larger values are preferred.
 }
 
 func (s *sortableServer) String() string {
@@ -259,10 +288,18 @@ type sortableServerList []sortableServer
 func (l sortableServerList) Len() int      { return len(l) }
 func (l sortableServerList) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
 func (l sortableServerList) Less(i, j int) bool {
-	if l[i].protocolRank == l[j].protocolRank {
-		return l[i].locality > l[j].locality
+	a, b := &l[i], &l[j]
+	switch {
+	case a.protocolRank != b.protocolRank:
+		return a.protocolRank > b.protocolRank
+	case a.sameZone != b.sameZone:
+		return a.sameZone
+	case a.failures != b.failures:
+		return a.failures < b.failures
+	case a.locality != b.locality:
+		return a.locality > b.locality
 	}
-	return l[i].protocolRank > l[j].protocolRank
+	return a.lbKey > b.lbKey
 }
 
 func mkProtocolRankMap(list []string) map[string]int {
@@ -9,8 +9,10 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"v.io/v23/naming"
+	"v.io/x/ref/lib/serverlabels"
 )
 
 func servers2names(servers []naming.MountedServer) []string {
@@ -209,3 +211,95 @@ func TestOrderingByLocality(t *testing.T) {
 		t.Errorf("got: %v, want %v", got, want)
 	}
 }
+
+func TestOrderingWithLabels(t *testing.T) {
+	_, ipnet, _ := net.ParseCIDR("127.0.0.0/8")
+	ipnets := []*net.IPNet{ipnet}
+	labeled := func(a string, l serverlabels.Labels) naming.MountedServer {
+		name := naming.JoinAddressName(naming.FormatEndpoint("tcp", a), "")
+		return naming.MountedServer{Server: serverlabels.Attach(name, l)}
+	}
+	servers := []naming.MountedServer{
+		labeled("127.0.0.1", serverlabels.Labels{Zone: "a"}),
+		labeled("127.0.0.2", serverlabels.Labels{Zone: "b"}),
+		labeled("127.0.0.3", serverlabels.Labels{}),
+		labeled("74.125.69.139", serverlabels.Labels{Zone: "b"}),
+	}
+
+	// Without a balancer, labels are stripped and otherwise ignored.
+	result, err := filterAndOrderServers(servers, []string{"tcp"}, ipnets...)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := []string{
+		"/@6@tcp@127.0.0.1@@@@@@",
+		"/@6@tcp@127.0.0.2@@@@@@",
+		"/@6@tcp@127.0.0.3@@@@@@",
+		"/@6@tcp@74.125.69.139@@@@@@",
+	}
+	if got := servers2names(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want %v", got, want)
+	}
+
+	// Servers in the client's zone come first, then those that haven't
+	// failed recently.
+	b := newBalancer("b")
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	b.failed("/@6@tcp@127.0.0.2@@@@@@")
+	if result, err = balanceServers(servers, []string{"tcp"}, b, ipnets...); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want = []string{
+		"/@6@tcp@74.125.69.139@@@@@@",
+		"/@6@tcp@127.0.0.2@@@@@@",
+		"/@6@tcp@127.0.0.1@@@@@@",
+		"/@6@tcp@127.0.0.3@@@@@@",
+	}
+	if got := servers2names(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want %v", got, want)
+	}
+
+	// Failures are forgotten after a while, and lower latency servers are
+	// preferred.
+	now = now.Add(2 * failureMemory)
+	b.succeeded("/@6@tcp@127.0.0.1@@@@@@", time.Second)
+	b.succeeded("/@6@tcp@127.0.0.3@@@@@@", time.Millisecond)
+	if result, err = balanceServers(servers, []string{"tcp"}, b, ipnets...); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want = []string{
+		"/@6@tcp@127.0.0.2@@@@@@",
+		"/@6@tcp@74.125.69.139@@@@@@",
+		"/@6@tcp@127.0.0.3@@@@@@",
+		"/@6@tcp@127.0.0.1@@@@@@",
+	}
+	if got := servers2names(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want %v", got, want)
+	}
+}
+
+func TestWeightedBalancing(t *testing.T) {
+	_, ipnet, _ := net.ParseCIDR("127.0.0.0/8")
+	ipnets := []*net.IPNet{ipnet}
+	var servers []naming.MountedServer
+	for i, a := range []string{"127.0.0.1", "127.0.0.2"} {
+		name := naming.JoinAddressName(naming.FormatEndpoint("tcp", a), "")
+		servers = append(servers, naming.MountedServer{Server: serverlabels.Attach(name, serverlabels.Labels{Weight: 1 + 3*i})})
+	}
+	b := newBalancer("")
+	first := make(map[string]int)
+	const iterations = 4000
+	for i := 0; i < iterations; i++ {
+		result, err := balanceServers(servers, []string{"tcp"}, b, ipnets...)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		first[result[0].Server]++
+	}
+	// The second server has four times the weight of the first, so it
+	// should be chosen first about 80% of the time.
+	if got := first["/@6@tcp@127.0.0.2@@@@@@"]; got < iterations*70/100 || got > iterations*90/100 {
+		t.Errorf("server with weight 4 was first %d out of %d times", got, iterations)
+	}
+}
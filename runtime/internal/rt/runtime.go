@@ -10,6 +10,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
@@ -40,6 +41,7 @@ import (
 	inamespace "v.io/x/ref/runtime/internal/naming/namespace"
 	irpc "v.io/x/ref/runtime/internal/rpc"
 	ivtrace "v.io/x/ref/runtime/internal/vtrace"
+	"v.io/x/ref/services/revocation"
 )
 
 type contextKey int
@@ -62,6 +64,7 @@ func init() {
 
 var (
 	errDiscoveryNotInitialized = verror.Register(pkgPath+".errDiscoveryNotInitialized", verror.NoRetry, "{1:}{2:} discovery not initialized")
+	errInvalidRefresh          = verror.Register(pkgPath+".errInvalidRefresh", verror.NoRetry, "{1:}{2:} invalid revocation snapshot refresh interval {3}")
 )
 
 var setPrincipalCounter int32 = -1
@@ -76,6 +79,7 @@ type initData struct {
 }
 
 type vtraceDependency struct{}
+type revocationDependency struct{}
 
 // Runtime implements the v23.Runtime interface.
 // Please see the interface definition for documentation of the
@@ -183,6 +187,12 @@ func Init(
 		return nil, nil, nil, err
 	}
 
+	// Validate discharges against revocation snapshots.
+	ctx = revocation.WithSnapshots(ctx, flags.Revocation.MaxStaleness)
+	if err := r.initRevocation(ctx, flags.Revocation); err != nil {
+		return nil, nil, nil, err
+	}
+
 	r.ctx = ctx
 	return r, r.WithBackgroundContext(ctx), r.shutdown, nil
 }
@@ -237,6 +247,30 @@ func (r *Runtime) initSignalHandling(ctx *context.T) {
 	})
 }
 
+// initRevocation periodically fetches the revocation snapshots from the
+// revocation list services named in flags.
+func (r *Runtime) initRevocation(ctx *context.T, flags flags.RevocationFlags) error {
+	if flags.Snapshots == "" {
+		return nil
+	}
+	if flags.Refresh <= 0 {
+		return verror.New(errInvalidRefresh, ctx, flags.Refresh)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for _, name := range strings.Split(flags.Snapshots, ",") {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			revocation.Refresh(ctx, name, flags.Refresh)
+		}(name)
+	}
+	return r.addChild(ctx, revocationDependency{}, func() {
+		cancel()
+		wg.Wait()
+	}, r.GetClient(ctx))
+}
+
 func (r *Runtime) setPrincipal(ctx *context.T, principal security.Principal, shutdown func()) (*context.T, error) {
 	stop := shutdown
 	if principal != nil {
@@ -35,8 +35,13 @@ type rpcInfo struct {
 type rpcHandler struct {
 	decode decoderFunc
 	f      reflect.Value
+	// Whether f takes the connection on which the rpc was received as its
+	// first argument.
+	withConn bool
 }
 
+var connType = reflect.TypeOf((*IPCConn)(nil))
+
 // IPC provides interprocess rpcs.
 // One process must act as the "server" by listening for connections.
 // However once connected rpcs can flow in either direction.
@@ -60,6 +65,9 @@ type IPCConn struct {
 	nextId uint64
 	ipc    *IPC
 	rpcs   map[uint64]rpcInfo
+	// The credentials of the process at the other end of conn.
+	peer    PeerCredentials
+	peerErr error
 }
 
 // Close the connection to the process.
@@ -163,6 +171,7 @@ func (ipc *IPC) newConn(conn net.Conn) *IPCConn {
 // Must be called while holding ipc.mu
 func (ipc *IPC) newConnLocked(conn net.Conn) *IPCConn {
 	result := &IPCConn{enc: vom.NewEncoder(conn), dec: vom.NewDecoder(conn), conn: conn, ipc: ipc, rpcs: make(map[uint64]rpcInfo)}
+	result.peer, result.peerErr = peerCredentialsOf(conn)
 	// Don't allow any rpcs to be sent until negotiateVersion unlocks this.
 	result.mu.Lock()
 	ipc.conns = append(ipc.conns, result)
@@ -258,11 +267,15 @@ func (ipc *IPC) negotiateVersion(c *IPCConn) int32 {
 	return -1
 }
 
-func makeDecoder(t reflect.Type) decoderFunc {
-	numArgs := t.NumIn() - 1
+func makeDecoder(t reflect.Type, withConn bool) decoderFunc {
+	first := 1
+	if withConn {
+		first = 2
+	}
+	numArgs := t.NumIn() - first
 	inTypes := make([]reflect.Type, numArgs)
-	for i := 1; i < numArgs+1; i++ {
-		inTypes[i-1] = t.In(i)
+	for i := first; i < numArgs+first; i++ {
+		inTypes[i-first] = t.In(i)
 	}
 	return func(n uint32, dec *vom.Decoder) (result []reflect.Value, err error) {
 		if n != uint32(numArgs) {
@@ -301,6 +314,8 @@ func noDecoder(n uint32, dec *vom.Decoder) (result []reflect.Value, err error) {
 // All arguments and results for these methods must be VOM serializable.
 // Additionally each method must have at least one return value, and
 // the final return value must be an 'error'.
+// Methods whose first argument is an *IPCConn receive the connection on which
+// the rpc was received in that argument, which is not sent by the caller.
 // Serve must be called before Listen() or Connect().
 func (ipc *IPC) Serve(x interface{}) error {
 	v := reflect.ValueOf(x)
@@ -310,7 +325,8 @@ func (ipc *IPC) Serve(x interface{}) error {
 		if _, exists := ipc.handlers[m.Name]; exists {
 			return fmt.Errorf("Method %s already registered", m.Name)
 		}
-		ipc.handlers[m.Name] = rpcHandler{makeDecoder(m.Type), v.Method(i)}
+		withConn := m.Type.NumIn() > 1 && m.Type.In(1) == connType
+		ipc.handlers[m.Name] = rpcHandler{makeDecoder(m.Type, withConn), v.Method(i), withConn}
 		vlog.VI(4).Infof("Registered method %q", m.Name)
 	}
 	return nil
@@ -319,7 +335,7 @@ func (ipc *IPC) Serve(x interface{}) error {
 func (ipc *IPC) dispatch(req agent.RpcRequest) rpcHandler {
 	handler, ok := ipc.handlers[req.Method]
 	if !ok {
-		handler = rpcHandler{noDecoder, reflect.Value{}}
+		handler = rpcHandler{noDecoder, reflect.Value{}, false}
 	}
 	return handler
 }
@@ -384,6 +400,8 @@ func (ipc *IPC) readMessage(c *IPCConn) (func(), error) {
 
 		if err != nil {
 			err = fmt.Errorf("%s: %v", msg.Method, err)
+		} else if handler.withConn {
+			args = append([]reflect.Value{reflect.ValueOf(c)}, args...)
 		}
 		return func() { ipc.handleReq(c, msg.Id, handler.f, args, err) }, nil
 	case agent.RpcResponse:
@@ -54,6 +54,16 @@ func (returnError) BreakMe(a, b string) (int, error) {
 	return 0, fmt.Errorf("%s %s", a, b)
 }
 
+type whoami int
+
+func (whoami) WhoAmI(c *IPCConn, prefix string) (string, error) {
+	creds, err := c.PeerCredentials()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%d", prefix, creds.Uid), nil
+}
+
 type tunnel struct {
 	ipc *IPC
 }
@@ -241,6 +251,50 @@ func TestEcho(t *testing.T) {
 	}
 }
 
+func TestPeerCredentials(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("peer credentials are only supported on linux")
+	}
+	var w whoami
+	_, path, cleanup := newServer(t, w)
+	defer cleanup()
+
+	ipc2 := NewIPC()
+	defer ipc2.Close()
+	client, err := ipc2.Connect(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result string
+	if err := client.Call("WhoAmI", []interface{}{"uid "}, &result); err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf("uid %d", os.Getuid()); result != want {
+		t.Fatalf("Expected %q, got %q", want, result)
+	}
+}
+
+func TestPeerExecutable(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("peer credentials are only supported on linux")
+	}
+	want, err := os.Readlink("/proc/self/exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := PeerCredentials{Pid: os.Getpid(), start: processStart(os.Getpid())}
+	if got := executable(creds); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	// A process with the same pid that started at another time is not
+	// identified as the same process.
+	creds.start += "0"
+	if got := executable(creds); got != "" {
+		t.Errorf("Expected no executable, got %q", got)
+	}
+}
+
 func TestOutOfOrder(t *testing.T) {
 	delay := newDelayedEcho()
 	_, path, cleanup := newServer(t, delay)
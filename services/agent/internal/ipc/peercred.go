@@ -0,0 +1,45 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ipc
+
+import (
+	"errors"
+	"net"
+)
+
+var errPeerCredentialsUnsupported = errors.New("peer credentials are not supported on this platform")
+
+// PeerCredentials identifies the process at the other end of a connection.
+type PeerCredentials struct {
+	Pid, Uid, Gid int
+	// Executable is the path of the executable of the process, if known.
+	Executable string
+	// start identifies the process among those with the same pid over
+	// time.
+	start string
+}
+
+// PeerCredentials returns the credentials of the process that established the
+// connection.  The executable is read again on each call, so that a process
+// that executes another program after connecting is identified by that
+// program, and is unknown once the process exits.  Note that the other
+// processes that the connection is shared with or passed to, e.g. the
+// children of the process, are identified as the process that connected.
+func (c *IPCConn) PeerCredentials() (PeerCredentials, error) {
+	if c.peerErr != nil {
+		return PeerCredentials{}, c.peerErr
+	}
+	creds := c.peer
+	creds.Executable = executable(creds)
+	return creds, nil
+}
+
+func peerCredentialsOf(conn net.Conn) (PeerCredentials, error) {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return PeerCredentials{}, errPeerCredentialsUnsupported
+	}
+	return unixPeerCredentials(uc)
+}
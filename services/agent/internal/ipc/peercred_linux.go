@@ -0,0 +1,70 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ipc
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+)
+
+func unixPeerCredentials(conn *net.UnixConn) (PeerCredentials, error) {
+	f, err := conn.File()
+	if err != nil {
+		return PeerCredentials{}, err
+	}
+	defer f.Close()
+	fd := int(f.Fd())
+	// File puts the (shared) file descriptor in blocking mode, which the
+	// connection does not expect.
+	defer syscall.SetNonblock(fd, true)
+	ucred, err := syscall.GetsockoptUcred(fd, syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	if err != nil {
+		return PeerCredentials{}, err
+	}
+	creds := PeerCredentials{Pid: int(ucred.Pid), Uid: int(ucred.Uid), Gid: int(ucred.Gid)}
+	creds.start = processStart(creds.Pid)
+	creds.Executable = executable(creds)
+	return creds, nil
+}
+
+// executable returns the path of the executable that the process identified by
+// creds runs now, or "" if it can not be read, e.g. because the process has
+// exited and its pid may have been reused.
+func executable(creds PeerCredentials) string {
+	if creds.start == "" || processStart(creds.Pid) != creds.start {
+		return ""
+	}
+	// The executable can only be read by the same user, or by root.
+	exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", creds.Pid))
+	if err != nil || processStart(creds.Pid) != creds.start {
+		return ""
+	}
+	return exe
+}
+
+// processStart returns the start time of the process with the given pid, or ""
+// if there is no such process.
+func processStart(pid int) string {
+	stat, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return ""
+	}
+	// The name of the executable, in parentheses, may contain spaces.
+	i := bytes.LastIndexByte(stat, ')')
+	if i < 0 {
+		return ""
+	}
+	// The start time is the 22nd field, i.e. the 20th after the name.
+	fields := strings.Fields(string(stat[i+1:]))
+	if len(fields) < 20 {
+		return ""
+	}
+	return fields[19]
+}
@@ -0,0 +1,18 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package ipc
+
+import "net"
+
+func unixPeerCredentials(*net.UnixConn) (PeerCredentials, error) {
+	return PeerCredentials{}, errPeerCredentialsUnsupported
+}
+
+func executable(PeerCredentials) string {
+	return ""
+}
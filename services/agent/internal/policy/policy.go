@@ -0,0 +1,291 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package policy implements the rules that restrict the private key
+// operations, and the changes of the blessings and roots, that the agent
+// performs on behalf of each of its clients.
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"v.io/v23/security"
+	"v.io/v23/verror"
+	"v.io/v23/vom"
+)
+
+const pkgPath = "v.io/x/ref/services/agent/internal/policy"
+
+var (
+	errNoRule           = verror.Register(pkgPath+".errNoRule", verror.NoRetry, "{1:}{2:} no policy rule applies to {3}")
+	errNotAllowed       = verror.Register(pkgPath+".errNotAllowed", verror.NoRetry, "{1:}{2:} {3} is not allowed for {4}")
+	errBadExtension     = verror.Register(pkgPath+".errBadExtension", verror.NoRetry, "{1:}{2:} extension {3} is not allowed for {4}")
+	errExpiryRequired   = verror.Register(pkgPath+".errExpiryRequired", verror.NoRetry, "{1:}{2:} {3} for {4} requires an expiry caveat within {5}")
+	errNotConfirmed     = verror.Register(pkgPath+".errNotConfirmed", verror.NoRetry, "{1:}{2:} {3} for {4} was not confirmed")
+	errInvalidPolicy    = verror.Register(pkgPath+".errInvalidPolicy", verror.NoRetry, "{1:}{2:} invalid policy{:_}")
+	errUnknownOperation = verror.Register(pkgPath+".errUnknownOperation", verror.NoRetry, "{1:}{2:} unknown operation {3}")
+)
+
+// The operations of the agent that use the private key of the principal.
+const (
+	Sign          = "Sign"
+	Bless         = "Bless"
+	BlessSelf     = "BlessSelf"
+	MintDischarge = "MintDischarge"
+)
+
+// The operations of the agent that change the blessings, or the recognized
+// roots, of the principal.
+const (
+	SetBlessings        = "SetBlessings"
+	SetDefaultBlessings = "SetDefaultBlessings"
+	AddRoot             = "AddRoot"
+)
+
+// Client identifies the process on whose behalf an operation is performed.
+type Client struct {
+	// Known is false if the process could not be identified, in which
+	// case only the rules that match every client apply to it.
+	Known bool
+	Pid   int
+	Uid   int
+	// Executable is the path of the executable of the process, if known.
+	Executable string
+}
+
+func (c Client) String() string {
+	if !c.Known {
+		return "unidentified client"
+	}
+	if c.Executable == "" {
+		return fmt.Sprintf("pid %d (uid %d)", c.Pid, c.Uid)
+	}
+	return fmt.Sprintf("%s (pid %d, uid %d)", c.Executable, c.Pid, c.Uid)
+}
+
+// Request describes an operation to be performed by the agent.
+type Request struct {
+	Client    Client
+	Operation string
+	// Extension is the extension of the blessing for Bless, and the name
+	// of the blessing for BlessSelf.
+	Extension string
+	// Caveats are the caveats of the blessing for Bless and BlessSelf, and
+	// of the discharge for MintDischarge.
+	Caveats []security.Caveat
+	// Blessings are the blessings set by SetBlessings and
+	// SetDefaultBlessings.
+	Blessings security.Blessings
+	// Root is the public key of the root added by AddRoot.
+	Root security.PublicKey
+	// Pattern is the pattern of the peers for SetBlessings, and of the
+	// blessings of the root for AddRoot.
+	Pattern security.BlessingPattern
+}
+
+func (r Request) String() string {
+	switch r.Operation {
+	case SetBlessings:
+		return fmt.Sprintf("%s(%v, %q)", r.Operation, r.Blessings, r.Pattern)
+	case SetDefaultBlessings:
+		return fmt.Sprintf("%s(%v)", r.Operation, r.Blessings)
+	case AddRoot:
+		return fmt.Sprintf("%s(%v, %q)", r.Operation, r.Root, r.Pattern)
+	}
+	if r.Extension == "" {
+		return r.Operation
+	}
+	return fmt.Sprintf("%s(%q)", r.Operation, r.Extension)
+}
+
+// Rule allows operations to the clients it matches.  The clients it matches
+// are not allowed the operations it does not list, including those that
+// change the blessings or the recognized roots of the principal.
+type Rule struct {
+	// Executable, if set, is a pattern, in the syntax of filepath.Match,
+	// that the executable of the client must match.
+	Executable string
+	// Uids, if set, are the user ids one of which the client must run as.
+	Uids []int
+	// Operations are the operations allowed to the client.
+	Operations []string
+	// Extensions, if set, are blessing patterns one of which the extension
+	// of the blessings created with Bless or BlessSelf must match.  For
+	// instance "laptop" allows "laptop" and "laptop:ssh", but not "phone".
+	Extensions []security.BlessingPattern
+	// MaxDuration, if set, is the time, e.g. "24h", within which the
+	// blessings and discharges must expire.  The operations creating them
+	// must have an expiry caveat within that time.
+	MaxDuration string
+	// Confirm are the operations that must be interactively confirmed.
+	Confirm []string
+}
+
+// Policy restricts the operations that the agent performs on behalf of its
+// clients.  Each request is checked against the first rule that matches its
+// client.  A Policy with no rules allows every operation.
+type Policy struct {
+	Rules []Rule
+	// Confirm asks for the confirmation of the request, and returns true
+	// iff it is confirmed.  If nil, no request can be confirmed.
+	Confirm func(Request) bool `json:"-"`
+}
+
+// Load reads the policy from the JSON-encoded file at path.
+func Load(path string) (*Policy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var p Policy
+	if err := json.NewDecoder(f).Decode(&p); err != nil {
+		return nil, verror.New(errInvalidPolicy, nil, err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, verror.New(errInvalidPolicy, nil, err)
+	}
+	return &p, nil
+}
+
+func (p *Policy) validate() error {
+	for _, r := range p.Rules {
+		if _, err := filepath.Match(r.Executable, ""); err != nil {
+			return fmt.Errorf("executable pattern %q: %v", r.Executable, err)
+		}
+		for _, ops := range [][]string{r.Operations, r.Confirm} {
+			for _, op := range ops {
+				switch op {
+				case Sign, Bless, BlessSelf, MintDischarge, SetBlessings, SetDefaultBlessings, AddRoot:
+				default:
+					return verror.New(errUnknownOperation, nil, op)
+				}
+			}
+		}
+		if r.MaxDuration != "" {
+			if _, err := time.ParseDuration(r.MaxDuration); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Check returns nil iff the policy allows req.
+func (p *Policy) Check(req Request) error {
+	if p == nil || len(p.Rules) == 0 {
+		return nil
+	}
+	for _, r := range p.Rules {
+		if r.matches(req.Client) {
+			return p.check(r, req)
+		}
+	}
+	return verror.New(errNoRule, nil, req.Client)
+}
+
+func (r Rule) matches(c Client) bool {
+	if r.Executable != "" {
+		if !c.Known || c.Executable == "" {
+			return false
+		}
+		if ok, _ := filepath.Match(r.Executable, c.Executable); !ok {
+			return false
+		}
+	}
+	if len(r.Uids) > 0 {
+		if !c.Known || !contains(r.Uids, c.Uid) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Policy) check(r Rule, req Request) error {
+	if !containsString(r.Operations, req.Operation) {
+		return verror.New(errNotAllowed, nil, req, req.Client)
+	}
+	if (req.Operation == Bless || req.Operation == BlessSelf) && len(r.Extensions) > 0 {
+		matched := false
+		for _, pattern := range r.Extensions {
+			if pattern.MatchedBy(req.Extension) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return verror.New(errBadExtension, nil, req.Extension, req.Client)
+		}
+	}
+	if r.MaxDuration != "" && (req.Operation == Bless || req.Operation == BlessSelf || req.Operation == MintDischarge) {
+		d, err := time.ParseDuration(r.MaxDuration)
+		if err != nil {
+			return verror.New(errInvalidPolicy, nil, err)
+		}
+		if !expiresBefore(req.Caveats, time.Now().Add(d)) {
+			return verror.New(errExpiryRequired, nil, req, req.Client, d)
+		}
+	}
+	if containsString(r.Confirm, req.Operation) {
+		if p.Confirm == nil || !p.Confirm(req) {
+			return verror.New(errNotConfirmed, nil, req, req.Client)
+		}
+	}
+	return nil
+}
+
+// expiresBefore returns true iff one of the caveats is an expiry caveat for a
+// time no later than deadline.
+func expiresBefore(caveats []security.Caveat, deadline time.Time) bool {
+	for _, cav := range caveats {
+		if cav.Id != security.ExpiryCaveat.Id {
+			continue
+		}
+		var expiry time.Time
+		if err := vom.Decode(cav.ParamVom, &expiry); err != nil {
+			continue
+		}
+		if !expiry.After(deadline) {
+			return true
+		}
+	}
+	return false
+}
+
+// Describe returns a human-readable description of req, for confirmation
+// prompts.
+func Describe(req Request) string {
+	desc := fmt.Sprintf("%v requests %v", req.Client, req)
+	if len(req.Caveats) > 0 {
+		var cavs []string
+		for _, c := range req.Caveats {
+			cavs = append(cavs, c.String())
+		}
+		desc += " with caveats " + strings.Join(cavs, ", ")
+	}
+	return desc
+}
+
+func contains(list []int, x int) bool {
+	for _, y := range list {
+		if x == y {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, x string) bool {
+	for _, y := range list {
+		if x == y {
+			return true
+		}
+	}
+	return false
+}
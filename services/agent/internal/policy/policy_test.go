@@ -0,0 +1,126 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"v.io/v23/security"
+)
+
+func TestCheck(t *testing.T) {
+	expiry := func(d time.Duration) security.Caveat {
+		c, err := security.NewExpiryCaveat(time.Now().Add(d))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	var (
+		ssh     = Client{Known: true, Pid: 10, Uid: 1000, Executable: "/usr/bin/ssh"}
+		tool    = Client{Known: true, Pid: 11, Uid: 1000, Executable: "/home/user/bin/tool"}
+		other   = Client{Known: true, Pid: 12, Uid: 1001, Executable: "/home/other/bin/tool"}
+		unknown = Client{}
+
+		confirmed []Request
+	)
+	p := &Policy{
+		Rules: []Rule{
+			{Executable: "/usr/bin/*", Operations: []string{Sign}},
+			{
+				Uids:        []int{1000},
+				Operations:  []string{Sign, Bless},
+				Extensions:  []security.BlessingPattern{"laptop"},
+				MaxDuration: "24h",
+				Confirm:     []string{Bless},
+			},
+		},
+		Confirm: func(req Request) bool {
+			confirmed = append(confirmed, req)
+			return req.Extension != "laptop:denied"
+		},
+	}
+	testcases := []struct {
+		req Request
+		ok  bool
+	}{
+		{Request{Client: ssh, Operation: Sign}, true},
+		{Request{Client: ssh, Operation: Bless, Extension: "laptop", Caveats: []security.Caveat{expiry(time.Hour)}}, false},
+		{Request{Client: tool, Operation: Sign}, true},
+		{Request{Client: tool, Operation: MintDischarge, Caveats: []security.Caveat{expiry(time.Hour)}}, false},
+		{Request{Client: tool, Operation: Bless, Extension: "laptop:app", Caveats: []security.Caveat{expiry(time.Hour)}}, true},
+		{Request{Client: tool, Operation: Bless, Extension: "phone", Caveats: []security.Caveat{expiry(time.Hour)}}, false},
+		{Request{Client: tool, Operation: Bless, Extension: "laptop", Caveats: []security.Caveat{expiry(48 * time.Hour)}}, false},
+		{Request{Client: tool, Operation: Bless, Extension: "laptop", Caveats: []security.Caveat{security.UnconstrainedUse()}}, false},
+		{Request{Client: tool, Operation: Bless, Extension: "laptop:denied", Caveats: []security.Caveat{expiry(time.Hour)}}, false},
+		{Request{Client: tool, Operation: SetDefaultBlessings}, false},
+		{Request{Client: tool, Operation: AddRoot, Pattern: "root"}, false},
+		{Request{Client: ssh, Operation: SetBlessings, Pattern: "..."}, false},
+		{Request{Client: other, Operation: Sign}, false},
+		{Request{Client: unknown, Operation: Sign}, false},
+	}
+	for i, tc := range testcases {
+		if err := p.Check(tc.req); (err == nil) != tc.ok {
+			t.Errorf("#%d: Check(%v) returned %v, want ok=%v", i, Describe(tc.req), err, tc.ok)
+		}
+	}
+	if got, want := len(confirmed), 2; got != want {
+		t.Errorf("got %d confirmations, want %d", got, want)
+	}
+
+	// Changes of the blessings and roots are allowed, and confirmed, as any
+	// other operation.
+	p.Rules = append([]Rule{{Executable: "/home/user/bin/*", Operations: []string{SetBlessings, AddRoot}, MaxDuration: "1h", Confirm: []string{AddRoot}}}, p.Rules...)
+	confirmed = nil
+	for _, tc := range []struct {
+		req Request
+		ok  bool
+	}{
+		{Request{Client: tool, Operation: SetBlessings, Pattern: "..."}, true},
+		{Request{Client: tool, Operation: AddRoot, Pattern: "root"}, true},
+		{Request{Client: tool, Operation: SetDefaultBlessings}, false},
+	} {
+		if err := p.Check(tc.req); (err == nil) != tc.ok {
+			t.Errorf("Check(%v) returned %v, want ok=%v", Describe(tc.req), err, tc.ok)
+		}
+	}
+	if got, want := len(confirmed), 1; got != want {
+		t.Errorf("got %d confirmations, want %d", got, want)
+	}
+
+	// Without rules, everything is allowed.
+	var empty *Policy
+	if err := empty.Check(Request{Client: unknown, Operation: Bless}); err != nil {
+		t.Errorf("Check failed without a policy: %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	f, err := ioutil.TempFile("", "policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	for _, tc := range []struct {
+		policy string
+		ok     bool
+	}{
+		{`{"Rules": [{"Executable": "/usr/bin/*", "Operations": ["Sign"], "MaxDuration": "1h"}]}`, true},
+		{`{"Rules": [{"Operations": ["Sign", "SetBlessings", "SetDefaultBlessings", "AddRoot"]}]}`, true},
+		{`{"Rules": [{"Operations": ["Encrypt"]}]}`, false},
+		{`{"Rules": [{"Operations": ["Sign"], "MaxDuration": "forever"}]}`, false},
+		{`{"Rules": [{"Executable": "[", "Operations": ["Sign"]}]}`, false},
+	} {
+		if err := ioutil.WriteFile(f.Name(), []byte(tc.policy), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(f.Name()); (err == nil) != tc.ok {
+			t.Errorf("Load(%s) returned %v, want ok=%v", tc.policy, err, tc.ok)
+		}
+	}
+}
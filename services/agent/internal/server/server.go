@@ -9,12 +9,15 @@ import (
 	"time"
 
 	"v.io/v23/security"
+	"v.io/x/lib/vlog"
 	"v.io/x/ref/services/agent/internal/ipc"
+	"v.io/x/ref/services/agent/internal/policy"
 )
 
 type agentd struct {
 	ipc       *ipc.IPC
 	principal security.Principal
+	policy    *policy.Policy
 	mu        sync.RWMutex
 }
 
@@ -22,11 +25,32 @@ type agentd struct {
 // It will respond to requests using 'principal'.
 // Must be called before ipc.Listen or ipc.Connect.
 func ServeAgent(i *ipc.IPC, principal security.Principal) (err error) {
-	server := &agentd{ipc: i, principal: principal}
+	return ServeAgentWithPolicy(i, principal, nil)
+}
+
+// ServeAgentWithPolicy is like ServeAgent, but the operations using the
+// private key of 'principal' are only performed if allowed by 'pol'.
+func ServeAgentWithPolicy(i *ipc.IPC, principal security.Principal, pol *policy.Policy) (err error) {
+	server := &agentd{ipc: i, principal: principal, policy: pol}
 	return i.Serve(server)
 }
 
-func (a *agentd) Bless(key []byte, with security.Blessings, extension string, caveat security.Caveat, additionalCaveats []security.Caveat) (security.Blessings, error) {
+// check returns nil iff the policy allows the operation requested on c.
+func (a *agentd) check(c *ipc.IPCConn, req policy.Request) error {
+	if creds, err := c.PeerCredentials(); err == nil {
+		req.Client = policy.Client{Known: true, Pid: creds.Pid, Uid: creds.Uid, Executable: creds.Executable}
+	}
+	if err := a.policy.Check(req); err != nil {
+		vlog.Infof("Denied %v: %v", policy.Describe(req), err)
+		return err
+	}
+	return nil
+}
+
+func (a *agentd) Bless(c *ipc.IPCConn, key []byte, with security.Blessings, extension string, caveat security.Caveat, additionalCaveats []security.Caveat) (security.Blessings, error) {
+	if err := a.check(c, policy.Request{Operation: policy.Bless, Extension: extension, Caveats: append([]security.Caveat{caveat}, additionalCaveats...)}); err != nil {
+		return security.Blessings{}, err
+	}
 	pkey, err := security.UnmarshalPublicKey(key)
 	if err != nil {
 		return security.Blessings{}, err
@@ -34,15 +58,24 @@ func (a *agentd) Bless(key []byte, with security.Blessings, extension string, ca
 	return a.principal.Bless(pkey, with, extension, caveat, additionalCaveats...)
 }
 
-func (a *agentd) BlessSelf(name string, caveats []security.Caveat) (security.Blessings, error) {
+func (a *agentd) BlessSelf(c *ipc.IPCConn, name string, caveats []security.Caveat) (security.Blessings, error) {
+	if err := a.check(c, policy.Request{Operation: policy.BlessSelf, Extension: name, Caveats: caveats}); err != nil {
+		return security.Blessings{}, err
+	}
 	return a.principal.BlessSelf(name, caveats...)
 }
 
-func (a *agentd) Sign(message []byte) (security.Signature, error) {
+func (a *agentd) Sign(c *ipc.IPCConn, message []byte) (security.Signature, error) {
+	if err := a.check(c, policy.Request{Operation: policy.Sign}); err != nil {
+		return security.Signature{}, err
+	}
 	return a.principal.Sign(message)
 }
 
-func (a *agentd) MintDischarge(forCaveat, caveatOnDischarge security.Caveat, additionalCaveatsOnDischarge []security.Caveat) (security.Discharge, error) {
+func (a *agentd) MintDischarge(c *ipc.IPCConn, forCaveat, caveatOnDischarge security.Caveat, additionalCaveatsOnDischarge []security.Caveat) (security.Discharge, error) {
+	if err := a.check(c, policy.Request{Operation: policy.MintDischarge, Caveats: append([]security.Caveat{caveatOnDischarge}, additionalCaveatsOnDischarge...)}); err != nil {
+		return security.Discharge{}, err
+	}
 	return a.principal.MintDischarge(forCaveat, caveatOnDischarge, additionalCaveatsOnDischarge...)
 }
 
@@ -57,7 +90,10 @@ func (a *agentd) PublicKey() ([]byte, error) {
 	return a.principal.PublicKey().MarshalBinary()
 }
 
-func (a *agentd) BlessingStoreSet(blessings security.Blessings, forPeers security.BlessingPattern) (security.Blessings, error) {
+func (a *agentd) BlessingStoreSet(c *ipc.IPCConn, blessings security.Blessings, forPeers security.BlessingPattern) (security.Blessings, error) {
+	if err := a.check(c, policy.Request{Operation: policy.SetBlessings, Blessings: blessings, Pattern: forPeers}); err != nil {
+		return security.Blessings{}, err
+	}
 	defer a.unlock()
 	a.mu.Lock()
 	return a.principal.BlessingStore().Set(blessings, forPeers)
@@ -69,7 +105,10 @@ func (a *agentd) BlessingStoreForPeer(peerBlessings []string) (security.Blessing
 	return a.principal.BlessingStore().ForPeer(peerBlessings...), nil
 }
 
-func (a *agentd) BlessingStoreSetDefault(blessings security.Blessings) error {
+func (a *agentd) BlessingStoreSetDefault(c *ipc.IPCConn, blessings security.Blessings) error {
+	if err := a.check(c, policy.Request{Operation: policy.SetDefaultBlessings, Blessings: blessings}); err != nil {
+		return err
+	}
 	defer a.unlock()
 	a.mu.Lock()
 	return a.principal.BlessingStore().SetDefault(blessings)
@@ -122,7 +161,14 @@ func (a *agentd) BlessingStoreDischarge2(caveat security.Caveat, impetus securit
 	return discharge, cacheTime, nil
 }
 
-func (a *agentd) BlessingRootsAdd(root []byte, pattern security.BlessingPattern) error {
+func (a *agentd) BlessingRootsAdd(c *ipc.IPCConn, root []byte, pattern security.BlessingPattern) error {
+	key, err := security.UnmarshalPublicKey(root)
+	if err != nil {
+		return err
+	}
+	if err := a.check(c, policy.Request{Operation: policy.AddRoot, Root: key, Pattern: pattern}); err != nil {
+		return err
+	}
 	defer a.unlock()
 	a.mu.Lock()
 	return a.principal.Roots().Add(root, pattern)
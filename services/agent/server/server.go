@@ -18,7 +18,11 @@ import (
 	"v.io/v23/verror"
 	vsecurity "v.io/x/ref/lib/security"
 	"v.io/x/ref/lib/security/passphrase"
+	// Register the signer for principals whose private key is held by a
+	// PKCS#11 token.
+	_ "v.io/x/ref/lib/security/pkcs11"
 	"v.io/x/ref/services/agent/internal/ipc"
+	"v.io/x/ref/services/agent/internal/policy"
 	"v.io/x/ref/services/agent/internal/server"
 )
 
@@ -34,8 +38,9 @@ var (
 
 // LoadPrincipal returns the principal persisted in the given credentials
 // directory.  If the private key is encrypted, it prompts for a decryption
-// passphrase.  If the principal doesn't exist and create is true, it creates
-// the principal.
+// passphrase.  If the private key is held by a PKCS#11 token, it prompts for
+// the PIN of the token.  If the principal doesn't exist and create is true, it
+// creates the principal.
 func LoadPrincipal(credentials string, create bool) (security.Principal, error) {
 	switch p, err := vsecurity.LoadPersistentPrincipal(credentials, nil); {
 	case err == nil:
@@ -75,6 +80,12 @@ func (s ipcState) NumConnections() int {
 // Serve serves the given principal using the given socket file, and returns an
 // IPCState for the service.
 func Serve(p security.Principal, socketPath string) (IPCState, error) {
+	return ServeWithPolicy(p, socketPath, nil)
+}
+
+// ServeWithPolicy is like Serve, but the operations using the private key of
+// the principal are only performed for the clients allowed by pol.
+func ServeWithPolicy(p security.Principal, socketPath string, pol *policy.Policy) (IPCState, error) {
 	var err error
 	if socketPath, err = filepath.Abs(socketPath); err != nil {
 		return nil, fmt.Errorf("Abs failed: %v", err)
@@ -83,7 +94,7 @@ func Serve(p security.Principal, socketPath string) (IPCState, error) {
 
 	// Start running our server.
 	i := ipc.NewIPC()
-	if err := server.ServeAgent(i, p); err != nil {
+	if err := server.ServeAgentWithPolicy(i, p, pol); err != nil {
 		i.Close()
 		return nil, fmt.Errorf("ServeAgent failed: %v", err)
 	}
@@ -118,7 +129,7 @@ func handleDoesNotExist(dir string) (security.Principal, error) {
 }
 
 func handlePassphrase(dir string) (security.Principal, error) {
-	pass, err := passphrase.Get(fmt.Sprintf("Passphrase required to decrypt encrypted private key file for credentials in %v.\nEnter passphrase: ", dir))
+	pass, err := passphrase.Get(fmt.Sprintf("Passphrase required to decrypt encrypted private key file (or PIN of the token holding the private key) for credentials in %v.\nEnter passphrase: ", dir))
 	if err != nil {
 		return nil, verror.New(errCantReadPassphrase, nil, err)
 	}
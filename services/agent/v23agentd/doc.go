@@ -32,6 +32,11 @@ The v23agentd commands are:
    help        Display help for commands or topics
 
 The v23agentd flags are:
+ -confirm-command=
+   If set, the command run to confirm the operations that the policy requires to
+   be confirmed.  It is given a description of the operation as argument, and
+   confirms it by exiting with status 0.  Since the agent runs in the
+   background, it should not read from the terminal.
  -create=false
    Whether to create the credentials if missing.
  -credentials=
@@ -39,6 +44,10 @@ The v23agentd flags are:
  -daemon=true
    Run the agent as a daemon (returns right away but leaves the agent running in
    the background)
+ -policy=
+   If set, the JSON-encoded file with the rules restricting, for each client
+   process, the operations that use the private key or change the blessings and
+   roots.  Without rules, all operations are allowed to all clients.
  -timeout=0
    How long the agent stays alive without any client connections. Zero implies
    no timeout.
@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strconv"
 	"time"
@@ -25,6 +26,7 @@ import (
 	"v.io/x/ref/services/agent/internal/constants"
 	"v.io/x/ref/services/agent/internal/launcher"
 	"v.io/x/ref/services/agent/internal/lock"
+	"v.io/x/ref/services/agent/internal/policy"
 	"v.io/x/ref/services/agent/internal/version"
 	"v.io/x/ref/services/agent/server"
 )
@@ -41,6 +43,8 @@ var (
 	stop         bool
 	credentials  string
 	createCreds  bool
+	policyFile   string
+	confirmCmd   string
 )
 
 func main() {
@@ -49,6 +53,8 @@ func main() {
 	cmdAgentD.Flags.Var(&versionToUse, constants.VersionFlag, "Version that the agent should use.  Will fail if the version is not in the range of supported versions (obtained from the --metadata flag)")
 	cmdAgentD.Flags.BoolVar(&daemon, constants.DaemonFlag, true, "Run the agent as a daemon (returns right away but leaves the agent running in the background)")
 	cmdAgentD.Flags.DurationVar(&idleGrace, constants.TimeoutFlag, 0, "How long the agent stays alive without any client connections. Zero implies no timeout.")
+	cmdAgentD.Flags.StringVar(&policyFile, "policy", "", "If set, the JSON-encoded file with the rules restricting, for each client process, the operations that use the private key or change the blessings and roots.  Without rules, all operations are allowed to all clients.")
+	cmdAgentD.Flags.StringVar(&confirmCmd, "confirm-command", "", "If set, the command run to confirm the operations that the policy requires to be confirmed.  It is given a description of the operation as argument, and confirms it by exiting with status 0.  Since the agent runs in the background, it should not read from the terminal.")
 	cmdline.HideGlobalFlagsExcept()
 	cmdline.Main(cmdAgentD)
 }
@@ -119,6 +125,14 @@ func runAgentD(env *cmdline.Env, args []string) error {
 	case err != nil:
 		return fmt.Errorf("cannot access credentials dir \"%s\": %v", credentials, err)
 	}
+	if policyFile != "" {
+		// The daemon runs in the agent directory.
+		abs, err := filepath.Abs(policyFile)
+		if err != nil {
+			return err
+		}
+		policyFile = abs
+	}
 	if daemon {
 		return launcher.LaunchAgent(credentials, os.Args[0], true, flagsFor(cmdAgentD)...)
 	}
@@ -198,7 +212,11 @@ func initialize(env *cmdline.Env, credentials string) (func(), commandChannels,
 	if err != nil {
 		return nil, commandChannels{}, nil, fmt.Errorf("failed to create new principal from dir(%s): %v", credentials, err)
 	}
-	ipc, err := server.Serve(p, constants.SocketPath(credentials))
+	pol, err := loadPolicy(env)
+	if err != nil {
+		return nil, commandChannels{}, nil, err
+	}
+	ipc, err := server.ServeWithPolicy(p, constants.SocketPath(credentials), pol)
 	if err != nil {
 		return nil, commandChannels{}, nil, fmt.Errorf("Serve failed: %v", err)
 	}
@@ -222,6 +240,26 @@ func initialize(env *cmdline.Env, credentials string) (func(), commandChannels,
 	return cleanup, commandCh, ipc, nil
 }
 
+// loadPolicy returns the policy specified by the --policy and
+// --confirm-command flags.
+func loadPolicy(env *cmdline.Env) (*policy.Policy, error) {
+	if policyFile == "" {
+		return nil, nil
+	}
+	pol, err := policy.Load(policyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load policy from %s: %v", policyFile, err)
+	}
+	if confirmCmd != "" {
+		pol.Confirm = func(req policy.Request) bool {
+			cmd := exec.Command(confirmCmd, policy.Describe(req))
+			cmd.Stdout, cmd.Stderr = env.Stderr, env.Stderr
+			return cmd.Run() == nil
+		}
+	}
+	return pol, nil
+}
+
 func push(a, b func()) func() {
 	return func() {
 		b()
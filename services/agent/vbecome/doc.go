@@ -12,12 +12,18 @@ Usage:
    vbecome [flags] <command> [command args...]
 
 The vbecome flags are:
+ -approval-timeout=30m0s
+   Time to wait for the approval of the request made with --reason.
  -duration=1h0m0s
    Duration for the blessing.
  -name=
    If set, the derived principal will be given an extension of the caller's
    blessings with this name.  A union blessing will be created if used in
    conjunction with --role
+ -reason=
+   If set, the blessing of the role is requested for this reason and
+   --duration, and vbecome waits until another member of the role approves the
+   request.  Required for roles that require approval.
  -role=
    If set, the derived principal will be given a blessing obtained from this
    role object.  A union blessing will be created if used in conjunction with
@@ -53,6 +59,14 @@ The global flags are:
  -v23.proxy=
    object name of proxy service to use to export services across network
    boundaries
+ -v23.revocation.max-staleness=24h0m0s
+   Maximum age of the revocation snapshots against which discharges past their
+   freshness are validated.
+ -v23.revocation.refresh=1h0m0s
+   Interval at which revocation snapshots are fetched.
+ -v23.revocation.snapshots=
+   Names of the revocation list services to fetch revocation snapshots from,
+   comma separated.
  -v23.tcp.address=
    address to listen on
  -v23.tcp.protocol=wsh
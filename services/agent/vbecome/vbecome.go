@@ -22,6 +22,7 @@ import (
 	"v.io/v23"
 	"v.io/v23/context"
 	"v.io/v23/security"
+	"v.io/v23/verror"
 	"v.io/x/lib/cmdline"
 	"v.io/x/ref"
 	vsecurity "v.io/x/ref/lib/security"
@@ -34,12 +35,18 @@ import (
 )
 
 var (
-	durationFlag time.Duration
-	timeoutFlag  time.Duration
-	nameFlag     string
-	roleFlag     string
+	durationFlag        time.Duration
+	timeoutFlag         time.Duration
+	nameFlag            string
+	roleFlag            string
+	reasonFlag          string
+	approvalTimeoutFlag time.Duration
 )
 
+// approvalPollInterval is the interval at which vbecome checks whether its
+// request for the blessings of a role was approved.
+const approvalPollInterval = 5 * time.Second
+
 var cmdVbecome = &cmdline.Command{
 	Runner:   v23cmd.RunnerFunc(vbecome),
 	Name:     "vbecome",
@@ -60,6 +67,8 @@ func main() {
 	cmdVbecome.Flags.DurationVar(&timeoutFlag, "timeout", 2*time.Minute, "Timeout for the RPCs.")
 	cmdVbecome.Flags.StringVar(&nameFlag, "name", "", "If set, the derived principal will be given an extension of the caller's blessings with this name.  A union blessing will be created if used in conjunction with --role")
 	cmdVbecome.Flags.StringVar(&roleFlag, "role", "", "If set, the derived principal will be given a blessing obtained from this role object.  A union blessing will be created if used in conjunction with --name")
+	cmdVbecome.Flags.StringVar(&reasonFlag, "reason", "", "If set, the blessing of the role is requested for this reason and --duration, and vbecome waits until another member of the role approves the request.  Required for roles that require approval.")
+	cmdVbecome.Flags.DurationVar(&approvalTimeoutFlag, "approval-timeout", 30*time.Minute, "Time to wait for the approval of the request made with --reason.")
 
 	cmdline.Main(cmdVbecome)
 }
@@ -175,7 +184,38 @@ func setupRole(ctx *context.T, principal security.Principal, roleStr string) (se
 	if ctx, err = v23.WithPrincipal(ctx, pseeker); err != nil {
 		return security.Blessings{}, err
 	}
+	if len(reasonFlag) > 0 {
+		return seekApprovedBlessings(ctx, roleStr)
+	}
 	ctx, cancel := context.WithTimeout(ctx, timeoutFlag)
 	defer cancel()
 	return role.RoleClient(roleStr).SeekBlessings(ctx)
 }
+
+// seekApprovedBlessings requests the blessings of the role for reasonFlag and
+// durationFlag, and waits until the request is approved.
+func seekApprovedBlessings(ctx *context.T, roleStr string) (security.Blessings, error) {
+	c := role.RoleClient(roleStr)
+	rctx, cancel := context.WithTimeout(ctx, timeoutFlag)
+	id, err := c.RequestBlessings(rctx, reasonFlag, durationFlag)
+	cancel()
+	if err != nil {
+		return security.Blessings{}, err
+	}
+	deadline := time.Now().Add(approvalTimeoutFlag)
+	for notified := false; ; notified = true {
+		rctx, cancel := context.WithTimeout(ctx, timeoutFlag)
+		b, err := c.SeekApprovedBlessings(rctx, id)
+		cancel()
+		if verror.ErrorID(err) != role.ErrNotApproved.ID {
+			return b, err
+		}
+		if time.Now().After(deadline) {
+			return security.Blessings{}, fmt.Errorf("request %v was not approved within %v", id, approvalTimeoutFlag)
+		}
+		if !notified {
+			fmt.Fprintf(os.Stderr, "Waiting for the approval of request %v by another member of %v\n", id, roleStr)
+		}
+		time.Sleep(approvalPollInterval)
+	}
+}
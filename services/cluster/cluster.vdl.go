@@ -14,6 +14,7 @@ import (
 	"v.io/v23/security"
 	"v.io/v23/security/access"
 	"v.io/v23/vdl"
+	"v.io/x/ref/services/succession"
 )
 
 var _ = __VDLInit() // Must be first; see __VDLInit comments for details.
@@ -24,6 +25,10 @@ var _ = __VDLInit() // Must be first; see __VDLInit comments for details.
 // ClusterAgentClientMethods is the client interface
 // containing ClusterAgent methods.
 type ClusterAgentClientMethods interface {
+	// Re-issues the blessings retrieved with SeekBlessings to the successor
+	// of the key of the caller.  Like SeekBlessings, it requires no
+	// authorization other than the key succession.
+	succession.SuccessorBlesserClientMethods
 	// Retrieves all the blessings associated with a particular secret.
 	// The only authorization required to access this method is the secret
 	// itself.
@@ -41,11 +46,13 @@ type ClusterAgentClientStub interface {
 
 // ClusterAgentClient returns a client stub for ClusterAgent.
 func ClusterAgentClient(name string) ClusterAgentClientStub {
-	return implClusterAgentClientStub{name}
+	return implClusterAgentClientStub{name, succession.SuccessorBlesserClient(name)}
 }
 
 type implClusterAgentClientStub struct {
 	name string
+
+	succession.SuccessorBlesserClientStub
 }
 
 func (c implClusterAgentClientStub) SeekBlessings(ctx *context.T, i0 string, opts ...rpc.CallOpt) (o0 security.Blessings, err error) {
@@ -56,6 +63,10 @@ func (c implClusterAgentClientStub) SeekBlessings(ctx *context.T, i0 string, opt
 // ClusterAgentServerMethods is the interface a server writer
 // implements for ClusterAgent.
 type ClusterAgentServerMethods interface {
+	// Re-issues the blessings retrieved with SeekBlessings to the successor
+	// of the key of the caller.  Like SeekBlessings, it requires no
+	// authorization other than the key succession.
+	succession.SuccessorBlesserServerMethods
 	// Retrieves all the blessings associated with a particular secret.
 	// The only authorization required to access this method is the secret
 	// itself.
@@ -83,7 +94,8 @@ type ClusterAgentServerStub interface {
 // an object that may be used by rpc.Server.
 func ClusterAgentServer(impl ClusterAgentServerMethods) ClusterAgentServerStub {
 	stub := implClusterAgentServerStub{
-		impl: impl,
+		impl:                       impl,
+		SuccessorBlesserServerStub: succession.SuccessorBlesserServer(impl),
 	}
 	// Initialize GlobState; always check the stub itself first, to handle the
 	// case where the user has the Glob method defined in their VDL source.
@@ -97,7 +109,8 @@ func ClusterAgentServer(impl ClusterAgentServerMethods) ClusterAgentServerStub {
 
 type implClusterAgentServerStub struct {
 	impl ClusterAgentServerMethods
-	gs   *rpc.GlobState
+	succession.SuccessorBlesserServerStub
+	gs *rpc.GlobState
 }
 
 func (s implClusterAgentServerStub) SeekBlessings(ctx *context.T, call rpc.ServerCall, i0 string) (security.Blessings, error) {
@@ -109,7 +122,7 @@ func (s implClusterAgentServerStub) Globber() *rpc.GlobState {
 }
 
 func (s implClusterAgentServerStub) Describe__() []rpc.InterfaceDesc {
-	return []rpc.InterfaceDesc{ClusterAgentDesc}
+	return []rpc.InterfaceDesc{ClusterAgentDesc, succession.SuccessorBlesserDesc}
 }
 
 // ClusterAgentDesc describes the ClusterAgent interface.
@@ -119,6 +132,9 @@ var ClusterAgentDesc rpc.InterfaceDesc = descClusterAgent
 var descClusterAgent = rpc.InterfaceDesc{
 	Name:    "ClusterAgent",
 	PkgPath: "v.io/x/ref/services/cluster",
+	Embeds: []rpc.EmbedDesc{
+		{"SuccessorBlesser", "v.io/x/ref/services/succession", "// Re-issues the blessings retrieved with SeekBlessings to the successor\n// of the key of the caller.  Like SeekBlessings, it requires no\n// authorization other than the key succession."},
+	},
 	Methods: []rpc.MethodDesc{
 		{
 			Name: "SeekBlessings",
@@ -234,7 +250,7 @@ func (s implClusterAgentAdminServerStub) Globber() *rpc.GlobState {
 }
 
 func (s implClusterAgentAdminServerStub) Describe__() []rpc.InterfaceDesc {
-	return []rpc.InterfaceDesc{ClusterAgentAdminDesc, ClusterAgentDesc}
+	return []rpc.InterfaceDesc{ClusterAgentAdminDesc, ClusterAgentDesc, succession.SuccessorBlesserDesc}
 }
 
 // ClusterAgentAdminDesc describes the ClusterAgentAdmin interface.
@@ -33,7 +33,11 @@ type authorizer struct {
 }
 
 func (a *authorizer) Authorize(ctx *context.T, call security.Call) error {
-	if call.Method() == "SeekBlessings" {
+	switch call.Method() {
+	// SeekBlessings and BlessSuccessor are authorized by the service itself:
+	// they only grant blessings obtained with secrets that have not been
+	// forgotten.
+	case "SeekBlessings", "BlessSuccessor":
 		return nil
 	}
 	return a.auth.Authorize(ctx, call)
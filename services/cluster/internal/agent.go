@@ -5,12 +5,15 @@
 package internal
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/hex"
 	"reflect"
 
 	"v.io/v23/security"
 	"v.io/v23/verror"
+
+	"v.io/x/ref/services/succession"
 )
 
 const secretSize = 256 // Bytes
@@ -26,6 +29,8 @@ type AgentStorage interface {
 	// Delete deletes the secret and its associated blessings. It returns
 	// an error is there was a problem deleting the secret.
 	Delete(secret string) error
+	// List returns the blessings associated with all the secrets.
+	List() ([]security.Blessings, error)
 }
 
 func NewAgent(principal security.Principal, storage AgentStorage) *ClusterAgent {
@@ -76,3 +81,41 @@ func (a *ClusterAgent) Bless(secret string, publicKey security.PublicKey, name s
 	// possessor of 'secret'.
 	return a.principal.Bless(publicKey, blessings, name, security.UnconstrainedUse())
 }
+
+// Granted returns the blessings, among blessings, that Bless granted with the
+// blessings associated with a secret that has not been forgotten, i.e. that
+// the holder of the secret could still obtain.  If there are none, it returns
+// the zero blessings.
+func (a *ClusterAgent) Granted(blessings security.Blessings) (security.Blessings, error) {
+	associated, err := a.storage.List()
+	if err != nil {
+		return security.Blessings{}, err
+	}
+	var known [][]security.Certificate
+	for _, b := range associated {
+		var wire security.WireBlessings
+		if err := security.WireBlessingsFromNative(&wire, b); err != nil {
+			return security.Blessings{}, err
+		}
+		known = append(known, wire.CertificateChains...)
+	}
+	return succession.FilterBlessings(blessings, func(chain []security.Certificate) bool {
+		for _, k := range known {
+			if len(chain) == len(k)+1 && sameCertificates(chain[:len(k)], k) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// sameCertificates returns true if the certificates in a and b have the same
+// signatures.
+func sameCertificates(a, b []security.Certificate) bool {
+	for i := range a {
+		if !bytes.Equal(a[i].Signature.R, b[i].Signature.R) || !bytes.Equal(a[i].Signature.S, b[i].Signature.S) {
+			return false
+		}
+	}
+	return true
+}
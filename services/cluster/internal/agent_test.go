@@ -49,7 +49,30 @@ func TestClusterAgent(t *testing.T) {
 		t.Errorf("unexpected blessings. Got %q, expected %q", blessings2, expected)
 	}
 
+	// Only the blessings that the agent granted, with the blessings of a
+	// secret that is not forgotten, are granted.
+	if granted, err := agent.Granted(blessings2); err != nil || !granted.Equivalent(blessings2) {
+		t.Errorf("agent.Granted(%v) returned (%v, %v), expected (%v, nil)", blessings2, granted, err, blessings2)
+	}
+	otherB, err := serviceP.Bless(instanceP.PublicKey(), serviceB, "foo:bar", security.UnconstrainedUse())
+	if err != nil {
+		t.Fatalf("serviceP.Bless failed: %v", err)
+	}
+	if granted, err := agent.Granted(otherB); err != nil || !granted.IsZero() {
+		t.Errorf("agent.Granted(%v) returned (%v, %v), expected zero blessings", otherB, granted, err)
+	}
+	union, err := security.UnionOfBlessings(blessings2, otherB)
+	if err != nil {
+		t.Fatalf("security.UnionOfBlessings failed: %v", err)
+	}
+	if granted, err := agent.Granted(union); err != nil || !granted.Equivalent(blessings2) {
+		t.Errorf("agent.Granted(%v) returned (%v, %v), expected (%v, nil)", union, granted, err, blessings2)
+	}
+
 	if err := agent.ForgetSecret(secret); err != nil {
 		t.Errorf("agent.ForgetSecret failed: %v", err)
 	}
+	if granted, err := agent.Granted(blessings2); err != nil || !granted.IsZero() {
+		t.Errorf("agent.Granted(%v) returned (%v, %v) after ForgetSecret, expected zero blessings", blessings2, granted, err)
+	}
 }
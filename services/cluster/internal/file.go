@@ -58,3 +58,23 @@ func (f *fileStorage) Delete(secret string) error {
 	}
 	return nil
 }
+
+func (f *fileStorage) List() ([]security.Blessings, error) {
+	files, err := ioutil.ReadDir(f.baseDir)
+	if err != nil {
+		return nil, err
+	}
+	var list []security.Blessings
+	for _, file := range files {
+		data, err := ioutil.ReadFile(filepath.Join(f.baseDir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var blessings security.Blessings
+		if err := vom.Decode(data, &blessings); err != nil {
+			return nil, err
+		}
+		list = append(list, blessings)
+	}
+	return list, nil
+}
@@ -48,6 +48,14 @@ func TestFileStorage(t *testing.T) {
 		}
 	}
 
+	list, err := fs.List()
+	if err != nil {
+		t.Errorf("unexpected List failure: %v", err)
+	}
+	if len(list) != N {
+		t.Errorf("unexpected number of blessings. Got %d, expected %d", len(list), N)
+	}
+
 	for _, i := range rand.Perm(N) {
 		secret := fmt.Sprintf("blessing%d", i)
 		b := secrets[secret]
@@ -62,4 +70,7 @@ func TestFileStorage(t *testing.T) {
 			t.Errorf("unexpected Delete(%v) failure: %v", secret, err)
 		}
 	}
+	if list, err := fs.List(); err != nil || len(list) != 0 {
+		t.Errorf("unexpected List result after Delete. Got (%v, %v), expected no blessings", list, err)
+	}
 }
@@ -8,18 +8,22 @@ import (
 	"v.io/v23/context"
 	"v.io/v23/rpc"
 	"v.io/v23/security"
+	"v.io/v23/verror"
 
+	vsecurity "v.io/x/ref/lib/security"
 	"v.io/x/ref/services/cluster"
+	"v.io/x/ref/services/succession"
 )
 
 // NewService returns a new clusterAgentService.
 func NewService(agent *ClusterAgent) cluster.ClusterAgentAdminServerMethods {
-	return &clusterAgentService{agent}
+	return &clusterAgentService{agent, succession.NewBlesser(succession.DefaultMaxAge)}
 }
 
 // clusterAgentService implements the ClusterAgentAdmin interface.
 type clusterAgentService struct {
-	agent *ClusterAgent
+	agent   *ClusterAgent
+	blesser succession.SuccessorBlesserServerMethods
 }
 
 // NewSecret creates a new "secret" that can be used to retrieve extensions of
@@ -39,3 +43,17 @@ func (i *clusterAgentService) SeekBlessings(ctx *context.T, call rpc.ServerCall,
 	publicKey := call.Security().RemoteBlessings().PublicKey()
 	return i.agent.Bless(secret, publicKey, "x")
 }
+
+// BlessSuccessor re-issues the blessings granted by SeekBlessings to the
+// successor of the key of the caller.  As with SeekBlessings, the secret the
+// blessings were obtained with must not have been forgotten.
+func (i *clusterAgentService) BlessSuccessor(ctx *context.T, call rpc.ServerCall, s vsecurity.KeySuccession, blessings security.Blessings) (security.Blessings, error) {
+	granted, err := i.agent.Granted(blessings)
+	if err != nil {
+		return security.Blessings{}, verror.Convert(verror.ErrInternal, ctx, err)
+	}
+	if granted.IsZero() {
+		return security.Blessings{}, verror.New(vsecurity.ErrNotGrantedByBlesser, ctx, blessings)
+	}
+	return i.blesser.BlessSuccessor(ctx, call, s, granted)
+}
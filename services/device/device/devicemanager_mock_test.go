@@ -239,7 +239,15 @@ func (mdi *mockDeviceInvoker) Update(*context.T, rpc.ServerCall) error {
 	return mdi.simpleCore("Update", "Update")
 }
 
-func (*mockDeviceInvoker) UpdateTo(*context.T, rpc.ServerCall, string) error { return nil }
+// Mock UpdateTo
+type UpdateToStimulus struct {
+	fun string
+	von string
+}
+
+func (mdi *mockDeviceInvoker) UpdateTo(_ *context.T, _ rpc.ServerCall, von string) error {
+	return mdi.simpleCore(UpdateToStimulus{"UpdateTo", von}, "UpdateTo")
+}
 
 // Mock Permissions getting and setting
 type GetPermissionsResponse struct {
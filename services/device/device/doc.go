@@ -25,6 +25,7 @@ The device commands are:
    kill          Kill the given application instance.
    revert        Revert the device manager or applications.
    update        Update the device manager or applications.
+   rollout       Roll out an application version to many installations.
    status        Get device manager or application status.
    debug         Debug the device.
    acl           Tool for setting device manager Permissions
@@ -66,6 +67,14 @@ The global flags are:
  -v23.permissions.literal=
    explicitly specify the runtime perms as a JSON-encoded access.Permissions.
    Overrides all --v23.permissions.file flags.
+ -v23.revocation.max-staleness=24h0m0s
+   Maximum age of the revocation snapshots against which discharges past their
+   freshness are validated.
+ -v23.revocation.refresh=1h0m0s
+   Interval at which revocation snapshots are fetched.
+ -v23.revocation.snapshots=
+   Names of the revocation list services to fetch revocation snapshots from,
+   comma separated.
  -v23.tcp.address=
    address to listen on
  -v23.tcp.protocol=wsh
@@ -316,6 +325,41 @@ The device update flags are:
    Specifies the level of parallelism for the handler execution. One of: [BYKIND
    FULL NONE].
 
+Device rollout
+
+Roll out updates the application installations matching the given patterns, and
+their instances, to the given envelope.  The installations are updated in
+waves: the first wave (the canary) updates --canary percent of them, and each
+subsequent wave --wave percent of them.
+
+After each wave, and once --soak has passed, the instances of the wave must be
+healthy: those that were running before the rollout must be running, must not
+have been restarted by their device manager, and must be ready if they have a
+readiness probe.  Otherwise, or if an update fails, all the installations and
+instances updated so far are reverted to their previous versions.
+
+The progress of the rollout is recorded in the --state file, so that an
+interrupted rollout resumes where it left off when run again with the same
+arguments.
+
+Usage:
+   device rollout [flags] <envelope> <installation patterns...>
+
+<envelope> is the vanadium object name of the application envelope to roll out.
+
+<installation patterns...> are vanadium object names or glob name patterns
+corresponding to application installations.
+
+The device rollout flags are:
+ -canary=5
+   Percentage of the installations updated by the first wave.
+ -soak=5m0s
+   Time to wait after each wave before checking the health of its instances.
+ -state=
+   File that records the progress of the rollout.  Required.
+ -wave=25
+   Percentage of the installations updated by each wave after the first.
+
 Device status
 
 Get the status of the device manager or application instances and installations.
@@ -0,0 +1,454 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/naming"
+	"v.io/v23/services/device"
+	"v.io/v23/services/stats"
+	"v.io/v23/verror"
+	"v.io/x/lib/cmdline"
+	"v.io/x/ref/lib/v23cmd"
+	"v.io/x/ref/services/device/internal/errors"
+)
+
+var (
+	rolloutCanary    float64
+	rolloutWave      float64
+	rolloutSoak      time.Duration
+	rolloutStateFile string
+)
+
+var cmdRollout = &cmdline.Command{
+	Runner: v23cmd.RunnerFunc(runRollout),
+	Name:   "rollout",
+	Short:  "Roll out an application version to many installations.",
+	Long: `
+Roll out updates the application installations matching the given patterns, and
+their instances, to the given envelope.  The installations are updated in
+waves: the first wave (the canary) updates --canary percent of them, and each
+subsequent wave --wave percent of them.
+
+After each wave, and once --soak has passed, the instances of the wave must be
+healthy: those that were running before the rollout must be running, must not
+have been restarted by their device manager, and must be ready if they have a
+readiness probe.  Otherwise, or if an update fails, all the installations and
+instances updated so far are reverted to their previous versions.
+
+The progress of the rollout is recorded in the --state file, so that an
+interrupted rollout resumes where it left off when run again with the same
+arguments.
+`,
+	ArgsName: "<envelope> <installation patterns...>",
+	ArgsLong: `
+<envelope> is the vanadium object name of the application envelope to roll out.
+
+<installation patterns...> are vanadium object names or glob name patterns corresponding to application installations.
+`,
+}
+
+func init() {
+	cmdRollout.Flags.Float64Var(&rolloutCanary, "canary", 5, "Percentage of the installations updated by the first wave.")
+	cmdRollout.Flags.Float64Var(&rolloutWave, "wave", 25, "Percentage of the installations updated by each wave after the first.")
+	cmdRollout.Flags.DurationVar(&rolloutSoak, "soak", 5*time.Minute, "Time to wait after each wave before checking the health of its instances.")
+	cmdRollout.Flags.StringVar(&rolloutStateFile, "state", "", "File that records the progress of the rollout.  Required.")
+}
+
+// deviceManagerStatsPrefix is the prefix of the names of the stats exported
+// by device managers.
+const deviceManagerStatsPrefix = "device-manager"
+
+const (
+	rolloutInProgress  = "in progress"
+	rolloutRollingBack = "rolling back"
+	rolloutDone        = "done"
+	rolloutRolledBack  = "rolled back"
+)
+
+// rolloutTarget records the installation that a rollout updates, and its
+// instances, as they were before the rollout.
+type rolloutTarget struct {
+	Name string
+	// Version is the version of the installation before the rollout.
+	Version string
+	// Instances are the instances of the installation, if the installation
+	// was updated.
+	Instances []rolloutInstance
+	Updated   bool
+	Reverted  bool
+}
+
+type rolloutInstance struct {
+	Name    string
+	Version string
+	Running bool
+}
+
+// rolloutState is the progress of a rollout, which is saved after each step.
+type rolloutState struct {
+	Envelope string
+	Patterns []string
+	Phase    string
+	// Targets are in the order in which they are updated.
+	Targets []*rolloutTarget
+	// Waves are the indices in Targets at which each wave ends.
+	Waves []int
+	// WavesDone is the number of waves that were found healthy.
+	WavesDone int
+	// Reason is why the rollout was rolled back.
+	Reason string
+}
+
+func (s *rolloutState) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("MarshalIndent(%v) failed: %v", s, err)
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("WriteFile(%v) failed: %v", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("Rename(%v, %v) failed: %v", tmp, path, err)
+	}
+	return nil
+}
+
+// loadRolloutState loads the rollout state saved at path, or returns nil if
+// there is none.
+func loadRolloutState(path string) (*rolloutState, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ReadFile(%v) failed: %v", path, err)
+	}
+	s := new(rolloutState)
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("Unmarshal(%v) failed: %v", path, err)
+	}
+	return s, nil
+}
+
+// RolloutWaves returns the indices at which the waves of a rollout to n
+// installations end, given the percentages of installations updated by the
+// first wave and by each subsequent wave.  Each wave updates at least one
+// installation.
+// The identifier is exported for use in unit tests.
+func RolloutWaves(n int, canary, wave float64) []int {
+	size := func(percent float64) int {
+		s := int(math.Ceil(float64(n) * percent / 100))
+		if s < 1 {
+			s = 1
+		}
+		return s
+	}
+	var waves []int
+	for end := size(canary); ; end += size(wave) {
+		if end >= n {
+			return append(waves, n)
+		}
+		waves = append(waves, end)
+	}
+}
+
+func runRollout(ctx *context.T, env *cmdline.Env, args []string) error {
+	if expected, got := 2, len(args); got < expected {
+		return env.UsageErrorf("rollout: incorrect number of arguments, expected at least %d, got %d", expected, got)
+	}
+	if rolloutStateFile == "" {
+		return env.UsageErrorf("rollout: --state must be specified")
+	}
+	if rolloutCanary <= 0 || rolloutCanary > 100 || rolloutWave <= 0 || rolloutWave > 100 {
+		return env.UsageErrorf("rollout: --canary and --wave must be percentages between 0 (excluded) and 100")
+	}
+	envelope, patterns := args[0], args[1:]
+	s, err := loadRolloutState(rolloutStateFile)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		if s, err = newRollout(ctx, env, envelope, patterns); err != nil {
+			return err
+		}
+		if err := s.save(rolloutStateFile); err != nil {
+			return err
+		}
+	} else if s.Envelope != envelope || !reflect.DeepEqual(s.Patterns, patterns) {
+		return fmt.Errorf("%v records a rollout of %v to %v", rolloutStateFile, s.Envelope, s.Patterns)
+	} else {
+		fmt.Fprintf(env.Stdout, "Resuming rollout of %v (%v).\n", envelope, s.Phase)
+	}
+	switch s.Phase {
+	case rolloutInProgress:
+		if reason := s.rollOut(ctx, env); reason != nil {
+			s.Phase, s.Reason = rolloutRollingBack, reason.Error()
+			if err := s.save(rolloutStateFile); err != nil {
+				return err
+			}
+			return s.rollBack(ctx, env)
+		}
+		return nil
+	case rolloutRollingBack:
+		return s.rollBack(ctx, env)
+	case rolloutDone:
+		fmt.Fprintf(env.Stdout, "Rollout of %v is done.\n", envelope)
+		return nil
+	case rolloutRolledBack:
+		return fmt.Errorf("rollout of %v was rolled back: %v", envelope, s.Reason)
+	default:
+		return fmt.Errorf("%v has unknown rollout phase %q", rolloutStateFile, s.Phase)
+	}
+}
+
+// newRollout returns the state of a new rollout of envelope to the active
+// installations matching patterns.
+func newRollout(ctx *context.T, env *cmdline.Env, envelope string, patterns []string) (*rolloutState, error) {
+	s := &rolloutState{
+		Envelope: envelope,
+		Patterns: patterns,
+		Phase:    rolloutInProgress,
+	}
+	for _, r := range glob(ctx, env, patterns) {
+		if status, ok := r.Status.(device.StatusInstallation); ok && status.Value.State == device.InstallationStateActive {
+			s.Targets = append(s.Targets, &rolloutTarget{Name: r.Name, Version: status.Value.Version})
+		}
+	}
+	if len(s.Targets) == 0 {
+		return nil, fmt.Errorf("no installations found")
+	}
+	sort.Sort(byTargetName(s.Targets))
+	s.Waves = RolloutWaves(len(s.Targets), rolloutCanary, rolloutWave)
+	return s, nil
+}
+
+type byTargetName []*rolloutTarget
+
+func (a byTargetName) Len() int           { return len(a) }
+func (a byTargetName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byTargetName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+
+// rollOut runs the waves of the rollout that are not done yet, and returns
+// why the rollout must be rolled back, if it must.
+func (s *rolloutState) rollOut(ctx *context.T, env *cmdline.Env) error {
+	for s.WavesDone < len(s.Waves) {
+		begin, end := 0, s.Waves[s.WavesDone]
+		if s.WavesDone > 0 {
+			begin = s.Waves[s.WavesDone-1]
+		}
+		wave := s.Targets[begin:end]
+		fmt.Fprintf(env.Stdout, "Wave %d of %d: updating %d installation(s).\n", s.WavesDone+1, len(s.Waves), len(wave))
+		for _, t := range wave {
+			if t.Updated {
+				continue
+			}
+			if err := t.update(ctx, env.Stdout, env.Stderr, s.Envelope, func() error { return s.save(rolloutStateFile) }); err != nil {
+				return fmt.Errorf("update of %v failed: %v", t.Name, err)
+			}
+			if err := s.save(rolloutStateFile); err != nil {
+				return err
+			}
+		}
+		// The restarts of the instances are counted from the end of
+		// the updates, which restart them.
+		restarts := make(map[string]int64)
+		for _, t := range wave {
+			for _, i := range t.Instances {
+				restarts[i.Name], _ = readInstanceStat(ctx, i.Name, "restarts")
+			}
+		}
+		time.Sleep(rolloutSoak)
+		for _, t := range wave {
+			for _, i := range t.Instances {
+				if err := checkInstanceHealth(ctx, i, restarts[i.Name]); err != nil {
+					return fmt.Errorf("instance %v is unhealthy: %v", i.Name, err)
+				}
+			}
+		}
+		if s.WavesDone++; s.WavesDone == len(s.Waves) {
+			s.Phase = rolloutDone
+		}
+		if err := s.save(rolloutStateFile); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(env.Stdout, "Rollout of %v is done.\n", s.Envelope)
+	return nil
+}
+
+// update updates the installation and its instances to envelope.  The
+// instances are recorded, with save, before they are updated.
+func (t *rolloutTarget) update(ctx *context.T, stdout, stderr io.Writer, envelope string, save func() error) error {
+	if t.Instances == nil {
+		t.Instances = []rolloutInstance{}
+		for _, r := range glob(ctx, &cmdline.Env{Stdout: stdout, Stderr: stderr}, []string{naming.Join(t.Name, "*")}) {
+			if status, ok := r.Status.(device.StatusInstance); ok && status.Value.State != device.InstanceStateDeleted {
+				t.Instances = append(t.Instances, rolloutInstance{
+					Name:    r.Name,
+					Version: status.Value.Version,
+					Running: status.Value.State == device.InstanceStateRunning,
+				})
+			}
+		}
+		if err := save(); err != nil {
+			return err
+		}
+	}
+	err := device.ApplicationClient(t.Name).UpdateTo(ctx, envelope)
+	switch {
+	case err == nil:
+		fmt.Fprintf(stdout, "Successful update of version for installation \"%s\".\n", t.Name)
+	case verror.ErrorID(err) == errors.ErrUpdateNoOp.ID:
+		// The installation was updated before the rollout was
+		// interrupted.
+	default:
+		return fmt.Errorf("UpdateTo failed: %v", err)
+	}
+	for _, i := range t.Instances {
+		status, err := instanceStatus(ctx, i.Name)
+		if err != nil {
+			return err
+		}
+		if err := changeVersionInstance(ctx, stdout, stderr, i.Name, status, false); err != nil {
+			return fmt.Errorf("instance %v: %v", i.Name, err)
+		}
+	}
+	t.Updated = true
+	return nil
+}
+
+// rollBack reverts the installations and instances updated by the rollout to
+// the versions they had before it, most recent first.
+func (s *rolloutState) rollBack(ctx *context.T, env *cmdline.Env) error {
+	fmt.Fprintf(env.Stderr, "Rolling back rollout of %v: %v.\n", s.Envelope, s.Reason)
+	for j := len(s.Targets) - 1; j >= 0; j-- {
+		t := s.Targets[j]
+		if t.Instances == nil || t.Reverted {
+			continue
+		}
+		if err := t.revert(ctx, env.Stdout, env.Stderr); err != nil {
+			return fmt.Errorf("revert of %v failed, the rollout can be resumed to retry: %v", t.Name, err)
+		}
+		if err := s.save(rolloutStateFile); err != nil {
+			return err
+		}
+	}
+	s.Phase = rolloutRolledBack
+	if err := s.save(rolloutStateFile); err != nil {
+		return err
+	}
+	return fmt.Errorf("rollout of %v was rolled back: %v", s.Envelope, s.Reason)
+}
+
+// revert reverts the instances and the installation that are not at their
+// versions from before the rollout.  Comparing the versions makes revert
+// idempotent, so that an interrupted roll back can be resumed.
+func (t *rolloutTarget) revert(ctx *context.T, stdout, stderr io.Writer) error {
+	for _, i := range t.Instances {
+		status, err := instanceStatus(ctx, i.Name)
+		if err != nil {
+			return err
+		}
+		if status.Value.Version == i.Version || status.Value.State == device.InstanceStateDeleted {
+			continue
+		}
+		if err := changeVersionInstance(ctx, stdout, stderr, i.Name, status, true); err != nil {
+			return fmt.Errorf("instance %v: %v", i.Name, err)
+		}
+	}
+	status, err := device.ApplicationClient(t.Name).Status(ctx)
+	if err != nil {
+		return fmt.Errorf("Status failed: %v", err)
+	}
+	s, ok := status.(device.StatusInstallation)
+	if !ok {
+		return fmt.Errorf("Status of wrong type (%T)", status)
+	}
+	if s.Value.Version != t.Version {
+		if err := changeVersionOne(ctx, "installation", stdout, stderr, t.Name, true); err != nil {
+			return err
+		}
+	}
+	t.Reverted = true
+	return nil
+}
+
+func instanceStatus(ctx *context.T, name string) (device.StatusInstance, error) {
+	status, err := device.ApplicationClient(name).Status(ctx)
+	if err != nil {
+		return device.StatusInstance{}, fmt.Errorf("Failed to get status for instance %q: %v", name, err)
+	}
+	s, ok := status.(device.StatusInstance)
+	if !ok {
+		return device.StatusInstance{}, fmt.Errorf("Status for instance %q of wrong type (%T)", name, status)
+	}
+	return s, nil
+}
+
+// checkInstanceHealth returns an error if the instance, which was restarted
+// the given number of times when its wave was updated, is not healthy.
+func checkInstanceHealth(ctx *context.T, i rolloutInstance, restarts int64) error {
+	if !i.Running {
+		return nil
+	}
+	status, err := instanceStatus(ctx, i.Name)
+	if err != nil {
+		return err
+	}
+	if status.Value.State != device.InstanceStateRunning {
+		return fmt.Errorf("it is %v", status.Value.State)
+	}
+	if n, err := readInstanceStat(ctx, i.Name, "restarts"); err != nil && verror.ErrorID(err) != verror.ErrNoExist.ID {
+		return err
+	} else if n > restarts {
+		return fmt.Errorf("it was restarted %d time(s)", n-restarts)
+	}
+	if ready, err := readInstanceStat(ctx, i.Name, "ready"); verror.ErrorID(err) == verror.ErrNoExist.ID {
+		// The instance has no readiness probe.
+	} else if err != nil {
+		return err
+	} else if ready == 0 {
+		return fmt.Errorf("it is not ready")
+	}
+	return nil
+}
+
+// readInstanceStat reads the device manager's stat with the given name for
+// the instance.  Stats that do not exist (yet) read as zero, along with an
+// ErrNoExist error.
+func readInstanceStat(ctx *context.T, instance, stat string) (int64, error) {
+	const appsComponent = "/apps/"
+	idx := strings.Index(instance, appsComponent)
+	if idx < 0 {
+		return 0, fmt.Errorf("%v is not the name of an instance", instance)
+	}
+	deviceName, instanceName := instance[:idx], instance[idx+len(appsComponent):]
+	name := naming.Join(deviceName, "__debug", "stats", deviceManagerStatsPrefix, stat, instanceName)
+	v, err := stats.StatsClient(name).Value(ctx)
+	if verror.ErrorID(err) == verror.ErrNoExist.ID {
+		return 0, err
+	}
+	if err != nil {
+		return 0, fmt.Errorf("Value(%v) failed: %v", name, err)
+	}
+	var n int64
+	if err := v.ToValue(&n); err != nil {
+		return 0, fmt.Errorf("stat %v is not an integer: %v", name, err)
+	}
+	return n, nil
+}
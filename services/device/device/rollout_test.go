@@ -0,0 +1,324 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"v.io/v23"
+	"v.io/v23/naming"
+	"v.io/v23/services/device"
+	"v.io/x/lib/cmdline"
+	"v.io/x/ref/lib/stats"
+	"v.io/x/ref/lib/v23cmd"
+	"v.io/x/ref/test"
+
+	cmd_device "v.io/x/ref/services/device/device"
+	"v.io/x/ref/services/internal/servicetest"
+)
+
+var (
+	installationActiveV2 = device.StatusInstallation{device.InstallationStatus{
+		State:   device.InstallationStateActive,
+		Version: "sequel",
+	}}
+	instanceRunningV2 = device.StatusInstance{device.InstanceStatus{
+		State:   device.InstanceStateRunning,
+		Version: "sequel",
+	}}
+)
+
+// TestRolloutWaves verifies how the installations of a rollout are split in
+// waves.
+func TestRolloutWaves(t *testing.T) {
+	for _, c := range []struct {
+		n             int
+		canary, wave  float64
+		expectedWaves []int
+	}{
+		{1, 5, 25, []int{1}},
+		{3, 5, 25, []int{1, 2, 3}},
+		{10, 10, 50, []int{1, 6, 10}},
+		{100, 5, 25, []int{5, 30, 55, 80, 100}},
+		{100, 100, 25, []int{100}},
+		{7, 50, 100, []int{4, 7}},
+	} {
+		if got := cmd_device.RolloutWaves(c.n, c.canary, c.wave); !reflect.DeepEqual(got, c.expectedWaves) {
+			t.Errorf("RolloutWaves(%d, %v, %v): got %v, want %v", c.n, c.canary, c.wave, got, c.expectedWaves)
+		}
+	}
+}
+
+// TestRolloutCommand verifies the device rollout command.
+func TestRolloutCommand(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+	tapes := servicetest.NewTapeMap()
+	ctx, server, err := v23.WithNewDispatchingServer(ctx, "", newDispatcher(t, tapes))
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	addr := server.Status().Endpoints[0].String()
+	root := cmd_device.CmdRoot
+	rootTape := tapes.ForSuffix("")
+	dir, err := ioutil.TempDir("", "rollout_test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "state")
+
+	const envelope = "envelope"
+	var (
+		pattern         = naming.JoinAddressName(addr, "apps/*")
+		app1, app2      = naming.JoinAddressName(addr, "apps/app/1"), naming.JoinAddressName(addr, "apps/app/2")
+		app1i, app2i    = app1 + "/i", app2 + "/i"
+		updateTo        = UpdateToStimulus{"UpdateTo", envelope}
+		kill            = KillStimulus{"Kill", 10 * time.Second}
+		globs           = []interface{}{GlobStimulus{"apps/*"}, GlobStimulus{"apps/app/1/*"}, GlobStimulus{"apps/app/2/*"}}
+		globResponses   = []interface{}{GlobResponse{results: []string{"apps/app/2", "apps/app/1"}}, GlobResponse{results: []string{"apps/app/1/i"}}, GlobResponse{results: []string{"apps/app/2/i"}}}
+		restartsOnRead  = func() func() int64 { n := int64(0); return func() int64 { n++; return n } }
+		notReady        = func() int64 { return 0 }
+		unhealthyReason = fmt.Sprintf("instance %s is unhealthy: it is not ready", app1i)
+		restartedReason = fmt.Sprintf("instance %s is unhealthy: it was restarted 1 time(s)", app1i)
+		joinLines       = func(args ...string) string { return strings.Join(args, "\n") }
+		targetState     = func(name, instance string, updated, reverted bool) string {
+			return fmt.Sprintf(`{"Name": %q, "Version": "extended cut", "Instances": [{"Name": %q, "Version": "tv version", "Running": true}], "Updated": %v, "Reverted": %v}`, name, instance, updated, reverted)
+		}
+		rolloutState = func(phase, reason string, wavesDone int, targets ...string) string {
+			return fmt.Sprintf(`{"Envelope": %q, "Patterns": [%q], "Phase": %q, "Targets": [%s], "Waves": [1, 2], "WavesDone": %d, "Reason": %q}`, envelope, pattern, phase, strings.Join(targets, ", "), wavesDone, reason)
+		}
+	)
+	for _, c := range []struct {
+		// state is the saved state of the rollout, if any.
+		state           string
+		globResponses   []interface{}
+		responses       map[string][]interface{}
+		stats           map[string]func() int64
+		expectedStimuli map[string][]interface{}
+		expectedStdout  string
+		expectedStderr  string
+		expectedError   string
+		expectedPhase   string
+		expectedWaves   int
+	}{
+		{ // Both waves are updated, and healthy.
+			"",
+			globResponses,
+			map[string][]interface{}{
+				"apps/app/1":   []interface{}{installationActive, nil},
+				"apps/app/1/i": []interface{}{instanceRunning, instanceRunning, nil, nil, nil, instanceRunning},
+				"apps/app/2":   []interface{}{installationActive, nil},
+				"apps/app/2/i": []interface{}{instanceRunning, instanceRunning, nil, nil, nil, instanceRunning},
+			},
+			nil,
+			map[string][]interface{}{
+				"":             globs,
+				"apps/app/1":   []interface{}{"Status", updateTo},
+				"apps/app/1/i": []interface{}{"Status", "Status", kill, "Update", "Run", "Status"},
+				"apps/app/2":   []interface{}{"Status", updateTo},
+				"apps/app/2/i": []interface{}{"Status", "Status", kill, "Update", "Run", "Status"},
+			},
+			joinLines(
+				"Wave 1 of 2: updating 1 installation(s).",
+				fmt.Sprintf("Successful update of version for installation \"%s\".", app1),
+				fmt.Sprintf("Successful update of version for instance \"%s\".", app1i),
+				"Wave 2 of 2: updating 1 installation(s).",
+				fmt.Sprintf("Successful update of version for installation \"%s\".", app2),
+				fmt.Sprintf("Successful update of version for instance \"%s\".", app2i),
+				"Rollout of envelope is done."),
+			"",
+			"",
+			"done",
+			2,
+		},
+		{ // The instance of the first wave is not ready: the first wave is
+			// rolled back, and the second is left alone.
+			"",
+			globResponses[:2],
+			map[string][]interface{}{
+				"apps/app/1":   []interface{}{installationActive, nil, installationActiveV2, nil},
+				"apps/app/1/i": []interface{}{instanceRunning, instanceRunning, nil, nil, nil, instanceRunning, instanceRunningV2, nil, nil, nil},
+				"apps/app/2":   []interface{}{installationActive},
+			},
+			map[string]func() int64{"device-manager/ready/app/1/i": notReady},
+			map[string][]interface{}{
+				"":             globs[:2],
+				"apps/app/1":   []interface{}{"Status", updateTo, "Status", "Revert"},
+				"apps/app/1/i": []interface{}{"Status", "Status", kill, "Update", "Run", "Status", "Status", kill, "Revert", "Run"},
+				"apps/app/2":   []interface{}{"Status"},
+				"apps/app/2/i": []interface{}{},
+			},
+			joinLines(
+				"Wave 1 of 2: updating 1 installation(s).",
+				fmt.Sprintf("Successful update of version for installation \"%s\".", app1),
+				fmt.Sprintf("Successful update of version for instance \"%s\".", app1i),
+				fmt.Sprintf("Successful revert of version for instance \"%s\".", app1i),
+				fmt.Sprintf("Successful revert of version for installation \"%s\".", app1)),
+			fmt.Sprintf("Rolling back rollout of envelope: %s.", unhealthyReason),
+			"rollout of envelope was rolled back: " + unhealthyReason,
+			"rolled back",
+			0,
+		},
+		{ // The instance of the first wave is restarted after the update.
+			"",
+			globResponses[:2],
+			map[string][]interface{}{
+				"apps/app/1":   []interface{}{installationActive, nil, installationActiveV2, nil},
+				"apps/app/1/i": []interface{}{instanceRunning, instanceRunning, nil, nil, nil, instanceRunning, instanceRunningV2, nil, nil, nil},
+				"apps/app/2":   []interface{}{installationActive},
+			},
+			map[string]func() int64{"device-manager/restarts/app/1/i": restartsOnRead()},
+			map[string][]interface{}{
+				"":             globs[:2],
+				"apps/app/1":   []interface{}{"Status", updateTo, "Status", "Revert"},
+				"apps/app/1/i": []interface{}{"Status", "Status", kill, "Update", "Run", "Status", "Status", kill, "Revert", "Run"},
+				"apps/app/2":   []interface{}{"Status"},
+			},
+			joinLines(
+				"Wave 1 of 2: updating 1 installation(s).",
+				fmt.Sprintf("Successful update of version for installation \"%s\".", app1),
+				fmt.Sprintf("Successful update of version for instance \"%s\".", app1i),
+				fmt.Sprintf("Successful revert of version for instance \"%s\".", app1i),
+				fmt.Sprintf("Successful revert of version for installation \"%s\".", app1)),
+			fmt.Sprintf("Rolling back rollout of envelope: %s.", restartedReason),
+			"rollout of envelope was rolled back: " + restartedReason,
+			"rolled back",
+			0,
+		},
+		{ // A rollout interrupted after its first wave resumes with the
+			// second.
+			rolloutState("in progress", "", 1, targetState(app1, app1i, true, false), fmt.Sprintf(`{"Name": %q, "Version": "extended cut"}`, app2)),
+			globResponses[2:],
+			map[string][]interface{}{
+				"apps/app/2":   []interface{}{nil},
+				"apps/app/2/i": []interface{}{instanceRunning, instanceRunning, nil, nil, nil, instanceRunning},
+			},
+			nil,
+			map[string][]interface{}{
+				"":             globs[2:],
+				"apps/app/1":   []interface{}{},
+				"apps/app/1/i": []interface{}{},
+				"apps/app/2":   []interface{}{updateTo},
+				"apps/app/2/i": []interface{}{"Status", "Status", kill, "Update", "Run", "Status"},
+			},
+			joinLines(
+				"Resuming rollout of envelope (in progress).",
+				"Wave 2 of 2: updating 1 installation(s).",
+				fmt.Sprintf("Successful update of version for installation \"%s\".", app2),
+				fmt.Sprintf("Successful update of version for instance \"%s\".", app2i),
+				"Rollout of envelope is done."),
+			"",
+			"",
+			"done",
+			2,
+		},
+		{ // An interrupted roll back resumes, and only reverts what is
+			// not reverted yet.
+			rolloutState("rolling back", unhealthyReason, 1, targetState(app1, app1i, true, false), targetState(app2, app2i, true, true)),
+			nil,
+			map[string][]interface{}{
+				"apps/app/1":   []interface{}{installationActiveV2, nil},
+				"apps/app/1/i": []interface{}{instanceRunning},
+			},
+			nil,
+			map[string][]interface{}{
+				"":             []interface{}{},
+				"apps/app/1":   []interface{}{"Status", "Revert"},
+				"apps/app/1/i": []interface{}{"Status"},
+				"apps/app/2":   []interface{}{},
+				"apps/app/2/i": []interface{}{},
+			},
+			joinLines(
+				"Resuming rollout of envelope (rolling back).",
+				fmt.Sprintf("Successful revert of version for installation \"%s\".", app1)),
+			fmt.Sprintf("Rolling back rollout of envelope: %s.", unhealthyReason),
+			"rollout of envelope was rolled back: " + unhealthyReason,
+			"rolled back",
+			1,
+		},
+		{ // A done rollout does nothing.
+			rolloutState("done", "", 2, targetState(app1, app1i, true, false), targetState(app2, app2i, true, false)),
+			nil,
+			nil,
+			nil,
+			map[string][]interface{}{
+				"":           []interface{}{},
+				"apps/app/1": []interface{}{},
+				"apps/app/2": []interface{}{},
+			},
+			joinLines(
+				"Resuming rollout of envelope (done).",
+				"Rollout of envelope is done."),
+			"",
+			"",
+			"done",
+			2,
+		},
+	} {
+		var stdout, stderr bytes.Buffer
+		env := &cmdline.Env{Stdout: &stdout, Stderr: &stderr}
+		tapes.Rewind()
+		os.Remove(stateFile)
+		if c.state != "" {
+			if err := ioutil.WriteFile(stateFile, []byte(c.state), 0600); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+		}
+		rootTape.SetResponses(c.globResponses...)
+		for n, r := range c.responses {
+			tapes.ForSuffix(n).SetResponses(r...)
+		}
+		for n, f := range c.stats {
+			stats.NewIntegerFunc(n, f)
+		}
+		args := []string{"rollout", "--canary=50", "--wave=50", "--soak=0", "--state=" + stateFile, envelope, pattern}
+		if err := v23cmd.ParseAndRunForTest(root, ctx, env, args); err != nil {
+			if want, got := c.expectedError, err.Error(); want != got {
+				t.Errorf("Unexpected error: want %v, got %v", want, got)
+			}
+		} else if c.expectedError != "" {
+			t.Errorf("Expected to get error %v, but didn't get any error.", c.expectedError)
+		}
+		for n := range c.stats {
+			stats.Delete(n)
+		}
+
+		if expected, got := c.expectedStdout, strings.TrimSpace(stdout.String()); got != expected {
+			t.Errorf("Unexpected stdout output from rollout.\nGot:\n%v\nExpected:\n%v", got, expected)
+		}
+		if expected, got := c.expectedStderr, strings.TrimSpace(stderr.String()); got != expected {
+			t.Errorf("Unexpected stderr output from rollout.\nGot:\n%v\nExpected:\n%v", got, expected)
+		}
+		for n, m := range c.expectedStimuli {
+			if want, got := m, tapes.ForSuffix(n).Play(); !reflect.DeepEqual(want, got) {
+				t.Errorf("Unexpected stimuli for %v. Want: %v, got %v.", n, want, got)
+			}
+		}
+		data, err := ioutil.ReadFile(stateFile)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		var state struct {
+			Phase     string
+			WavesDone int
+		}
+		if err := json.Unmarshal(data, &state); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		if state.Phase != c.expectedPhase || state.WavesDone != c.expectedWaves {
+			t.Errorf("Unexpected state: want phase %q after %d wave(s), got %q after %d", c.expectedPhase, c.expectedWaves, state.Phase, state.WavesDone)
+		}
+	}
+}
@@ -23,6 +23,7 @@ var (
 	initHelper  string
 	devUserName string
 	origin      string
+	cgroup      string
 	singleUser  bool
 	sessionMode bool
 	initMode    bool
@@ -59,6 +60,7 @@ func init() {
 	cmdInstall.Flags.StringVar(&agent, "agent", "", "path to security agent")
 	cmdInstall.Flags.StringVar(&initHelper, "init_helper", "", "path to sysinit helper")
 	cmdInstall.Flags.StringVar(&origin, "origin", "", "if specified, self-updates will use this origin")
+	cmdInstall.Flags.StringVar(&cgroup, "cgroup", "", "if specified, app instances with resource limits are run in cgroups created under this cgroup (v2), which must be delegated to the device manager")
 	cmdInstall.Flags.StringVar(&devUserName, "devuser", "", "if specified, device manager will run as this user. Provided by devicex but ignored .")
 	cmdInstall.Flags.BoolVar(&singleUser, "single_user", false, "if set, performs the installation assuming a single-user system")
 	cmdInstall.Flags.BoolVar(&sessionMode, "session_mode", false, "if set, installs the device manager to run a single session. Otherwise, the device manager is configured to get restarted upon exit")
@@ -86,7 +88,7 @@ func runInstall(ctx *context.T, env *cmdline.Env, args []string) error {
 	if initMode && initHelper == "" {
 		return env.UsageErrorf("--init_helper must be set")
 	}
-	if err := installer.SelfInstall(ctx, installationDir(ctx, env), suidHelper, restarter, agent, initHelper, origin, singleUser, sessionMode, initMode, args, os.Environ(), env.Stderr, env.Stdout); err != nil {
+	if err := installer.SelfInstall(ctx, installationDir(ctx, env), suidHelper, restarter, agent, initHelper, origin, cgroup, singleUser, sessionMode, initMode, args, os.Environ(), env.Stderr, env.Stdout); err != nil {
 		ctx.Errorf("SelfInstall failed: %v", err)
 		return err
 	}
@@ -43,6 +43,8 @@ The global flags are:
    Path to the application's security agent socket.
  -alsologtostderr=true
    log to standard error as well as files
+ -cgroup=
+   Path to the cgroup in which to run the application.
  -chown=false
    Change owner of files and directories given as command-line arguments to the
    user specified by this flag
@@ -92,6 +94,14 @@ The global flags are:
  -v23.proxy=
    object name of proxy service to use to export services across network
    boundaries
+ -v23.revocation.max-staleness=24h0m0s
+   Maximum age of the revocation snapshots against which discharges past their
+   freshness are validated.
+ -v23.revocation.refresh=1h0m0s
+   Interval at which revocation snapshots are fetched.
+ -v23.revocation.snapshots=
+   Names of the revocation list services to fetch revocation snapshots from,
+   comma separated.
  -v23.tcp.address=
    address to listen on
  -v23.tcp.protocol=wsh
@@ -115,6 +125,8 @@ The global flags are:
    comma-separated list of regexppattern=N settings for file pathname-filtered
    logging (without the .go suffix).  E.g. foo/bar/baz.go is matched by patterns
    foo/bar/baz or fo.*az or oo/ba or b.z but not by foo/bar/baz.go or fo*az
+ -wait=false
+   Wait for the application to exit, and exit with its exit status.
  -workspace=
    Path to the application's workspace directory.
 
@@ -131,6 +143,9 @@ Arguments to be passed to the installed device manager
 The deviced install flags are:
  -agent=
    path to security agent
+ -cgroup=
+   if specified, app instances with resource limits are run in cgroups created
+   under this cgroup (v2), which must be delegated to the device manager
  -devuser=
    if specified, device manager will run as this user. Provided by devicex but
    ignored .
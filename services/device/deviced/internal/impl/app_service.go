@@ -129,6 +129,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -202,6 +203,35 @@ type appRunner struct {
 	// appServiceName is a name by which the appService can be reached
 	appServiceName string
 	stats          *stats
+	// cgroups runs the instances with resource limits in cgroups.
+	cgroups *cgroupManager
+	// health runs the health probes of the instances.
+	health *healthChecker
+	// generations lets restarts detect that their instance was run,
+	// killed or deleted while they backed off.
+	generations instanceGenerations
+}
+
+// instanceGenerations counts the Run, Kill and Delete operations on each
+// instance.
+type instanceGenerations struct {
+	mu   sync.Mutex
+	gens map[string]uint64 // GUARDED_BY(mu)
+}
+
+func (g *instanceGenerations) get(instanceDir string) uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.gens[instanceDir]
+}
+
+func (g *instanceGenerations) bump(instanceDir string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.gens == nil {
+		g.gens = make(map[string]uint64)
+	}
+	g.gens[instanceDir]++
 }
 
 // appService implements the Device manager's Application interface.
@@ -218,6 +248,8 @@ type appService struct {
 	// State needed to (re)start an application.
 	runner *appRunner
 	stats  *stats
+	// blobs holds the binaries and packages shared by the versions.
+	blobs *blobCache
 }
 
 func saveEnvelope(ctx *context.T, dir string, envelope *application.Envelope) error {
@@ -385,8 +417,9 @@ func fetchAppEnvelope(ctx *context.T, origin string) (*application.Envelope, err
 	return envelope, nil
 }
 
-// newVersion sets up the directory for a new application version.
-func newVersion(ctx *context.T, installationDir string, envelope *application.Envelope, oldVersionDir string) (string, error) {
+// newVersion sets up the directory for a new application version.  The
+// signatures of its binary and packages are verified according to policy.
+func newVersion(ctx *context.T, blobs *blobCache, policy *signaturePolicy, installationDir string, envelope *application.Envelope, oldVersionDir string) (string, error) {
 	versionDir := filepath.Join(installationDir, generateVersionDirName())
 	if err := mkdirPerm(ctx, versionDir, 0711); err != nil {
 		return "", verror.New(errors.ErrOperationFailed, ctx, err)
@@ -399,11 +432,10 @@ func newVersion(ctx *context.T, installationDir string, envelope *application.En
 		return "", verror.New(errors.ErrOperationFailed, ctx, err)
 	}
 	publisher := envelope.Publisher
-	// TODO(caprita): Share binaries if already existing locally.
-	if err := downloadBinary(ctx, publisher, &envelope.Binary, versionDir, "bin"); err != nil {
+	if err := downloadBinary(ctx, blobs, policy, publisher, &envelope.Binary, versionDir, "bin"); err != nil {
 		return versionDir, err
 	}
-	if err := downloadPackages(ctx, publisher, envelope.Packages, pkgDir); err != nil {
+	if err := downloadPackages(ctx, blobs, policy, publisher, envelope.Packages, pkgDir); err != nil {
 		return versionDir, err
 	}
 	if err := installPackages(ctx, installationDir, versionDir); err != nil {
@@ -434,6 +466,13 @@ func (i *appService) Install(ctx *context.T, call rpc.ServerCall, applicationVON
 	if err != nil {
 		return "", err
 	}
+	policy, err := signaturePolicyFromConfig(config)
+	if err != nil {
+		return "", err
+	}
+	if err := policy.checkPublisher(ctx, envelope); err != nil {
+		return "", err
+	}
 	installationID := generateID()
 	installationDir := filepath.Join(i.config.Root, applicationDirName(envelope.Title), installationDirName(installationID))
 	deferrer := func() {
@@ -460,17 +499,19 @@ func (i *appService) Install(ctx *context.T, call rpc.ServerCall, applicationVON
 	if err := savePackages(ctx, installationDir, packages); err != nil {
 		return "", err
 	}
+	if err := saveSignaturePolicy(ctx, installationDir, policy); err != nil {
+		return "", err
+	}
 	pkgDir := filepath.Join(installationDir, "pkg")
 	if err := mkdir(ctx, pkgDir); err != nil {
 		return "", verror.New(errors.ErrOperationFailed, ctx, err)
 	}
-	// We use a zero value publisher, meaning that any signatures present in the
-	// package files are not verified.
-	// TODO(caprita): Issue warnings when signatures are present and ignored.
-	if err := downloadPackages(ctx, security.Blessings{}, packages, pkgDir); err != nil {
+	// The packages given at install time override those of the envelope,
+	// so they must be signed by the envelope's publisher as well.
+	if err := downloadPackages(ctx, i.blobs, policy, envelope.Publisher, packages, pkgDir); err != nil {
 		return "", err
 	}
-	if _, err := newVersion(ctx, installationDir, envelope, ""); err != nil {
+	if _, err := newVersion(ctx, i.blobs, policy, installationDir, envelope, ""); err != nil {
 		return "", err
 	}
 	// TODO(caprita,rjkroege): Should the installation AccessLists really be
@@ -704,7 +745,7 @@ func (i *appService) newInstance(ctx *context.T, call device.ApplicationInstanti
 	return instanceDir, instanceID, nil
 }
 
-func genCmd(ctx *context.T, instanceDir, nsRoot string) (*exec.Cmd, error) {
+func genCmd(ctx *context.T, instanceDir, nsRoot string, cgroups *cgroupManager) (*exec.Cmd, error) {
 	systemName, err := readSystemNameForInstance(instanceDir)
 	if err != nil {
 		return nil, err
@@ -740,9 +781,24 @@ func genCmd(ctx *context.T, instanceDir, nsRoot string) (*exec.Cmd, error) {
 	appName := strings.Map(sanitize, rawAppName)
 	saArgs.progname = appName
 
+	// The resource limits in the envelope are enforced by running the app
+	// in a cgroup, and not passed to the app.
+	limits, env, err := parseLimits(envelope.Env)
+	if err != nil {
+		return nil, err
+	}
+	// Likewise for the health probes, which are run by the device
+	// manager.
+	if _, _, env, err = parseProbes(env); err != nil {
+		return nil, err
+	}
+	if saArgs.cgroup, err = cgroups.cgroupFor(ctx, instanceDir, limits); err != nil {
+		return nil, err
+	}
+
 	// Set the app's default namespace root to the local namespace.
 	saArgs.env = []string{ref.EnvNamespacePrefix + "=" + nsRoot}
-	saArgs.env = append(saArgs.env, envelope.Env...)
+	saArgs.env = append(saArgs.env, env...)
 	rootDir := filepath.Join(instanceDir, "root")
 	saArgs.dir = rootDir
 	saArgs.workspace = rootDir
@@ -886,7 +942,10 @@ func (i *appRunner) run(ctx *context.T, instanceDir string) error {
 	}
 	var pid int
 
-	cmd, err := genCmd(ctx, instanceDir, i.mtAddress)
+	cmd, err := genCmd(ctx, instanceDir, i.mtAddress, i.cgroups)
+	if err == nil {
+		err = i.cgroups.prepare(ctx, instanceDir)
+	}
 	if err == nil {
 		pid, err = i.startCmd(ctx, instanceDir, cmd)
 	}
@@ -902,29 +961,30 @@ func (i *appRunner) run(ctx *context.T, instanceDir string) error {
 		return err
 	}
 	i.reap.startWatching(instanceDir, pid)
+	i.health.start(instanceDir)
 	return nil
 }
 
-func synchronizedShouldRestart(ctx *context.T, instanceDir string) bool {
+func synchronizedShouldRestart(ctx *context.T, instanceDir string) (bool, time.Duration) {
 	info, err := loadInstanceInfo(nil, instanceDir)
 	if err != nil {
 		ctx.Error(err)
-		return false
+		return false, 0
 	}
 
 	envelope, err := loadEnvelopeForInstance(nil, instanceDir)
 	if err != nil {
 		ctx.Error(err)
-		return false
+		return false, 0
 	}
 
-	shouldRestart := newBasicRestartPolicy().decide(envelope, info)
+	shouldRestart, delay := newBasicRestartPolicy().decide(envelope, info)
 
 	if err := saveInstanceInfo(nil, instanceDir, info); err != nil {
 		ctx.Error(err)
-		return false
+		return false, 0
 	}
-	return shouldRestart
+	return shouldRestart, delay
 }
 
 // restartAppIfNecessary restarts an application if its daemon
@@ -936,11 +996,12 @@ func synchronizedShouldRestart(ctx *context.T, instanceDir string) bool {
 // running and the go routine invoking this function having a chance to
 // complete.
 func (i *appRunner) restartAppIfNecessary(ctx *context.T, instanceDir string) {
+	generation := i.generations.get(instanceDir)
 	if err := transitionInstance(instanceDir, device.InstanceStateNotRunning, device.InstanceStateLaunching); err != nil {
 		ctx.Error(err)
 		return
 	}
-	shouldRestart := synchronizedShouldRestart(ctx, instanceDir)
+	shouldRestart, delay := synchronizedShouldRestart(ctx, instanceDir)
 
 	if err := transitionInstance(instanceDir, device.InstanceStateLaunching, device.InstanceStateNotRunning); err != nil {
 		ctx.Error(err)
@@ -951,6 +1012,18 @@ func (i *appRunner) restartAppIfNecessary(ctx *context.T, instanceDir string) {
 		return
 	}
 
+	// Back off before restarting an instance that keeps failing.  If the
+	// instance is run, killed or deleted in the meantime, the restart is
+	// abandoned.
+	if delay > 0 {
+		ctx.Infof("Restarting %v in %v", instanceDir, delay)
+		time.Sleep(delay)
+		if i.generations.get(instanceDir) != generation {
+			ctx.Infof("Not restarting %v: it was run, killed or deleted in the meantime", instanceDir)
+			return
+		}
+	}
+
 	if instanceName, err := instanceNameFromDir(ctx, instanceDir); err != nil {
 		ctx.Error(err)
 		i.stats.incrRestarts("unknown")
@@ -1026,6 +1099,7 @@ func (i *appService) Run(ctx *context.T, call rpc.ServerCall) error {
 	}
 
 	i.stats.incrRuns(naming.Join(i.suffix...))
+	i.runner.generations.bump(instanceDir)
 
 	// TODO(caprita): We should reset the Restarts and RestartWindowBegan
 	// fields in the instance info when the instance is started with Run.
@@ -1110,6 +1184,7 @@ func (i *appService) Delete(ctx *context.T, _ rpc.ServerCall) error {
 	if err != nil {
 		return err
 	}
+	i.runner.generations.bump(instanceDir)
 	return transitionInstance(instanceDir, device.InstanceStateNotRunning, device.InstanceStateDeleted)
 }
 
@@ -1118,9 +1193,13 @@ func (i *appService) Kill(ctx *context.T, _ rpc.ServerCall, deadline time.Durati
 	if err != nil {
 		return err
 	}
+	i.runner.generations.bump(instanceDir)
 	if err := transitionInstance(instanceDir, device.InstanceStateRunning, device.InstanceStateDying); err != nil {
 		return err
 	}
+	// Stop the probes first, so that they do not kill the instance while
+	// it shuts down.
+	i.runner.health.stop(instanceDir)
 	info, err := loadInstanceInfo(ctx, instanceDir)
 	if err != nil {
 		return err
@@ -1132,6 +1211,7 @@ func (i *appService) Kill(ctx *context.T, _ rpc.ServerCall, deadline time.Durati
 		if err := transitionInstance(instanceDir, device.InstanceStateDying, device.InstanceStateRunning); err != nil {
 			ctx.Errorf("transitionInstance(%v, %v, %v): %v", instanceDir, device.InstanceStateDying, device.InstanceStateRunning, err)
 		}
+		i.runner.health.start(instanceDir)
 		// Return the stop error.
 		return err
 	} else if err != nil {
@@ -1141,6 +1221,7 @@ func (i *appService) Kill(ctx *context.T, _ rpc.ServerCall, deadline time.Durati
 	if err := i.runner.principalMgr.StopServing(instanceDir); err != nil {
 		ctx.Errorf("StopServing(%v) failed: %v", instanceDir, err)
 	}
+	i.runner.cgroups.release(ctx, instanceDir)
 	return transitionInstance(instanceDir, device.InstanceStateDying, device.InstanceStateNotRunning)
 }
 
@@ -1183,17 +1264,24 @@ func updateInstance(ctx *context.T, instanceDir string) (err error) {
 	return UpdateLink(latestVersionDir, versionLink)
 }
 
-func updateInstallation(ctx *context.T, installationDir string) error {
-	if !installationStateIs(installationDir, device.InstallationStateActive) {
-		return verror.New(errors.ErrInvalidOperation, ctx)
-	}
+func updateInstallation(ctx *context.T, blobs *blobCache, installationDir string) error {
 	originVON, err := loadOrigin(ctx, installationDir)
 	if err != nil {
 		return err
 	}
+	return updateInstallationTo(ctx, blobs, installationDir, originVON)
+}
+
+// updateInstallationTo installs the envelope fetched from envelopeVON as the
+// new current version of the installation.  The envelope must have the same
+// title as the current version, and differ from it.
+func updateInstallationTo(ctx *context.T, blobs *blobCache, installationDir, envelopeVON string) error {
+	if !installationStateIs(installationDir, device.InstallationStateActive) {
+		return verror.New(errors.ErrInvalidOperation, ctx)
+	}
 	ctx, cancel := context.WithTimeout(ctx, rpcContextLongTimeout)
 	defer cancel()
-	newEnvelope, err := fetchAppEnvelope(ctx, originVON)
+	newEnvelope, err := fetchAppEnvelope(ctx, envelopeVON)
 	if err != nil {
 		return err
 	}
@@ -1220,7 +1308,14 @@ func updateInstallation(ctx *context.T, installationDir string) error {
 	if reflect.DeepEqual(oldEnvelope, newEnvelope) {
 		return verror.New(errors.ErrUpdateNoOp, ctx)
 	}
-	versionDir, err := newVersion(ctx, installationDir, newEnvelope, oldVersionDir)
+	policy, err := loadSignaturePolicy(ctx, installationDir)
+	if err != nil {
+		return err
+	}
+	if err := policy.checkPublisher(ctx, newEnvelope); err != nil {
+		return err
+	}
+	versionDir, err := newVersion(ctx, blobs, policy, installationDir, newEnvelope, oldVersionDir)
 	if err != nil {
 		CleanupDir(ctx, versionDir, "")
 		return err
@@ -1230,7 +1325,7 @@ func updateInstallation(ctx *context.T, installationDir string) error {
 
 func (i *appService) Update(ctx *context.T, _ rpc.ServerCall) error {
 	if installationDir, err := i.installationDir(); err == nil {
-		return updateInstallation(ctx, installationDir)
+		return updateInstallation(ctx, i.blobs, installationDir)
 	}
 	if instanceDir, err := i.instanceDir(); err == nil {
 		return updateInstance(ctx, instanceDir)
@@ -1238,9 +1333,17 @@ func (i *appService) Update(ctx *context.T, _ rpc.ServerCall) error {
 	return verror.New(errors.ErrInvalidSuffix, nil)
 }
 
-func (*appService) UpdateTo(_ *context.T, _ rpc.ServerCall, von string) error {
-	// TODO(jsimsa): Implement.
-	return nil
+// UpdateTo updates the installation to the envelope served at von, instead of
+// the one served at the installation's origin.  The origin is left unchanged,
+// so a subsequent Update moves the installation back to the origin's envelope.
+// As with Update, the new version links to the current one as its previous
+// version, so that Revert undoes the update.
+func (i *appService) UpdateTo(ctx *context.T, _ rpc.ServerCall, von string) error {
+	installationDir, err := i.installationDir()
+	if err != nil {
+		return verror.New(errors.ErrInvalidSuffix, nil)
+	}
+	return updateInstallationTo(ctx, i.blobs, installationDir, von)
 }
 
 func revertInstance(ctx *context.T, instanceDir string) (err error) {
@@ -1558,6 +1661,8 @@ Envelope: {{printf "%+v" .Envelope}}
 
 Info: {{printf "%+v" .Info}}
 
+Readiness: {{.Readiness}}
+
 Principal: {{.PrincipalDebug}}
 Public Key: {{.Principal.PublicKey}}
 Blessing Store: {{.Principal.BlessingStore.DebugString}}
@@ -1573,14 +1678,15 @@ Roots: {{.Principal.Roots.DebugString}}
 		return "", err
 	}
 	debugInfo := struct {
-		InstanceDir, SystemName, StartSystemName string
-		Cmd                                      *exec.Cmd
-		Envelope                                 *application.Envelope
-		Info                                     *instanceInfo
-		Principal                                agent.Principal
-		PrincipalDebug                           string
+		InstanceDir, SystemName, StartSystemName, Readiness string
+		Cmd                                                 *exec.Cmd
+		Envelope                                            *application.Envelope
+		Info                                                *instanceInfo
+		Principal                                           agent.Principal
+		PrincipalDebug                                      string
 	}{}
 	debugInfo.InstanceDir = instanceDir
+	debugInfo.Readiness = i.runner.health.readiness(instanceDir)
 
 	debugInfo.SystemName = suidHelper.usernameForPrincipal(ctx, call, i.uat)
 	if startSystemName, err := readSystemNameForInstance(instanceDir); err != nil {
@@ -1594,7 +1700,7 @@ Roots: {{.Principal.Roots.DebugString}}
 	} else {
 		debugInfo.Info = info
 	}
-	if cmd, err := genCmd(ctx, instanceDir, i.runner.mtAddress); err != nil {
+	if cmd, err := genCmd(ctx, instanceDir, i.runner.mtAddress, i.runner.cgroups); err != nil {
 		return "", err
 	} else {
 		debugInfo.Cmd = cmd
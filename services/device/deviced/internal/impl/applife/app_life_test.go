@@ -231,11 +231,16 @@ func TestLifeOfAnApp(t *testing.T) {
 
 	// Updating the installation to itself is a no-op.
 	utiltest.UpdateAppExpectError(t, ctx, appID, errors.ErrUpdateNoOp.ID)
+	utiltest.UpdateAppToExpectError(t, ctx, appID, utiltest.MockApplicationRepoName, errors.ErrUpdateNoOp.ID)
 
 	// Updating the installation should not work with a mismatched title.
 	*envelope = utiltest.EnvelopeFromShell(sh, nil, nil, utiltest.App, "bogus", 0, 0, "bogus")
 
 	utiltest.UpdateAppExpectError(t, ctx, appID, errors.ErrAppTitleMismatch.ID)
+	utiltest.UpdateAppToExpectError(t, ctx, appID, utiltest.MockApplicationRepoName, errors.ErrAppTitleMismatch.ID)
+
+	// Instances can not be updated to a given envelope.
+	utiltest.UpdateAppToExpectError(t, ctx, appID+"/"+instance1ID, utiltest.MockApplicationRepoName, errors.ErrInvalidSuffix.ID)
 
 	// Create a second version of the app and update the app to it.
 	*envelope = utiltest.EnvelopeFromShell(sh, []string{utiltest.TestEnvVarName + "=env-val-envelope"}, nil, utiltest.App, "google naps", 0, 0, "appV2")
@@ -362,12 +367,49 @@ func TestLifeOfAnApp(t *testing.T) {
 	// We are already on the first version, no further revert possible.
 	utiltest.RevertAppExpectError(t, ctx, appID, errors.ErrUpdateNoOp.ID)
 
+	// Update the app to an envelope served by another repository.
+	otherEnvelope, otherCleanup := utiltest.StartApplicationRepositoryAt(ctx, "ar2")
+	defer otherCleanup()
+	*otherEnvelope = utiltest.EnvelopeFromShell(sh, []string{utiltest.TestEnvVarName + "=env-val-envelope"}, nil, utiltest.App, "google naps", 0, 0, "appV3")
+	utiltest.UpdateAppTo(t, ctx, appID, "ar2")
+	v3 := utiltest.VerifyState(t, ctx, device.InstallationStateActive, appID)
+	if v3 == v1 || v3 == v2 {
+		t.Fatalf("Version did not change for %v: %v", appID, v3)
+	}
+
+	// Start a fifth instance.  It should be running from version 3.
+	instance5ID := utiltest.LaunchApp(t, ctx, appID)
+	if v := utiltest.VerifyState(t, ctx, device.InstanceStateRunning, appID, instance5ID); v != v3 {
+		t.Fatalf("Instance version expected to be %v, got %v instead", v3, v)
+	}
+	pingCh.VerifyPingArgs(t, utiltest.UserName(t), "flag-val-install", "env-val-envelope") // Wait until the app pings us that it's ready.
+	utiltest.Resolve(t, ctx, "appV3", 1, true)
+	utiltest.TerminateApp(t, ctx, appID, instance5ID)
+	utiltest.ResolveExpectNotFound(t, ctx, "appV3", true)
+
+	// Reverting the app undoes the update.
+	utiltest.RevertApp(t, ctx, appID)
+	if v := utiltest.VerifyState(t, ctx, device.InstallationStateActive, appID); v != v1 {
+		t.Fatalf("Installation version expected to be %v, got %v instead", v1, v)
+	}
+
+	// Start a sixth instance.  It should be running from version 1 again.
+	instance6ID := utiltest.LaunchApp(t, ctx, appID)
+	if v := utiltest.VerifyState(t, ctx, device.InstanceStateRunning, appID, instance6ID); v != v1 {
+		t.Fatalf("Instance version expected to be %v, got %v instead", v1, v)
+	}
+	pingCh.VerifyPingArgs(t, utiltest.UserName(t), "flag-val-install", "env-val-envelope") // Wait until the app pings us that it's ready.
+	utiltest.Resolve(t, ctx, "appV1", 1, true)
+	utiltest.TerminateApp(t, ctx, appID, instance6ID)
+	utiltest.ResolveExpectNotFound(t, ctx, "appV1", true)
+
 	// Uninstall the app.
 	utiltest.UninstallApp(t, ctx, appID)
 	utiltest.VerifyState(t, ctx, device.InstallationStateUninstalled, appID)
 
 	// Updating the installation should no longer be allowed.
 	utiltest.UpdateAppExpectError(t, ctx, appID, errors.ErrInvalidOperation.ID)
+	utiltest.UpdateAppToExpectError(t, ctx, appID, utiltest.MockApplicationRepoName, errors.ErrInvalidOperation.ID)
 
 	// Reverting the installation should no longer be allowed.
 	utiltest.RevertAppExpectError(t, ctx, appID, errors.ErrInvalidOperation.ID)
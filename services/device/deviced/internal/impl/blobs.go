@@ -0,0 +1,151 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package impl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/services/repository"
+	"v.io/v23/verror"
+	"v.io/x/ref/services/device/internal/errors"
+	"v.io/x/ref/services/internal/binarylib"
+	"v.io/x/ref/services/internal/packages"
+)
+
+// blobsDirName is the directory, under the device manager's root, that holds
+// the blob cache.
+const blobsDirName = "blobs"
+
+// blobCache is a content-addressed cache of the binaries and packages
+// downloaded by the device manager, so that the versions and installations
+// that use the same binary share a single copy of it on disk.
+//
+// Blobs are keyed by the checksum of their content reported by the binary
+// repository, and by the permissions of the files that use them (since hard
+// links share their permissions).  Each blob is hard-linked into the version
+// and installation directories that use it, so the link count of a blob is
+// its reference count: blobs whose only link is the one from the cache are
+// no longer used, and are garbage collected by the tidying daemon.
+type blobCache struct {
+	dir string
+	// mu serializes the linking of blobs with their garbage collection, so
+	// that a blob is not removed while it is being linked.
+	mu sync.Mutex
+}
+
+func newBlobCache(root string) *blobCache {
+	return &blobCache{dir: filepath.Join(root, blobsDirName)}
+}
+
+// install makes dst a file with the content of the binary with the given
+// object name, and the given permissions, and returns the media info of the
+// binary.  The binary is only downloaded if it is not already in the cache.  A
+// nil cache always downloads the binary.
+func (c *blobCache) install(ctx *context.T, von, dst string, perm os.FileMode) (repository.MediaInfo, error) {
+	if c == nil {
+		data, mediaInfo, err := binarylib.Download(ctx, von)
+		if err != nil {
+			return repository.MediaInfo{}, verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("Download(%v) failed: %v", von, err))
+		}
+		if err := ioutil.WriteFile(dst, data, perm); err != nil {
+			return repository.MediaInfo{}, verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("WriteFile(%v, %v) failed: %v", dst, perm, err))
+		}
+		return mediaInfo, nil
+	}
+	checksum, err := binarylib.Checksum(ctx, von)
+	if err != nil {
+		return repository.MediaInfo{}, verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("Checksum(%v) failed: %v", von, err))
+	}
+	blob := filepath.Join(c.dir, fmt.Sprintf("%s-%o", checksum, perm))
+	if mediaInfo, cached, err := c.link(blob, dst, perm); cached {
+		return mediaInfo, err
+	}
+	if err := os.MkdirAll(c.dir, os.FileMode(0700)); err != nil {
+		return repository.MediaInfo{}, verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("MkdirAll(%v) failed: %v", c.dir, err))
+	}
+	// DownloadToFile verifies the content of the binary against the
+	// checksums of its parts, and saves its media info once the binary is
+	// in place; the media info thus marks complete blobs.  Concurrent
+	// downloads of the same blob all succeed, with the same content.
+	if err := binarylib.DownloadToFile(ctx, von, blob); err != nil {
+		return repository.MediaInfo{}, verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("DownloadToFile(%v, %v) failed: %v", von, blob, err))
+	}
+	if err := os.Chmod(blob, perm); err != nil {
+		return repository.MediaInfo{}, verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("Chmod(%v, %v) failed: %v", blob, perm, err))
+	}
+	mediaInfo, cached, err := c.link(blob, dst, perm)
+	if !cached {
+		return repository.MediaInfo{}, verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("blob %v vanished after download: %v", blob, err))
+	}
+	return mediaInfo, err
+}
+
+// link links dst to blob if the blob is in the cache, in which case it
+// returns the media info of the blob and cached is true.
+func (c *blobCache) link(blob, dst string, perm os.FileMode) (mediaInfo repository.MediaInfo, cached bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if mediaInfo, err = packages.LoadMediaInfo(blob); err != nil {
+		return repository.MediaInfo{}, false, err
+	}
+	if err := os.Link(blob, dst); err != nil {
+		// Can't create hard link (e.g., different filesystem).
+		data, err := ioutil.ReadFile(blob)
+		if err != nil {
+			return repository.MediaInfo{}, true, verror.New(errors.ErrOperationFailed, nil, fmt.Sprintf("ReadFile(%v) failed: %v", blob, err))
+		}
+		if err := ioutil.WriteFile(dst, data, perm); err != nil {
+			return repository.MediaInfo{}, true, verror.New(errors.ErrOperationFailed, nil, fmt.Sprintf("WriteFile(%v, %v) failed: %v", dst, perm, err))
+		}
+	}
+	return mediaInfo, true, nil
+}
+
+// gc removes the blobs that are no longer linked to.  Blobs downloaded less
+// than about a day ago are kept, since they may not be linked to yet.
+func (c *blobCache) gc(ctx *context.T, now time.Time) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	paths, err := filepath.Glob(filepath.Join(c.dir, "*"))
+	if err != nil {
+		return err
+	}
+	infoSuffix := packages.MediaInfoFile("")
+	allerrors := make([]pthError, 0)
+	for _, pth := range paths {
+		if strings.HasSuffix(pth, infoSuffix) {
+			continue
+		}
+		fi, err := os.Lstat(pth)
+		if err != nil {
+			allerrors = append(allerrors, pthError{pth, err})
+			continue
+		}
+		if st, ok := fi.Sys().(*syscall.Stat_t); !ok || st.Nlink > 1 || !oldEnoughToTidy(fi, now) {
+			continue
+		}
+		// The media info is removed first, so that the blob is no
+		// longer considered to be in the cache if its removal fails.
+		if err := os.Remove(packages.MediaInfoFile(pth)); err != nil && !os.IsNotExist(err) {
+			allerrors = append(allerrors, pthError{pth, err})
+			continue
+		}
+		if err := os.Remove(pth); err != nil {
+			allerrors = append(allerrors, pthError{pth, err})
+		}
+	}
+	return processErrors(ctx, allerrors)
+}
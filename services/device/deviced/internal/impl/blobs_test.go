@@ -0,0 +1,73 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package impl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"v.io/v23/services/repository"
+	"v.io/x/ref/services/internal/packages"
+)
+
+// TestBlobCacheGC verifies that the blobs that are no longer linked to are
+// garbage collected, unless they are recent.
+func TestBlobCacheGC(t *testing.T) {
+	root, err := ioutil.TempDir("", "blobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	c := newBlobCache(root)
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	old := now.Add(-2 * aboutOneDay)
+	addBlob := func(name string, modTime time.Time) string {
+		blob := filepath.Join(c.dir, name)
+		if err := ioutil.WriteFile(blob, []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := packages.SaveMediaInfo(blob, repository.MediaInfo{Type: "application/octet-stream"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(blob, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		return blob
+	}
+	unused, recent, used := addBlob("unused-600", old), addBlob("recent-600", now), addBlob("used-600", old)
+	dst := filepath.Join(root, "bin")
+	if _, cached, err := c.link(used, dst, 0600); !cached || err != nil {
+		t.Fatalf("link(%v, %v) failed: %v, %v", used, dst, cached, err)
+	}
+	if err := c.gc(nil, now); err != nil {
+		t.Fatalf("gc failed: %v", err)
+	}
+	for blob, wantKept := range map[string]bool{unused: false, recent: true, used: true} {
+		for _, f := range []string{blob, packages.MediaInfoFile(blob)} {
+			if _, err := os.Stat(f); (err == nil) != wantKept {
+				t.Errorf("%v: got %v, want kept: %v", f, err, wantKept)
+			}
+		}
+	}
+	if _, cached, _ := c.link(unused, dst+"2", 0600); cached {
+		t.Errorf("collected blob %v is still in the cache", unused)
+	}
+	// Once the last link to a blob is gone, it is collected.
+	if err := os.Remove(dst); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.gc(nil, now); err != nil {
+		t.Fatalf("gc failed: %v", err)
+	}
+	if _, err := os.Stat(used); !os.IsNotExist(err) {
+		t.Errorf("unused blob %v was not collected: %v", used, err)
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package impl
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"v.io/v23/context"
+	"v.io/v23/verror"
+	"v.io/x/ref/services/device/internal/errors"
+)
+
+// The resource limits of an app are specified in its envelope, as environment
+// variables with the following names.  The device manager removes them from
+// the environment of the app, and enforces them by running each instance of
+// the app in its own cgroup (v2).
+const (
+	// LimitCPUEnv is the number of CPUs, e.g. "0.5", that an instance can
+	// use.
+	LimitCPUEnv = "V23_LIMIT_CPU"
+	// LimitMemoryEnv is the memory, in bytes, optionally with a K, M or G
+	// suffix, that an instance can use.  Instances that exceed it are
+	// killed.
+	LimitMemoryEnv = "V23_LIMIT_MEMORY"
+	// LimitPidsEnv is the number of processes and threads that an instance
+	// can have.
+	LimitPidsEnv = "V23_LIMIT_PIDS"
+	// LimitIOWeightEnv is the weight, between 1 and 10000, of the block IO
+	// of an instance, relative to the default weight of 100.
+	LimitIOWeightEnv = "V23_LIMIT_IO_WEIGHT"
+)
+
+// cpuPeriod is the period, in microseconds, over which the CPU limit of an
+// instance is enforced.
+const cpuPeriod = 100000
+
+var (
+	errInvalidLimit     = verror.Register(pkgPath+".errInvalidLimit", verror.NoRetry, "{1:}{2:} invalid resource limit {3}={4}{:_}")
+	errLimitsNotEnabled = verror.Register(pkgPath+".errLimitsNotEnabled", verror.NoRetry, "{1:}{2:} resource limits are specified, but the device manager has no cgroup configured{:_}")
+)
+
+// resourceLimits are the resource limits of an app instance.  Zero values
+// mean no limit.
+type resourceLimits struct {
+	cpu      float64
+	memory   int64
+	pids     int64
+	ioWeight int64
+}
+
+func (l resourceLimits) empty() bool {
+	return l == resourceLimits{}
+}
+
+// parseLimits extracts the resource limits from env, the environment of an
+// app envelope, and returns them along with the rest of env.
+func parseLimits(env []string) (resourceLimits, []string, error) {
+	var (
+		l    resourceLimits
+		rest []string
+		err  error
+	)
+	for _, e := range env {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) != 2 {
+			rest = append(rest, e)
+			continue
+		}
+		k, v := kv[0], kv[1]
+		switch k {
+		case LimitCPUEnv:
+			l.cpu, err = strconv.ParseFloat(v, 64)
+			if err == nil && l.cpu <= 0 {
+				err = fmt.Errorf("must be positive")
+			}
+		case LimitMemoryEnv:
+			l.memory, err = parseBytes(v)
+		case LimitPidsEnv:
+			l.pids, err = strconv.ParseInt(v, 10, 64)
+			if err == nil && l.pids <= 0 {
+				err = fmt.Errorf("must be positive")
+			}
+		case LimitIOWeightEnv:
+			l.ioWeight, err = strconv.ParseInt(v, 10, 64)
+			if err == nil && (l.ioWeight < 1 || l.ioWeight > 10000) {
+				err = fmt.Errorf("must be between 1 and 10000")
+			}
+		default:
+			rest = append(rest, e)
+			continue
+		}
+		if err != nil {
+			return resourceLimits{}, nil, verror.New(errInvalidLimit, nil, k, v, err)
+		}
+	}
+	return l, rest, nil
+}
+
+// parseBytes parses a positive number of bytes, with an optional K, M or G
+// suffix.
+func parseBytes(s string) (int64, error) {
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		multiplier = 1 << 30
+	}
+	if multiplier != 1 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("must be positive")
+	}
+	return n * multiplier, nil
+}
+
+// files returns the contents of the cgroup interface files that enforce l.
+func (l resourceLimits) files() map[string]string {
+	files := make(map[string]string)
+	if l.cpu > 0 {
+		quota := int64(l.cpu * cpuPeriod)
+		if quota < 1000 {
+			quota = 1000
+		}
+		files["cpu.max"] = fmt.Sprintf("%d %d", quota, cpuPeriod)
+	}
+	if l.memory > 0 {
+		files["memory.max"] = strconv.FormatInt(l.memory, 10)
+	}
+	if l.pids > 0 {
+		files["pids.max"] = strconv.FormatInt(l.pids, 10)
+	}
+	if l.ioWeight > 0 {
+		files["io.weight"] = fmt.Sprintf("default %d", l.ioWeight)
+	}
+	return files
+}
+
+// limitEvents maps the names of the instance stats that count the times that
+// the limits of an instance were hit to the cgroup interface files and keys
+// the counts are read from.
+var limitEvents = map[string][2]string{
+	"oom-kills":         {"memory.events", "oom_kill"},
+	"memory-limit-hits": {"memory.events", "max"},
+	"pids-limit-hits":   {"pids.events", "max"},
+	"cpu-throttled":     {"cpu.stat", "nr_throttled"},
+}
+
+// cgroupManager runs app instances in cgroups under a cgroup delegated to the
+// device manager.  A nil cgroupManager can only run instances without
+// resource limits.
+type cgroupManager struct {
+	root  string
+	stats *stats
+}
+
+// newCgroupManager returns a cgroupManager for the cgroup at root, or nil if
+// root is empty.
+func newCgroupManager(ctx *context.T, root string, stats *stats) *cgroupManager {
+	if root == "" {
+		return nil
+	}
+	// Enable the controllers for the cgroups of the instances.  The
+	// controllers that are not available are only reported, since they
+	// are only needed by the instances that use them.
+	for _, c := range []string{"cpu", "memory", "pids", "io"} {
+		if err := writeCgroupFile(root, "cgroup.subtree_control", "+"+c); err != nil {
+			ctx.Errorf("Failed to enable the %v controller in %v: %v", c, root, err)
+		}
+	}
+	return &cgroupManager{root: root, stats: stats}
+}
+
+// path returns the path of the cgroup of the instance.
+func (m *cgroupManager) path(instanceDir string) string {
+	_, appDir, installation, instance := parseInstanceDir(instanceDir)
+	return filepath.Join(m.root, strings.TrimPrefix(appDir, "/")+"-"+installation+"-"+instance)
+}
+
+// cgroupFor returns the cgroup in which the instance with the given limits is
+// run, or "" if the instance has no limits.
+func (m *cgroupManager) cgroupFor(ctx *context.T, instanceDir string, limits resourceLimits) (string, error) {
+	if limits.empty() {
+		return "", nil
+	}
+	if m == nil {
+		return "", verror.New(errLimitsNotEnabled, ctx)
+	}
+	return m.path(instanceDir), nil
+}
+
+// prepare creates the cgroup for the instance, if its envelope has resource
+// limits.  It must be called before the instance is started.  The instance is
+// moved into the cgroup by the suidhelper, which joins it before starting the
+// instance.
+func (m *cgroupManager) prepare(ctx *context.T, instanceDir string) error {
+	envelope, err := loadEnvelopeForInstance(ctx, instanceDir)
+	if err != nil {
+		return err
+	}
+	limits, _, err := parseLimits(envelope.Env)
+	if err != nil {
+		return err
+	}
+	dir, err := m.cgroupFor(ctx, instanceDir, limits)
+	if err != nil || dir == "" {
+		return err
+	}
+	// The cgroup left by the previous run of the instance is removed, so
+	// that limits that no longer apply are dropped.
+	m.release(ctx, instanceDir)
+	if err := os.Mkdir(dir, 0755); err != nil && !os.IsExist(err) {
+		return verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("Mkdir(%v) failed: %v", dir, err))
+	}
+	for file, value := range limits.files() {
+		if err := writeCgroupFile(dir, file, value); err != nil {
+			return verror.New(errors.ErrOperationFailed, ctx, err)
+		}
+	}
+	return nil
+}
+
+// release records the limits hit by the instance in the stats, and removes its
+// cgroup.  It must be called after the instance exits.
+func (m *cgroupManager) release(ctx *context.T, instanceDir string) {
+	if m == nil {
+		return
+	}
+	dir := m.path(instanceDir)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return
+	}
+	counts := make(map[string]int64)
+	for stat, source := range limitEvents {
+		if n, ok := readCgroupKey(dir, source[0], source[1]); ok && n > 0 {
+			counts[stat] = n
+		}
+	}
+	// The counts are only recorded once the cgroup is gone, so that they
+	// are not counted again.  The cgroup can not be removed while some
+	// process of the instance is still in it.
+	if err := os.Remove(dir); err != nil {
+		ctx.Errorf("Remove(%v) failed: %v", dir, err)
+		return
+	}
+	if len(counts) == 0 {
+		return
+	}
+	instanceName, err := instanceNameFromDir(ctx, instanceDir)
+	if err != nil {
+		ctx.Error(err)
+		instanceName = "unknown"
+	}
+	for stat, n := range counts {
+		m.stats.incrLimitEvents(stat, instanceName, n)
+	}
+}
+
+func writeCgroupFile(dir, file, value string) error {
+	path := filepath.Join(dir, file)
+	if err := ioutil.WriteFile(path, []byte(value), 0644); err != nil {
+		return fmt.Errorf("WriteFile(%v, %q) failed: %v", path, value, err)
+	}
+	return nil
+}
+
+// readCgroupKey reads the value of key from a flat keyed cgroup interface
+// file.
+func readCgroupKey(dir, file, key string) (int64, bool) {
+	f, err := os.Open(filepath.Join(dir, file))
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != key {
+			continue
+		}
+		n, err := strconv.ParseInt(fields[1], 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
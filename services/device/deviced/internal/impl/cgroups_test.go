@@ -0,0 +1,93 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package impl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestParseLimits verifies that the resource limits are extracted from the
+// environment of envelopes, and translated to cgroup interface files.
+func TestParseLimits(t *testing.T) {
+	testVectors := []struct {
+		env       []string
+		wantRest  []string
+		wantFiles map[string]string
+		wantErr   bool
+	}{
+		{
+			env:       []string{"A=B", "C"},
+			wantRest:  []string{"A=B", "C"},
+			wantFiles: map[string]string{},
+		},
+		{
+			env:      []string{"A=B", LimitCPUEnv + "=0.5", LimitMemoryEnv + "=64M", LimitPidsEnv + "=100", LimitIOWeightEnv + "=50"},
+			wantRest: []string{"A=B"},
+			wantFiles: map[string]string{
+				"cpu.max":    "50000 100000",
+				"memory.max": "67108864",
+				"pids.max":   "100",
+				"io.weight":  "default 50",
+			},
+		},
+		{
+			env:       []string{LimitCPUEnv + "=0.001"},
+			wantFiles: map[string]string{"cpu.max": "1000 100000"},
+		},
+		{env: []string{LimitCPUEnv + "=-1"}, wantErr: true},
+		{env: []string{LimitMemoryEnv + "=lots"}, wantErr: true},
+		{env: []string{LimitMemoryEnv + "=0G"}, wantErr: true},
+		{env: []string{LimitPidsEnv + "=0"}, wantErr: true},
+		{env: []string{LimitIOWeightEnv + "=10001"}, wantErr: true},
+	}
+	for _, tv := range testVectors {
+		limits, rest, err := parseLimits(tv.env)
+		if tv.wantErr {
+			if err == nil {
+				t.Errorf("parseLimits(%v) should have failed", tv.env)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseLimits(%v) failed: %v", tv.env, err)
+			continue
+		}
+		if !reflect.DeepEqual(rest, tv.wantRest) {
+			t.Errorf("parseLimits(%v): got env %v, want %v", tv.env, rest, tv.wantRest)
+		}
+		if got := limits.files(); !reflect.DeepEqual(got, tv.wantFiles) {
+			t.Errorf("parseLimits(%v): got files %v, want %v", tv.env, got, tv.wantFiles)
+		}
+	}
+}
+
+// TestReadCgroupKey verifies that the limit events are read from flat keyed
+// cgroup interface files.
+func TestReadCgroupKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cgroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	events := "low 0\nhigh 0\nmax 12\noom 3\noom_kill 2\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "memory.events"), []byte(events), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]int64{"max": 12, "oom_kill": 2} {
+		if got, ok := readCgroupKey(dir, "memory.events", key); !ok || got != want {
+			t.Errorf("readCgroupKey(%q): got %v, %v, want %v", key, got, ok, want)
+		}
+	}
+	if _, ok := readCgroupKey(dir, "memory.events", "oom_group_kill"); ok {
+		t.Errorf("readCgroupKey should have failed for a missing key")
+	}
+	if _, ok := readCgroupKey(dir, "pids.events", "max"); ok {
+		t.Errorf("readCgroupKey should have failed for a missing file")
+	}
+}
@@ -424,7 +424,7 @@ func (s *deviceService) updateDeviceManager(ctx *context.T) error {
 			return err
 		}
 	} else {
-		if err := downloadBinary(ctx, envelope.Publisher, &envelope.Binary, workspace, "deviced"); err != nil {
+		if err := downloadBinary(ctx, nil, nil, envelope.Publisher, &envelope.Binary, workspace, "deviced"); err != nil {
 			return err
 		}
 	}
@@ -42,6 +42,8 @@ type internalState struct {
 	runner *appRunner
 	// tidying is the automatic state tidying subsystem.
 	tidying chan<- tidyRequests
+	// blobs is the cache of downloaded binaries and packages.
+	blobs *blobCache
 }
 
 // dispatcher holds the state of the device manager dispatcher.
@@ -96,6 +98,7 @@ func NewDispatcher(ctx *context.T, config *config.State, mtAddress string, testM
 		return nil, nil, verror.New(errCantCreateAccountStore, ctx, err)
 	}
 	InitSuidHelper(ctx, config.Helper)
+	blobs := newBlobCache(config.Root)
 	d := &dispatcher{
 		internal: &internalState{
 			callback:       newCallbackState(config.Name),
@@ -103,7 +106,8 @@ func NewDispatcher(ctx *context.T, config *config.State, mtAddress string, testM
 			restartHandler: restartHandler,
 			stats:          newStats("device-manager"),
 			testMode:       testMode,
-			tidying:        newTidyingDaemon(ctx, config.Root),
+			tidying:        newTidyingDaemon(ctx, config.Root, blobs),
+			blobs:          blobs,
 			principalMgr:   newPrincipalManager(),
 		},
 		config:     config,
@@ -117,7 +121,9 @@ func NewDispatcher(ctx *context.T, config *config.State, mtAddress string, testM
 		appServiceName: naming.Join(d.config.Name, appsSuffix),
 		mtAddress:      d.mtAddress,
 		stats:          d.internal.stats,
+		cgroups:        newCgroupManager(ctx, config.Cgroup, d.internal.stats),
 	}
+	runner.health = newHealthChecker(ctx, runner)
 	d.internal.runner = runner
 	reap, err := newReaper(ctx, config.Root, runner)
 	if err != nil {
@@ -295,6 +301,7 @@ func (d *dispatcher) internalLookup(suffix string) (interface{}, security.Author
 			permsStore: d.permsStore,
 			runner:     d.internal.runner,
 			stats:      d.internal.stats,
+			blobs:      d.internal.blobs,
 		})
 		appSpecificAuthorizer, err := newAppSpecificAuthorizer(auth, d.config, components[1:], d.permsStore)
 		if err != nil {
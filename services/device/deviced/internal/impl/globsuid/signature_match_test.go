@@ -17,6 +17,7 @@ import (
 	"v.io/v23/services/device"
 	"v.io/v23/services/repository"
 	"v.io/v23/verror"
+	"v.io/x/ref/lib/mgmt"
 	"v.io/x/ref/services/device/deviced/internal/impl/utiltest"
 	"v.io/x/ref/services/device/deviced/internal/versioning"
 	"v.io/x/ref/services/device/internal/errors"
@@ -141,6 +142,39 @@ func TestDownloadSignatureMatch(t *testing.T) {
 		t.Fatalf("Failed to Install app:%v", err)
 	}
 
+	// Verify that the installation's signature policy is enforced.
+	publisherNames := security.BlessingNames(p, envelope.Publisher)
+	if len(publisherNames) == 0 {
+		t.Fatalf("publisher %v has no names", envelope.Publisher)
+	}
+	signedPkgs := application.Packages{"extra": application.SignedFile{File: pkgVON, Signature: *pkgSig}}
+	unsignedPkgs := application.Packages{"extra": application.SignedFile{File: pkgVON}}
+	for _, c := range []struct {
+		config  device.Config
+		pkgs    application.Packages
+		wantErr bool
+	}{
+		{device.Config{mgmt.AppTrustedPublishersConfigKey: "someone:else"}, nil, true},
+		{device.Config{mgmt.AppTrustedPublishersConfigKey: "someone:else," + publisherNames[0]}, nil, false},
+		{device.Config{mgmt.AppSignaturePolicyConfigKey: "paranoid"}, nil, true},
+		{device.Config{mgmt.AppSignaturePolicyConfigKey: mgmt.LaxSignaturePolicy}, unsignedPkgs, false},
+		{device.Config{mgmt.AppSignaturePolicyConfigKey: mgmt.StrictSignaturePolicy}, unsignedPkgs, true},
+		{device.Config{mgmt.AppSignaturePolicyConfigKey: mgmt.StrictSignaturePolicy}, signedPkgs, false},
+	} {
+		_, err := utiltest.AppStub().Install(ctx, utiltest.MockApplicationRepoName, c.config, c.pkgs)
+		if got := err != nil; got != c.wantErr {
+			t.Errorf("Install with config %v and packages %v: got error %v, want error: %v", c.config, c.pkgs, err, c.wantErr)
+		}
+	}
+
+	// Under a strict policy, envelopes without a publisher are refused.
+	publisher = envelope.Publisher
+	envelope.Publisher = security.Blessings{}
+	if _, err := utiltest.AppStub().Install(ctx, utiltest.MockApplicationRepoName, device.Config{mgmt.AppSignaturePolicyConfigKey: mgmt.StrictSignaturePolicy}, nil); err == nil {
+		t.Errorf("Install of an envelope without publisher under a strict policy should have failed")
+	}
+	envelope.Publisher = publisher
+
 	// Verify that when the package contents are corrupted, signature verification fails.
 	pkgContents[0] = pkgContents[0] ^ 0xFF
 	if err := binarylib.Delete(ctx, pkgVON); err != nil {
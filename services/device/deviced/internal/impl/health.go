@@ -0,0 +1,357 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package impl
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"v.io/v23"
+	"v.io/v23/context"
+	"v.io/v23/naming"
+	"v.io/v23/services/device"
+	libstats "v.io/v23/services/stats"
+	"v.io/v23/verror"
+	"v.io/x/ref"
+)
+
+// The health probes of an app are specified in its envelope, as environment
+// variables with the following names.  The device manager removes them from
+// the environment of the app.  A probe is one of:
+//
+//   rpc:<name>.<method>
+//     calls method, which must take no arguments and return no results, on
+//     the object with the given name; relative names are resolved in the
+//     device's mounttable.
+//   stats:<name>[=<value>]
+//     reads the stat with the given name from the instance's __debug/stats,
+//     and compares its value to value if specified.
+//   exec:<command>
+//     runs command with the shell, as the system user of the instance and in
+//     its workspace; it must exit with status 0.
+const (
+	// LivenessProbeEnv is the probe that checks that an instance is not
+	// hung.  Instances that fail it repeatedly are killed, and restarted
+	// according to their restart policy.
+	LivenessProbeEnv = "V23_LIVENESS_PROBE"
+	// ReadinessProbeEnv is the probe that checks that an instance is ready
+	// to serve.
+	ReadinessProbeEnv = "V23_READINESS_PROBE"
+)
+
+const (
+	// probePeriod is the time between two runs of the probes of an instance.
+	probePeriod = 10 * time.Second
+	// probeTimeout is the time after which a probe is considered failed.
+	probeTimeout = 5 * time.Second
+	// livenessFailureThreshold is the number of consecutive liveness probe
+	// failures after which an instance is killed.
+	livenessFailureThreshold = 3
+)
+
+var (
+	errInvalidProbe = verror.Register(pkgPath+".errInvalidProbe", verror.NoRetry, "{1:}{2:} invalid health probe {3}={4}{:_}")
+	errProbeFailed  = verror.Register(pkgPath+".errProbeFailed", verror.NoRetry, "{1:}{2:} health probe {3} failed{:_}")
+)
+
+// probe is a health probe of an app instance.
+type probe struct {
+	kind, arg string
+	// value is the expected value of the stat of a stats probe, if any.
+	value string
+}
+
+func (p *probe) String() string {
+	s := p.kind + ":" + p.arg
+	if p.value != "" {
+		s += "=" + p.value
+	}
+	return s
+}
+
+func parseProbe(spec string) (*probe, error) {
+	kv := strings.SplitN(spec, ":", 2)
+	if len(kv) != 2 || kv[1] == "" {
+		return nil, fmt.Errorf("must be of the form <kind>:<argument>")
+	}
+	p := &probe{kind: kv[0], arg: kv[1]}
+	switch p.kind {
+	case "rpc":
+		if dot := strings.LastIndex(p.arg, "."); dot < 0 || dot == len(p.arg)-1 {
+			return nil, fmt.Errorf("rpc probes must be of the form rpc:<name>.<method>")
+		}
+	case "stats":
+		if eq := strings.Index(p.arg, "="); eq >= 0 {
+			p.arg, p.value = p.arg[:eq], p.arg[eq+1:]
+		}
+	case "exec":
+	default:
+		return nil, fmt.Errorf("unknown kind of probe %q", p.kind)
+	}
+	return p, nil
+}
+
+// parseProbes extracts the liveness and readiness probes, if any, from env,
+// the environment of an app envelope, and returns them along with the rest
+// of env.
+func parseProbes(env []string) (liveness, readiness *probe, rest []string, err error) {
+	for _, e := range env {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) != 2 || (kv[0] != LivenessProbeEnv && kv[0] != ReadinessProbeEnv) {
+			rest = append(rest, e)
+			continue
+		}
+		p, err := parseProbe(kv[1])
+		if err != nil {
+			return nil, nil, nil, verror.New(errInvalidProbe, nil, kv[0], kv[1], err)
+		}
+		if kv[0] == LivenessProbeEnv {
+			liveness = p
+		} else {
+			readiness = p
+		}
+	}
+	return liveness, readiness, rest, nil
+}
+
+// check runs the probe against the instance, and returns nil iff it succeeds.
+func (p *probe) check(ctx *context.T, runner *appRunner, instanceDir string) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	var err error
+	switch p.kind {
+	case "rpc":
+		dot := strings.LastIndex(p.arg, ".")
+		name, method := p.arg[:dot], p.arg[dot+1:]
+		if !naming.Rooted(name) {
+			name = naming.Join(runner.mtAddress, name)
+		}
+		err = v23.GetClient(ctx).Call(ctx, name, method, nil, nil)
+	case "stats":
+		err = checkStat(ctx, instanceDir, p.arg, p.value)
+	case "exec":
+		err = runProbeCmd(ctx, instanceDir, runner.mtAddress, p.arg)
+	}
+	if err != nil {
+		return verror.New(errProbeFailed, ctx, p, err)
+	}
+	return nil
+}
+
+func checkStat(ctx *context.T, instanceDir, stat, want string) error {
+	info, err := loadInstanceInfo(ctx, instanceDir)
+	if err != nil {
+		return err
+	}
+	name := naming.JoinAddressName(info.AppCycleMgrName, naming.Join("__debug", "stats", stat))
+	v, err := libstats.StatsClient(name).Value(ctx)
+	if err != nil || want == "" {
+		return err
+	}
+	var value interface{}
+	if err := v.ToValue(&value); err != nil {
+		return err
+	}
+	if got := fmt.Sprint(value); got != want {
+		return fmt.Errorf("stat %v is %v, want %v", stat, got, want)
+	}
+	return nil
+}
+
+// runProbeCmd runs command with the shell, through the suidhelper, as the
+// system user of the instance.
+func runProbeCmd(ctx *context.T, instanceDir, nsRoot, command string) error {
+	systemName, err := readSystemNameForInstance(instanceDir)
+	if err != nil {
+		return err
+	}
+	rootDir := filepath.Join(instanceDir, "root")
+	cmd, err := suidHelper.getAppCmd(ctx, &suidAppCmdArgs{
+		targetUser: systemName,
+		progname:   "probe",
+		binpath:    ShellPath,
+		workspace:  rootDir,
+		logdir:     filepath.Join(instanceDir, "logs"),
+		dir:        rootDir,
+		env:        []string{ref.EnvNamespacePrefix + "=" + nsRoot},
+		appArgs:    []string{"-c", command},
+		wait:       true,
+	})
+	if err != nil {
+		return err
+	}
+	return waitProbeCmd(ctx, cmd, command)
+}
+
+// waitProbeCmd starts cmd, and waits for it to exit.  If ctx is done first,
+// cmd is terminated: the suidhelper then kills the process group of the
+// probe, and reaps it, before exiting.
+func waitProbeCmd(ctx *context.T, cmd *exec.Cmd, command string) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		if _, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("%q exited with %v", command, err)
+		}
+		return err
+	case <-ctx.Done():
+		if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+			ctx.Errorf("Signal(%v) to the probe %q failed: %v", syscall.SIGTERM, command, err)
+		}
+		<-done
+		return fmt.Errorf("%q timed out", command)
+	}
+}
+
+// healthChecker runs the health probes of the running instances.
+type healthChecker struct {
+	// ctx is used for the probes, which outlive the requests that start
+	// the instances.
+	ctx    *context.T
+	runner *appRunner
+	// period is the time between two runs of the probes of an instance.
+	period time.Duration
+	mu     sync.Mutex
+	// The instances being probed, by instance dir.
+	probing map[string]*probedInstance // GUARDED_BY(mu)
+}
+
+type probedInstance struct {
+	name string
+	// stop is closed to stop the probing of the instance.
+	stop chan struct{}
+	// hasReadiness is true iff the instance has a readiness probe, in
+	// which case ready is its readiness.
+	hasReadiness, ready bool
+}
+
+func newHealthChecker(ctx *context.T, runner *appRunner) *healthChecker {
+	return &healthChecker{
+		ctx:     ctx,
+		runner:  runner,
+		period:  probePeriod,
+		probing: make(map[string]*probedInstance),
+	}
+}
+
+// start starts probing the instance, if its envelope has health probes.
+func (h *healthChecker) start(instanceDir string) {
+	envelope, err := loadEnvelopeForInstance(h.ctx, instanceDir)
+	if err != nil {
+		h.ctx.Error(err)
+		return
+	}
+	liveness, readiness, _, err := parseProbes(envelope.Env)
+	if err != nil {
+		h.ctx.Error(err)
+		return
+	}
+	if liveness == nil && readiness == nil {
+		return
+	}
+	instanceName, err := instanceNameFromDir(h.ctx, instanceDir)
+	if err != nil {
+		h.ctx.Error(err)
+		return
+	}
+	h.stop(instanceDir)
+	instance := &probedInstance{
+		name:         instanceName,
+		stop:         make(chan struct{}),
+		hasReadiness: readiness != nil,
+	}
+	h.mu.Lock()
+	h.probing[instanceDir] = instance
+	if instance.hasReadiness {
+		h.runner.stats.setReady(instanceName, false)
+	}
+	h.mu.Unlock()
+	go h.probe(instanceDir, instance, liveness, readiness)
+}
+
+// stop stops probing the instance.
+func (h *healthChecker) stop(instanceDir string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	instance, ok := h.probing[instanceDir]
+	if !ok {
+		return
+	}
+	close(instance.stop)
+	delete(h.probing, instanceDir)
+	if instance.hasReadiness {
+		h.runner.stats.setReady(instance.name, false)
+	}
+}
+
+// readiness returns a description of the readiness of the instance.
+func (h *healthChecker) readiness(instanceDir string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	instance, ok := h.probing[instanceDir]
+	switch {
+	case !ok || !instance.hasReadiness:
+		return "unknown"
+	case instance.ready:
+		return "ready"
+	default:
+		return "not ready"
+	}
+}
+
+func (h *healthChecker) probe(instanceDir string, instance *probedInstance, liveness, readiness *probe) {
+	ctx := h.ctx
+	ticker := time.NewTicker(h.period)
+	defer ticker.Stop()
+	failures := 0
+	for {
+		select {
+		case <-instance.stop:
+			return
+		case <-ticker.C:
+		}
+		if !instanceStateIs(instanceDir, device.InstanceStateRunning) {
+			continue
+		}
+		if readiness != nil {
+			err := readiness.check(ctx, h.runner, instanceDir)
+			if err != nil {
+				ctx.VI(1).Infof("%v is not ready: %v", instance.name, err)
+			}
+			h.mu.Lock()
+			// The probing may have been stopped during the check.
+			if h.probing[instanceDir] == instance {
+				instance.ready = err == nil
+				h.runner.stats.setReady(instance.name, instance.ready)
+			}
+			h.mu.Unlock()
+		}
+		if liveness == nil {
+			continue
+		}
+		if err := liveness.check(ctx, h.runner, instanceDir); err != nil {
+			failures++
+			ctx.Infof("%v failed %d consecutive liveness probes: %v", instance.name, failures, err)
+		} else {
+			failures = 0
+		}
+		if failures >= livenessFailureThreshold {
+			// The reaper notices the death of the instance, and
+			// restarts it according to its restart policy.
+			ctx.Errorf("Killing %v after %d failed liveness probes", instance.name, failures)
+			h.runner.reap.forciblySuspend(instanceDir)
+			failures = 0
+		}
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package impl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"v.io/v23"
+	"v.io/v23/context"
+	"v.io/v23/naming"
+	"v.io/v23/rpc"
+	"v.io/v23/services/device"
+	libstats "v.io/x/ref/lib/stats"
+	"v.io/x/ref/test"
+)
+
+// TestParseProbes verifies that the health probes are extracted from the
+// environment of envelopes.
+func TestParseProbes(t *testing.T) {
+	testVectors := []struct {
+		env                 []string
+		liveness, readiness *probe
+		rest                []string
+		wantErr             bool
+	}{
+		{
+			env:  []string{"A=B"},
+			rest: []string{"A=B"},
+		},
+		{
+			env:       []string{LivenessProbeEnv + "=rpc:app/health.Ping", "A=B", ReadinessProbeEnv + "=stats:app/ready=true"},
+			liveness:  &probe{kind: "rpc", arg: "app/health.Ping"},
+			readiness: &probe{kind: "stats", arg: "app/ready", value: "true"},
+			rest:      []string{"A=B"},
+		},
+		{
+			env:       []string{ReadinessProbeEnv + "=exec:test -f ready"},
+			readiness: &probe{kind: "exec", arg: "test -f ready"},
+		},
+		{env: []string{LivenessProbeEnv + "=rpc:app/health"}, wantErr: true},
+		{env: []string{LivenessProbeEnv + "=http://localhost/health"}, wantErr: true},
+		{env: []string{ReadinessProbeEnv + "=exec:"}, wantErr: true},
+	}
+	for _, tv := range testVectors {
+		liveness, readiness, rest, err := parseProbes(tv.env)
+		if tv.wantErr {
+			if err == nil {
+				t.Errorf("parseProbes(%v) should have failed", tv.env)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseProbes(%v) failed: %v", tv.env, err)
+			continue
+		}
+		if !reflect.DeepEqual(liveness, tv.liveness) || !reflect.DeepEqual(readiness, tv.readiness) {
+			t.Errorf("parseProbes(%v): got probes %v, %v, want %v, %v", tv.env, liveness, readiness, tv.liveness, tv.readiness)
+		}
+		if !reflect.DeepEqual(rest, tv.rest) {
+			t.Errorf("parseProbes(%v): got env %v, want %v", tv.env, rest, tv.rest)
+		}
+	}
+}
+
+// TestWaitProbeCmd verifies that the commands of exec probes must succeed
+// in time, and are reaped when they do not.
+func TestWaitProbeCmd(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+
+	if err := waitProbeCmd(ctx, exec.Command(ShellPath, "-c", "exit 0"), "exit 0"); err != nil {
+		t.Errorf("waitProbeCmd(exit 0) failed: %v", err)
+	}
+	if err := waitProbeCmd(ctx, exec.Command(ShellPath, "-c", "exit 1"), "exit 1"); err == nil {
+		t.Errorf("waitProbeCmd(exit 1) should have failed")
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	cmd := exec.Command(ShellPath, "-c", "sleep 60")
+	start := time.Now()
+	if err := waitProbeCmd(timeoutCtx, cmd, "sleep 60"); err == nil {
+		t.Errorf("waitProbeCmd(sleep 60) should have timed out")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("waitProbeCmd(sleep 60) returned after %v", elapsed)
+	}
+	if cmd.ProcessState == nil {
+		t.Errorf("the timed out probe was not reaped")
+	}
+}
+
+// healthServer serves the methods called by rpc probes.
+type healthServer struct {
+	sync.Mutex
+	live, ready bool
+	// failures is the number of failed calls to Live.
+	failures int
+}
+
+func (s *healthServer) Live(*context.T, rpc.ServerCall) error {
+	s.Lock()
+	defer s.Unlock()
+	if !s.live {
+		s.failures++
+		return fmt.Errorf("not live")
+	}
+	return nil
+}
+
+func (s *healthServer) Ready(*context.T, rpc.ServerCall) error {
+	s.Lock()
+	defer s.Unlock()
+	if !s.ready {
+		return fmt.Errorf("not ready")
+	}
+	return nil
+}
+
+func (s *healthServer) set(live, ready bool) {
+	s.Lock()
+	defer s.Unlock()
+	s.live, s.ready = live, ready
+}
+
+func (s *healthServer) liveFailures() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.failures
+}
+
+// TestHealthChecker verifies that the readiness of instances is reported,
+// and that instances failing their liveness probe are killed, and probed
+// again once restarted by the reaper.
+func TestHealthChecker(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+
+	server := &healthServer{live: true}
+	ctx, s, err := v23.WithNewServer(ctx, "", server, nil)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	name := s.Status().Endpoints[0].Name()
+	liveness, err := parseProbe("rpc:" + name + ".Live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	readiness, err := parseProbe("rpc:" + name + ".Ready")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instanceDir, err := ioutil.TempDir("", "health_test")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir() failed: %v", err)
+	}
+	defer os.RemoveAll(instanceDir)
+	if err := initializeInstance(instanceDir, device.InstanceStateRunning); err != nil {
+		t.Fatal(err)
+	}
+
+	reap := &reaper{c: make(chan pidInstanceDirPair), stopped: make(chan struct{})}
+	defer close(reap.stopped)
+	runner := &appRunner{reap: reap, stats: newStats("health_test")}
+	h := newHealthChecker(ctx, runner)
+	h.period = 10 * time.Millisecond
+	instance := &probedInstance{name: "app/instance", stop: make(chan struct{}), hasReadiness: true}
+	h.probing[instanceDir] = instance
+	go h.probe(instanceDir, instance, liveness, readiness)
+	defer h.stop(instanceDir)
+
+	readyStat := naming.Join("health_test", "ready", "app/instance")
+	waitForReadiness := func(want string, wantStat int64) {
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			got := h.readiness(instanceDir)
+			stat, err := libstats.Value(readyStat)
+			if got == want && err == nil && stat == wantStat {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("got readiness %v and stat %v (%v), want %v and %v", got, stat, err, want, wantStat)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitForKill := func() {
+		select {
+		case cmd := <-reap.c:
+			if cmd.instanceDir != instanceDir || cmd.pid != -2 {
+				t.Fatalf("got reaper command %+v, want forciblySuspend(%v)", cmd, instanceDir)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("instance not killed")
+		}
+	}
+
+	waitForReadiness("not ready", 0)
+	server.set(true, true)
+	waitForReadiness("ready", 1)
+	server.set(true, false)
+	waitForReadiness("not ready", 0)
+
+	// The instance is killed once it fails livenessFailureThreshold
+	// consecutive liveness probes.
+	server.set(false, false)
+	waitForKill()
+	if got := server.liveFailures(); got < livenessFailureThreshold {
+		t.Errorf("instance killed after %d failed probes, want at least %d", got, livenessFailureThreshold)
+	}
+	// The restarted instance is probed again, and killed again once it
+	// fails as many probes.
+	waitForKill()
+	if got := server.liveFailures(); got < 2*livenessFailureThreshold {
+		t.Errorf("instance killed again after %d failed probes, want at least %d", got, 2*livenessFailureThreshold)
+	}
+}
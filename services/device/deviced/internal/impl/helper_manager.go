@@ -82,7 +82,9 @@ func (s suidHelperState) chownTree(ctx *context.T, username string, dirOrFile st
 
 type suidAppCmdArgs struct {
 	// args to helper
-	targetUser, progname, workspace, logdir, binpath, sockPath string
+	targetUser, progname, workspace, logdir, binpath, sockPath, cgroup string
+	// wait for the app to exit, and exit with its status
+	wait bool
 	// fields in exec.Cmd
 	env            []string
 	stdout, stderr io.Writer
@@ -114,6 +116,12 @@ func (s suidHelperState) getAppCmd(ctx *context.T, a *suidAppCmdArgs) (*exec.Cmd
 	if a.sockPath != "" {
 		cmd.Args = append(cmd.Args, "--agentsock", a.sockPath)
 	}
+	if a.cgroup != "" {
+		cmd.Args = append(cmd.Args, "--cgroup", a.cgroup)
+	}
+	if a.wait {
+		cmd.Args = append(cmd.Args, "--wait")
+	}
 
 	cmd.Args = append(cmd.Args, "--run", a.binpath)
 	cmd.Args = append(cmd.Args, "--")
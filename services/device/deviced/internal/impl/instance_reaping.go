@@ -52,6 +52,8 @@ func newReaper(ctx *context.T, root string, appRunner *appRunner) (*reaper, erro
 }
 
 func markNotRunning(ctx *context.T, runner *appRunner, idir string) error {
+	runner.health.stop(idir)
+	runner.cgroups.release(ctx, idir)
 	if err := runner.principalMgr.StopServing(idir); err != nil {
 		return fmt.Errorf("StopServing(%v) failed: %v", idir, err)
 	}
@@ -10,12 +10,22 @@ import (
 	"v.io/v23/services/application"
 )
 
+const (
+	// restartBackoffBase is the delay before the second consecutive
+	// restart of an instance.  The first restart is immediate, and the
+	// delay doubles with every subsequent restart.
+	restartBackoffBase = 500 * time.Millisecond
+	// restartBackoffMax is the maximum delay before a restart.
+	restartBackoffMax = 5 * time.Minute
+)
+
 // RestartPolicy instances provide a policy for deciding if an
 // application should be restarted on failure.
 type restartPolicy interface {
 	// decide determines if this application instance should be (re)started, returning
-	// true if the application should be be (re)started.
-	decide(envelope *application.Envelope, instance *instanceInfo) bool
+	// true if the application should be be (re)started, and the delay after which
+	// to (re)start it.
+	decide(envelope *application.Envelope, instance *instanceInfo) (bool, time.Duration)
 }
 
 type basicDecisionPolicy struct {
@@ -25,31 +35,41 @@ func newBasicRestartPolicy() restartPolicy {
 	return new(basicDecisionPolicy)
 }
 
-func (rp *basicDecisionPolicy) decide(envelope *application.Envelope, instance *instanceInfo) bool {
+// decide restarts an instance at most envelope.Restarts consecutive times (or
+// without limit if negative), with an exponentially increasing delay.  The
+// restarts are consecutive unless the instance ran for longer than
+// envelope.RestartTimeWindow since its last restart, in which case the count
+// and the delay are reset.  A zero RestartTimeWindow never resets them.
+func (rp *basicDecisionPolicy) decide(envelope *application.Envelope, instance *instanceInfo) (bool, time.Duration) {
 	if envelope.Restarts == 0 {
-		return false
+		return false, 0
 	}
-	if envelope.Restarts < 0 {
-		return true
+	now := time.Now()
+	if instance.Restarts > 0 && envelope.RestartTimeWindow > 0 && now.Sub(instance.RestartWindowBegan) > envelope.RestartTimeWindow {
+		instance.Restarts = 0
 	}
-
-	if instance.Restarts == 0 {
-		instance.RestartWindowBegan = time.Now()
+	if envelope.Restarts > 0 && instance.Restarts >= envelope.Restarts {
+		return false, 0
 	}
+	delay := restartBackoff(instance.Restarts)
+	instance.Restarts++
+	// RestartWindowBegan records the time of the last restart.
+	instance.RestartWindowBegan = now.Add(delay)
+	return true, delay
+}
 
-	endOfWindow := instance.RestartWindowBegan.Add(envelope.RestartTimeWindow)
-	if time.Now().After(endOfWindow) {
-		instance.Restarts = 1
-		instance.RestartWindowBegan = time.Now()
-		return true
+// restartBackoff returns the delay before a restart that follows the given
+// number of consecutive restarts.
+func restartBackoff(restarts int32) time.Duration {
+	if restarts == 0 {
+		return 0
 	}
-
-	if instance.Restarts < envelope.Restarts {
-		instance.Restarts++
-		instance.RestartWindowBegan = time.Now()
-		return true
+	delay := restartBackoffBase
+	for i := int32(1); i < restarts && delay < restartBackoffMax; i++ {
+		delay *= 2
 	}
-
-	return false
-
+	if delay > restartBackoffMax {
+		delay = restartBackoffMax
+	}
+	return delay
 }
@@ -5,10 +5,15 @@
 package impl
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"v.io/v23/services/application"
+	"v.io/v23/services/device"
+	"v.io/x/ref/test"
 )
 
 // TestRestartPolicy verifies that the daemon mode restart policy operates
@@ -21,6 +26,7 @@ func TestRestartPolicy(t *testing.T) {
 		info     *instanceInfo
 		wantInfo *instanceInfo
 		decision bool
+		delay    time.Duration
 	}
 
 	testNow := time.Now()
@@ -35,9 +41,11 @@ func TestRestartPolicy(t *testing.T) {
 				Restarts: 0,
 			},
 			&instanceInfo{
-				Restarts: 0,
+				Restarts:           1,
+				RestartWindowBegan: time.Now(),
 			},
 			true,
+			0,
 		},
 		// 0 means restart exactly 0 times.
 		{
@@ -51,6 +59,7 @@ func TestRestartPolicy(t *testing.T) {
 				Restarts: 0,
 			},
 			false,
+			0,
 		},
 		// 1 means restart once (2 invocations total)
 		{
@@ -66,6 +75,7 @@ func TestRestartPolicy(t *testing.T) {
 				RestartWindowBegan: time.Now(),
 			},
 			true,
+			0,
 		},
 		// but only ever once.
 		{
@@ -82,6 +92,7 @@ func TestRestartPolicy(t *testing.T) {
 				RestartWindowBegan: testNow,
 			},
 			false,
+			0,
 		},
 		// after time window, restart count is reset.
 		{
@@ -98,9 +109,10 @@ func TestRestartPolicy(t *testing.T) {
 				RestartWindowBegan: time.Now(),
 			},
 			true,
+			0,
 		},
-		// Every time a restart happens, the beginning of the window
-		// should be reset.
+		// Consecutive restarts are delayed, and the time of the last
+		// restart is recorded.
 		{
 			&application.Envelope{
 				Restarts:          2,
@@ -112,16 +124,54 @@ func TestRestartPolicy(t *testing.T) {
 			},
 			&instanceInfo{
 				Restarts:           2,
+				RestartWindowBegan: time.Now().Add(restartBackoffBase),
+			},
+			true,
+			restartBackoffBase,
+		},
+		// The delay doubles with every consecutive restart, and a zero
+		// window never resets the restart count.
+		{
+			&application.Envelope{
+				Restarts: -1,
+			},
+			&instanceInfo{
+				Restarts:           5,
+				RestartWindowBegan: time.Now().Add(-time.Hour),
+			},
+			&instanceInfo{
+				Restarts:           6,
+				RestartWindowBegan: time.Now().Add(16 * restartBackoffBase),
+			},
+			true,
+			16 * restartBackoffBase,
+		},
+		// The delay is capped.
+		{
+			&application.Envelope{
+				Restarts: -1,
+			},
+			&instanceInfo{
+				Restarts:           100,
 				RestartWindowBegan: time.Now(),
 			},
+			&instanceInfo{
+				Restarts:           101,
+				RestartWindowBegan: time.Now().Add(restartBackoffMax),
+			},
 			true,
+			restartBackoffMax,
 		},
 	}
 
 	for ti, tv := range testVectors {
-		if got, want := nbr.decide(tv.envelope, tv.info), tv.decision; got != want {
+		decision, delay := nbr.decide(tv.envelope, tv.info)
+		if got, want := decision, tv.decision; got != want {
 			t.Errorf("Test case #%d: basicDecisionPolicy decide: got %v, want %v", ti, got, want)
 		}
+		if got, want := delay, tv.delay; got != want {
+			t.Errorf("Test case #%d: basicDecisionPolicy delay: got %v, want %v", ti, got, want)
+		}
 
 		if got, want := tv.info.Restarts, tv.wantInfo.Restarts; got != want {
 			t.Errorf("basicDecisionPolicy instanceInfo Restarts update got %v, want %v", got, want)
@@ -133,3 +183,62 @@ func TestRestartPolicy(t *testing.T) {
 		}
 	}
 }
+
+// TestRestartAbandoned verifies that a restart backing off is abandoned if the
+// instance is run, killed or deleted in the meantime.
+func TestRestartAbandoned(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+
+	root, err := ioutil.TempDir("", "restart_test")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir() failed: %v", err)
+	}
+	defer os.RemoveAll(root)
+	instanceDir, versionDir := filepath.Join(root, "instance"), filepath.Join(root, "version")
+	for _, dir := range []string{instanceDir, versionDir} {
+		if err := os.Mkdir(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := saveEnvelope(ctx, versionDir, &application.Envelope{Restarts: -1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(versionDir, filepath.Join(instanceDir, "version")); err != nil {
+		t.Fatal(err)
+	}
+	// The instance already restarted once, so the next restart backs off.
+	if err := saveInstanceInfo(ctx, instanceDir, &instanceInfo{Restarts: 1, RestartWindowBegan: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := initializeInstance(instanceDir, device.InstanceStateNotRunning); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := &appRunner{stats: newStats("restart_test")}
+	done := make(chan struct{})
+	go func() {
+		runner.restartAppIfNecessary(ctx, instanceDir)
+		close(done)
+	}()
+	// Wait for the restart to be decided, and then kill the instance.
+	for {
+		info, err := loadInstanceInfo(ctx, instanceDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Restarts == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	runner.generations.bump(instanceDir)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("restart not abandoned")
+	}
+	if got := len(runner.stats.restartsPerInstance); got != 0 {
+		t.Errorf("instance restarted after it was killed")
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package impl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"v.io/v23/context"
+	"v.io/v23/security"
+	"v.io/v23/services/application"
+	"v.io/v23/services/device"
+	"v.io/v23/verror"
+	"v.io/x/ref/lib/mgmt"
+	"v.io/x/ref/services/device/internal/errors"
+)
+
+var (
+	errInvalidSignaturePolicy = verror.Register(pkgPath+".errInvalidSignaturePolicy", verror.NoRetry, "{1:}{2:} invalid signature policy {3}={4}{:_}")
+	errUntrustedPublisher     = verror.Register(pkgPath+".errUntrustedPublisher", verror.NoRetry, "{1:}{2:} publisher {3} of {4} is not trusted by the installation{:_}")
+	errUnsigned               = verror.Register(pkgPath+".errUnsigned", verror.NoRetry, "{1:}{2:} {3} is not signed by a publisher{:_}")
+)
+
+// signaturePolicy determines which publishers an installation trusts, and
+// whether it accepts binaries and packages that are not signed by them.  It is
+// set from the config given to Install, and applies to all the versions of the
+// installation.
+type signaturePolicy struct {
+	// Strict policies refuse the envelopes without a trusted publisher, and
+	// the binaries and packages that are not signed by it.
+	Strict bool
+	// TrustedPublishers are the patterns that the blessings of the
+	// publisher must match, if any.
+	TrustedPublishers []security.BlessingPattern
+}
+
+// signaturePolicyFromConfig extracts the signature policy from the config
+// given to Install, and removes its keys from config.
+func signaturePolicyFromConfig(config device.Config) (*signaturePolicy, error) {
+	p := new(signaturePolicy)
+	if v, ok := config[mgmt.AppSignaturePolicyConfigKey]; ok {
+		delete(config, mgmt.AppSignaturePolicyConfigKey)
+		switch v {
+		case mgmt.StrictSignaturePolicy:
+			p.Strict = true
+		case mgmt.LaxSignaturePolicy:
+		default:
+			return nil, verror.New(errInvalidSignaturePolicy, nil, mgmt.AppSignaturePolicyConfigKey, v)
+		}
+	}
+	if v, ok := config[mgmt.AppTrustedPublishersConfigKey]; ok {
+		delete(config, mgmt.AppTrustedPublishersConfigKey)
+		for _, s := range strings.Split(v, ",") {
+			pattern := security.BlessingPattern(strings.TrimSpace(s))
+			if !pattern.IsValid() {
+				return nil, verror.New(errInvalidSignaturePolicy, nil, mgmt.AppTrustedPublishersConfigKey, v)
+			}
+			p.TrustedPublishers = append(p.TrustedPublishers, pattern)
+		}
+	}
+	return p, nil
+}
+
+func saveSignaturePolicy(ctx *context.T, dir string, p *signaturePolicy) error {
+	jsonPolicy, err := json.Marshal(p)
+	if err != nil {
+		return verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("Marshal(%v) failed: %v", p, err))
+	}
+	path := filepath.Join(dir, "signature-policy")
+	if err := ioutil.WriteFile(path, jsonPolicy, 0600); err != nil {
+		return verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("WriteFile(%v) failed: %v", path, err))
+	}
+	return nil
+}
+
+// loadSignaturePolicy loads the signature policy of the installation.
+// Installations that predate signature policies have the default (lax)
+// policy.
+func loadSignaturePolicy(ctx *context.T, dir string) (*signaturePolicy, error) {
+	path := filepath.Join(dir, "signature-policy")
+	p := new(signaturePolicy)
+	if policyBytes, err := ioutil.ReadFile(path); os.IsNotExist(err) {
+		return p, nil
+	} else if err != nil {
+		return nil, verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("ReadFile(%v) failed: %v", path, err))
+	} else if err := json.Unmarshal(policyBytes, p); err != nil {
+		return nil, verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("Unmarshal(%v) failed: %v", policyBytes, err))
+	}
+	return p, nil
+}
+
+// checkPublisher verifies that the publisher of envelope is trusted.  A nil
+// policy trusts all publishers.
+func (p *signaturePolicy) checkPublisher(ctx *context.T, envelope *application.Envelope) error {
+	if p == nil || (!p.Strict && len(p.TrustedPublishers) == 0) {
+		return nil
+	}
+	// The names only include the blessings recognized by the device, which
+	// fetchEnvelope already requires if the envelope has a publisher.
+	names, _ := publisherBlessingNames(ctx, *envelope)
+	if len(names) == 0 {
+		if p.Strict {
+			return verror.New(errUnsigned, ctx, fmt.Sprintf("envelope %v", envelope.Title))
+		}
+		// Lax policies accept envelopes without a publisher.
+		return nil
+	}
+	if len(p.TrustedPublishers) == 0 {
+		return nil
+	}
+	for _, pattern := range p.TrustedPublishers {
+		if pattern.MatchedBy(names...) {
+			return nil
+		}
+	}
+	return verror.New(errUntrustedPublisher, ctx, names, envelope.Title)
+}
+
+// verify verifies the signature sig of data, a binary or package published by
+// publisher and described by what.  Under a lax (or nil) policy, data is
+// accepted without verification if there is no publisher, or if it is not
+// signed and !signatureRequired.  Under a strict policy, such data is refused.
+func (p *signaturePolicy) verify(ctx *context.T, what string, data []byte, publisher security.Blessings, sig security.Signature, signatureRequired bool) error {
+	signed := !reflect.DeepEqual(sig, security.Signature{})
+	switch {
+	case (publisher.IsZero() || !signed) && p != nil && p.Strict:
+		return verror.New(errUnsigned, ctx, what)
+	case publisher.IsZero():
+		if signed {
+			ctx.Infof("Warning: the signature of %v is not verified, since there is no publisher", what)
+		}
+		return nil
+	case !signed && !signatureRequired:
+		ctx.Infof("Warning: %v is not signed", what)
+		return nil
+	}
+	if err := verifySignature(data, publisher, sig); err != nil {
+		return verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("Publisher %v signature verification failed", what))
+	}
+	return nil
+}
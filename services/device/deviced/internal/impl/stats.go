@@ -27,6 +27,11 @@ type stats struct {
 	// TODO(caprita): Garbage-collect old instances?
 	runsPerInstance     map[string]*counter.Counter
 	restartsPerInstance map[string]*counter.Counter
+	// How many times instances hit their resource limits, by kind of
+	// limit and instance.
+	limitEventsPerInstance map[string]*counter.Counter
+	// The readiness of the instances with a readiness probe.
+	readyPerInstance map[string]*libstats.Integer
 }
 
 func newCounter(names ...string) *counter.Counter {
@@ -35,11 +40,13 @@ func newCounter(names ...string) *counter.Counter {
 
 func newStats(prefix string) *stats {
 	return &stats{
-		runs:                newCounter(prefix, "runs"),
-		runsPerInstance:     make(map[string]*counter.Counter),
-		restarts:            newCounter(prefix, "restarts"),
-		restartsPerInstance: make(map[string]*counter.Counter),
-		prefix:              prefix,
+		runs:                   newCounter(prefix, "runs"),
+		runsPerInstance:        make(map[string]*counter.Counter),
+		restarts:               newCounter(prefix, "restarts"),
+		restartsPerInstance:    make(map[string]*counter.Counter),
+		limitEventsPerInstance: make(map[string]*counter.Counter),
+		readyPerInstance:       make(map[string]*libstats.Integer),
+		prefix:                 prefix,
 	}
 }
 
@@ -66,3 +73,30 @@ func (s *stats) incrRuns(instance string) {
 	}
 	perInstanceCtr.Incr(1)
 }
+
+func (s *stats) incrLimitEvents(kind, instance string, n int64) {
+	s.Lock()
+	defer s.Unlock()
+	name := naming.Join(kind, instance)
+	perInstanceCtr, ok := s.limitEventsPerInstance[name]
+	if !ok {
+		perInstanceCtr = newCounter(s.prefix, kind, instance)
+		s.limitEventsPerInstance[name] = perInstanceCtr
+	}
+	perInstanceCtr.Incr(n)
+}
+
+func (s *stats) setReady(instance string, ready bool) {
+	s.Lock()
+	defer s.Unlock()
+	perInstanceReady, ok := s.readyPerInstance[instance]
+	if !ok {
+		perInstanceReady = libstats.NewInteger(naming.Join(s.prefix, "ready", instance))
+		s.readyPerInstance[instance] = perInstanceReady
+	}
+	if ready {
+		perInstanceReady.Set(1)
+	} else {
+		perInstanceReady.Set(0)
+	}
+}
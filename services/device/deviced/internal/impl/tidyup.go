@@ -212,7 +212,7 @@ func pruneOldLogs(ctx *context.T, root string, now time.Time) error {
 }
 
 // tidyHarness runs device manager cleanup operations
-func tidyHarness(ctx *context.T, root string) error {
+func tidyHarness(ctx *context.T, root string, blobs *blobCache) error {
 	now := MockableNow()
 
 	if err := pruneDeletedInstances(ctx, root, now); err != nil {
@@ -223,21 +223,27 @@ func tidyHarness(ctx *context.T, root string) error {
 		return err
 	}
 
+	// The blobs are collected after the installations, so that the blobs
+	// of freshly-pruned installations are collected too.
+	if err := blobs.gc(ctx, now); err != nil {
+		return err
+	}
+
 	return pruneOldLogs(ctx, root, now)
 }
 
 // tidyDaemon runs in a Go routine, processing requests to tidy
 // or tidying on a schedule.
-func tidyDaemon(ctx *context.T, c <-chan tidyRequests, root string) {
+func tidyDaemon(ctx *context.T, c <-chan tidyRequests, root string, blobs *blobCache) {
 	for {
 		select {
 		case req, ok := <-c:
 			if !ok {
 				return
 			}
-			req.bc <- tidyHarness(req.ctx, root)
+			req.bc <- tidyHarness(req.ctx, root, blobs)
 		case <-time.After(AutomaticTidyingInterval):
-			if err := tidyHarness(nil, root); err != nil {
+			if err := tidyHarness(nil, root, blobs); err != nil {
 				ctx.Errorf("tidyDaemon failed to tidy: %v", err)
 			}
 		}
@@ -250,8 +256,8 @@ type tidyRequests struct {
 	bc  chan<- error
 }
 
-func newTidyingDaemon(ctx *context.T, root string) chan<- tidyRequests {
+func newTidyingDaemon(ctx *context.T, root string, blobs *blobCache) chan<- tidyRequests {
 	c := make(chan tidyRequests)
-	go tidyDaemon(ctx, c, root)
+	go tidyDaemon(ctx, c, root, blobs)
 	return c
 }
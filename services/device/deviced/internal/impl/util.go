@@ -13,7 +13,6 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"reflect"
 	"regexp"
 	"strings"
 	"time"
@@ -26,7 +25,7 @@ import (
 	"v.io/v23/verror"
 	"v.io/x/ref/services/device/internal/config"
 	"v.io/x/ref/services/device/internal/errors"
-	"v.io/x/ref/services/internal/binarylib"
+	"v.io/x/ref/services/internal/packages"
 )
 
 // TODO(caprita): Set these timeout in a more principled manner.
@@ -47,42 +46,44 @@ func verifySignature(data []byte, publisher security.Blessings, sig security.Sig
 	return nil
 }
 
-func downloadBinary(ctx *context.T, publisher security.Blessings, bin *application.SignedFile, workspace, fileName string) error {
+// downloadBinary installs the binary of an envelope as the given file in
+// workspace, through the blob cache, and verifies its signature according to
+// policy.
+func downloadBinary(ctx *context.T, blobs *blobCache, policy *signaturePolicy, publisher security.Blessings, bin *application.SignedFile, workspace, fileName string) error {
 	// TODO(gauthamt): Reduce the number of passes we make over the binary/package
 	// data to verify its checksum and signature.
-	data, _, err := binarylib.Download(ctx, bin.File)
-	if err != nil {
-		return verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("Download(%v) failed: %v", bin.File, err))
-	}
-	if err := verifySignature(data, publisher, bin.Signature); err != nil {
-		return verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("Publisher binary(%v) signature verification failed", bin.File))
-	}
 	path, perm := filepath.Join(workspace, fileName), os.FileMode(0755)
-	if err := ioutil.WriteFile(path, data, perm); err != nil {
-		return verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("WriteFile(%v, %v) failed: %v", path, perm, err))
+	if _, err := blobs.install(ctx, bin.File, path, perm); err != nil {
+		return err
 	}
-	return nil
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("ReadFile(%v) failed: %v", path, err))
+	}
+	return policy.verify(ctx, fmt.Sprintf("binary(%v)", bin.File), data, publisher, bin.Signature, true)
 }
 
 // TODO(caprita): share code between downloadBinary and downloadPackages.
-func downloadPackages(ctx *context.T, publisher security.Blessings, packages application.Packages, pkgDir string) error {
-	for localPkg, pkgName := range packages {
+func downloadPackages(ctx *context.T, blobs *blobCache, policy *signaturePolicy, publisher security.Blessings, pkgs application.Packages, pkgDir string) error {
+	for localPkg, pkgName := range pkgs {
 		if localPkg == "" || localPkg[0] == '.' || strings.Contains(localPkg, string(filepath.Separator)) {
 			return verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("invalid local package name: %q", localPkg))
 		}
 		path := filepath.Join(pkgDir, localPkg)
-		if err := binarylib.DownloadToFile(ctx, pkgName.File, path); err != nil {
-			return verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("DownloadToFile(%q, %q) failed: %v", pkgName, path, err))
+		mediaInfo, err := blobs.install(ctx, pkgName.File, path, os.FileMode(0600))
+		if err != nil {
+			return err
+		}
+		if err := packages.SaveMediaInfo(path, mediaInfo); err != nil {
+			return verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("SaveMediaInfo(%v) failed: %v", path, err))
 		}
 		data, err := ioutil.ReadFile(path)
 		if err != nil {
 			return verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("ReadPackage(%v) failed: %v", path, err))
 		}
-		// If a nonempty signature is present, verify it. (i.e., we accept unsigned packages.)
-		if !reflect.DeepEqual(pkgName.Signature, security.Signature{}) {
-			if err := verifySignature(data, publisher, pkgName.Signature); err != nil {
-				return verror.New(errors.ErrOperationFailed, ctx, fmt.Sprintf("Publisher package(%v:%v) signature verification failed", localPkg, pkgName))
-			}
+		// Unless the policy is strict, unsigned packages are accepted.
+		if err := policy.verify(ctx, fmt.Sprintf("package(%v:%v)", localPkg, pkgName.File), data, publisher, pkgName.Signature, false); err != nil {
+			return err
 		}
 	}
 	return nil
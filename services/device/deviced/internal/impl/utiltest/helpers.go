@@ -430,6 +430,18 @@ func UpdateAppExpectError(t *testing.T, ctx *context.T, appID string, expectedEr
 	}
 }
 
+func UpdateAppTo(t *testing.T, ctx *context.T, appID, von string) {
+	if err := AppStub(appID).UpdateTo(ctx, von); err != nil {
+		t.Fatal(testutil.FormatLogLine(2, "UpdateTo(%v, %v) failed: %v [%v]", appID, von, verror.ErrorID(err), err))
+	}
+}
+
+func UpdateAppToExpectError(t *testing.T, ctx *context.T, appID, von string, expectedError verror.ID) {
+	if err := AppStub(appID).UpdateTo(ctx, von); err == nil || verror.ErrorID(err) != expectedError {
+		t.Fatal(testutil.FormatLogLine(2, "UpdateTo(%v, %v) expected to fail with %v, got %v [%v]", appID, von, expectedError, verror.ErrorID(err), err))
+	}
+}
+
 func RevertApp(t *testing.T, ctx *context.T, appID string) {
 	if err := AppStub(appID).Revert(ctx); err != nil {
 		t.Fatal(testutil.FormatLogLine(2, "Revert(%v) failed: %v [%v]", appID, verror.ErrorID(err), err))
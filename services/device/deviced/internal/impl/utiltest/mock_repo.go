@@ -42,8 +42,13 @@ func StartMockRepos(t *testing.T, ctx *context.T) (*application.Envelope, func()
 // repository.  It returns a pointer to the envelope that the repository returns
 // to clients (so that it can be changed).  It also returns a cleanup function.
 func StartApplicationRepository(ctx *context.T) (*application.Envelope, func()) {
+	return StartApplicationRepositoryAt(ctx, MockApplicationRepoName)
+}
+
+// StartApplicationRepositoryAt is like StartApplicationRepository, but mounts
+// the repository at name.
+func StartApplicationRepositoryAt(ctx *context.T, name string) (*application.Envelope, func()) {
 	invoker := new(arInvoker)
-	name := MockApplicationRepoName
 	ctx, cancel := context.WithCancel(ctx)
 	ctx, server, err := v23.WithNewServer(ctx, name, repository.ApplicationServer(invoker), security.AllowEveryone())
 	if err != nil {
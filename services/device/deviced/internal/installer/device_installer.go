@@ -114,7 +114,7 @@ func addToPATH(env []string, dir string) []string {
 
 // SelfInstall installs the device manager and configures it using the
 // environment and the supplied command-line flags.
-func SelfInstall(ctx *context.T, installDir, suidHelper, restarter, agent, initHelper, origin string, singleUser, sessionMode, init bool, args, env []string, stderr, stdout io.Writer) error {
+func SelfInstall(ctx *context.T, installDir, suidHelper, restarter, agent, initHelper, origin, cgroup string, singleUser, sessionMode, init bool, args, env []string, stderr, stdout io.Writer) error {
 	if os.Getenv(ref.EnvCredentials) != "" {
 		return fmt.Errorf("Attempting to install device manager with the %q environment variable set.", ref.EnvCredentials)
 	}
@@ -140,6 +140,7 @@ func SelfInstall(ctx *context.T, installDir, suidHelper, restarter, agent, initH
 		Origin:      origin,
 		CurrentLink: currLink,
 		Helper:      suidHelper,
+		Cgroup:      cgroup,
 	}
 	if err := configState.Validate(); err != nil {
 		return fmt.Errorf("invalid config %v: %v", configState, err)
@@ -72,6 +72,10 @@ type State struct {
 	// Helper is the path to the setuid helper for running applications as
 	// specific users.
 	Helper string
+	// Cgroup is the path to a cgroup (v2) delegated to the device manager,
+	// under which app instances with resource limits are run.  If empty,
+	// instances with resource limits cannot be started.
+	Cgroup string
 }
 
 // Validate checks the config state.
@@ -109,6 +113,7 @@ func Load() (*State, error) {
 		Origin:      os.Getenv(OriginEnv),
 		CurrentLink: os.Getenv(CurrentLinkEnv),
 		Helper:      os.Getenv(HelperEnv),
+		Cgroup:      os.Getenv(CgroupEnv),
 	}, nil
 }
 
@@ -136,6 +141,7 @@ func (c *State) Save(envelope *application.Envelope) ([]string, error) {
 		OriginEnv:      c.Origin,
 		CurrentLinkEnv: c.CurrentLink,
 		HelperEnv:      c.Helper,
+		CgroupEnv:      c.Cgroup,
 	}
 	// We need to manually pass the namespace roots to the child, since we
 	// currently don't have a way for the child to obtain this information
@@ -49,6 +49,7 @@ func TestState(t *testing.T) {
 		Origin:      "pet/store",
 		CurrentLink: currLink,
 		Helper:      "santas/little/helper",
+		Cgroup:      "/sys/fs/cgroup/kennel",
 	}
 	if err := state.Validate(); err != nil {
 		t.Errorf("Config state %v failed to validate: %v", state, err)
@@ -25,4 +25,7 @@ const (
 	// HelperEnv is the name of the environment variable that holds the path
 	// to the suid helper used to start apps as specific system users.
 	HelperEnv = "V23_DM_HELPER"
+	// CgroupEnv is the name of the environment variable that holds the
+	// path to the cgroup under which app instances are run.
+	CgroupEnv = "V23_DM_CGROUP"
 )
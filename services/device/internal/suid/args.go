@@ -35,10 +35,12 @@ type WorkParameters struct {
 	workspace string
 	agentsock string
 	logDir    string
+	cgroup    string
 	argv0     string
 	argv      []string
 	envv      []string
 	dryrun    bool
+	wait      bool
 	remove    bool
 	chown     bool
 	kill      bool
@@ -55,9 +57,9 @@ type ArgsSavedForTest struct {
 const SavedArgs = "V23_SAVED_ARGS"
 
 var (
-	flagUsername, flagWorkspace, flagLogDir, flagRun, flagProgName, flagAgentSock *string
-	flagMinimumUid                                                                *int64
-	flagRemove, flagKill, flagChown, flagDryrun                                   *bool
+	flagUsername, flagWorkspace, flagLogDir, flagRun, flagProgName, flagAgentSock, flagCgroup *string
+	flagMinimumUid                                                                            *int64
+	flagRemove, flagKill, flagChown, flagDryrun, flagWait                                     *bool
 )
 
 func init() {
@@ -72,11 +74,13 @@ func setupFlags(fs *flag.FlagSet) {
 	flagLogDir = fs.String("logdir", "", "Path to the log directory.")
 	flagRun = fs.String("run", "", "Path to the application to exec.")
 	flagProgName = fs.String("progname", "unnamed_app", "Visible name of the application, used in argv[0]")
+	flagCgroup = fs.String("cgroup", "", "Path to the cgroup in which to run the application.")
 	flagMinimumUid = fs.Int64("minuid", uidThreshold, "UIDs cannot be less than this number.")
 	flagRemove = fs.Bool("rm", false, "Remove the file trees given as command-line arguments.")
 	flagKill = fs.Bool("kill", false, "Kill process ids given as command-line arguments.")
 	flagChown = fs.Bool("chown", false, "Change owner of files and directories given as command-line arguments to the user specified by this flag")
 	flagDryrun = fs.Bool("dryrun", false, "Elides root-requiring systemcalls.")
+	flagWait = fs.Bool("wait", false, "Wait for the application to exit, and exit with its exit status.")
 }
 
 func cleanEnv(env []string) []string {
@@ -226,6 +230,8 @@ func (wp *WorkParameters) ProcessArguments(fs *flag.FlagSet, env []string) error
 	wp.agentsock = *flagAgentSock
 	wp.argv0 = *flagRun
 	wp.logDir = *flagLogDir
+	wp.cgroup = *flagCgroup
+	wp.wait = *flagWait
 	wp.argv = append([]string{*flagProgName}, fs.Args()...)
 	// TODO(rjkroege): Reduce the environment to the absolute minimum needed.
 	wp.envv = env
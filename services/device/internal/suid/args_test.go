@@ -58,7 +58,7 @@ func TestParseArguments(t *testing.T) {
 
 		{
 			[]string{"setuidhelper", "--minuid", "1", "--username", testUserName, "--workspace", "/hello",
-				"--logdir", "/logging", "--agentsock", "/tmp/sXXXX", "--run", "/bin/v23", "--", "one", "two"},
+				"--logdir", "/logging", "--agentsock", "/tmp/sXXXX", "--cgroup", "/sys/fs/cgroup/app", "--run", "/bin/v23", "--", "one", "two"},
 			[]string{"A=B"},
 			"",
 			WorkParameters{
@@ -67,6 +67,7 @@ func TestParseArguments(t *testing.T) {
 				workspace: "/hello",
 				agentsock: "/tmp/sXXXX",
 				logDir:    "/logging",
+				cgroup:    "/sys/fs/cgroup/app",
 				argv0:     "/bin/v23",
 				argv:      []string{"unnamed_app", "one", "two"},
 				envv:      []string{"A=B"},
@@ -78,6 +79,23 @@ func TestParseArguments(t *testing.T) {
 			},
 		},
 
+		{
+			[]string{"setuidhelper", "--minuid", "1", "--username", testUserName, "--workspace", "/hello",
+				"--logdir", "/logging", "--wait", "--run", "/bin/bash", "--", "-c", "true"},
+			[]string{"A=B"},
+			"",
+			WorkParameters{
+				uid:       testUid,
+				gid:       testGid,
+				workspace: "/hello",
+				logDir:    "/logging",
+				argv0:     "/bin/bash",
+				argv:      []string{"unnamed_app", "-c", "true"},
+				envv:      []string{"A=B"},
+				wait:      true,
+			},
+		},
+
 		{
 			[]string{"setuidhelper", "--username", testUserName},
 			[]string{"A=B"},
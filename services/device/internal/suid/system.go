@@ -8,9 +8,12 @@ package suid
 
 import (
 	"encoding/binary"
+	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"syscall"
 
 	"v.io/v23/verror"
@@ -23,6 +26,7 @@ var (
 	errRemoveAllFailed    = verror.Register(pkgPath+".errRemoveAllFailed", verror.NoRetry, "{1:}{2:} os.RemoveAll({3}) failed{:_}")
 	errFindProcessFailed  = verror.Register(pkgPath+".errFindProcessFailed", verror.NoRetry, "{1:}{2:} os.FindProcess({3}) failed{:_}")
 	errKillFailed         = verror.Register(pkgPath+".errKillFailed", verror.NoRetry, "{1:}{2:} os.Process.Kill({3}) failed{:_}")
+	errJoinCgroupFailed   = verror.Register(pkgPath+".errJoinCgroupFailed", verror.NoRetry, "{1:}{2:} joining cgroup {3} failed{:_}")
 )
 
 // Chown is only availabe on UNIX platforms so this file has a build
@@ -86,9 +90,26 @@ func (hw *WorkParameters) Exec() error {
 		attr.Sys.Credential.Uid = uint32(hw.uid)
 	}
 
+	// Join the cgroup before starting the child, so that the child is
+	// subject to the limits of the cgroup from its very start.
+	if hw.cgroup != "" {
+		procs := filepath.Join(hw.cgroup, "cgroup.procs")
+		if err := ioutil.WriteFile(procs, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+			return verror.New(errJoinCgroupFailed, nil, hw.cgroup, err)
+		}
+	}
+
 	// Make sure the child won't talk on the fd we use to talk back to the parent
 	syscall.CloseOnExec(PipeToParentFD)
 
+	// The parent terminates the helper to stop a child it waits for, e.g.
+	// when a health probe times out.
+	var terminate chan os.Signal
+	if hw.wait {
+		terminate = make(chan os.Signal, 1)
+		signal.Notify(terminate, syscall.SIGTERM, syscall.SIGINT)
+	}
+
 	// Start the child process
 	pid, _, err := syscall.StartProcess(hw.argv0, hw.argv, attr)
 	if err != nil {
@@ -100,6 +121,10 @@ func (hw *WorkParameters) Exec() error {
 		return verror.New(errStartProcessFailed, nil, hw.argv0, err)
 	}
 
+	if hw.wait {
+		os.Exit(waitForChild(pid, terminate))
+	}
+
 	// Return the pid of the new child process
 	pipeToParent := os.NewFile(PipeToParentFD, "pipe_to_parent_wr")
 	if err = binary.Write(pipeToParent, binary.LittleEndian, int32(pid)); err != nil {
@@ -112,6 +137,33 @@ func (hw *WorkParameters) Exec() error {
 	return nil // Not reached.
 }
 
+// waitForChild waits for the child pid to exit, and returns its exit status.
+// If terminated first, it kills the process group of the child, which leads
+// its own session, and reaps the child.
+func waitForChild(pid int, terminate <-chan os.Signal) int {
+	exited := make(chan int, 1)
+	go func() {
+		var status syscall.WaitStatus
+		if _, err := syscall.Wait4(pid, &status, 0, nil); err != nil {
+			log.Printf("Wait4(%d) failed: %v", pid, err)
+			exited <- 1
+			return
+		}
+		exited <- status.ExitStatus()
+	}()
+	select {
+	case status := <-exited:
+		return status
+	case sig := <-terminate:
+		log.Printf("Killing process group %d on %v", pid, sig)
+		if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+			log.Printf("Kill(%d) failed: %v", -pid, err)
+		}
+		<-exited
+		return 1
+	}
+}
+
 func (hw *WorkParameters) Remove() error {
 	for _, p := range hw.argv {
 		if err := os.RemoveAll(p); err != nil {
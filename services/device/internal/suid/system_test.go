@@ -9,8 +9,11 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"os/exec"
 	"path"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestChown(t *testing.T) {
@@ -72,3 +75,42 @@ func TestChown(t *testing.T) {
 		}
 	}
 }
+
+func TestWaitForChild(t *testing.T) {
+	// The exit status of the child is returned.
+	cmd := exec.Command("/bin/sh", "-c", "exit 3")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	if got, want := waitForChild(cmd.Process.Pid, nil), 3; got != want {
+		t.Errorf("got exit status %d, expected %d", got, want)
+	}
+
+	// When terminated, the whole process group of the child is killed:
+	// the background sleep, which holds the stdout of the child, exits too.
+	cmd = exec.Command("/bin/sh", "-c", "sleep 60 & wait")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("StdoutPipe() failed: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	terminate := make(chan os.Signal, 1)
+	terminate <- syscall.SIGTERM
+	if got := waitForChild(cmd.Process.Pid, terminate); got == 0 {
+		t.Errorf("got exit status 0 for a terminated child")
+	}
+	closed := make(chan struct{})
+	go func() {
+		ioutil.ReadAll(stdout)
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(10 * time.Second):
+		t.Errorf("the process group of the child was not killed")
+	}
+}
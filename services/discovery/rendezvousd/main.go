@@ -0,0 +1,56 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The following enables go generate to generate the doc.go file.
+//go:generate go run $JIRI_ROOT/release/go/src/v.io/x/lib/cmdline/testdata/gendoc.go . -help
+
+package main
+
+import (
+	"fmt"
+
+	"v.io/v23"
+	"v.io/v23/context"
+	"v.io/x/lib/cmdline"
+	"v.io/x/ref/lib/discovery/plugins/relay"
+	"v.io/x/ref/lib/security/securityflag"
+	"v.io/x/ref/lib/signals"
+	"v.io/x/ref/lib/v23cmd"
+	_ "v.io/x/ref/runtime/factories/roaming"
+)
+
+var name string
+
+func main() {
+	cmdRendezvousD.Flags.StringVar(&name, "name", "", "Name to mount the rendezvous server as.")
+
+	cmdline.HideGlobalFlagsExcept()
+	cmdline.Main(cmdRendezvousD)
+}
+
+var cmdRendezvousD = &cmdline.Command{
+	Runner: v23cmd.RunnerFunc(runRendezvousD),
+	Name:   "rendezvousd",
+	Short:  "Runs the discovery rendezvous daemon.",
+	Long: `
+Command rendezvousd runs the discovery rendezvous daemon, which implements the
+v.io/x/ref/lib/discovery/plugins/relay.Rendezvous interface.
+
+The daemon relays advertisements between the discovery relay plugins that
+connect to it, so that applications on different networks, such as the LANs of
+two offices, discover each other as soon as their advertisements change.
+`,
+}
+
+func runRendezvousD(ctx *context.T, env *cmdline.Env, args []string) error {
+	ctx, server, err := v23.WithNewServer(ctx, name, relay.RendezvousServer(relay.NewRendezvous()), securityflag.NewAuthorizerOrDie())
+	if err != nil {
+		return fmt.Errorf("NewServer() failed: %v", err)
+	}
+	ctx.Infof("Rendezvous server running at endpoint=%v", server.Status().Endpoints[0])
+
+	// Wait until shutdown.
+	<-signals.ShutdownOnSignals(ctx)
+	return nil
+}
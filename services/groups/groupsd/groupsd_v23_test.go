@@ -100,8 +100,13 @@ func TestV23GroupServerIntegration(t *testing.T) {
 		want := relateResult{
 			Remainder:      set.String.FromSlice([]string{"b:c:d", "c:d", "d"}),
 			Approximations: nil,
-			Version:        "2",
 		}
+		// groupA nests groupB, so the version is that of the membership
+		// expanded through both groups, which is not predictable.
+		if got.Version == "" || got.Version == "2" {
+			t.Errorf("got version %q, want the version of the expanded membership", got.Version)
+		}
+		got.Version = ""
 		if !reflect.DeepEqual(got, want) {
 			t.Errorf("got %v, want %v", got, want)
 		}
@@ -48,7 +48,11 @@ v.io/v23/services/groups.Group interface.
 }
 
 func runGroupsD(ctx *context.T, env *cmdline.Env, args []string) error {
-	dispatcher, err := lib.NewGroupsDispatcher(flagRootDir, flagEngine)
+	var names []string
+	if flagName != "" {
+		names = append(names, flagName)
+	}
+	dispatcher, err := lib.NewGroupsDispatcher(flagRootDir, flagEngine, names...)
 	if err != nil {
 		return err
 	}
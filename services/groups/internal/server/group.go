@@ -17,6 +17,7 @@ import (
 	"v.io/v23/verror"
 	"v.io/x/lib/set"
 	"v.io/x/ref/services/groups/internal/store"
+	"v.io/x/ref/services/groups/membership"
 )
 
 type group struct {
@@ -24,7 +25,7 @@ type group struct {
 	m    *manager
 }
 
-var _ groups.GroupServerMethods = (*group)(nil)
+var _ membership.GroupServerMethods = (*group)(nil)
 
 // TODO(sadovsky): Limit the number of groups that a particular user
 // (v23/conventsions.GetClientUserId) can create?
@@ -61,6 +62,7 @@ func (g *group) Create(ctx *context.T, call rpc.ServerCall, perms access.Permiss
 		}
 		return verror.New(verror.ErrInternal, ctx, err)
 	}
+	g.m.index.changed(ctx, g.name)
 	return nil
 }
 
@@ -109,6 +111,26 @@ func (g *group) Relate(ctx *context.T, call rpc.ServerCall, blessings map[string
 		return nil, nil, "", err
 	}
 
+	// Use the entries of the nested local groups from the membership index,
+	// unless the caller is not allowed to see them, in which case they are
+	// resolved through their servers as any other group.  The version of
+	// the expanded membership changes whenever any of the nested local
+	// groups change, unlike the version of the group itself, which is
+	// still used if the group has no nested local groups.
+	entries := make(map[string]struct{}, len(gd.Entries))
+	for p := range gd.Entries {
+		entries[string(p)] = struct{}{}
+	}
+	var cycle []string
+	if e, version, err := g.m.index.expand(g.name); err != nil {
+		ctx.Errorf("failed to expand group %q: %v", g.name, err)
+	} else if g.authorizeNested(ctx, call.Security(), e) {
+		entries, cycle = e.entries, e.cycle
+		if len(e.perms) > 0 {
+			resVersion = version
+		}
+	}
+
 	// If version is set and matches the Group's current version,
 	// send an empty response (the equivalent of "HTTP 304 Not Modified").
 	if reqVersion == resVersion {
@@ -117,7 +139,14 @@ func (g *group) Relate(ctx *context.T, call rpc.ServerCall, blessings map[string
 
 	remainder := make(map[string]struct{})
 	var approximations []groups.Approximation
-	for p := range gd.Entries {
+	if len(cycle) > 0 {
+		err := membership.NewErrCycle(ctx, cycle)
+		approximations = append(approximations, groups.Approximation{
+			Reason:  string(verror.ErrorID(err)),
+			Details: err.Error(),
+		})
+	}
+	for p := range entries {
 		rem, apprxs := groups.Match(ctx, security.BlessingPattern(p), hint, visitedGroups, blessings)
 		set.String.Union(remainder, rem)
 		approximations = append(approximations, apprxs...)
@@ -140,6 +169,35 @@ func (g *group) GetPermissions(ctx *context.T, call rpc.ServerCall) (perms acces
 	return gd.Perms, version, nil
 }
 
+func (g *group) WatchMembership(ctx *context.T, call membership.GroupWatchMembershipServerCall) error {
+	if _, _, err := g.getInternal(ctx, call.Security()); err != nil {
+		return err
+	}
+	w, change, err := g.m.index.watch(g.name)
+	if err != nil {
+		return verror.New(verror.ErrInternal, ctx, err)
+	}
+	defer g.m.index.unwatch(g.name, w)
+
+	stream := call.SendStream()
+	if err := stream.Send(change); err != nil {
+		return err
+	}
+	for {
+		select {
+		case change, ok := <-w.ch:
+			if !ok {
+				return membership.NewErrWatchTooSlow(ctx)
+			}
+			if err := stream.Send(change); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
 ////////////////////////////////////////
 // Internal helpers
 
@@ -153,6 +211,17 @@ func (g *group) authorize(ctx *context.T, call security.Call, perms access.Permi
 	return nil
 }
 
+// authorizeNested returns true iff the caller is allowed to see the entries of
+// all the nested local groups of the expansion.
+func (g *group) authorizeNested(ctx *context.T, call security.Call, e *expansion) bool {
+	for _, perms := range e.perms {
+		if err := g.authorize(ctx, call, perms); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
 // Returns a VDL-compatible error. Performs access check.
 func (g *group) getInternal(ctx *context.T, call security.Call) (gd groupData, version string, err error) {
 	version, err = g.m.st.Get(g.name, &gd)
@@ -201,6 +270,7 @@ func (g *group) readModifyWrite(ctx *context.T, call security.Call, version stri
 				return verror.New(verror.ErrInternal, ctx, err)
 			}
 		} else {
+			g.m.index.changed(ctx, g.name)
 			return nil
 		}
 	}
@@ -0,0 +1,345 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/security/access"
+	"v.io/v23/verror"
+	"v.io/x/ref/services/groups/internal/store"
+	"v.io/x/ref/services/groups/membership"
+)
+
+const (
+	groupRefPrefix = "<grp:"
+	groupRefSuffix = ">"
+
+	// watchBufferSize is the number of changes that are buffered for each
+	// watch before the watch is considered too slow, and is ended.
+	watchBufferSize = 100
+)
+
+// localRef is a reference to a group served by the same server.
+type localRef struct {
+	name  string // The name of the group, relative to the server.
+	chunk string // The entry that refers to the group.
+}
+
+// indexNode is the index entry of a group.
+type indexNode struct {
+	exists  bool
+	perms   access.Permissions
+	entries []string   // The entries that are not references to local groups.
+	refs    []localRef // The references to local groups.
+
+	version  string
+	expanded *expansion // nil if stale.
+	watchers map[*watcher]struct{}
+}
+
+// expansion is the membership of a group expanded through its nested local
+// groups.
+type expansion struct {
+	// entries are the entries of the group and of its nested local groups
+	// that are not references to existing local groups.
+	entries map[string]struct{}
+	// perms are the permissions of the nested local groups, which must all
+	// allow the caller to see their entries.
+	perms map[string]access.Permissions
+	// cycle are the groups that are nested in each other in a cycle with
+	// the group, if any.
+	cycle []string
+}
+
+type watcher struct {
+	ch      chan membership.Change
+	tooSlow bool // GUARDED_BY(membershipIndex.mu)
+}
+
+// membershipIndex is a materialized index of the membership of groups,
+// expanded through the groups nested in them that are served by the same
+// server.  Groups are loaded from the store when they are first needed, and
+// the index is updated incrementally as they change, so that only the groups
+// that the changed group is nested in are expanded again.
+type membershipIndex struct {
+	st    store.Store
+	roots []string
+	epoch int64
+
+	mu      sync.Mutex
+	nodes   map[string]*indexNode          // GUARDED_BY(mu)
+	parents map[string]map[string]struct{} // GUARDED_BY(mu)
+	seq     uint64                         // GUARDED_BY(mu)
+}
+
+// newMembershipIndex returns a new index of the groups in the store.  roots
+// are the names that the server is mounted under, which are used to recognize
+// the references to local groups.
+func newMembershipIndex(st store.Store, roots []string) *membershipIndex {
+	return &membershipIndex{
+		st:      st,
+		roots:   roots,
+		epoch:   time.Now().UnixNano(),
+		nodes:   make(map[string]*indexNode),
+		parents: make(map[string]map[string]struct{}),
+	}
+}
+
+// localGroup returns the name of the local group that the entry refers to,
+// relative to the server, if any.
+func (x *membershipIndex) localGroup(chunk string) (string, bool) {
+	if !strings.HasPrefix(chunk, groupRefPrefix) || !strings.HasSuffix(chunk, groupRefSuffix) {
+		return "", false
+	}
+	name := chunk[len(groupRefPrefix) : len(chunk)-len(groupRefSuffix)]
+	for _, root := range x.roots {
+		if strings.HasPrefix(name, root+"/") {
+			return name[len(root)+1:], true
+		}
+	}
+	return "", false
+}
+
+func (x *membershipIndex) newVersionLocked() string {
+	x.seq++
+	return fmt.Sprintf("%x.%d", x.epoch, x.seq)
+}
+
+// nodeLocked returns the node of the group, loading it from the store if it
+// is not loaded yet.
+func (x *membershipIndex) nodeLocked(name string) (*indexNode, error) {
+	if n := x.nodes[name]; n != nil {
+		return n, nil
+	}
+	n := &indexNode{version: x.newVersionLocked()}
+	if err := x.loadLocked(name, n); err != nil {
+		return nil, err
+	}
+	x.nodes[name] = n
+	return n, nil
+}
+
+// loadLocked reads the group from the store into the node, and updates the
+// references from the group to the local groups.
+func (x *membershipIndex) loadLocked(name string, n *indexNode) error {
+	var gd groupData
+	_, err := x.st.Get(name, &gd)
+	switch {
+	case err == nil:
+	case verror.ErrorID(err) == store.ErrUnknownKey.ID:
+		gd = groupData{}
+	default:
+		return err
+	}
+
+	for _, ref := range n.refs {
+		delete(x.parents[ref.name], name)
+		if len(x.parents[ref.name]) == 0 {
+			delete(x.parents, ref.name)
+		}
+	}
+	n.exists, n.perms, n.entries, n.refs = err == nil, gd.Perms, nil, nil
+	for chunk := range gd.Entries {
+		if ref, ok := x.localGroup(string(chunk)); ok {
+			n.refs = append(n.refs, localRef{ref, string(chunk)})
+			if x.parents[ref] == nil {
+				x.parents[ref] = make(map[string]struct{})
+			}
+			x.parents[ref][name] = struct{}{}
+		} else {
+			n.entries = append(n.entries, string(chunk))
+		}
+	}
+	return nil
+}
+
+// expandLocked returns the expansion of the group, computing it if it is
+// stale.
+func (x *membershipIndex) expandLocked(name string) (*expansion, string, error) {
+	n, err := x.nodeLocked(name)
+	if err != nil {
+		return nil, "", err
+	}
+	if n.expanded != nil {
+		return n.expanded, n.version, nil
+	}
+
+	e := &expansion{
+		entries: make(map[string]struct{}),
+		perms:   make(map[string]access.Permissions),
+	}
+	for _, entry := range n.entries {
+		e.entries[entry] = struct{}{}
+	}
+	// Walk the nested local groups.  The visited groups are the ones that
+	// can be reached from the group through at least one reference.
+	visited := make(map[string]bool)
+	stack := append([]localRef(nil), n.refs...)
+	for len(stack) > 0 {
+		ref := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if visited[ref.name] {
+			continue
+		}
+		visited[ref.name] = true
+		m, err := x.nodeLocked(ref.name)
+		if err != nil {
+			return nil, "", err
+		}
+		if !m.exists {
+			// Keep the reference so that it is resolved, and reported, as
+			// any other group.
+			e.entries[ref.chunk] = struct{}{}
+			continue
+		}
+		e.perms[ref.name] = m.perms
+		for _, entry := range m.entries {
+			e.entries[entry] = struct{}{}
+		}
+		stack = append(stack, m.refs...)
+	}
+
+	// The group is in a cycle with the visited groups that it can be
+	// reached from.
+	if visited[name] {
+		reaching := map[string]bool{name: true}
+		queue := []string{name}
+		for len(queue) > 0 {
+			child := queue[0]
+			queue = queue[1:]
+			for parent := range x.parents[child] {
+				if !reaching[parent] && visited[parent] {
+					reaching[parent] = true
+					queue = append(queue, parent)
+				}
+			}
+		}
+		for g := range reaching {
+			e.cycle = append(e.cycle, g)
+		}
+		sort.Strings(e.cycle)
+	}
+	n.expanded = e
+	return e, n.version, nil
+}
+
+// expand returns the expansion of the group, and the version of its
+// membership.
+func (x *membershipIndex) expand(name string) (*expansion, string, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.expandLocked(name)
+}
+
+// changed reloads the group from the store after it is changed, and notifies
+// the watchers of the groups that it is nested in.
+func (x *membershipIndex) changed(ctx *context.T, name string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	n := x.nodes[name]
+	if n == nil && len(x.parents[name]) == 0 {
+		// Neither the group nor any group that refers to it is loaded.
+		return
+	}
+	if n == nil {
+		n = &indexNode{}
+		x.nodes[name] = n
+	}
+	if err := x.loadLocked(name, n); err != nil {
+		// Keep the previous entries of the group, but still notify the
+		// watchers so that they do not rely on them.
+		ctx.Errorf("failed to load group %q: %v", name, err)
+	}
+
+	// Invalidate the group and all the groups that it is nested in.
+	affected := map[string]bool{name: true}
+	queue := []string{name}
+	for len(queue) > 0 {
+		child := queue[0]
+		queue = queue[1:]
+		for parent := range x.parents[child] {
+			if !affected[parent] {
+				affected[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+	for g := range affected {
+		if m := x.nodes[g]; m != nil {
+			m.version = x.newVersionLocked()
+			m.expanded = nil
+		}
+	}
+	for g := range affected {
+		m := x.nodes[g]
+		if m == nil || len(m.watchers) == 0 {
+			continue
+		}
+		change, err := x.changeLocked(g)
+		if err != nil {
+			ctx.Errorf("failed to expand group %q: %v", g, err)
+			continue
+		}
+		if len(change.Cycle) > 0 {
+			ctx.Infof("group %q is in a cycle with groups %v", g, change.Cycle)
+		}
+		for w := range m.watchers {
+			if w.tooSlow {
+				continue
+			}
+			select {
+			case w.ch <- change:
+			default:
+				w.tooSlow = true
+				close(w.ch)
+			}
+		}
+	}
+}
+
+func (x *membershipIndex) changeLocked(name string) (membership.Change, error) {
+	e, version, err := x.expandLocked(name)
+	if err != nil {
+		return membership.Change{}, err
+	}
+	return membership.Change{
+		Version: version,
+		Deleted: !x.nodes[name].exists,
+		Cycle:   e.cycle,
+	}, nil
+}
+
+// watch registers a watcher of the changes of the group, and returns the
+// current membership of the group.
+func (x *membershipIndex) watch(name string) (*watcher, membership.Change, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	change, err := x.changeLocked(name)
+	if err != nil {
+		return nil, membership.Change{}, err
+	}
+	n := x.nodes[name]
+	if n.watchers == nil {
+		n.watchers = make(map[*watcher]struct{})
+	}
+	w := &watcher{ch: make(chan membership.Change, watchBufferSize)}
+	n.watchers[w] = struct{}{}
+	return w, change, nil
+}
+
+func (x *membershipIndex) unwatch(name string, w *watcher) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if n := x.nodes[name]; n != nil {
+		delete(n.watchers, w)
+	}
+}
@@ -10,21 +10,26 @@ import (
 	"v.io/v23/context"
 	"v.io/v23/rpc"
 	"v.io/v23/security"
-	"v.io/v23/services/groups"
 	"v.io/x/ref/services/groups/internal/store"
+	"v.io/x/ref/services/groups/membership"
 )
 
 type manager struct {
 	st               store.Store
 	createAuthorizer security.Authorizer
+	index            *membershipIndex
 }
 
 // NewManager returns an rpc.Dispatcher implementation for a namespace of groups.
 //
 // The authorization policy for the creation of new groups will be controlled
 // by the provided Authorizer.
-func NewManager(st store.Store, auth security.Authorizer) rpc.Dispatcher {
-	return &manager{st: st, createAuthorizer: auth}
+//
+// The names that the groups are served under are used to recognize the
+// references to them in the entries of other groups, so that the membership
+// of nested groups is expanded without calling the server again.
+func NewManager(st store.Store, auth security.Authorizer, names ...string) rpc.Dispatcher {
+	return &manager{st: st, createAuthorizer: auth, index: newMembershipIndex(st, names)}
 }
 
 func (m *manager) Lookup(_ *context.T, suffix string) (interface{}, security.Authorizer, error) {
@@ -33,5 +38,5 @@ func (m *manager) Lookup(_ *context.T, suffix string) (interface{}, security.Aut
 	// A permissive authorizer (AllowEveryone) is used here since access
 	// control happens in the implementation of individual RPC methods. See
 	// the implementation of the group operations on the 'group' type.
-	return groups.GroupServer(&group{name: suffix, m: m}), security.AllowEveryone(), nil
+	return membership.GroupServer(&group{name: suffix, m: m}), security.AllowEveryone(), nil
 }
@@ -25,6 +25,7 @@ import (
 	"v.io/x/ref/services/groups/internal/store"
 	"v.io/x/ref/services/groups/internal/store/leveldb"
 	"v.io/x/ref/services/groups/internal/store/mem"
+	"v.io/x/ref/services/groups/membership"
 	"v.io/x/ref/test"
 	"v.io/x/ref/test/testutil"
 )
@@ -36,6 +37,10 @@ const (
 	memstore
 )
 
+// serverRoot is the name under which the groups are referred to in the entries
+// of other groups.
+const serverRoot = "groupsd"
+
 func Fatal(t *testing.T, args ...interface{}) {
 	debug.PrintStack()
 	t.Fatal(args...)
@@ -131,7 +136,7 @@ func newServer(ctx *context.T, be backend) (string, func()) {
 		ctx.Fatal("unknown backend: ", be)
 	}
 
-	m := server.NewManager(st, reservedAuthorizer{})
+	m := server.NewManager(st, reservedAuthorizer{}, serverRoot)
 
 	ctx, cancel := context.WithCancel(ctx)
 	ctx, server, err := v23.WithNewDispatchingServer(ctx, "", m)
@@ -590,6 +595,139 @@ func testRemoveHelper(t *testing.T, be backend) {
 	}
 }
 
+func groupRef(name string) string {
+	return "<grp:" + naming.Join(serverRoot, name) + ">"
+}
+
+func TestRelateNested(t *testing.T) {
+	ctx, serverName, cleanup := setupOrDie(memstore)
+	defer cleanup()
+
+	gA := groups.GroupClient(naming.JoinAddressName(serverName, "grpA"))
+	gB := groups.GroupClient(naming.JoinAddressName(serverName, "grpB"))
+	if err := gA.Create(ctx, nil, bpcSlice("a", groupRef("grpB"))); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := gB.Create(ctx, nil, bpcSlice("b")); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	relate := func(blessing, reqVersion string) (map[string]struct{}, []groups.Approximation, string) {
+		rem, apprxs, version, err := gA.Relate(ctx, map[string]struct{}{blessing: struct{}{}}, groups.ApproximationTypeUnder, reqVersion, nil)
+		if err != nil {
+			Fatalf(t, "Relate failed: %v", err)
+		}
+		return rem, apprxs, version
+	}
+
+	// The nested group is expanded by the server itself.
+	rem, apprxs, version := relate("b:c", "")
+	if want := map[string]struct{}{"c": struct{}{}}; !reflect.DeepEqual(rem, want) || len(apprxs) != 0 {
+		t.Errorf("got %v, %v, want %v and no approximations", rem, apprxs, want)
+	}
+
+	// The version does not change as long as the membership does not.
+	if rem, _, v := relate("b:c", version); len(rem) != 0 || v != version {
+		t.Errorf("got %v, %v, want no remainder and version %v", rem, v, version)
+	}
+
+	// Groups without nested groups have the version of the group itself.
+	_, storeVersion, err := gB.Get(ctx, groups.GetRequest{}, "")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if _, _, v, err := gB.Relate(ctx, map[string]struct{}{"b:c": struct{}{}}, groups.ApproximationTypeUnder, "", nil); err != nil || v != storeVersion {
+		t.Errorf("got version %v (%v), want %v", v, err, storeVersion)
+	}
+
+	// Changes to the nested group are reflected right away, even to the
+	// callers that pass the previous version.
+	if err := gB.Add(ctx, bpc("d"), ""); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	rem, apprxs, v := relate("d:e", version)
+	if want := map[string]struct{}{"e": struct{}{}}; v == version || !reflect.DeepEqual(rem, want) || len(apprxs) != 0 {
+		t.Errorf("got %v, %v, %v, want %v, no approximations and a version other than %v", rem, apprxs, v, want, version)
+	}
+	version = v
+
+	// Cycles are expanded to all the groups in them, and reported.
+	if err := gB.Add(ctx, bpc(groupRef("grpA")), ""); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	rem, apprxs, v = relate("a:f", version)
+	if want := map[string]struct{}{"f": struct{}{}}; v == version || !reflect.DeepEqual(rem, want) {
+		t.Errorf("got %v, %v, want %v and a version other than %v", rem, v, want, version)
+	}
+	if len(apprxs) != 1 || apprxs[0].Reason != string(membership.ErrCycle.ID) {
+		t.Errorf("got approximations %v, want one for the cycle", apprxs)
+	}
+}
+
+func TestWatchMembership(t *testing.T) {
+	ctx, serverName, cleanup := setupOrDie(memstore)
+	defer cleanup()
+
+	gA := membership.GroupClient(naming.JoinAddressName(serverName, "grpA"))
+	gB := membership.GroupClient(naming.JoinAddressName(serverName, "grpB"))
+	if err := gA.Create(ctx, nil, bpcSlice("a", groupRef("grpB"))); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	call, err := gA.WatchMembership(wctx)
+	if err != nil {
+		t.Fatalf("WatchMembership failed: %v", err)
+	}
+	stream := call.RecvStream()
+	next := func() membership.Change {
+		if !stream.Advance() {
+			Fatalf(t, "Advance failed: %v", stream.Err())
+		}
+		return stream.Value()
+	}
+
+	change := next()
+	if change.Deleted || len(change.Cycle) != 0 {
+		t.Errorf("unexpected change: %v", change)
+	}
+	version := change.Version
+
+	// Creating the nested group changes the membership.
+	if err := gB.Create(ctx, nil, bpcSlice("b")); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if change = next(); change.Version == version || change.Deleted || len(change.Cycle) != 0 {
+		t.Errorf("unexpected change: %v", change)
+	}
+	version = change.Version
+
+	// Cycles are reported.
+	if err := gB.Add(ctx, bpc(groupRef("grpA")), ""); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	change = next()
+	if want := []string{"grpA", "grpB"}; change.Version == version || !reflect.DeepEqual(change.Cycle, want) {
+		t.Errorf("got %v, want a new version with cycle %v", change, want)
+	}
+
+	if err := gB.Remove(ctx, bpc(groupRef("grpA")), ""); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if change = next(); len(change.Cycle) != 0 {
+		t.Errorf("unexpected change: %v", change)
+	}
+
+	// Deletion of the group is reported.
+	if err := gA.Delete(ctx, ""); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if change = next(); !change.Deleted {
+		t.Errorf("got %v, want deleted", change)
+	}
+}
+
 func TestGet(t *testing.T) {
 	// TODO(sadovsky): Implement.
 }
@@ -51,16 +51,19 @@ func (createAuthorizer) Authorize(ctx *context.T, call security.Call) error {
 //
 // engine is the storage engine for groups.  Currently, only "leveldb" and
 // "memstore" are supported.
-func NewGroupsDispatcher(rootDir, engine string) (rpc.Dispatcher, error) {
+//
+// names are the names that the groups service is mounted under, which are
+// used to expand the groups nested in other groups of the service.
+func NewGroupsDispatcher(rootDir, engine string, names ...string) (rpc.Dispatcher, error) {
 	switch engine {
 	case "leveldb":
 		store, err := leveldb.Open(rootDir)
 		if err != nil {
 			return nil, fmt.Errorf("Open(%v) failed: %v", rootDir, err)
 		}
-		return server.NewManager(store, createAuthorizer{}), nil
+		return server.NewManager(store, createAuthorizer{}, names...), nil
 	case "memstore":
-		return server.NewManager(mem.New(), createAuthorizer{}), nil
+		return server.NewManager(mem.New(), createAuthorizer{}, names...), nil
 	default:
 		return nil, fmt.Errorf("unknown storage engine %v", engine)
 	}
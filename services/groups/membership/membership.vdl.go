@@ -0,0 +1,481 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file was auto-generated by the vanadium vdl tool.
+// Package: membership
+
+// Package membership defines an interface for watching the membership of
+// groups, including the membership of the groups nested in them, so that
+// clients such as authorizers can cache the membership of groups and
+// invalidate their caches when it changes.
+package membership
+
+import (
+	"io"
+	"v.io/v23"
+	"v.io/v23/context"
+	"v.io/v23/i18n"
+	"v.io/v23/rpc"
+	"v.io/v23/security/access"
+	"v.io/v23/services/groups"
+	"v.io/v23/vdl"
+	"v.io/v23/verror"
+)
+
+var _ = __VDLInit() // Must be first; see __VDLInit comments for details.
+
+//////////////////////////////////////////////////
+// Type definitions
+
+// Change describes the membership of a group after a change to it or to one
+// of the groups nested in it.
+type Change struct {
+	// Version of the membership of the group.  It changes whenever the
+	// entries of the group, or of any group nested in it, change.
+	Version string
+	// Deleted is true iff the group does not exist.
+	Deleted bool
+	// Cycle is the names, relative to the groups server, of the groups that
+	// are nested in each other in a cycle with the group, if any.
+	Cycle []string
+}
+
+func (Change) __VDLReflect(struct {
+	Name string `vdl:"v.io/x/ref/services/groups/membership.Change"`
+}) {
+}
+
+func (x Change) VDLIsZero() bool {
+	if x.Version != "" {
+		return false
+	}
+	if x.Deleted {
+		return false
+	}
+	if len(x.Cycle) != 0 {
+		return false
+	}
+	return true
+}
+
+func (x Change) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_struct_1); err != nil {
+		return err
+	}
+	if x.Version != "" {
+		if err := enc.NextFieldValueString(0, vdl.StringType, x.Version); err != nil {
+			return err
+		}
+	}
+	if x.Deleted {
+		if err := enc.NextFieldValueBool(1, vdl.BoolType, x.Deleted); err != nil {
+			return err
+		}
+	}
+	if len(x.Cycle) != 0 {
+		if err := enc.NextField(2); err != nil {
+			return err
+		}
+		if err := __VDLWriteAnon_list_1(enc, x.Cycle); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func __VDLWriteAnon_list_1(enc vdl.Encoder, x []string) error {
+	if err := enc.StartValue(__VDLType_list_2); err != nil {
+		return err
+	}
+	if err := enc.SetLenHint(len(x)); err != nil {
+		return err
+	}
+	for _, elem := range x {
+		if err := enc.NextEntryValueString(vdl.StringType, elem); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextEntry(true); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x *Change) VDLRead(dec vdl.Decoder) error {
+	*x = Change{}
+	if err := dec.StartValue(__VDLType_struct_1); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	for {
+		index, err := dec.NextField()
+		switch {
+		case err != nil:
+			return err
+		case index == -1:
+			return dec.FinishValue()
+		}
+		if decType != __VDLType_struct_1 {
+			index = __VDLType_struct_1.FieldIndexByName(decType.Field(index).Name)
+			if index == -1 {
+				if err := dec.SkipValue(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		switch index {
+		case 0:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				x.Version = value
+			}
+		case 1:
+			switch value, err := dec.ReadValueBool(); {
+			case err != nil:
+				return err
+			default:
+				x.Deleted = value
+			}
+		case 2:
+			if err := __VDLReadAnon_list_1(dec, &x.Cycle); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func __VDLReadAnon_list_1(dec vdl.Decoder, x *[]string) error {
+	if err := dec.StartValue(__VDLType_list_2); err != nil {
+		return err
+	}
+	if len := dec.LenHint(); len > 0 {
+		*x = make([]string, 0, len)
+	} else {
+		*x = nil
+	}
+	for {
+		switch done, elem, err := dec.NextEntryValueString(); {
+		case err != nil:
+			return err
+		case done:
+			return dec.FinishValue()
+		default:
+			*x = append(*x, elem)
+		}
+	}
+}
+
+//////////////////////////////////////////////////
+// Error definitions
+
+var (
+
+	// Indicates that the watch does not keep up with the changes, and
+	// should be restarted.
+	ErrWatchTooSlow = verror.Register("v.io/x/ref/services/groups/membership.WatchTooSlow", verror.RetryBackoff, "{1:}{2:} watch does not keep up with the changes")
+	// Indicates that the group is nested in itself through the groups in a
+	// cycle with it.  Relate reports it as an approximation.
+	ErrCycle = verror.Register("v.io/x/ref/services/groups/membership.Cycle", verror.NoRetry, "{1:}{2:} group is in a cycle with groups {3}")
+)
+
+// NewErrWatchTooSlow returns an error with the ErrWatchTooSlow ID.
+func NewErrWatchTooSlow(ctx *context.T) error {
+	return verror.New(ErrWatchTooSlow, ctx)
+}
+
+// NewErrCycle returns an error with the ErrCycle ID.
+func NewErrCycle(ctx *context.T, cycle []string) error {
+	return verror.New(ErrCycle, ctx, cycle)
+}
+
+//////////////////////////////////////////////////
+// Interface definitions
+
+// GroupClientMethods is the client interface
+// containing Group methods.
+//
+// Group is a group whose membership, including the membership of the groups
+// nested in it that are served by the same groups server, is indexed by the
+// groups server.
+type GroupClientMethods interface {
+	// Group is implemented by services that support group management.
+	groups.GroupClientMethods
+	// WatchMembership streams the current membership of the group, followed
+	// by a change each time the membership of the group changes.
+	WatchMembership(*context.T, ...rpc.CallOpt) (GroupWatchMembershipClientCall, error)
+}
+
+// GroupClientStub adds universal methods to GroupClientMethods.
+type GroupClientStub interface {
+	GroupClientMethods
+	rpc.UniversalServiceMethods
+}
+
+// GroupClient returns a client stub for Group.
+func GroupClient(name string) GroupClientStub {
+	return implGroupClientStub{name, groups.GroupClient(name)}
+}
+
+type implGroupClientStub struct {
+	name string
+
+	groups.GroupClientStub
+}
+
+func (c implGroupClientStub) WatchMembership(ctx *context.T, opts ...rpc.CallOpt) (ocall GroupWatchMembershipClientCall, err error) {
+	var call rpc.ClientCall
+	if call, err = v23.GetClient(ctx).StartCall(ctx, c.name, "WatchMembership", nil, opts...); err != nil {
+		return
+	}
+	ocall = &implGroupWatchMembershipClientCall{ClientCall: call}
+	return
+}
+
+// GroupWatchMembershipClientStream is the client stream for Group.WatchMembership.
+type GroupWatchMembershipClientStream interface {
+	// RecvStream returns the receiver side of the Group.WatchMembership client stream.
+	RecvStream() interface {
+		// Advance stages an item so that it may be retrieved via Value.  Returns
+		// true iff there is an item to retrieve.  Advance must be called before
+		// Value is called.  May block if an item is not available.
+		Advance() bool
+		// Value returns the item that was staged by Advance.  May panic if Advance
+		// returned false or was not called.  Never blocks.
+		Value() Change
+		// Err returns any error encountered by Advance.  Never blocks.
+		Err() error
+	}
+}
+
+// GroupWatchMembershipClientCall represents the call returned from Group.WatchMembership.
+type GroupWatchMembershipClientCall interface {
+	GroupWatchMembershipClientStream
+	// Finish blocks until the server is done, and returns the positional return
+	// values for call.
+	//
+	// Finish returns immediately if the call has been canceled; depending on the
+	// timing the output could either be an error signaling cancelation, or the
+	// valid positional return values from the server.
+	//
+	// Calling Finish is mandatory for releasing stream resources, unless the call
+	// has been canceled or any of the other methods return an error.  Finish should
+	// be called at most once.
+	Finish() error
+}
+
+type implGroupWatchMembershipClientCall struct {
+	rpc.ClientCall
+	valRecv Change
+	errRecv error
+}
+
+func (c *implGroupWatchMembershipClientCall) RecvStream() interface {
+	Advance() bool
+	Value() Change
+	Err() error
+} {
+	return implGroupWatchMembershipClientCallRecv{c}
+}
+
+type implGroupWatchMembershipClientCallRecv struct {
+	c *implGroupWatchMembershipClientCall
+}
+
+func (c implGroupWatchMembershipClientCallRecv) Advance() bool {
+	c.c.valRecv = Change{}
+	c.c.errRecv = c.c.Recv(&c.c.valRecv)
+	return c.c.errRecv == nil
+}
+func (c implGroupWatchMembershipClientCallRecv) Value() Change {
+	return c.c.valRecv
+}
+func (c implGroupWatchMembershipClientCallRecv) Err() error {
+	if c.c.errRecv == io.EOF {
+		return nil
+	}
+	return c.c.errRecv
+}
+func (c *implGroupWatchMembershipClientCall) Finish() (err error) {
+	err = c.ClientCall.Finish()
+	return
+}
+
+// GroupServerMethods is the interface a server writer
+// implements for Group.
+//
+// Group is a group whose membership, including the membership of the groups
+// nested in it that are served by the same groups server, is indexed by the
+// groups server.
+type GroupServerMethods interface {
+	// Group is implemented by services that support group management.
+	groups.GroupServerMethods
+	// WatchMembership streams the current membership of the group, followed
+	// by a change each time the membership of the group changes.
+	WatchMembership(*context.T, GroupWatchMembershipServerCall) error
+}
+
+// GroupServerStubMethods is the server interface containing
+// Group methods, as expected by rpc.Server.
+// The only difference between this interface and GroupServerMethods
+// is the streaming methods.
+type GroupServerStubMethods interface {
+	// Group is implemented by services that support group management.
+	groups.GroupServerStubMethods
+	// WatchMembership streams the current membership of the group, followed
+	// by a change each time the membership of the group changes.
+	WatchMembership(*context.T, *GroupWatchMembershipServerCallStub) error
+}
+
+// GroupServerStub adds universal methods to GroupServerStubMethods.
+type GroupServerStub interface {
+	GroupServerStubMethods
+	// Describe the Group interfaces.
+	Describe__() []rpc.InterfaceDesc
+}
+
+// GroupServer returns a server stub for Group.
+// It converts an implementation of GroupServerMethods into
+// an object that may be used by rpc.Server.
+func GroupServer(impl GroupServerMethods) GroupServerStub {
+	stub := implGroupServerStub{
+		impl:            impl,
+		GroupServerStub: groups.GroupServer(impl),
+	}
+	// Initialize GlobState; always check the stub itself first, to handle the
+	// case where the user has the Glob method defined in their VDL source.
+	if gs := rpc.NewGlobState(stub); gs != nil {
+		stub.gs = gs
+	} else if gs := rpc.NewGlobState(impl); gs != nil {
+		stub.gs = gs
+	}
+	return stub
+}
+
+type implGroupServerStub struct {
+	impl GroupServerMethods
+	groups.GroupServerStub
+	gs *rpc.GlobState
+}
+
+func (s implGroupServerStub) WatchMembership(ctx *context.T, call *GroupWatchMembershipServerCallStub) error {
+	return s.impl.WatchMembership(ctx, call)
+}
+
+func (s implGroupServerStub) Globber() *rpc.GlobState {
+	return s.gs
+}
+
+func (s implGroupServerStub) Describe__() []rpc.InterfaceDesc {
+	return []rpc.InterfaceDesc{GroupDesc, groups.GroupDesc}
+}
+
+// GroupDesc describes the Group interface.
+var GroupDesc rpc.InterfaceDesc = descGroup
+
+// descGroup hides the desc to keep godoc clean.
+var descGroup = rpc.InterfaceDesc{
+	Name:    "Group",
+	PkgPath: "v.io/x/ref/services/groups/membership",
+	Doc:     "// Group is a group whose membership, including the membership of the groups\n// nested in it that are served by the same groups server, is indexed by the\n// groups server.",
+	Embeds: []rpc.EmbedDesc{
+		{"Group", "v.io/v23/services/groups", "// Group is implemented by services that support group management."},
+	},
+	Methods: []rpc.MethodDesc{
+		{
+			Name: "WatchMembership",
+			Doc:  "// WatchMembership streams the current membership of the group, followed\n// by a change each time the membership of the group changes.",
+			Tags: []*vdl.Value{vdl.ValueOf(access.Tag("Read"))},
+		},
+	},
+}
+
+// GroupWatchMembershipServerStream is the server stream for Group.WatchMembership.
+type GroupWatchMembershipServerStream interface {
+	// SendStream returns the send side of the Group.WatchMembership server stream.
+	SendStream() interface {
+		// Send places the item onto the output stream.  Returns errors encountered
+		// while sending.  Blocks if there is no buffer space; will unblock when
+		// buffer space is available.
+		Send(item Change) error
+	}
+}
+
+// GroupWatchMembershipServerCall represents the context passed to Group.WatchMembership.
+type GroupWatchMembershipServerCall interface {
+	rpc.ServerCall
+	GroupWatchMembershipServerStream
+}
+
+// GroupWatchMembershipServerCallStub is a wrapper that converts rpc.StreamServerCall into
+// a typesafe stub that implements GroupWatchMembershipServerCall.
+type GroupWatchMembershipServerCallStub struct {
+	rpc.StreamServerCall
+}
+
+// Init initializes GroupWatchMembershipServerCallStub from rpc.StreamServerCall.
+func (s *GroupWatchMembershipServerCallStub) Init(call rpc.StreamServerCall) {
+	s.StreamServerCall = call
+}
+
+// SendStream returns the send side of the Group.WatchMembership server stream.
+func (s *GroupWatchMembershipServerCallStub) SendStream() interface {
+	Send(item Change) error
+} {
+	return implGroupWatchMembershipServerCallSend{s}
+}
+
+type implGroupWatchMembershipServerCallSend struct {
+	s *GroupWatchMembershipServerCallStub
+}
+
+func (s implGroupWatchMembershipServerCallSend) Send(item Change) error {
+	return s.s.Send(item)
+}
+
+// Hold type definitions in package-level variables, for better performance.
+var (
+	__VDLType_struct_1 *vdl.Type
+	__VDLType_list_2   *vdl.Type
+)
+
+var __VDLInitCalled bool
+
+// __VDLInit performs vdl initialization.  It is safe to call multiple times.
+// If you have an init ordering issue, just insert the following line verbatim
+// into your source files in this package, right after the "package foo" clause:
+//
+//    var _ = __VDLInit()
+//
+// The purpose of this function is to ensure that vdl initialization occurs in
+// the right order, and very early in the init sequence.  In particular, vdl
+// registration and package variable initialization needs to occur before
+// functions like vdl.TypeOf will work properly.
+//
+// This function returns a dummy value, so that it can be used to initialize the
+// first var in the file, to take advantage of Go's defined init order.
+func __VDLInit() struct{} {
+	if __VDLInitCalled {
+		return struct{}{}
+	}
+	__VDLInitCalled = true
+
+	// Register types.
+	vdl.Register((*Change)(nil))
+
+	// Initialize type definitions.
+	__VDLType_struct_1 = vdl.TypeOf((*Change)(nil)).Elem()
+	__VDLType_list_2 = vdl.TypeOf((*[]string)(nil))
+
+	// Set error format strings.
+	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrWatchTooSlow.ID), "{1:}{2:} watch does not keep up with the changes")
+	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrCycle.ID), "{1:}{2:} group is in a cycle with groups {3}")
+
+	return struct{}{}
+}
@@ -9,11 +9,16 @@
 package identity
 
 import (
+	"fmt"
+	"io"
+	"time"
 	"v.io/v23"
 	"v.io/v23/context"
 	"v.io/v23/rpc"
 	"v.io/v23/security"
+	"v.io/v23/security/access"
 	"v.io/v23/vdl"
+	vdltime "v.io/v23/vdlroot/time"
 )
 
 var _ = __VDLInit() // Must be first; see __VDLInit comments for details.
@@ -146,6 +151,428 @@ func __VDLReadAnon_list_1(dec vdl.Decoder, x *[]string) error {
 	}
 }
 
+// RevocationFilter selects blessing audit log entries by whether the
+// blessings they record have been revoked.
+type RevocationFilter int
+
+const (
+	RevocationFilterAny RevocationFilter = iota
+	RevocationFilterRevoked
+	RevocationFilterNotRevoked
+)
+
+// RevocationFilterAll holds all labels for RevocationFilter.
+var RevocationFilterAll = [...]RevocationFilter{RevocationFilterAny, RevocationFilterRevoked, RevocationFilterNotRevoked}
+
+// RevocationFilterFromString creates a RevocationFilter from a string label.
+func RevocationFilterFromString(label string) (x RevocationFilter, err error) {
+	err = x.Set(label)
+	return
+}
+
+// Set assigns label to x.
+func (x *RevocationFilter) Set(label string) error {
+	switch label {
+	case "Any", "any":
+		*x = RevocationFilterAny
+		return nil
+	case "Revoked", "revoked":
+		*x = RevocationFilterRevoked
+		return nil
+	case "NotRevoked", "notrevoked":
+		*x = RevocationFilterNotRevoked
+		return nil
+	}
+	*x = -1
+	return fmt.Errorf("unknown label %q in identity.RevocationFilter", label)
+}
+
+// String returns the string label of x.
+func (x RevocationFilter) String() string {
+	switch x {
+	case RevocationFilterAny:
+		return "Any"
+	case RevocationFilterRevoked:
+		return "Revoked"
+	case RevocationFilterNotRevoked:
+		return "NotRevoked"
+	}
+	return ""
+}
+
+func (RevocationFilter) __VDLReflect(struct {
+	Name string `vdl:"v.io/x/ref/services/identity.RevocationFilter"`
+	Enum struct{ Any, Revoked, NotRevoked string }
+}) {
+}
+
+func (x RevocationFilter) VDLIsZero() bool {
+	return x == RevocationFilterAny
+}
+
+func (x RevocationFilter) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.WriteValueString(__VDLType_enum_3, x.String()); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (x *RevocationFilter) VDLRead(dec vdl.Decoder) error {
+	switch value, err := dec.ReadValueString(); {
+	case err != nil:
+		return err
+	default:
+		if err := x.Set(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BlessingAuditQuery selects entries of the blessing audit log.  Zero-valued
+// fields select all entries.
+type BlessingAuditQuery struct {
+	// Email selects the blessings granted to this user.
+	Email string
+	// Start and End select the blessings granted in [Start, End).
+	Start time.Time
+	End   time.Time
+	// Pattern selects the blessings that have a name matched by it.
+	Pattern security.BlessingPattern
+	// CaveatType selects the blessings that have a caveat of this type: one of
+	// "Expiry", "Method", "PeerBlessings", "Revocation" or the hex-encoded id
+	// of a caveat descriptor.
+	CaveatType string
+	// Revocation selects the blessings by revocation status.
+	Revocation RevocationFilter
+}
+
+func (BlessingAuditQuery) __VDLReflect(struct {
+	Name string `vdl:"v.io/x/ref/services/identity.BlessingAuditQuery"`
+}) {
+}
+
+func (x BlessingAuditQuery) VDLIsZero() bool {
+	if x.Email != "" {
+		return false
+	}
+	if !x.Start.IsZero() {
+		return false
+	}
+	if !x.End.IsZero() {
+		return false
+	}
+	if x.Pattern != "" {
+		return false
+	}
+	if x.CaveatType != "" {
+		return false
+	}
+	if x.Revocation != RevocationFilterAny {
+		return false
+	}
+	return true
+}
+
+func (x BlessingAuditQuery) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_struct_4); err != nil {
+		return err
+	}
+	if x.Email != "" {
+		if err := enc.NextFieldValueString(0, vdl.StringType, x.Email); err != nil {
+			return err
+		}
+	}
+	if !x.Start.IsZero() {
+		if err := enc.NextField(1); err != nil {
+			return err
+		}
+		var wire vdltime.Time
+		if err := vdltime.TimeFromNative(&wire, x.Start); err != nil {
+			return err
+		}
+		if err := wire.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
+	if !x.End.IsZero() {
+		if err := enc.NextField(2); err != nil {
+			return err
+		}
+		var wire vdltime.Time
+		if err := vdltime.TimeFromNative(&wire, x.End); err != nil {
+			return err
+		}
+		if err := wire.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
+	if x.Pattern != "" {
+		if err := enc.NextFieldValueString(3, __VDLType_string_6, string(x.Pattern)); err != nil {
+			return err
+		}
+	}
+	if x.CaveatType != "" {
+		if err := enc.NextFieldValueString(4, vdl.StringType, x.CaveatType); err != nil {
+			return err
+		}
+	}
+	if x.Revocation != RevocationFilterAny {
+		if err := enc.NextFieldValueString(5, __VDLType_enum_3, x.Revocation.String()); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x *BlessingAuditQuery) VDLRead(dec vdl.Decoder) error {
+	*x = BlessingAuditQuery{}
+	if err := dec.StartValue(__VDLType_struct_4); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	for {
+		index, err := dec.NextField()
+		switch {
+		case err != nil:
+			return err
+		case index == -1:
+			return dec.FinishValue()
+		}
+		if decType != __VDLType_struct_4 {
+			index = __VDLType_struct_4.FieldIndexByName(decType.Field(index).Name)
+			if index == -1 {
+				if err := dec.SkipValue(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		switch index {
+		case 0:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				x.Email = value
+			}
+		case 1:
+			var wire vdltime.Time
+			if err := wire.VDLRead(dec); err != nil {
+				return err
+			}
+			if err := vdltime.TimeToNative(wire, &x.Start); err != nil {
+				return err
+			}
+		case 2:
+			var wire vdltime.Time
+			if err := wire.VDLRead(dec); err != nil {
+				return err
+			}
+			if err := vdltime.TimeToNative(wire, &x.End); err != nil {
+				return err
+			}
+		case 3:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				x.Pattern = security.BlessingPattern(value)
+			}
+		case 4:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				x.CaveatType = value
+			}
+		case 5:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				if err := x.Revocation.Set(value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// BlessingAuditEntry is an entry of the blessing audit log.
+type BlessingAuditEntry struct {
+	// Email of the user that was blessed.
+	Email string
+	// Timestamp is the time at which the blessings were granted.
+	Timestamp time.Time
+	// Names of the blessings.
+	Blessings []string
+	// Caveats of the blessings, in human-readable form.
+	Caveats []string
+	// RevocationCaveatId is the id of the revocation caveat of the blessings,
+	// if any.
+	RevocationCaveatId string
+	// RevocationTime is the time at which the blessings were revoked, or the
+	// zero time if they were not.
+	RevocationTime time.Time
+}
+
+func (BlessingAuditEntry) __VDLReflect(struct {
+	Name string `vdl:"v.io/x/ref/services/identity.BlessingAuditEntry"`
+}) {
+}
+
+func (x BlessingAuditEntry) VDLIsZero() bool {
+	if x.Email != "" {
+		return false
+	}
+	if !x.Timestamp.IsZero() {
+		return false
+	}
+	if len(x.Blessings) != 0 {
+		return false
+	}
+	if len(x.Caveats) != 0 {
+		return false
+	}
+	if x.RevocationCaveatId != "" {
+		return false
+	}
+	if !x.RevocationTime.IsZero() {
+		return false
+	}
+	return true
+}
+
+func (x BlessingAuditEntry) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_struct_7); err != nil {
+		return err
+	}
+	if x.Email != "" {
+		if err := enc.NextFieldValueString(0, vdl.StringType, x.Email); err != nil {
+			return err
+		}
+	}
+	if !x.Timestamp.IsZero() {
+		if err := enc.NextField(1); err != nil {
+			return err
+		}
+		var wire vdltime.Time
+		if err := vdltime.TimeFromNative(&wire, x.Timestamp); err != nil {
+			return err
+		}
+		if err := wire.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
+	if len(x.Blessings) != 0 {
+		if err := enc.NextField(2); err != nil {
+			return err
+		}
+		if err := __VDLWriteAnon_list_1(enc, x.Blessings); err != nil {
+			return err
+		}
+	}
+	if len(x.Caveats) != 0 {
+		if err := enc.NextField(3); err != nil {
+			return err
+		}
+		if err := __VDLWriteAnon_list_1(enc, x.Caveats); err != nil {
+			return err
+		}
+	}
+	if x.RevocationCaveatId != "" {
+		if err := enc.NextFieldValueString(4, vdl.StringType, x.RevocationCaveatId); err != nil {
+			return err
+		}
+	}
+	if !x.RevocationTime.IsZero() {
+		if err := enc.NextField(5); err != nil {
+			return err
+		}
+		var wire vdltime.Time
+		if err := vdltime.TimeFromNative(&wire, x.RevocationTime); err != nil {
+			return err
+		}
+		if err := wire.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x *BlessingAuditEntry) VDLRead(dec vdl.Decoder) error {
+	*x = BlessingAuditEntry{}
+	if err := dec.StartValue(__VDLType_struct_7); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	for {
+		index, err := dec.NextField()
+		switch {
+		case err != nil:
+			return err
+		case index == -1:
+			return dec.FinishValue()
+		}
+		if decType != __VDLType_struct_7 {
+			index = __VDLType_struct_7.FieldIndexByName(decType.Field(index).Name)
+			if index == -1 {
+				if err := dec.SkipValue(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		switch index {
+		case 0:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				x.Email = value
+			}
+		case 1:
+			var wire vdltime.Time
+			if err := wire.VDLRead(dec); err != nil {
+				return err
+			}
+			if err := vdltime.TimeToNative(wire, &x.Timestamp); err != nil {
+				return err
+			}
+		case 2:
+			if err := __VDLReadAnon_list_1(dec, &x.Blessings); err != nil {
+				return err
+			}
+		case 3:
+			if err := __VDLReadAnon_list_1(dec, &x.Caveats); err != nil {
+				return err
+			}
+		case 4:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				x.RevocationCaveatId = value
+			}
+		case 5:
+			var wire vdltime.Time
+			if err := wire.VDLRead(dec); err != nil {
+				return err
+			}
+			if err := vdltime.TimeToNative(wire, &x.RevocationTime); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 //////////////////////////////////////////////////
 // Interface definitions
 
@@ -258,10 +685,241 @@ var descMacaroonBlesser = rpc.InterfaceDesc{
 	},
 }
 
+// BlessingAuditLogClientMethods is the client interface
+// containing BlessingAuditLog methods.
+//
+// BlessingAuditLog provides access to the log of the blessings granted by an
+// identity provider.
+type BlessingAuditLogClientMethods interface {
+	// Query streams the entries of the log selected by query.
+	Query(_ *context.T, query BlessingAuditQuery, _ ...rpc.CallOpt) (BlessingAuditLogQueryClientCall, error)
+}
+
+// BlessingAuditLogClientStub adds universal methods to BlessingAuditLogClientMethods.
+type BlessingAuditLogClientStub interface {
+	BlessingAuditLogClientMethods
+	rpc.UniversalServiceMethods
+}
+
+// BlessingAuditLogClient returns a client stub for BlessingAuditLog.
+func BlessingAuditLogClient(name string) BlessingAuditLogClientStub {
+	return implBlessingAuditLogClientStub{name}
+}
+
+type implBlessingAuditLogClientStub struct {
+	name string
+}
+
+func (c implBlessingAuditLogClientStub) Query(ctx *context.T, i0 BlessingAuditQuery, opts ...rpc.CallOpt) (ocall BlessingAuditLogQueryClientCall, err error) {
+	var call rpc.ClientCall
+	if call, err = v23.GetClient(ctx).StartCall(ctx, c.name, "Query", []interface{}{i0}, opts...); err != nil {
+		return
+	}
+	ocall = &implBlessingAuditLogQueryClientCall{ClientCall: call}
+	return
+}
+
+// BlessingAuditLogQueryClientStream is the client stream for BlessingAuditLog.Query.
+type BlessingAuditLogQueryClientStream interface {
+	// RecvStream returns the receiver side of the BlessingAuditLog.Query client stream.
+	RecvStream() interface {
+		// Advance stages an item so that it may be retrieved via Value.  Returns
+		// true iff there is an item to retrieve.  Advance must be called before
+		// Value is called.  May block if an item is not available.
+		Advance() bool
+		// Value returns the item that was staged by Advance.  May panic if Advance
+		// returned false or was not called.  Never blocks.
+		Value() BlessingAuditEntry
+		// Err returns any error encountered by Advance.  Never blocks.
+		Err() error
+	}
+}
+
+// BlessingAuditLogQueryClientCall represents the call returned from BlessingAuditLog.Query.
+type BlessingAuditLogQueryClientCall interface {
+	BlessingAuditLogQueryClientStream
+	// Finish blocks until the server is done, and returns the positional return
+	// values for call.
+	//
+	// Finish returns immediately if the call has been canceled; depending on the
+	// timing the output could either be an error signaling cancelation, or the
+	// valid positional return values from the server.
+	//
+	// Calling Finish is mandatory for releasing stream resources, unless the call
+	// has been canceled or any of the other methods return an error.  Finish should
+	// be called at most once.
+	Finish() error
+}
+
+type implBlessingAuditLogQueryClientCall struct {
+	rpc.ClientCall
+	valRecv BlessingAuditEntry
+	errRecv error
+}
+
+func (c *implBlessingAuditLogQueryClientCall) RecvStream() interface {
+	Advance() bool
+	Value() BlessingAuditEntry
+	Err() error
+} {
+	return implBlessingAuditLogQueryClientCallRecv{c}
+}
+
+type implBlessingAuditLogQueryClientCallRecv struct {
+	c *implBlessingAuditLogQueryClientCall
+}
+
+func (c implBlessingAuditLogQueryClientCallRecv) Advance() bool {
+	c.c.valRecv = BlessingAuditEntry{}
+	c.c.errRecv = c.c.Recv(&c.c.valRecv)
+	return c.c.errRecv == nil
+}
+func (c implBlessingAuditLogQueryClientCallRecv) Value() BlessingAuditEntry {
+	return c.c.valRecv
+}
+func (c implBlessingAuditLogQueryClientCallRecv) Err() error {
+	if c.c.errRecv == io.EOF {
+		return nil
+	}
+	return c.c.errRecv
+}
+func (c *implBlessingAuditLogQueryClientCall) Finish() (err error) {
+	err = c.ClientCall.Finish()
+	return
+}
+
+// BlessingAuditLogServerMethods is the interface a server writer
+// implements for BlessingAuditLog.
+//
+// BlessingAuditLog provides access to the log of the blessings granted by an
+// identity provider.
+type BlessingAuditLogServerMethods interface {
+	// Query streams the entries of the log selected by query.
+	Query(_ *context.T, _ BlessingAuditLogQueryServerCall, query BlessingAuditQuery) error
+}
+
+// BlessingAuditLogServerStubMethods is the server interface containing
+// BlessingAuditLog methods, as expected by rpc.Server.
+// The only difference between this interface and BlessingAuditLogServerMethods
+// is the streaming methods.
+type BlessingAuditLogServerStubMethods interface {
+	// Query streams the entries of the log selected by query.
+	Query(_ *context.T, _ *BlessingAuditLogQueryServerCallStub, query BlessingAuditQuery) error
+}
+
+// BlessingAuditLogServerStub adds universal methods to BlessingAuditLogServerStubMethods.
+type BlessingAuditLogServerStub interface {
+	BlessingAuditLogServerStubMethods
+	// Describe the BlessingAuditLog interfaces.
+	Describe__() []rpc.InterfaceDesc
+}
+
+// BlessingAuditLogServer returns a server stub for BlessingAuditLog.
+// It converts an implementation of BlessingAuditLogServerMethods into
+// an object that may be used by rpc.Server.
+func BlessingAuditLogServer(impl BlessingAuditLogServerMethods) BlessingAuditLogServerStub {
+	stub := implBlessingAuditLogServerStub{
+		impl: impl,
+	}
+	// Initialize GlobState; always check the stub itself first, to handle the
+	// case where the user has the Glob method defined in their VDL source.
+	if gs := rpc.NewGlobState(stub); gs != nil {
+		stub.gs = gs
+	} else if gs := rpc.NewGlobState(impl); gs != nil {
+		stub.gs = gs
+	}
+	return stub
+}
+
+type implBlessingAuditLogServerStub struct {
+	impl BlessingAuditLogServerMethods
+	gs   *rpc.GlobState
+}
+
+func (s implBlessingAuditLogServerStub) Query(ctx *context.T, call *BlessingAuditLogQueryServerCallStub, i0 BlessingAuditQuery) error {
+	return s.impl.Query(ctx, call, i0)
+}
+
+func (s implBlessingAuditLogServerStub) Globber() *rpc.GlobState {
+	return s.gs
+}
+
+func (s implBlessingAuditLogServerStub) Describe__() []rpc.InterfaceDesc {
+	return []rpc.InterfaceDesc{BlessingAuditLogDesc}
+}
+
+// BlessingAuditLogDesc describes the BlessingAuditLog interface.
+var BlessingAuditLogDesc rpc.InterfaceDesc = descBlessingAuditLog
+
+// descBlessingAuditLog hides the desc to keep godoc clean.
+var descBlessingAuditLog = rpc.InterfaceDesc{
+	Name:    "BlessingAuditLog",
+	PkgPath: "v.io/x/ref/services/identity",
+	Doc:     "// BlessingAuditLog provides access to the log of the blessings granted by an\n// identity provider.",
+	Methods: []rpc.MethodDesc{
+		{
+			Name: "Query",
+			Doc:  "// Query streams the entries of the log selected by query.",
+			InArgs: []rpc.ArgDesc{
+				{"query", ``}, // BlessingAuditQuery
+			},
+			Tags: []*vdl.Value{vdl.ValueOf(access.Tag("Read"))},
+		},
+	},
+}
+
+// BlessingAuditLogQueryServerStream is the server stream for BlessingAuditLog.Query.
+type BlessingAuditLogQueryServerStream interface {
+	// SendStream returns the send side of the BlessingAuditLog.Query server stream.
+	SendStream() interface {
+		// Send places the item onto the output stream.  Returns errors encountered
+		// while sending.  Blocks if there is no buffer space; will unblock when
+		// buffer space is available.
+		Send(item BlessingAuditEntry) error
+	}
+}
+
+// BlessingAuditLogQueryServerCall represents the context passed to BlessingAuditLog.Query.
+type BlessingAuditLogQueryServerCall interface {
+	rpc.ServerCall
+	BlessingAuditLogQueryServerStream
+}
+
+// BlessingAuditLogQueryServerCallStub is a wrapper that converts rpc.StreamServerCall into
+// a typesafe stub that implements BlessingAuditLogQueryServerCall.
+type BlessingAuditLogQueryServerCallStub struct {
+	rpc.StreamServerCall
+}
+
+// Init initializes BlessingAuditLogQueryServerCallStub from rpc.StreamServerCall.
+func (s *BlessingAuditLogQueryServerCallStub) Init(call rpc.StreamServerCall) {
+	s.StreamServerCall = call
+}
+
+// SendStream returns the send side of the BlessingAuditLog.Query server stream.
+func (s *BlessingAuditLogQueryServerCallStub) SendStream() interface {
+	Send(item BlessingAuditEntry) error
+} {
+	return implBlessingAuditLogQueryServerCallSend{s}
+}
+
+type implBlessingAuditLogQueryServerCallSend struct {
+	s *BlessingAuditLogQueryServerCallStub
+}
+
+func (s implBlessingAuditLogQueryServerCallSend) Send(item BlessingAuditEntry) error {
+	return s.s.Send(item)
+}
+
 // Hold type definitions in package-level variables, for better performance.
 var (
 	__VDLType_struct_1 *vdl.Type
 	__VDLType_list_2   *vdl.Type
+	__VDLType_enum_3   *vdl.Type
+	__VDLType_struct_4 *vdl.Type
+	__VDLType_struct_5 *vdl.Type
+	__VDLType_string_6 *vdl.Type
+	__VDLType_struct_7 *vdl.Type
 )
 
 var __VDLInitCalled bool
@@ -287,10 +945,18 @@ func __VDLInit() struct{} {
 
 	// Register types.
 	vdl.Register((*BlessingRootResponse)(nil))
+	vdl.Register((*RevocationFilter)(nil))
+	vdl.Register((*BlessingAuditQuery)(nil))
+	vdl.Register((*BlessingAuditEntry)(nil))
 
 	// Initialize type definitions.
 	__VDLType_struct_1 = vdl.TypeOf((*BlessingRootResponse)(nil)).Elem()
 	__VDLType_list_2 = vdl.TypeOf((*[]string)(nil))
+	__VDLType_enum_3 = vdl.TypeOf((*RevocationFilter)(nil))
+	__VDLType_struct_4 = vdl.TypeOf((*BlessingAuditQuery)(nil)).Elem()
+	__VDLType_struct_5 = vdl.TypeOf((*vdltime.Time)(nil)).Elem()
+	__VDLType_string_6 = vdl.TypeOf((*security.BlessingPattern)(nil))
+	__VDLType_struct_7 = vdl.TypeOf((*BlessingAuditEntry)(nil)).Elem()
 
 	return struct{}{}
 }
@@ -0,0 +1,184 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/security"
+	"v.io/x/lib/cmdline"
+	"v.io/x/ref/lib/v23cmd"
+	"v.io/x/ref/services/identity"
+)
+
+var (
+	flagAuditEmail, flagAuditStart, flagAuditEnd string
+	flagAuditPattern, flagAuditCaveatType        string
+	flagAuditRevocation                          identity.RevocationFilter
+	flagAuditFormat                              string
+)
+
+func init() {
+	cmdAuditLog.Flags.StringVar(&flagAuditEmail, "email", "", "If provided, only the blessings granted to this user are listed.")
+	cmdAuditLog.Flags.StringVar(&flagAuditStart, "start", "", "If provided, only the blessings granted at or after this time, in RFC 3339 format, are listed.")
+	cmdAuditLog.Flags.StringVar(&flagAuditEnd, "end", "", "If provided, only the blessings granted before this time, in RFC 3339 format, are listed.")
+	cmdAuditLog.Flags.StringVar(&flagAuditPattern, "pattern", "", "If provided, only the blessings with a name matched by this blessing pattern are listed.")
+	cmdAuditLog.Flags.StringVar(&flagAuditCaveatType, "caveat-type", "", "If provided, only the blessings with a caveat of this type are listed.  One of 'Expiry', 'Method', 'PeerBlessings', 'Revocation' or the hex-encoded id of a caveat descriptor.")
+	cmdAuditLog.Flags.Var(&flagAuditRevocation, "revocation", "Lists the blessings with this revocation status: 'Any', 'Revoked' or 'NotRevoked'.")
+	cmdAuditLog.Flags.StringVar(&flagAuditFormat, "format", "json", "The format of the output, 'json' or 'csv'.")
+}
+
+var cmdAuditLog = &cmdline.Command{
+	Runner:   v23cmd.RunnerFunc(runAuditLog),
+	Name:     "auditlog",
+	Short:    "Queries the blessing audit log of an identity server",
+	ArgsName: "<name>",
+	ArgsLong: `
+<name> is the object name of the audit log of the identity server, e.g.
+identity/dev.v.io:u/auditlog.
+`,
+	Long: `
+Queries the log of the blessings granted by an identity server, and writes the
+selected entries to STDOUT as a JSON array or as CSV.
+
+The audit log is served by identity servers started with -audit-log-readers,
+to the principals with a blessing matched by one of its patterns.
+`,
+}
+
+// auditLogCSVHeader is the first record of the CSV output.
+var auditLogCSVHeader = []string{"Email", "Timestamp", "Blessings", "Caveats", "RevocationCaveatId", "RevocationTime"}
+
+func runAuditLog(ctx *context.T, env *cmdline.Env, args []string) error {
+	if expected, got := 1, len(args); expected != got {
+		return env.UsageErrorf("auditlog: incorrect number of arguments, expected %d, got %d", expected, got)
+	}
+	query := identity.BlessingAuditQuery{
+		Email:      flagAuditEmail,
+		Pattern:    security.BlessingPattern(flagAuditPattern),
+		CaveatType: flagAuditCaveatType,
+		Revocation: flagAuditRevocation,
+	}
+	var err error
+	if query.Start, err = parseAuditTime(flagAuditStart); err != nil {
+		return env.UsageErrorf("auditlog: invalid -start: %v", err)
+	}
+	if query.End, err = parseAuditTime(flagAuditEnd); err != nil {
+		return env.UsageErrorf("auditlog: invalid -end: %v", err)
+	}
+	var w auditLogWriter
+	switch flagAuditFormat {
+	case "json":
+		w = &jsonAuditLogWriter{w: env.Stdout}
+	case "csv":
+		w = &csvAuditLogWriter{w: csv.NewWriter(env.Stdout)}
+	default:
+		return env.UsageErrorf("auditlog: unsupported -format %q", flagAuditFormat)
+	}
+
+	call, err := identity.BlessingAuditLogClient(args[0]).Query(ctx, query)
+	if err != nil {
+		return err
+	}
+	stream := call.RecvStream()
+	for stream.Advance() {
+		if err := w.write(stream.Value()); err != nil {
+			return err
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return err
+	}
+	if err := call.Finish(); err != nil {
+		return err
+	}
+	return w.close()
+}
+
+func parseAuditTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// auditLogWriter writes blessing audit log entries in some format.
+type auditLogWriter interface {
+	write(entry identity.BlessingAuditEntry) error
+	close() error
+}
+
+// jsonAuditLogWriter writes the entries as a JSON array.
+type jsonAuditLogWriter struct {
+	w       io.Writer
+	entries int
+}
+
+func (j *jsonAuditLogWriter) write(entry identity.BlessingAuditEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if j.entries == 0 {
+		sep = "[\n"
+	}
+	j.entries++
+	_, err = fmt.Fprintf(j.w, "%s%s", sep, b)
+	return err
+}
+
+func (j *jsonAuditLogWriter) close() error {
+	if j.entries == 0 {
+		_, err := fmt.Fprintln(j.w, "[]")
+		return err
+	}
+	_, err := fmt.Fprintln(j.w, "\n]")
+	return err
+}
+
+// csvAuditLogWriter writes the entries as CSV, preceded by a header.  The
+// names of the blessings and the caveats are separated by semicolons.
+type csvAuditLogWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (c *csvAuditLogWriter) write(entry identity.BlessingAuditEntry) error {
+	if !c.header {
+		if err := c.w.Write(auditLogCSVHeader); err != nil {
+			return err
+		}
+		c.header = true
+	}
+	var revocationTime string
+	if !entry.RevocationTime.IsZero() {
+		revocationTime = entry.RevocationTime.Format(time.RFC3339)
+	}
+	return c.w.Write([]string{
+		entry.Email,
+		entry.Timestamp.Format(time.RFC3339),
+		strings.Join(entry.Blessings, ";"),
+		strings.Join(entry.Caveats, ";"),
+		entry.RevocationCaveatId,
+		revocationTime,
+	})
+}
+
+func (c *csvAuditLogWriter) close() error {
+	if !c.header {
+		if err := c.w.Write(auditLogCSVHeader); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
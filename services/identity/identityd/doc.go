@@ -21,11 +21,36 @@ Google Developer Console at https://cloud.google.com/console
 More details on Google OAuth at:
   https://developers.google.com/accounts/docs/OAuth2Login
 
+To use any other OpenID Connect provider, e.g. a corporate identity provider,
+the -oidc-config flag must be set to point to a JSON file of the form:
+  {
+    "Issuer": "https://idp.example.com",
+    "ClientID": "...",
+    "ClientSecret": "...",
+    "Scopes": ["email", "profile"],
+    "BlessingName": "{{.preferred_username}}",
+    "AllowUnverifiedEmail": false
+  }
+The provider's endpoints and keys are found through its discovery document.
+BlessingName is a Go template executed on the claims of the user's ID token
+that produces the name in the user's blessings; it defaults to "{{.email}}".
+Users whose email_verified claim is not true are rejected unless
+AllowUnverifiedEmail is set.
+
+The log of the blessings granted can be queried over RPC by the principals
+with a blessing matched by the -audit-log-readers flag, e.g. with the auditlog
+command.
+
 More details on the design of identityd at:
   https://vanadium.github.io/designdocs/identity-service.html
 
 Usage:
    identityd [flags]
+   identityd [flags] <command>
+
+The identityd commands are:
+   auditlog    Queries the blessing audit log of an identity server
+   help        Display help for commands or topics
 
 The identityd flags are:
  -app-blessings=
@@ -34,6 +59,16 @@ The identityd flags are:
    (i.e., a user using a specific app)
  -assets-prefix=
    Host serving the web assets for the identity server.
+ -audit-log-readers=
+   Comma-separated list of blessing patterns of the principals allowed to query
+   the blessing audit log over RPC, e.g. with the auditlog command.  If empty,
+   the audit log is not served over RPC.
+ -discharge-validity=0s
+   Duration for which discharges of revocation caveats are valid.  Discharges
+   valid for more than 24 hours are only accepted, after 24 hours, by verifiers
+   holding a recent snapshot of the revocations, e.g. fetched with the
+   --v23.revocation.snapshots flag from the revocations service that this server
+   publishes next to the discharger.  If 0, discharges are valid for 24 hours.
  -discharger-location=
    The name of the discharger service. May be rooted. If empty, the published
    name is used.
@@ -47,6 +82,9 @@ The identityd flags are:
    Address on which the HTTP server listens on.
  -mount-prefix=identity
    Mount name prefix to use.  May be rooted.
+ -oidc-config=
+   Path to JSON-encoded OpenID Connect provider configuration.  If provided,
+   the provider is used instead of Google.
  -registered-apps=
    Path to the config file for registered oauth clients.
  -sql-config=
@@ -63,6 +101,13 @@ The identityd flags are:
       }
    Paths must be either absolute or relative to the configuration file
    directory.
+ -store=
+   Specification of the storage used to persist blessings for auditing and
+   revocation, instead of -sql-config. Format: <engine>:<parameters>, where
+   <engine> can be 'sqlconfig', 'sqlite3', 'leveldb' or 'memstore'. For
+   'sqlconfig', <parameters> is the path to a MySQL configuration file as for
+   -sql-config, for 'sqlite3' the path to the database file, for 'leveldb' the
+   path to the database directory, and for 'memstore' it is ignored.
  -tls-config=
    Comma-separated list of TLS certificate and private key files, in that order.
    This must be provided.
@@ -103,6 +148,14 @@ The global flags are:
  -v23.proxy=
    object name of proxy service to use to export services across network
    boundaries
+ -v23.revocation.max-staleness=24h0m0s
+   Maximum age of the revocation snapshots against which discharges past their
+   freshness are validated.
+ -v23.revocation.refresh=1h0m0s
+   Interval at which revocation snapshots are fetched.
+ -v23.revocation.snapshots=
+   Names of the revocation list services to fetch revocation snapshots from,
+   comma separated.
  -v23.tcp.address=
    address to listen on
  -v23.tcp.protocol=wsh
@@ -126,5 +179,67 @@ The global flags are:
    comma-separated list of regexppattern=N settings for file pathname-filtered
    logging (without the .go suffix).  E.g. foo/bar/baz.go is matched by patterns
    foo/bar/baz or fo.*az or oo/ba or b.z but not by foo/bar/baz.go or fo*az
+
+Identityd auditlog - Queries the blessing audit log of an identity server
+
+Queries the log of the blessings granted by an identity server, and writes the
+selected entries to STDOUT as a JSON array or as CSV.
+
+The audit log is served by identity servers started with -audit-log-readers,
+to the principals with a blessing matched by one of its patterns.
+
+Usage:
+   identityd auditlog [flags] <name>
+
+<name> is the object name of the audit log of the identity server, e.g.
+identity/dev.v.io:u/auditlog.
+
+The identityd auditlog flags are:
+ -caveat-type=
+   If provided, only the blessings with a caveat of this type are listed.  One
+   of 'Expiry', 'Method', 'PeerBlessings', 'Revocation' or the hex-encoded id of
+   a caveat descriptor.
+ -email=
+   If provided, only the blessings granted to this user are listed.
+ -end=
+   If provided, only the blessings granted before this time, in RFC 3339
+   format, are listed.
+ -format=json
+   The format of the output, 'json' or 'csv'.
+ -pattern=
+   If provided, only the blessings with a name matched by this blessing pattern
+   are listed.
+ -revocation=Any
+   Lists the blessings with this revocation status: 'Any', 'Revoked' or
+   'NotRevoked'.
+ -start=
+   If provided, only the blessings granted at or after this time, in RFC 3339
+   format, are listed.
+
+Identityd help - Display help for commands or topics
+
+Help with no args displays the usage of the parent command.
+
+Help with args displays the usage of the specified sub-command or help topic.
+
+"help ..." recursively displays help for all commands and topics.
+
+Usage:
+   identityd help [flags] [command/topic ...]
+
+[command/topic ...] optionally identifies a specific sub-command or help topic.
+
+The identityd help flags are:
+ -style=compact
+   The formatting style for help output:
+      compact   - Good for compact cmdline output.
+      full      - Good for cmdline output, shows all global flags.
+      godoc     - Good for godoc processing.
+      shortonly - Only output short description.
+   Override the default by setting the CMDLINE_STYLE environment variable.
+ -width=<terminal width>
+   Format output to this target width in runes, or unlimited if width < 0.
+   Defaults to the terminal width if available.  Override the default by setting
+   the CMDLINE_WIDTH environment variable.
 */
 package main
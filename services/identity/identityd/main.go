@@ -9,12 +9,13 @@ package main
 
 import (
 	"bytes"
-	"database/sql"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
+	"time"
 
 	"v.io/v23"
 	"v.io/v23/context"
@@ -25,29 +26,31 @@ import (
 	vsecurity "v.io/x/ref/lib/security"
 	"v.io/x/ref/lib/v23cmd"
 	_ "v.io/x/ref/runtime/factories/roaming"
-	"v.io/x/ref/services/identity/internal/auditor"
 	"v.io/x/ref/services/identity/internal/caveats"
 	"v.io/x/ref/services/identity/internal/handlers"
 	"v.io/x/ref/services/identity/internal/oauth"
-	"v.io/x/ref/services/identity/internal/revocation"
 	"v.io/x/ref/services/identity/internal/server"
 	"v.io/x/ref/services/internal/restsigner"
 )
 
 var (
-	googleConfigWeb                                                  string
+	googleConfigWeb, oidcConfig                                      string
 	externalHttpAddr, httpAddr, tlsConfig, assetsPrefix, mountPrefix string
 	dischargerLocation                                               string
 	remoteSignerBlessingsDir                                         string
 	oauthRemoteSignerBlessingsDir                                    string
-	sqlConf                                                          string
+	sqlConf, storeSpec                                               string
 	registeredAppConfig                                              string
 	userBlessings, appBlessings                                      string
+	auditLogReaders                                                  string
+	dischargeValidity                                                time.Duration
 )
 
 func init() {
 	// Configuration for various Google OAuth-based clients.
 	cmdIdentityD.Flags.StringVar(&googleConfigWeb, "google-config-web", "", "Path to JSON-encoded OAuth client configuration for the web application that renders the audit log for blessings provided by this provider.")
+	// Configuration for a generic OpenID Connect provider.
+	cmdIdentityD.Flags.StringVar(&oidcConfig, "oidc-config", "", "Path to JSON-encoded OpenID Connect provider configuration.  If provided, the provider is used instead of Google.")
 
 	// Configuration using the remote signer
 	cmdIdentityD.Flags.StringVar(&userBlessings, "user-blessings", "", "Path to a file containing base64url-vom encoded blessings that will be extended with the username of the requestor.")
@@ -63,7 +66,10 @@ func init() {
 
 	// Flag controlling auditing and revocation of Blessing operations
 	cmdIdentityD.Flags.StringVar(&sqlConf, "sql-config", "", "Path to configuration file for MySQL database connection. Database is used to persist blessings for auditing and revocation. "+dbutil.SqlConfigFileDescription)
+	cmdIdentityD.Flags.StringVar(&storeSpec, "store", "", "Specification of the storage used to persist blessings for auditing and revocation, instead of -sql-config. Format: <engine>:<parameters>, where <engine> can be 'sqlconfig', 'sqlite3', 'leveldb' or 'memstore'. For 'sqlconfig', <parameters> is the path to a MySQL configuration file as for -sql-config, for 'sqlite3' the path to the database file, for 'leveldb' the path to the database directory, and for 'memstore' it is ignored.")
+	cmdIdentityD.Flags.StringVar(&auditLogReaders, "audit-log-readers", "", "Comma-separated list of blessing patterns of the principals allowed to query the blessing audit log over RPC, e.g. with the auditlog command.  If empty, the audit log is not served over RPC.")
 	cmdIdentityD.Flags.StringVar(&dischargerLocation, "discharger-location", "", "The name of the discharger service. May be rooted. If empty, the published name is used.")
+	cmdIdentityD.Flags.DurationVar(&dischargeValidity, "discharge-validity", 0, "Duration for which discharges of revocation caveats are valid.  Discharges valid for more than 24 hours are only accepted, after 24 hours, by verifiers holding a recent snapshot of the revocations, e.g. fetched with the --v23.revocation.snapshots flag from the revocations service that this server publishes next to the discharger.  If 0, discharges are valid for 24 hours.")
 }
 
 func main() {
@@ -72,9 +78,10 @@ func main() {
 }
 
 var cmdIdentityD = &cmdline.Command{
-	Runner: v23cmd.RunnerFunc(runIdentityD),
-	Name:   "identityd",
-	Short:  "Runs HTTP server that creates security.Blessings objects",
+	Runner:   v23cmd.RunnerFunc(runIdentityD),
+	Name:     "identityd",
+	Short:    "Runs HTTP server that creates security.Blessings objects",
+	Children: []*cmdline.Command{cmdAuditLog},
 	Long: `
 Command identityd runs a daemon HTTP server that uses OAuth to create
 security.Blessings objects.
@@ -91,20 +98,33 @@ Google Developer Console at https://cloud.google.com/console
 More details on Google OAuth at:
   https://developers.google.com/accounts/docs/OAuth2Login
 
+To use any other OpenID Connect provider, e.g. a corporate identity provider,
+the -oidc-config flag must be set to point to a JSON file of the form:
+  {
+    "Issuer": "https://idp.example.com",
+    "ClientID": "...",
+    "ClientSecret": "...",
+    "Scopes": ["email", "profile"],
+    "BlessingName": "{{.preferred_username}}",
+    "AllowUnverifiedEmail": false
+  }
+The provider's endpoints and keys are found through its discovery document.
+BlessingName is a Go template executed on the claims of the user's ID token
+that produces the name in the user's blessings; it defaults to "{{.email}}".
+Users whose email_verified claim is not true are rejected unless
+AllowUnverifiedEmail is set.
+
+The log of the blessings granted can be queried over RPC by the principals
+with a blessing matched by the -audit-log-readers flag, e.g. with the auditlog
+command.
+
 More details on the design of identityd at:
   https://vanadium.github.io/designdocs/identity-service.html
 `,
 }
 
 func runIdentityD(ctx *context.T, env *cmdline.Env, args []string) error {
-	var sqlDB *sql.DB
 	var err error
-	if sqlConf != "" {
-		if sqlDB, err = dbutil.NewSqlDBConnFromFile(sqlConf, "SERIALIZABLE"); err != nil {
-			return env.UsageErrorf("Failed to create sqlDB: %v", err)
-		}
-	}
-
 	if ctx, err = initRemoteSigner(ctx, userBlessings); err != nil {
 		return err
 	}
@@ -119,23 +139,22 @@ func runIdentityD(ctx *context.T, env *cmdline.Env, args []string) error {
 		return err
 	}
 
-	googleoauth, err := oauth.NewGoogleOAuth(ctx, googleConfigWeb)
-	if err != nil {
+	var oauthProvider oauth.OAuthProvider
+	if oidcConfig != "" {
+		if oauthProvider, err = oauth.NewOIDCOAuth(ctx, oidcConfig); err != nil {
+			return env.UsageErrorf("Failed to setup OpenID Connect provider: %v", err)
+		}
+	} else if oauthProvider, err = oauth.NewGoogleOAuth(ctx, googleConfigWeb); err != nil {
 		return env.UsageErrorf("Failed to setup GoogleOAuth: %v", err)
 	}
 
-	auditor, reader, err := auditor.NewSQLBlessingAuditor(ctx, sqlDB)
+	auditor, reader, revocationManager, err := openStorage(ctx)
 	if err != nil {
-		return fmt.Errorf("Failed to create sql auditor from config: %v", err)
-	}
-
-	revocationManager, err := revocation.NewRevocationManager(ctx, sqlDB)
-	if err != nil {
-		return fmt.Errorf("Failed to start RevocationManager: %v", err)
+		return env.UsageErrorf("%v", err)
 	}
 
 	s := server.NewIdentityServer(
-		googleoauth,
+		oauthProvider,
 		auditor,
 		reader,
 		revocationManager,
@@ -143,11 +162,24 @@ func runIdentityD(ctx *context.T, env *cmdline.Env, args []string) error {
 		assetsPrefix,
 		mountPrefix,
 		dischargerLocation,
-		registeredApps)
+		registeredApps,
+		readerPatterns(auditLogReaders),
+		dischargeValidity)
 	s.Serve(ctx, oauthCtx, externalHttpAddr, httpAddr, tlsConfig)
 	return nil
 }
 
+// readerPatterns returns the blessing patterns in the comma-separated list.
+func readerPatterns(list string) []security.BlessingPattern {
+	var patterns []security.BlessingPattern
+	for _, p := range strings.Split(list, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, security.BlessingPattern(p))
+		}
+	}
+	return patterns
+}
+
 func initRemoteSigner(ctx *context.T, blessings string) (*context.T, error) {
 	if len(blessings) == 0 {
 		return ctx, nil
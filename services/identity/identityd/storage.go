@@ -0,0 +1,88 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"v.io/v23/context"
+	"v.io/x/lib/dbutil"
+	"v.io/x/ref/lib/security/audit"
+	"v.io/x/ref/services/identity/internal/auditor"
+	"v.io/x/ref/services/identity/internal/revocation"
+	"v.io/x/ref/services/syncbase/store"
+	"v.io/x/ref/services/syncbase/store/leveldb"
+	"v.io/x/ref/services/syncbase/store/memstore"
+)
+
+// openStorage opens the storage for auditing and revocation specified by
+// -store, or by -sql-config if -store is not set.
+func openStorage(ctx *context.T) (audit.Auditor, auditor.BlessingLogReader, revocation.RevocationManager, error) {
+	spec := storeSpec
+	if spec == "" {
+		if sqlConf == "" {
+			return nil, nil, nil, fmt.Errorf("one of -store or -sql-config must be provided")
+		}
+		spec = "sqlconfig:" + sqlConf
+	}
+	pos := strings.Index(spec, ":")
+	if pos < 0 {
+		return nil, nil, nil, fmt.Errorf("invalid -store %q, must be in the format <engine>:<parameters>", spec)
+	}
+	engine, params := spec[:pos], spec[pos+1:]
+	switch engine {
+	case "sqlconfig":
+		sqlDB, err := dbutil.NewSqlDBConnFromFile(params, "SERIALIZABLE")
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("Failed to create sqlDB: %v", err)
+		}
+		a, r, err := auditor.NewSQLBlessingAuditor(ctx, sqlDB)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("Failed to create sql auditor from config: %v", err)
+		}
+		m, err := revocation.NewRevocationManager(ctx, sqlDB)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("Failed to start RevocationManager: %v", err)
+		}
+		return a, r, m, nil
+	case "sqlite3":
+		sqlDB, err := sql.Open("sqlite3", params)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("Failed to open %q: %v", params, err)
+		}
+		// SQLite does not support concurrent writers.
+		sqlDB.SetMaxOpenConns(1)
+		a, r, err := auditor.NewSQLiteBlessingAuditor(ctx, sqlDB)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("Failed to create sqlite auditor: %v", err)
+		}
+		m, err := revocation.NewSQLiteRevocationManager(ctx, sqlDB)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("Failed to start RevocationManager: %v", err)
+		}
+		return a, r, m, nil
+	case "leveldb", "memstore":
+		var st store.Store
+		if engine == "memstore" {
+			st = memstore.New()
+		} else {
+			var err error
+			if st, err = leveldb.Open(params, leveldb.OpenOptions{CreateIfMissing: true}); err != nil {
+				return nil, nil, nil, fmt.Errorf("Failed to open %q: %v", params, err)
+			}
+		}
+		a, r := auditor.NewStoreBlessingAuditor(ctx, st)
+		m, err := revocation.NewStoreRevocationManager(ctx, st)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("Failed to start RevocationManager: %v", err)
+		}
+		return a, r, m, nil
+	}
+	return nil, nil, nil, fmt.Errorf("unsupported -store engine %q", engine)
+}
@@ -80,7 +80,9 @@ var TestIdentityd = gosh.RegisterFunc("TestIdentityd", func() error {
 		"",
 		"identity",
 		"",
-		nil)
+		nil,
+		nil,
+		0)
 
 	_, eps, externalHttpAddress := s.Listen(ctx, ctx, *externalHttpAddr, *httpAddr, *tlsConfig)
 
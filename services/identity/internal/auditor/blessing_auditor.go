@@ -14,12 +14,17 @@ import (
 	"v.io/v23/security"
 	"v.io/v23/vom"
 	"v.io/x/ref/lib/security/audit"
+	"v.io/x/ref/services/syncbase/store"
 )
 
 // BlessingLogReader provides the Read method to read audit logs.
 // Read returns a channel of BlessingEntrys whose extension matches the provided email.
+// ReadRange returns a channel of the BlessingEntrys of all emails whose
+// blessings were created in [start, end), newest first.  A zero start or end
+// leaves the range unbounded on that side.
 type BlessingLogReader interface {
 	Read(ctx *context.T, email string) <-chan BlessingEntry
+	ReadRange(ctx *context.T, start, end time.Time) <-chan BlessingEntry
 }
 
 // BlessingEntry contains important logged information about a blessed principal.
@@ -44,6 +49,24 @@ func NewSQLBlessingAuditor(ctx *context.T, sqlDB *sql.DB) (audit.Auditor, Blessi
 	return auditor, reader, nil
 }
 
+// NewSQLiteBlessingAuditor is like NewSQLBlessingAuditor, but for a SQLite
+// database, e.g. one opened with the "sqlite3" driver on a local file.
+func NewSQLiteBlessingAuditor(ctx *context.T, sqlDB *sql.DB) (audit.Auditor, BlessingLogReader, error) {
+	db, err := newSQLiteDatabase(ctx, sqlDB, "BlessingAudit")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create sqlite db: %v", err)
+	}
+	return &blessingAuditor{db}, &blessingLogReader{db}, nil
+}
+
+// NewStoreBlessingAuditor is like NewSQLBlessingAuditor, but persists the
+// audits in a syncbase storage engine, e.g. leveldb.  The store may be shared
+// with other users, e.g. the revocation manager.
+func NewStoreBlessingAuditor(ctx *context.T, st store.Store) (audit.Auditor, BlessingLogReader) {
+	db := newStoreDatabase(st, "BlessingAudit")
+	return &blessingAuditor{db}, &blessingLogReader{db}
+}
+
 type blessingAuditor struct {
 	db database
 }
@@ -65,13 +88,18 @@ type blessingLogReader struct {
 
 func (r *blessingLogReader) Read(ctx *context.T, email string) <-chan BlessingEntry {
 	c := make(chan BlessingEntry)
-	go r.sendAuditEvents(ctx, c, email)
+	go r.sendAuditEvents(ctx, c, r.db.Query(ctx, email))
+	return c
+}
+
+func (r *blessingLogReader) ReadRange(ctx *context.T, start, end time.Time) <-chan BlessingEntry {
+	c := make(chan BlessingEntry)
+	go r.sendAuditEvents(ctx, c, r.db.QueryRange(ctx, start, end))
 	return c
 }
 
-func (r *blessingLogReader) sendAuditEvents(ctx *context.T, dst chan<- BlessingEntry, email string) {
+func (r *blessingLogReader) sendAuditEvents(ctx *context.T, dst chan<- BlessingEntry, dbch <-chan databaseEntry) {
 	defer close(dst)
-	dbch := r.db.Query(ctx, email)
 	for dbentry := range dbch {
 		dst <- newBlessingEntry(dbentry)
 	}
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"v.io/v23/context"
 	"v.io/v23/security"
 	vsecurity "v.io/x/ref/lib/security"
 	"v.io/x/ref/lib/security/audit"
@@ -18,8 +19,14 @@ import (
 func TestBlessingAuditor(t *testing.T) {
 	ctx, cancel := test.TestContext()
 	defer cancel()
-	auditor, reader := NewMockBlessingAuditor()
+	testBlessingAuditor(t, ctx, func() (audit.Auditor, BlessingLogReader) {
+		return NewMockBlessingAuditor()
+	})
+}
 
+// testBlessingAuditor tests the auditors returned by newAuditor, which must
+// return an auditor of an empty database on every call.
+func testBlessingAuditor(t *testing.T, ctx *context.T, newAuditor func() (audit.Auditor, BlessingLogReader)) {
 	p, err := vsecurity.NewPrincipal()
 	if err != nil {
 		t.Fatalf("failed to create principal: %v", err)
@@ -57,6 +64,7 @@ func TestBlessingAuditor(t *testing.T) {
 	}
 
 	for _, test := range tests {
+		auditor, reader := newAuditor()
 		args := []interface{}{nil, nil, test.Extension}
 		for _, cav := range test.Caveats {
 			args = append(args, cav)
@@ -68,7 +76,7 @@ func TestBlessingAuditor(t *testing.T) {
 		}); err != nil {
 			t.Errorf("Failed to audit Blessing %v: %v", test.Blessings, err)
 		}
-		ch := reader.Read(ctx, "query")
+		ch := reader.Read(ctx, test.Email)
 		got := <-ch
 		if got.Email != test.Email {
 			t.Errorf("got %v, want %v", got.Email, test.Email)
@@ -6,6 +6,7 @@ package auditor
 
 import (
 	"reflect"
+	"time"
 
 	"v.io/v23/context"
 	"v.io/x/ref/lib/security/audit"
@@ -36,3 +37,16 @@ func (db *mockDatabase) Query(ctx *context.T, email string) <-chan databaseEntry
 	}()
 	return c
 }
+
+func (db *mockDatabase) QueryRange(ctx *context.T, start, end time.Time) <-chan databaseEntry {
+	c := make(chan databaseEntry)
+	go func() {
+		var empty databaseEntry
+		t := db.NextEntry.timestamp
+		if !reflect.DeepEqual(db.NextEntry, empty) && !t.Before(start) && (end.IsZero() || t.Before(end)) {
+			c <- db.NextEntry
+		}
+		close(c)
+	}()
+	return c
+}
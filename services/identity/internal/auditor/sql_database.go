@@ -15,8 +15,15 @@ import (
 type database interface {
 	Insert(ctx *context.T, entry databaseEntry) error
 	Query(ctx *context.T, email string) <-chan databaseEntry
+	// QueryRange returns the entries of all emails with a timestamp in
+	// [start, end), newest first.  A zero start or end leaves the range
+	// unbounded on that side.
+	QueryRange(ctx *context.T, start, end time.Time) <-chan databaseEntry
 }
 
+// maxTimestamp is used in place of a zero end of a range in SQL queries.
+var maxTimestamp = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+
 type databaseEntry struct {
 	email              string
 	caveats, blessings []byte
@@ -34,14 +41,40 @@ func newSQLDatabase(ctx *context.T, db *sql.DB, table string) (database, error)
 	if _, err = createStmt.Exec(); err != nil {
 		return nil, err
 	}
+	return prepareSQLDatabase(db, table)
+}
+
+// newSQLiteDatabase returns a SQLite implementation of the database
+// interface.  If the table does not exist it creates it.
+func newSQLiteDatabase(ctx *context.T, db *sql.DB, table string) (database, error) {
+	// SQLite does not support KEY clauses in CREATE TABLE, so the index is
+	// created separately.
+	for _, stmt := range []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s ( Email VARBINARY(256), Caveats BLOB, Timestamp DATETIME, Blessings BLOB );", table),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %sEmailTimestamp ON %s (Email, Timestamp);", table, table),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %sTimestamp ON %s (Timestamp);", table, table),
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+	return prepareSQLDatabase(db, table)
+}
+
+func prepareSQLDatabase(db *sql.DB, table string) (database, error) {
 	insertStmt, err := db.Prepare(fmt.Sprintf("INSERT INTO %s (Email, Caveats, Timestamp, Blessings) VALUES (?, ?, ?, ?)", table))
 	if err != nil {
 		return nil, err
 	}
 	queryStmt, err := db.Prepare(fmt.Sprintf("SELECT Email, Caveats, Timestamp, Blessings FROM %s WHERE Email=? ORDER BY Timestamp DESC", table))
+	if err != nil {
+		return nil, err
+	}
+	queryRangeStmt, err := db.Prepare(fmt.Sprintf("SELECT Email, Caveats, Timestamp, Blessings FROM %s WHERE Timestamp>=? AND Timestamp<? ORDER BY Timestamp DESC", table))
 	return sqlDatabase{
-		insertStmt: insertStmt,
-		queryStmt:  queryStmt,
+		insertStmt:     insertStmt,
+		queryStmt:      queryStmt,
+		queryRangeStmt: queryRangeStmt,
 	}, err
 }
 
@@ -51,8 +84,8 @@ func newSQLDatabase(ctx *context.T, db *sql.DB, table string) (database, error)
 // (3) Blessings = vom encoded resulting blessings.
 // (4) Timestamp = time that the blessing happened.
 type sqlDatabase struct {
-	insertStmt, queryStmt *sql.Stmt
-	ctx                   *context.T
+	insertStmt, queryStmt, queryRangeStmt *sql.Stmt
+	ctx                                   *context.T
 }
 
 func (s sqlDatabase) Insert(ctx *context.T, entry databaseEntry) error {
@@ -62,25 +95,41 @@ func (s sqlDatabase) Insert(ctx *context.T, entry databaseEntry) error {
 
 func (s sqlDatabase) Query(ctx *context.T, email string) <-chan databaseEntry {
 	c := make(chan databaseEntry)
-	go s.sendDatabaseEntries(ctx, email, c)
+	go s.sendDatabaseEntries(ctx, c, s.queryStmt, email)
+	return c
+}
+
+func (s sqlDatabase) QueryRange(ctx *context.T, start, end time.Time) <-chan databaseEntry {
+	if end.IsZero() {
+		end = maxTimestamp
+	}
+	c := make(chan databaseEntry)
+	go s.sendDatabaseEntries(ctx, c, s.queryRangeStmt, start, end)
 	return c
 }
 
-func (s sqlDatabase) sendDatabaseEntries(ctx *context.T, email string, dst chan<- databaseEntry) {
+func (s sqlDatabase) sendDatabaseEntries(ctx *context.T, dst chan<- databaseEntry, stmt *sql.Stmt, args ...interface{}) {
 	defer close(dst)
-	rows, err := s.queryStmt.Query(email)
+	rows, err := stmt.Query(args...)
 	if err != nil {
 		ctx.Errorf("query failed %v", err)
 		dst <- databaseEntry{decodeErr: fmt.Errorf("Failed to query for all audits: %v", err)}
 		return
 	}
-	defer rows.Close()
+	// Read all the rows before sending any of them, since the receiver may
+	// need the connection that the rows hold, e.g. to get the revocation
+	// times of the entries from a SQLite database with a single connection.
+	var dbentries []databaseEntry
 	for rows.Next() {
 		var dbentry databaseEntry
 		if err = rows.Scan(&dbentry.email, &dbentry.caveats, &dbentry.timestamp, &dbentry.blessings); err != nil {
 			ctx.Errorf("scan of row failed %v", err)
 			dbentry.decodeErr = fmt.Errorf("failed to read sql row, %s", err)
 		}
+		dbentries = append(dbentries, dbentry)
+	}
+	rows.Close()
+	for _, dbentry := range dbentries {
 		dst <- dbentry
 	}
 }
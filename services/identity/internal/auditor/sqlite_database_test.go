@@ -0,0 +1,127 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package auditor
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	vsecurity "v.io/x/ref/lib/security"
+	"v.io/x/ref/lib/security/audit"
+	_ "v.io/x/ref/runtime/factories/fake"
+	"v.io/x/ref/services/identity/internal/revocation"
+	"v.io/x/ref/test"
+)
+
+// newSQLiteDB returns an empty in-memory SQLite database.
+func newSQLiteDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open SQLite database: %v", err)
+	}
+	// Every connection to ":memory:" opens a different database.
+	db.SetMaxOpenConns(1)
+	return db
+}
+
+func newSQLiteTestDatabase(t *testing.T) database {
+	ctx, cancel := test.TestContext()
+	defer cancel()
+	d, err := newSQLiteDatabase(ctx, newSQLiteDB(t), "tableName")
+	if err != nil {
+		t.Fatalf("failed to create SQLite database: %v", err)
+	}
+	return d
+}
+
+func TestSQLiteDatabaseQuery(t *testing.T) {
+	ctx, cancel := test.TestContext()
+	defer cancel()
+	testDatabaseQuery(t, ctx, newSQLiteTestDatabase(t))
+}
+
+func TestSQLiteDatabaseQueryRange(t *testing.T) {
+	ctx, cancel := test.TestContext()
+	defer cancel()
+	testDatabaseQueryRange(t, ctx, newSQLiteTestDatabase(t))
+}
+
+func TestSQLiteBlessingAuditor(t *testing.T) {
+	ctx, cancel := test.TestContext()
+	defer cancel()
+	testBlessingAuditor(t, ctx, func() (audit.Auditor, BlessingLogReader) {
+		auditor, reader, err := NewSQLiteBlessingAuditor(ctx, newSQLiteDB(t))
+		if err != nil {
+			t.Fatalf("NewSQLiteBlessingAuditor failed: %v", err)
+		}
+		return auditor, reader
+	})
+}
+
+// TestSQLiteSharedWithRevocationManager reads the audit entries and their
+// revocation times through one database, as the identity server does.
+func TestSQLiteSharedWithRevocationManager(t *testing.T) {
+	ctx, cancel := test.TestContext()
+	defer cancel()
+
+	db := newSQLiteDB(t)
+	auditor, reader, err := NewSQLiteBlessingAuditor(ctx, db)
+	if err != nil {
+		t.Fatalf("NewSQLiteBlessingAuditor failed: %v", err)
+	}
+	m, err := revocation.NewSQLiteRevocationManager(ctx, db)
+	if err != nil {
+		t.Fatalf("NewSQLiteRevocationManager failed: %v", err)
+	}
+
+	p, err := vsecurity.NewPrincipal()
+	if err != nil {
+		t.Fatalf("failed to create principal: %v", err)
+	}
+	var ids []string
+	for _, ext := range []string{"foo@bar.com:a", "foo@bar.com:b"} {
+		cav, err := m.NewCaveat(p.PublicKey(), "location")
+		if err != nil {
+			t.Fatalf("NewCaveat failed: %v", err)
+		}
+		ids = append(ids, cav.ThirdPartyDetails().ID())
+		if err := auditor.Audit(ctx, audit.Entry{
+			Method:    "Bless",
+			Arguments: []interface{}{nil, nil, ext, cav},
+			Results:   []interface{}{newBlessing(t, p, "test:"+ext)},
+			Timestamp: time.Now(),
+		}); err != nil {
+			t.Fatalf("Audit failed: %v", err)
+		}
+	}
+	if err := m.Revoke(ids[0]); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+
+	done := make(chan map[string]bool)
+	go func() {
+		revoked := make(map[string]bool)
+		for entry := range reader.Read(ctx, "foo@bar.com") {
+			if entry.DecodeError != nil {
+				t.Errorf("failed to read entry: %v", entry.DecodeError)
+				continue
+			}
+			revoked[entry.RevocationCaveatID] = m.GetRevocationTime(entry.RevocationCaveatID) != nil
+		}
+		done <- revoked
+	}()
+	select {
+	case revoked := <-done:
+		if want := map[string]bool{ids[0]: true, ids[1]: false}; !reflect.DeepEqual(revoked, want) {
+			t.Errorf("got %v, want %v", revoked, want)
+		}
+	case <-time.After(time.Minute):
+		t.Fatalf("reading the entries and their revocation times is blocked")
+	}
+}
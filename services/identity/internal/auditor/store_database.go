@@ -0,0 +1,147 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package auditor
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/vom"
+	"v.io/x/ref/services/syncbase/store"
+)
+
+// storeEntry is the VOM-encoded value of a databaseEntry in a store.Store.
+type storeEntry struct {
+	Email              string
+	Caveats, Blessings []byte
+	Timestamp          time.Time
+}
+
+// storeDatabase is an implementation of the database interface on a syncbase
+// storage engine, e.g. leveldb.
+//
+// Entries are keyed by <prefix><email>\x00<inverted timestamp><nonce>, so that
+// the entries of an email are scanned newest first.  An index keyed by
+// <timePrefix><inverted timestamp><nonce> maps to the keys of the entries of
+// all emails, newest first.
+type storeDatabase struct {
+	st                 store.Store
+	prefix, timePrefix string
+}
+
+// newStoreDatabase returns a store.Store implementation of the database
+// interface.  All keys written start with table.
+func newStoreDatabase(st store.Store, table string) database {
+	return &storeDatabase{st: st, prefix: table + "/", timePrefix: table + "ByTime/"}
+}
+
+func (s *storeDatabase) emailPrefix(email string) string {
+	return s.prefix + email + "\x00"
+}
+
+// invertedTimestamp returns the key component that orders t newest first.
+func invertedTimestamp(t time.Time) string {
+	return fmt.Sprintf("%016x", uint64(math.MaxInt64-t.UnixNano()))
+}
+
+func (s *storeDatabase) Insert(ctx *context.T, entry databaseEntry) error {
+	var nonce [8]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return err
+	}
+	suffix := invertedTimestamp(entry.timestamp) + hex.EncodeToString(nonce[:])
+	key := s.emailPrefix(entry.email) + suffix
+	value := storeEntry{
+		Email:     entry.email,
+		Caveats:   entry.caveats,
+		Blessings: entry.blessings,
+		Timestamp: entry.timestamp,
+	}
+	return store.RunInTransaction(s.st, func(tx store.Transaction) error {
+		if err := store.Put(ctx, tx, key, value); err != nil {
+			return err
+		}
+		return store.Put(ctx, tx, s.timePrefix+suffix, key)
+	})
+}
+
+func (s *storeDatabase) Query(ctx *context.T, email string) <-chan databaseEntry {
+	c := make(chan databaseEntry)
+	go s.sendDatabaseEntries(ctx, email, c)
+	return c
+}
+
+func (s *storeDatabase) QueryRange(ctx *context.T, start, end time.Time) <-chan databaseEntry {
+	c := make(chan databaseEntry)
+	go s.sendDatabaseEntriesInRange(ctx, start, end, c)
+	return c
+}
+
+func (s *storeDatabase) sendDatabaseEntries(ctx *context.T, email string, dst chan<- databaseEntry) {
+	defer close(dst)
+	prefix := s.emailPrefix(email)
+	stream := s.st.Scan([]byte(prefix), []byte(prefix+"\xff"))
+	defer stream.Cancel()
+	for stream.Advance() {
+		dst <- decodeStoreEntry(ctx, stream.Value(nil))
+	}
+	if err := stream.Err(); err != nil {
+		ctx.Errorf("scan failed %v", err)
+		dst <- databaseEntry{decodeErr: fmt.Errorf("Failed to query for all audits: %v", err)}
+	}
+}
+
+func (s *storeDatabase) sendDatabaseEntriesInRange(ctx *context.T, start, end time.Time, dst chan<- databaseEntry) {
+	defer close(dst)
+	// Timestamps are inverted in the keys, so end bounds the first key of the
+	// scan and start the last one.  Within a timestamp, the keys are followed
+	// by a nonce, hence the "\xff" suffixes.
+	first, limit := s.timePrefix, s.timePrefix+"\xff"
+	if !end.IsZero() {
+		first = s.timePrefix + invertedTimestamp(end) + "\xff"
+	}
+	if !start.IsZero() {
+		limit = s.timePrefix + invertedTimestamp(start) + "\xff"
+	}
+	stream := s.st.Scan([]byte(first), []byte(limit))
+	defer stream.Cancel()
+	for stream.Advance() {
+		var key string
+		if err := vom.Decode(stream.Value(nil), &key); err != nil {
+			ctx.Errorf("decode of index entry failed %v", err)
+			dst <- databaseEntry{decodeErr: fmt.Errorf("failed to decode index entry, %s", err)}
+			continue
+		}
+		value, err := s.st.Get([]byte(key), nil)
+		if err != nil {
+			ctx.Errorf("get of entry %q failed %v", key, err)
+			dst <- databaseEntry{decodeErr: fmt.Errorf("failed to read entry, %s", err)}
+			continue
+		}
+		dst <- decodeStoreEntry(ctx, value)
+	}
+	if err := stream.Err(); err != nil {
+		ctx.Errorf("scan failed %v", err)
+		dst <- databaseEntry{decodeErr: fmt.Errorf("Failed to query for all audits: %v", err)}
+	}
+}
+
+func decodeStoreEntry(ctx *context.T, value []byte) databaseEntry {
+	var entry storeEntry
+	if err := vom.Decode(value, &entry); err != nil {
+		ctx.Errorf("decode of entry failed %v", err)
+		return databaseEntry{decodeErr: fmt.Errorf("failed to decode entry, %s", err)}
+	}
+	return databaseEntry{
+		email:     entry.Email,
+		caveats:   entry.Caveats,
+		blessings: entry.Blessings,
+		timestamp: entry.Timestamp,
+	}
+}
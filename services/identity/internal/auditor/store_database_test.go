@@ -0,0 +1,102 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package auditor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+	_ "v.io/x/ref/runtime/factories/fake"
+	"v.io/x/ref/services/syncbase/store/memstore"
+	"v.io/x/ref/test"
+)
+
+func TestStoreDatabaseQuery(t *testing.T) {
+	ctx, cancel := test.TestContext()
+	defer cancel()
+	testDatabaseQuery(t, ctx, newStoreDatabase(memstore.New(), "tableName"))
+}
+
+func TestStoreDatabaseQueryRange(t *testing.T) {
+	ctx, cancel := test.TestContext()
+	defer cancel()
+	testDatabaseQueryRange(t, ctx, newStoreDatabase(memstore.New(), "tableName"))
+}
+
+// testDatabaseQuery tests Query on an empty database.
+func testDatabaseQuery(t *testing.T, ctx *context.T, d database) {
+	now := time.Now()
+	entries := []databaseEntry{
+		{email: "email", caveats: []byte("caveats1"), timestamp: now.Add(-time.Hour), blessings: []byte("blessings1")},
+		{email: "email", caveats: []byte("caveats2"), timestamp: now, blessings: []byte("blessings2")},
+		{email: "email", caveats: []byte("caveats3"), timestamp: now.Add(-time.Minute), blessings: []byte("blessings3")},
+		{email: "email2", caveats: []byte("caveats4"), timestamp: now, blessings: []byte("blessings4")},
+	}
+	for _, e := range entries {
+		if err := d.Insert(ctx, e); err != nil {
+			t.Errorf("failed to insert into database: %v", err)
+		}
+	}
+
+	// Entries are returned newest first.
+	var got []databaseEntry
+	for e := range d.Query(ctx, "email") {
+		got = append(got, e)
+	}
+	want := []databaseEntry{entries[1], entries[2], entries[0]}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].timestamp.Equal(want[i].timestamp) {
+			t.Errorf("entry %d: got timestamp %v, want %v", i, got[i].timestamp, want[i].timestamp)
+		}
+		got[i].timestamp = want[i].timestamp
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("entry %d: got %#v, expected %#v", i, got[i], want[i])
+		}
+	}
+}
+
+// testDatabaseQueryRange tests QueryRange on an empty database.
+func testDatabaseQueryRange(t *testing.T, ctx *context.T, d database) {
+	now := time.Now()
+	entries := []databaseEntry{
+		{email: "email1", timestamp: now.Add(-2 * time.Hour)},
+		{email: "email2", timestamp: now.Add(-time.Hour)},
+		{email: "email1", timestamp: now},
+		{email: "email3", timestamp: now.Add(time.Hour)},
+	}
+	for _, e := range entries {
+		if err := d.Insert(ctx, e); err != nil {
+			t.Errorf("failed to insert into database: %v", err)
+		}
+	}
+
+	testcases := []struct {
+		start, end time.Time
+		want       []string
+	}{
+		{time.Time{}, time.Time{}, []string{"email3", "email1", "email2", "email1"}},
+		{now.Add(-time.Hour), now.Add(time.Hour), []string{"email1", "email2"}},
+		{now, time.Time{}, []string{"email3", "email1"}},
+		{time.Time{}, now, []string{"email2", "email1"}},
+		{now.Add(time.Minute), now.Add(time.Hour), nil},
+	}
+	for _, tc := range testcases {
+		var got []string
+		for e := range d.QueryRange(ctx, tc.start, tc.end) {
+			if e.decodeErr != nil {
+				t.Errorf("QueryRange(%v, %v): %v", tc.start, tc.end, e.decodeErr)
+			}
+			got = append(got, e.email)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("QueryRange(%v, %v): got %v, want %v", tc.start, tc.end, got, tc.want)
+		}
+	}
+}
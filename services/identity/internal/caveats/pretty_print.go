@@ -0,0 +1,69 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caveats
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"v.io/v23/security"
+	"v.io/v23/vom"
+)
+
+// PrettyPrint returns a user friendly string for vanadium standard caveat.
+// Unrecognized caveats will fall back to the Caveat's String() method.
+func PrettyPrint(cavs []security.Caveat) ([]string, error) {
+	s := make([]string, len(cavs))
+	for i, cav := range cavs {
+		if cav.Id == security.PublicKeyThirdPartyCaveat.Id {
+			c := cav.ThirdPartyDetails()
+			s[i] = fmt.Sprintf("ThirdPartyCaveat: Requires discharge from %v (ID=%q)", c.Location(), c.ID())
+			continue
+		}
+
+		var param interface{}
+		if err := vom.Decode(cav.ParamVom, &param); err != nil {
+			return nil, err
+		}
+		switch cav.Id {
+		case security.ExpiryCaveat.Id:
+			s[i] = fmt.Sprintf("Expires at %v", param)
+		case security.MethodCaveat.Id:
+			s[i] = fmt.Sprintf("Restricted to methods %v", param)
+		case security.PeerBlessingsCaveat.Id:
+			s[i] = fmt.Sprintf("Restricted to peers with blessings %v", param)
+		default:
+			s[i] = cav.String()
+		}
+	}
+	return s, nil
+}
+
+// Type returns the CaveatInfo type of the caveats created by the
+// CaveatFactory that cav is one of, e.g. "Expiry" or "Revocation", or the
+// hex-encoded id of the caveat descriptor of cav otherwise.
+func Type(cav security.Caveat) string {
+	switch cav.Id {
+	case security.ExpiryCaveat.Id:
+		return "Expiry"
+	case security.MethodCaveat.Id:
+		return "Method"
+	case security.PeerBlessingsCaveat.Id:
+		return "PeerBlessings"
+	case security.PublicKeyThirdPartyCaveat.Id:
+		return "Revocation"
+	}
+	return hex.EncodeToString(cav.Id[:])
+}
+
+// ValidType returns true if t may be returned by Type.
+func ValidType(t string) bool {
+	switch t {
+	case "Expiry", "Method", "PeerBlessings", "Revocation":
+		return true
+	}
+	id, err := hex.DecodeString(t)
+	return err == nil && len(id) == len(security.ExpiryCaveat.Id)
+}
@@ -12,15 +12,20 @@ import (
 	"v.io/v23/rpc"
 	"v.io/v23/security"
 	"v.io/x/ref/services/discharger"
+	"v.io/x/ref/services/revocation"
 )
 
 const dischargeExpiryTime = 24 * time.Hour
 
 // dischargerd issues discharges for all caveats present in the current
 // namespace with no additional caveats iff the caveat is valid.
-type dischargerd struct{}
+type dischargerd struct {
+	// validity is the duration for which the discharges are valid, if it
+	// is longer than dischargeExpiryTime.
+	validity time.Duration
+}
 
-func (dischargerd) Discharge(ctx *context.T, call rpc.ServerCall, caveat security.Caveat, _ security.DischargeImpetus) (security.Discharge, error) {
+func (d dischargerd) Discharge(ctx *context.T, call rpc.ServerCall, caveat security.Caveat, _ security.DischargeImpetus) (security.Discharge, error) {
 	tp := caveat.ThirdPartyDetails()
 	if tp == nil {
 		return security.Discharge{}, discharger.NewErrNotAThirdPartyCaveat(ctx, caveat)
@@ -28,11 +33,23 @@ func (dischargerd) Discharge(ctx *context.T, call rpc.ServerCall, caveat securit
 	if err := tp.Dischargeable(ctx, call.Security()); err != nil {
 		return security.Discharge{}, fmt.Errorf("third-party caveat %v cannot be discharged for this context: %v", tp, err)
 	}
-	expiry, err := security.NewExpiryCaveat(time.Now().Add(dischargeExpiryTime))
+	p, now := call.Security().LocalPrincipal(), time.Now()
+	validity := dischargeExpiryTime
+	if d.validity > validity {
+		validity = d.validity
+	}
+	expiry, err := security.NewExpiryCaveat(now.Add(validity))
 	if err != nil {
 		return security.Discharge{}, fmt.Errorf("unable to create expiration caveat on the discharge: %v", err)
 	}
-	return call.Security().LocalPrincipal().MintDischarge(caveat, expiry)
+	if validity == dischargeExpiryTime {
+		return p.MintDischarge(caveat, expiry)
+	}
+	snapshot, err := revocation.NewSnapshotCaveat(p.PublicKey(), tp.ID(), now.Add(dischargeExpiryTime))
+	if err != nil {
+		return security.Discharge{}, fmt.Errorf("unable to create snapshot caveat on the discharge: %v", err)
+	}
+	return p.MintDischarge(caveat, expiry, snapshot)
 }
 
 // NewDischarger returns a discharger service implementation that grants
@@ -42,3 +59,12 @@ func (dischargerd) Discharge(ctx *context.T, call rpc.ServerCall, caveat securit
 func NewDischarger() discharger.DischargerServerMethods {
 	return dischargerd{}
 }
+
+// NewSnapshotDischarger is like NewDischarger, except that discharges are
+// valid for the given duration, if it is longer than 24 hours.  After 24
+// hours, such discharges are only accepted by verifiers that hold a recent
+// revocation snapshot from the discharger in which the discharged caveat is
+// not revoked, see v.io/x/ref/services/revocation.
+func NewSnapshotDischarger(validity time.Duration) discharger.DischargerServerMethods {
+	return dischargerd{validity: validity}
+}
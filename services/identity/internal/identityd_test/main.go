@@ -179,7 +179,9 @@ func runIdentityDTest(ctx *context.T, env *cmdline.Env, args []string) error {
 		assetsPrefix,
 		mountPrefix,
 		dischargerLocation,
-		nil)
+		nil,
+		nil,
+		0)
 	s.Serve(ctx, oauthCtx, externalHttpAddr, httpAddr, tlsConfig)
 	return nil
 }
@@ -201,7 +201,7 @@ func (h *handler) listBlessingsCallback(ctx *context.T, w http.ResponseWriter, r
 				Blessed:   entry.Blessings,
 			}
 			if len(entry.Caveats) > 0 {
-				if tmplEntry.Caveats, err = prettyPrintCaveats(entry.Caveats); err != nil {
+				if tmplEntry.Caveats, err = caveats.PrettyPrint(entry.Caveats); err != nil {
 					ctx.Errorf("Failed to pretty print caveats: %v", err)
 					tmplEntry.Error = fmt.Errorf("failed to pretty print caveats: %v", err)
 				}
@@ -226,35 +226,6 @@ func (h *handler) listBlessingsCallback(ctx *context.T, w http.ResponseWriter, r
 	}
 }
 
-// prettyPrintCaveats returns a user friendly string for vanadium standard caveat.
-// Unrecognized caveats will fall back to the Caveat's String() method.
-func prettyPrintCaveats(cavs []security.Caveat) ([]string, error) {
-	s := make([]string, len(cavs))
-	for i, cav := range cavs {
-		if cav.Id == security.PublicKeyThirdPartyCaveat.Id {
-			c := cav.ThirdPartyDetails()
-			s[i] = fmt.Sprintf("ThirdPartyCaveat: Requires discharge from %v (ID=%q)", c.Location(), c.ID())
-			continue
-		}
-
-		var param interface{}
-		if err := vom.Decode(cav.ParamVom, &param); err != nil {
-			return nil, err
-		}
-		switch cav.Id {
-		case security.ExpiryCaveat.Id:
-			s[i] = fmt.Sprintf("Expires at %v", param)
-		case security.MethodCaveat.Id:
-			s[i] = fmt.Sprintf("Restricted to methods %v", param)
-		case security.PeerBlessingsCaveat.Id:
-			s[i] = fmt.Sprintf("Restricted to peers with blessings %v", param)
-		default:
-			s[i] = cav.String()
-		}
-	}
-	return s, nil
-}
-
 func (h *handler) revoke(ctx *context.T, w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	const (
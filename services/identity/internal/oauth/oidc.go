@@ -0,0 +1,509 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	netcontext "golang.org/x/net/context"
+	"golang.org/x/oauth2"
+
+	"v.io/v23/context"
+	"v.io/v23/security"
+)
+
+const (
+	// oidcDiscoveryPath is appended to the issuer to obtain the provider's
+	// discovery document.
+	oidcDiscoveryPath = "/.well-known/openid-configuration"
+	// oidcClockSkew is the clock skew tolerated when validating ID tokens.
+	oidcClockSkew = 2 * time.Minute
+	// oidcMinKeyRefresh is the minimum time between fetches of the
+	// provider's keys, which are refetched when a token is signed by an
+	// unknown key.
+	oidcMinKeyRefresh = time.Minute
+	// oidcHTTPTimeout is the timeout of the requests to the provider.
+	oidcHTTPTimeout = 30 * time.Second
+)
+
+// OIDCConfig configures an OpenID Connect provider.
+type OIDCConfig struct {
+	// Issuer is the issuer identifier of the provider, e.g.
+	// "https://accounts.example.com".  The provider's endpoints are read
+	// from the discovery document at Issuer/.well-known/openid-configuration.
+	Issuer string
+	// ClientID and ClientSecret are the credentials of identityd with the
+	// provider.
+	ClientID, ClientSecret string
+	// Scopes are the scopes requested in addition to "openid".  The
+	// default is "email".
+	Scopes []string
+	// BlessingName is a text/template, executed on the claims of the ID
+	// token, that produces the name used in the blessings of the user.  The
+	// default is "{{.email}}".  For example, "{{.preferred_username}}"
+	// blesses users of a corporate IdP by their user name.
+	BlessingName string
+	// AllowUnverifiedEmail, if true, accepts users whose email_verified
+	// claim is not true.  By default they are rejected, as with Google.
+	AllowUnverifiedEmail bool
+}
+
+// oidcDiscovery is the subset of the provider's discovery document used by
+// oidcOAuth.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	IntrospectionEndpoint string `json:"introspection_endpoint"`
+}
+
+// jsonWebKey is a single key of a JSON Web Key Set, as described in RFC 7517.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA keys.
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC keys.
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// oidcOAuth implements the OAuthProvider interface with OpenID Connect.
+type oidcOAuth struct {
+	config   OIDCConfig
+	endpoint oidcDiscovery
+	name     *template.Template
+	now      func() time.Time
+	client   *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey // GUARDED_BY(mu), keyed by kid.
+	keysFetched time.Time                   // GUARDED_BY(mu)
+
+	ctx *context.T
+}
+
+// NewOIDCOAuth returns an OAuthProvider for the OpenID Connect provider
+// configured by the JSON-encoded OIDCConfig in configFile.
+func NewOIDCOAuth(ctx *context.T, configFile string) (OAuthProvider, error) {
+	f, err := os.Open(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %q: %v", configFile, err)
+	}
+	defer f.Close()
+	var config OIDCConfig
+	if err := json.NewDecoder(f).Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON in %q: %v", configFile, err)
+	}
+	return NewOIDC(ctx, config)
+}
+
+// NewOIDC returns an OAuthProvider for the OpenID Connect provider described
+// by config.  The provider's discovery document is fetched immediately.
+func NewOIDC(ctx *context.T, config OIDCConfig) (OAuthProvider, error) {
+	return newOIDC(ctx, config, time.Now)
+}
+
+func newOIDC(ctx *context.T, config OIDCConfig, now func() time.Time) (*oidcOAuth, error) {
+	if config.Issuer == "" || config.ClientID == "" {
+		return nil, fmt.Errorf("OIDC configuration must include Issuer and ClientID")
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"email"}
+	}
+	if config.BlessingName == "" {
+		config.BlessingName = "{{.email}}"
+	}
+	name, err := template.New("BlessingName").Option("missingkey=zero").Parse(config.BlessingName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BlessingName template %q: %v", config.BlessingName, err)
+	}
+	o := &oidcOAuth{
+		config: config,
+		name:   name,
+		now:    now,
+		client: &http.Client{Timeout: oidcHTTPTimeout},
+		ctx:    ctx,
+	}
+	discoveryURL := strings.TrimSuffix(config.Issuer, "/") + oidcDiscoveryPath
+	if err := o.getJSON(discoveryURL, "", &o.endpoint); err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC discovery document: %v", err)
+	}
+	// As per OpenID Connect Discovery 1.0, section 4.3.
+	if o.endpoint.Issuer != config.Issuer {
+		return nil, fmt.Errorf("discovery document is for issuer %q, not %q", o.endpoint.Issuer, config.Issuer)
+	}
+	if o.endpoint.AuthorizationEndpoint == "" || o.endpoint.TokenEndpoint == "" || o.endpoint.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document of %q lacks required endpoints", config.Issuer)
+	}
+	return o, nil
+}
+
+func (o *oidcOAuth) AuthURL(redirectUrl, state string, approval AuthURLApproval) string {
+	var opts []oauth2.AuthCodeOption
+	if approval == ExplicitApproval {
+		opts = append(opts, oauth2.SetAuthURLParam("prompt", "consent"))
+	}
+	return o.oauthConfig(redirectUrl).AuthCodeURL(state, opts...)
+}
+
+// ExchangeAuthCodeForEmail exchanges the authorization code for an OAuth
+// token and returns the blessing name derived from the claims of the ID token
+// in it.
+func (o *oidcOAuth) ExchangeAuthCodeForEmail(authcode string, url string) (string, error) {
+	hctx := netcontext.WithValue(oauth2.NoContext, oauth2.HTTPClient, o.client)
+	t, err := o.oauthConfig(url).Exchange(hctx, authcode)
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange authorization code for token: %v", err)
+	}
+	if !t.Valid() {
+		return "", fmt.Errorf("oauth2 token invalid")
+	}
+	idToken, ok := t.Extra("id_token").(string)
+	if !ok {
+		return "", fmt.Errorf("no ID token found in OAuth token")
+	}
+	claims, err := o.verifyIDToken(idToken)
+	if err != nil {
+		return "", err
+	}
+	return o.blessingName(claims)
+}
+
+// GetEmailAndClientID uses the provider's token introspection endpoint
+// (RFC 7662) to determine the client the access token was issued to, and its
+// userinfo endpoint to obtain the claims from which the blessing name is
+// derived.
+func (o *oidcOAuth) GetEmailAndClientID(accessToken string) (string, string, error) {
+	if o.endpoint.IntrospectionEndpoint == "" || o.endpoint.UserinfoEndpoint == "" {
+		return "", "", fmt.Errorf("provider %q does not support access token introspection", o.config.Issuer)
+	}
+	form := url.Values{"token": {accessToken}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequest("POST", o.endpoint.IntrospectionEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(o.config.ClientID), url.QueryEscape(o.config.ClientSecret))
+	var introspection struct {
+		Active   bool   `json:"active"`
+		ClientID string `json:"client_id"`
+	}
+	if err := o.doJSON(req, &introspection); err != nil {
+		return "", "", fmt.Errorf("unable to introspect access token: %v", err)
+	}
+	if !introspection.Active {
+		return "", "", fmt.Errorf("access token is not active")
+	}
+	var claims map[string]interface{}
+	if err := o.getJSON(o.endpoint.UserinfoEndpoint, accessToken, &claims); err != nil {
+		return "", "", fmt.Errorf("unable to fetch userinfo: %v", err)
+	}
+	name, err := o.blessingName(claims)
+	if err != nil {
+		return "", "", err
+	}
+	return name, introspection.ClientID, nil
+}
+
+func (o *oidcOAuth) oauthConfig(redirectUrl string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     o.config.ClientID,
+		ClientSecret: o.config.ClientSecret,
+		RedirectURL:  redirectUrl,
+		Scopes:       append([]string{"openid"}, o.config.Scopes...),
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  o.endpoint.AuthorizationEndpoint,
+			TokenURL: o.endpoint.TokenEndpoint,
+		},
+	}
+}
+
+// blessingName maps the claims of a user to the name used in their blessings.
+func (o *oidcOAuth) blessingName(claims map[string]interface{}) (string, error) {
+	if !o.config.AllowUnverifiedEmail {
+		if verified, _ := claims["email_verified"].(bool); !verified {
+			return "", fmt.Errorf("email not verified")
+		}
+	}
+	var buf bytes.Buffer
+	if err := o.name.Execute(&buf, claims); err != nil {
+		return "", fmt.Errorf("failed to map claims to a blessing name: %v", err)
+	}
+	name := buf.String()
+	if name == "" || name == "<no value>" {
+		return "", fmt.Errorf("claims do not yield a blessing name")
+	}
+	if strings.Contains(name, security.ChainSeparator) {
+		return "", fmt.Errorf("blessing name %q may not contain %q", name, security.ChainSeparator)
+	}
+	return name, nil
+}
+
+// verifyIDToken verifies the signature and the standard claims of a JWT
+// encoded ID token, and returns its claims.
+func (o *oidcOAuth) verifyIDToken(idToken string) (map[string]interface{}, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed ID token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed ID token header: %v", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed ID token signature: %v", err)
+	}
+	key, err := o.key(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyJWTSignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+	var claims map[string]interface{}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed ID token claims: %v", err)
+	}
+	// As per OpenID Connect Core 1.0, section 3.1.3.7.
+	if iss, _ := claims["iss"].(string); iss != o.config.Issuer {
+		return nil, fmt.Errorf("invalid issuer: %v", claims["iss"])
+	}
+	if !audienceContains(claims["aud"], o.config.ClientID) {
+		return nil, fmt.Errorf("unexpected audience(%v) in ID token", claims["aud"])
+	}
+	now := o.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(oidcClockSkew)) {
+		return nil, fmt.Errorf("ID token expired")
+	}
+	if iat, ok := claims["iat"].(float64); ok && now.Add(oidcClockSkew).Before(time.Unix(int64(iat), 0)) {
+		return nil, fmt.Errorf("ID token issued in the future")
+	}
+	return claims, nil
+}
+
+func audienceContains(aud interface{}, clientID string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == clientID
+	case []interface{}:
+		for _, a := range v {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// key returns the provider's public key with the given id, fetching the
+// provider's keys if it is not known.
+func (o *oidcOAuth) key(kid string) (crypto.PublicKey, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if key, ok := o.keys[kid]; ok {
+		return key, nil
+	}
+	if now := o.now(); o.keys == nil || now.Sub(o.keysFetched) > oidcMinKeyRefresh {
+		keys, err := o.fetchKeys()
+		if err != nil {
+			return nil, err
+		}
+		o.keys, o.keysFetched = keys, now
+	}
+	if key, ok := o.keys[kid]; ok {
+		return key, nil
+	}
+	// Providers with a single key need not name it.
+	if kid == "" && len(o.keys) == 1 {
+		for _, key := range o.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("ID token signed by unknown key %q", kid)
+}
+
+// fetchKeys fetches the provider's JSON Web Key Set.
+func (o *oidcOAuth) fetchKeys() (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := o.getJSON(o.endpoint.JWKSURI, "", &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch keys: %v", err)
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			o.ctx.Infof("ignoring key %q of %v: %v", jwk.Kid, o.config.Issuer, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if e.BitLen() > 31 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("invalid EC key")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// verifyJWTSignature verifies sig over signed with key, using the JWS
+// algorithm alg (RFC 7518).
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	if len(alg) != 5 {
+		return fmt.Errorf("unsupported signature algorithm %q", alg)
+	}
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported signature algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			break
+		}
+		if err := rsa.VerifyPKCS1v15(k, hash, digest, sig); err != nil {
+			return fmt.Errorf("invalid ID token signature: %v", err)
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			break
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return fmt.Errorf("invalid ID token signature length")
+		}
+		r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return fmt.Errorf("invalid ID token signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("signature algorithm %q does not match the key", alg)
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid key parameter %q", s)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// getJSON decodes the JSON response of a GET request to url, authorized with
+// accessToken if it is not empty, into v.
+func (o *oidcOAuth) getJSON(url, accessToken string, v interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return o.doJSON(req, v)
+}
+
+func (o *oidcOAuth) doJSON(req *http.Request, v interface{}) error {
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with %s", req.URL, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid JSON response from %s: %v", req.URL, err)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+)
+
+// stubIdP is a minimal OpenID Connect provider.
+type stubIdP struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]interface{}
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &stubIdP{key: key}
+	mux := http.NewServeMux()
+	idp.Server = httptest.NewServer(mux)
+	reply := func(w http.ResponseWriter, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+	mux.HandleFunc(oidcDiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		reply(w, map[string]string{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/auth",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/keys",
+			"userinfo_endpoint":      idp.URL + "/userinfo",
+			"introspection_endpoint": idp.URL + "/introspect",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		enc := base64.RawURLEncoding
+		reply(w, map[string][]jsonWebKey{"keys": {{
+			Kty: "RSA",
+			Kid: "k1",
+			Use: "sig",
+			N:   enc.EncodeToString(key.N.Bytes()),
+			E:   enc.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		reply(w, map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idp.sign(t, idp.claims),
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			http.Error(w, "bad token", http.StatusUnauthorized)
+			return
+		}
+		reply(w, idp.claims)
+	})
+	mux.HandleFunc("/introspect", func(w http.ResponseWriter, r *http.Request) {
+		reply(w, map[string]interface{}{
+			"active":    r.FormValue("token") == "access",
+			"client_id": "app",
+		})
+	})
+	return idp
+}
+
+func (idp *stubIdP) sign(t *testing.T, claims map[string]interface{}) string {
+	enc := base64.RawURLEncoding
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+	payload, _ := json.Marshal(claims)
+	signed := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + enc.EncodeToString(sig)
+}
+
+func TestOIDC(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	idp := newStubIdP(t)
+	defer idp.Close()
+
+	now := time.Now()
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":                idp.URL,
+			"aud":                "identityd",
+			"exp":                now.Add(time.Hour).Unix(),
+			"iat":                now.Unix(),
+			"email":              "alice@corp.example.com",
+			"email_verified":     true,
+			"preferred_username": "alice",
+		}
+	}
+	config := OIDCConfig{
+		Issuer:       idp.URL,
+		ClientID:     "identityd",
+		ClientSecret: "secret",
+	}
+	o, err := newOIDC(ctx, config, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		name   string
+		mutate func(map[string]interface{})
+		want   string
+	}{
+		{"valid", func(map[string]interface{}) {}, "alice@corp.example.com"},
+		{"audience list", func(c map[string]interface{}) { c["aud"] = []string{"other", "identityd"} }, "alice@corp.example.com"},
+		{"wrong audience", func(c map[string]interface{}) { c["aud"] = "other" }, ""},
+		{"wrong issuer", func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" }, ""},
+		{"expired", func(c map[string]interface{}) { c["exp"] = now.Add(-time.Hour).Unix() }, ""},
+		{"unverified", func(c map[string]interface{}) { c["email_verified"] = false }, ""},
+		{"verification unknown", func(c map[string]interface{}) { delete(c, "email_verified") }, ""},
+		{"no email", func(c map[string]interface{}) { delete(c, "email") }, ""},
+		{"bad name", func(c map[string]interface{}) { c["email"] = "alice:bob" }, ""},
+	}
+	for _, tc := range testcases {
+		idp.claims = validClaims()
+		tc.mutate(idp.claims)
+		got, err := o.ExchangeAuthCodeForEmail("code", "http://localhost/redirect")
+		if tc.want == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %q", tc.name, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("%s: got (%q, %v), want %q", tc.name, got, err, tc.want)
+		}
+	}
+
+	// A token signed by another key must be rejected.
+	idp.claims = validClaims()
+	token := idp.sign(t, idp.claims)
+	if idp.key, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.verifyIDToken(idp.sign(t, idp.claims)); err == nil {
+		t.Errorf("token signed by an unknown key was accepted")
+	}
+	if _, err := o.verifyIDToken(token); err != nil {
+		t.Errorf("verifyIDToken failed: %v", err)
+	}
+
+	// Access tokens are checked with the introspection and userinfo
+	// endpoints, and the blessing name is configurable.
+	config.BlessingName = "{{.preferred_username}}"
+	if o, err = newOIDC(ctx, config, func() time.Time { return now }); err != nil {
+		t.Fatal(err)
+	}
+	if email, clientID, err := o.GetEmailAndClientID("access"); err != nil || email != "alice" || clientID != "app" {
+		t.Errorf("got (%q, %q, %v), want (alice, app, nil)", email, clientID, err)
+	}
+	if _, _, err := o.GetEmailAndClientID("stale"); err == nil {
+		t.Errorf("expected an error for an inactive access token")
+	}
+
+	// Unverified users are accepted only if the configuration allows it.
+	idp.claims["email_verified"] = false
+	if _, _, err := o.GetEmailAndClientID("access"); err == nil {
+		t.Errorf("expected an error for an unverified user")
+	}
+	config.AllowUnverifiedEmail = true
+	if o, err = newOIDC(ctx, config, func() time.Time { return now }); err != nil {
+		t.Fatal(err)
+	}
+	if email, _, err := o.GetEmailAndClientID("access"); err != nil || email != "alice" {
+		t.Errorf("got (%q, %v), want (alice, nil)", email, err)
+	}
+}
@@ -39,3 +39,13 @@ func (m *mockDatabase) IsRevoked(revocationCaveatID []byte) (bool, error) {
 func (m *mockDatabase) RevocationTime(thirdPartyCaveatID string) (*time.Time, error) {
 	return m.revCavIDToTimestamp[string(m.tpCavIDToRevCavID[thirdPartyCaveatID])], nil
 }
+
+func (m *mockDatabase) RevokedCaveats() ([]string, error) {
+	var ids []string
+	for tpCavID, revCavID := range m.tpCavIDToRevCavID {
+		if _, revoked := m.revCavIDToTimestamp[string(revCavID)]; revoked {
+			ids = append(ids, tpCavID)
+		}
+	}
+	return ids, nil
+}
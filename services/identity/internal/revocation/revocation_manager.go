@@ -14,6 +14,7 @@ import (
 
 	"v.io/v23/context"
 	"v.io/v23/security"
+	"v.io/x/ref/services/syncbase/store"
 )
 
 // RevocationManager persists information for revocation caveats to provided discharges and allow for future revocations.
@@ -21,6 +22,9 @@ type RevocationManager interface {
 	NewCaveat(discharger security.PublicKey, dischargerLocation string) (security.Caveat, error)
 	Revoke(caveatID string) error
 	GetRevocationTime(caveatID string) *time.Time
+	// RevokedCaveats returns the IDs of the third-party caveats that have
+	// been revoked.
+	RevokedCaveats() ([]string, error)
 }
 
 // revocationManager persists information for revocation caveats to provided discharges and allow for future revocations.
@@ -30,13 +34,36 @@ type revocationManager struct{ ctx *context.T }
 // revocationCaveats in a SQL database and allows for revocation and caveat creation.
 // This function can only be called once because of the use of global variables.
 func NewRevocationManager(ctx *context.T, sqlDB *sql.DB) (RevocationManager, error) {
+	return newRevocationManager(ctx, func() (database, error) {
+		return newSQLDatabase(sqlDB, "RevocationCaveatInfo")
+	})
+}
+
+// NewSQLiteRevocationManager is like NewRevocationManager, but for a SQLite
+// database, e.g. one opened with the "sqlite3" driver on a local file.
+func NewSQLiteRevocationManager(ctx *context.T, sqlDB *sql.DB) (RevocationManager, error) {
+	return newRevocationManager(ctx, func() (database, error) {
+		return newSQLiteDatabase(sqlDB, "RevocationCaveatInfo")
+	})
+}
+
+// NewStoreRevocationManager is like NewRevocationManager, but persists the
+// information in a syncbase storage engine, e.g. leveldb.  The store may be
+// shared with other users, e.g. the blessing auditor.
+func NewStoreRevocationManager(ctx *context.T, st store.Store) (RevocationManager, error) {
+	return newRevocationManager(ctx, func() (database, error) {
+		return newStoreDatabase(ctx, st, "RevocationCaveatInfo"), nil
+	})
+}
+
+func newRevocationManager(ctx *context.T, newDB func() (database, error)) (RevocationManager, error) {
 	revocationLock.Lock()
 	defer revocationLock.Unlock()
 	if revocationDB != nil {
 		return nil, fmt.Errorf("NewRevocationManager can only be called once")
 	}
 	var err error
-	revocationDB, err = newSQLDatabase(sqlDB, "RevocationCaveatInfo")
+	revocationDB, err = newDB()
 	if err != nil {
 		return nil, err
 	}
@@ -83,6 +110,12 @@ func (r *revocationManager) GetRevocationTime(caveatID string) *time.Time {
 	return timestamp
 }
 
+// RevokedCaveats returns the IDs of the third-party caveats for which Revoke
+// has been called.
+func (r *revocationManager) RevokedCaveats() ([]string, error) {
+	return revocationDB.RevokedCaveats()
+}
+
 func isRevoked(ctx *context.T, call security.Call, key []byte) error {
 	revocationLock.RLock()
 	if revocationDB == nil {
@@ -31,7 +31,10 @@ func TestDischargeRevokeDischargeRevokeDischarge(t *testing.T) {
 	defer shutdown()
 
 	dcKey, dc, revoker := revokerSetup(t, ctx)
+	testDischargeRevokeDischargeRevokeDischarge(t, ctx, dcKey, dc, revoker)
+}
 
+func testDischargeRevokeDischargeRevokeDischarge(t *testing.T, ctx *context.T, dcKey security.PublicKey, dc string, revoker RevocationManager) {
 	discharger := discharger.DischargerClient(dc)
 	caveat, err := revoker.NewCaveat(dcKey, dc)
 	if err != nil {
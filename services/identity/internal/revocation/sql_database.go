@@ -16,6 +16,7 @@ type database interface {
 	Revoke(thirdPartyCaveatID string) error
 	IsRevoked(revocationCaveatID []byte) (bool, error)
 	RevocationTime(thirdPartyCaveatID string) (*time.Time, error)
+	RevokedCaveats() ([]string, error)
 }
 
 // Table with 3 columns:
@@ -23,7 +24,7 @@ type database interface {
 // (2) RevocationCaveatID= hex encoded revcationCaveatID.
 // (3) RevocationTime= time (if any) that the Caveat was revoked.
 type sqlDatabase struct {
-	insertCaveatStmt, revokeStmt, isRevokedStmt, revocationTimeStmt *sql.Stmt
+	insertCaveatStmt, revokeStmt, isRevokedStmt, revocationTimeStmt, revokedCaveatsStmt *sql.Stmt
 }
 
 func (s *sqlDatabase) InsertCaveat(thirdPartyCaveatID string, revocationCaveatID []byte) error {
@@ -61,6 +62,23 @@ func (s *sqlDatabase) RevocationTime(thirdPartyCaveatID string) (*time.Time, err
 	return nil, fmt.Errorf("the caveat (%v) was not revoked", thirdPartyCaveatID)
 }
 
+func (s *sqlDatabase) RevokedCaveats() ([]string, error) {
+	rows, err := s.revokedCaveatsStmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 func newSQLDatabase(db *sql.DB, table string) (database, error) {
 	createStmt, err := db.Prepare(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s ( ThirdPartyCaveatID NVARCHAR(255), RevocationCaveatID NVARCHAR(255), RevocationTime DATETIME, PRIMARY KEY (ThirdPartyCaveatID), KEY (RevocationCaveatID) );", table))
 	if err != nil {
@@ -69,6 +87,25 @@ func newSQLDatabase(db *sql.DB, table string) (database, error) {
 	if _, err = createStmt.Exec(); err != nil {
 		return nil, err
 	}
+	return prepareSQLDatabase(db, table)
+}
+
+// newSQLiteDatabase is like newSQLDatabase, but for SQLite.
+func newSQLiteDatabase(db *sql.DB, table string) (database, error) {
+	// SQLite does not support KEY clauses in CREATE TABLE, so the index is
+	// created separately.
+	for _, stmt := range []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s ( ThirdPartyCaveatID NVARCHAR(255), RevocationCaveatID NVARCHAR(255), RevocationTime DATETIME, PRIMARY KEY (ThirdPartyCaveatID) );", table),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %sRevocationCaveatID ON %s (RevocationCaveatID);", table, table),
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+	return prepareSQLDatabase(db, table)
+}
+
+func prepareSQLDatabase(db *sql.DB, table string) (database, error) {
 	insertCaveatStmt, err := db.Prepare(fmt.Sprintf("INSERT INTO %s (ThirdPartyCaveatID, RevocationCaveatID, RevocationTime) VALUES (?, ?, NULL)", table))
 	if err != nil {
 		return nil, err
@@ -82,5 +119,9 @@ func newSQLDatabase(db *sql.DB, table string) (database, error) {
 		return nil, err
 	}
 	revocationTimeStmt, err := db.Prepare(fmt.Sprintf("SELECT RevocationTime FROM %s WHERE ThirdPartyCaveatID=?", table))
-	return &sqlDatabase{insertCaveatStmt, revokeStmt, isRevokedStmt, revocationTimeStmt}, err
+	if err != nil {
+		return nil, err
+	}
+	revokedCaveatsStmt, err := db.Prepare(fmt.Sprintf("SELECT ThirdPartyCaveatID FROM %s WHERE RevocationTime IS NOT NULL", table))
+	return &sqlDatabase{insertCaveatStmt, revokeStmt, isRevokedStmt, revocationTimeStmt, revokedCaveatsStmt}, err
 }
@@ -75,4 +75,11 @@ func TestSQLDatabase(t *testing.T) {
 	if got, err := d.RevocationTime(tpCavID); err != nil || !reflect.DeepEqual(*got, revocationTime) {
 		t.Errorf("got %v, expected %v: err : %v", got, revocationTime, err)
 	}
+
+	// Test RevokedCaveats.
+	sqlmock.ExpectQuery("SELECT ThirdPartyCaveatID FROM tableName WHERE RevocationTime IS NOT NULL").
+		WillReturnRows(sqlmock.NewRows([]string{"ThirdPartyCaveatID"}).AddRow(tpCavID))
+	if got, err := d.RevokedCaveats(); err != nil || !reflect.DeepEqual(got, []string{tpCavID}) {
+		t.Errorf("got %v, expected %v: err: %v", got, []string{tpCavID}, err)
+	}
 }
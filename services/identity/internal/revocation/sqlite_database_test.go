@@ -0,0 +1,54 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package revocation
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"v.io/x/ref/test"
+)
+
+// newSQLiteDB returns an empty in-memory SQLite database.
+func newSQLiteDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open SQLite database: %v", err)
+	}
+	// Every connection to ":memory:" opens a different database.
+	db.SetMaxOpenConns(1)
+	return db
+}
+
+func TestSQLiteDatabase(t *testing.T) {
+	db := newSQLiteDB(t)
+	defer db.Close()
+	d, err := newSQLiteDatabase(db, "tableName")
+	if err != nil {
+		t.Fatalf("failed to create SQLite database: %v", err)
+	}
+	testDatabase(t, d)
+}
+
+func TestSQLiteDischargeRevokeDischargeRevokeDischarge(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+
+	dcKey, dc, _ := revokerSetup(t, ctx)
+	db := newSQLiteDB(t)
+	defer db.Close()
+	// The revocation database is global, and may have been set by another
+	// test.
+	revocationLock.Lock()
+	revocationDB = nil
+	revocationLock.Unlock()
+	revoker, err := NewSQLiteRevocationManager(ctx, db)
+	if err != nil {
+		t.Fatalf("NewSQLiteRevocationManager failed: %v", err)
+	}
+	testDischargeRevokeDischargeRevokeDischarge(t, ctx, dcKey, dc, revoker)
+}
@@ -0,0 +1,114 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package revocation
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/verror"
+	"v.io/v23/vom"
+	"v.io/x/ref/services/syncbase/store"
+)
+
+// storeCaveat is the information kept about a third-party caveat.
+type storeCaveat struct {
+	RevocationCaveatID []byte
+	Revoked            bool
+	RevocationTime     time.Time
+}
+
+// storeDatabase is an implementation of the database interface on a syncbase
+// storage engine, e.g. leveldb.
+//
+// It keeps two kinds of rows:
+// (1) <prefix>tp/<ThirdPartyCaveatID> = storeCaveat.
+// (2) <prefix>rev/<hex encoded RevocationCaveatID> = ThirdPartyCaveatID.
+type storeDatabase struct {
+	ctx    *context.T
+	st     store.Store
+	prefix string
+}
+
+// newStoreDatabase returns a store.Store implementation of the database
+// interface.  All keys written start with table.
+func newStoreDatabase(ctx *context.T, st store.Store, table string) database {
+	return &storeDatabase{ctx: ctx, st: st, prefix: table + "/"}
+}
+
+func (s *storeDatabase) caveatKey(thirdPartyCaveatID string) string {
+	return s.prefix + "tp/" + thirdPartyCaveatID
+}
+
+func (s *storeDatabase) revocationKey(revocationCaveatID []byte) string {
+	return s.prefix + "rev/" + hex.EncodeToString(revocationCaveatID)
+}
+
+func (s *storeDatabase) InsertCaveat(thirdPartyCaveatID string, revocationCaveatID []byte) error {
+	return store.RunInTransaction(s.st, func(tx store.Transaction) error {
+		if err := store.Put(s.ctx, tx, s.caveatKey(thirdPartyCaveatID), storeCaveat{RevocationCaveatID: revocationCaveatID}); err != nil {
+			return err
+		}
+		return store.Put(s.ctx, tx, s.revocationKey(revocationCaveatID), thirdPartyCaveatID)
+	})
+}
+
+func (s *storeDatabase) Revoke(thirdPartyCaveatID string) error {
+	return store.RunInTransaction(s.st, func(tx store.Transaction) error {
+		var cav storeCaveat
+		key := s.caveatKey(thirdPartyCaveatID)
+		if err := store.Get(s.ctx, tx, key, &cav); err != nil {
+			return err
+		}
+		cav.Revoked, cav.RevocationTime = true, time.Now()
+		return store.Put(s.ctx, tx, key, cav)
+	})
+}
+
+func (s *storeDatabase) IsRevoked(revocationCaveatID []byte) (bool, error) {
+	var thirdPartyCaveatID string
+	if err := store.Get(s.ctx, s.st, s.revocationKey(revocationCaveatID), &thirdPartyCaveatID); err != nil {
+		if verror.ErrorID(err) == verror.ErrNoExist.ID {
+			return false, nil
+		}
+		return false, err
+	}
+	var cav storeCaveat
+	if err := store.Get(s.ctx, s.st, s.caveatKey(thirdPartyCaveatID), &cav); err != nil {
+		return false, err
+	}
+	return cav.Revoked, nil
+}
+
+func (s *storeDatabase) RevocationTime(thirdPartyCaveatID string) (*time.Time, error) {
+	var cav storeCaveat
+	if err := store.Get(s.ctx, s.st, s.caveatKey(thirdPartyCaveatID), &cav); err != nil {
+		return nil, err
+	}
+	if !cav.Revoked {
+		return nil, fmt.Errorf("the caveat (%v) was not revoked", thirdPartyCaveatID)
+	}
+	return &cav.RevocationTime, nil
+}
+
+func (s *storeDatabase) RevokedCaveats() ([]string, error) {
+	prefix := s.caveatKey("")
+	stream := s.st.Scan([]byte(prefix), []byte(prefix+"\xff"))
+	defer stream.Cancel()
+	var ids []string
+	for stream.Advance() {
+		var cav storeCaveat
+		if err := vom.Decode(stream.Value(nil), &cav); err != nil {
+			return nil, verror.New(verror.ErrInternal, s.ctx, err)
+		}
+		if cav.Revoked {
+			ids = append(ids, strings.TrimPrefix(string(stream.Key(nil)), prefix))
+		}
+	}
+	return ids, stream.Err()
+}
@@ -0,0 +1,62 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package revocation
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"v.io/x/ref/services/syncbase/store/memstore"
+	"v.io/x/ref/test"
+)
+
+func TestStoreDatabase(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+	d := newStoreDatabase(ctx, memstore.New(), "tableName")
+	testDatabase(t, d)
+	if err := d.Revoke("unknown"); err == nil {
+		t.Errorf("Revoke of an unknown caveat succeeded")
+	}
+}
+
+// testDatabase tests an empty database.
+func testDatabase(t *testing.T, d database) {
+	tpCavID, revCavID := "tpCavID", []byte("revCavID")
+	tpCavID2, revCavID2 := "tpCavID2", []byte("revCavID2")
+	if err := d.InsertCaveat(tpCavID, revCavID); err != nil {
+		t.Errorf("failed to InsertCaveat into store: %v", err)
+	}
+	if err := d.InsertCaveat(tpCavID2, revCavID2); err != nil {
+		t.Errorf("second InsertCaveat into store failed: %v", err)
+	}
+
+	before := time.Now()
+	if err := d.Revoke(tpCavID); err != nil {
+		t.Errorf("failed to Revoke Caveat: %v", err)
+	}
+
+	if revoked, err := d.IsRevoked(revCavID); err != nil || !revoked {
+		t.Errorf("expected revCavID to be revoked: err: (%v)", err)
+	}
+	if revoked, err := d.IsRevoked(revCavID2); err != nil || revoked {
+		t.Errorf("expected revCavID2 to not be revoked: err: (%v)", err)
+	}
+	if revoked, err := d.IsRevoked([]byte("unknown")); err != nil || revoked {
+		t.Errorf("expected unknown caveat to not be revoked: err: (%v)", err)
+	}
+
+	if got, err := d.RevocationTime(tpCavID); err != nil || got.Before(before) {
+		t.Errorf("got %v, expected a time after %v: err : %v", got, before, err)
+	}
+	if _, err := d.RevocationTime(tpCavID2); err == nil {
+		t.Errorf("expected an error for a caveat that was not revoked")
+	}
+
+	if got, err := d.RevokedCaveats(); err != nil || !reflect.DeepEqual(got, []string{tpCavID}) {
+		t.Errorf("got %v, expected %v: err: %v", got, []string{tpCavID}, err)
+	}
+}
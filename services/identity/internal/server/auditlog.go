@@ -0,0 +1,120 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"fmt"
+
+	"v.io/v23"
+	"v.io/v23/context"
+	"v.io/v23/security"
+	"v.io/v23/verror"
+	"v.io/x/ref/services/identity"
+	"v.io/x/ref/services/identity/internal/auditor"
+	"v.io/x/ref/services/identity/internal/caveats"
+	"v.io/x/ref/services/identity/internal/revocation"
+)
+
+// blessingAuditLog implements the identity.BlessingAuditLog service on the
+// blessing audit log and the revocation manager of an identity server.
+type blessingAuditLog struct {
+	reader            auditor.BlessingLogReader
+	revocationManager revocation.RevocationManager
+}
+
+func (l *blessingAuditLog) Query(ctx *context.T, call identity.BlessingAuditLogQueryServerCall, query identity.BlessingAuditQuery) error {
+	return l.query(ctx, v23.GetPrincipal(ctx), query, call.SendStream().Send)
+}
+
+// query calls send with each entry of the audit log selected by q.  The names
+// of the blessings in the entries are those recognized by p.
+func (l *blessingAuditLog) query(ctx *context.T, p security.Principal, q identity.BlessingAuditQuery, send func(identity.BlessingAuditEntry) error) error {
+	if q.CaveatType != "" && !caveats.ValidType(q.CaveatType) {
+		return verror.New(verror.ErrBadArg, ctx, fmt.Sprintf("invalid caveat type %q", q.CaveatType))
+	}
+	var entries <-chan auditor.BlessingEntry
+	if q.Email != "" {
+		entries = l.reader.Read(ctx, q.Email)
+	} else {
+		entries = l.reader.ReadRange(ctx, q.Start, q.End)
+	}
+	var sendErr error
+	var unreadable int
+	for entry := range entries {
+		// The channel is always drained, so that the goroutine reading the
+		// log is not leaked.
+		if sendErr != nil {
+			continue
+		}
+		if entry.DecodeError != nil {
+			ctx.Errorf("Failed to read audit log entry: %v", entry.DecodeError)
+			unreadable++
+			continue
+		}
+		if e, ok := l.selectEntry(ctx, p, q, entry); ok {
+			sendErr = send(e)
+		}
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+	if unreadable > 0 {
+		return verror.New(verror.ErrInternal, ctx, fmt.Sprintf("failed to read %d entries of the audit log", unreadable))
+	}
+	return nil
+}
+
+// selectEntry returns the identity.BlessingAuditEntry for entry, and whether
+// it is selected by q.
+func (l *blessingAuditLog) selectEntry(ctx *context.T, p security.Principal, q identity.BlessingAuditQuery, entry auditor.BlessingEntry) (identity.BlessingAuditEntry, bool) {
+	e := identity.BlessingAuditEntry{
+		Email:              entry.Email,
+		Timestamp:          entry.Timestamp,
+		Blessings:          security.BlessingNames(p, entry.Blessings),
+		RevocationCaveatId: entry.RevocationCaveatID,
+	}
+	if !q.Start.IsZero() && e.Timestamp.Before(q.Start) || !q.End.IsZero() && !e.Timestamp.Before(q.End) {
+		return e, false
+	}
+	if q.Pattern != "" && !q.Pattern.MatchedBy(e.Blessings...) {
+		return e, false
+	}
+	if q.CaveatType != "" && !hasCaveatType(entry.Caveats, q.CaveatType) {
+		return e, false
+	}
+	if e.RevocationCaveatId != "" && l.revocationManager != nil {
+		if t := l.revocationManager.GetRevocationTime(e.RevocationCaveatId); t != nil {
+			e.RevocationTime = *t
+		}
+	}
+	switch revoked := !e.RevocationTime.IsZero(); q.Revocation {
+	case identity.RevocationFilterRevoked:
+		if !revoked {
+			return e, false
+		}
+	case identity.RevocationFilterNotRevoked:
+		if revoked {
+			return e, false
+		}
+	}
+	var err error
+	if e.Caveats, err = caveats.PrettyPrint(entry.Caveats); err != nil {
+		ctx.Errorf("Failed to pretty print caveats: %v", err)
+		e.Caveats = make([]string, len(entry.Caveats))
+		for i, cav := range entry.Caveats {
+			e.Caveats[i] = cav.String()
+		}
+	}
+	return e, true
+}
+
+func hasCaveatType(cavs []security.Caveat, t string) bool {
+	for _, cav := range cavs {
+		if caveats.Type(cav) == t {
+			return true
+		}
+	}
+	return false
+}
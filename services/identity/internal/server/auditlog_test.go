@@ -0,0 +1,105 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"v.io/v23/security"
+	"v.io/v23/verror"
+	"v.io/x/ref/lib/security/audit"
+	_ "v.io/x/ref/runtime/factories/fake"
+	"v.io/x/ref/services/identity"
+	"v.io/x/ref/services/identity/internal/auditor"
+	"v.io/x/ref/services/identity/internal/revocation"
+	"v.io/x/ref/services/syncbase/store/memstore"
+	"v.io/x/ref/test"
+	"v.io/x/ref/test/testutil"
+)
+
+func TestBlessingAuditLogQuery(t *testing.T) {
+	ctx, cancel := test.TestContext()
+	defer cancel()
+	a, reader := auditor.NewStoreBlessingAuditor(ctx, memstore.New())
+	revocationManager := revocation.NewMockRevocationManager(ctx)
+	root := testutil.NewPrincipal("root")
+	idp := testutil.IDProviderFromPrincipal(root)
+
+	now := time.Now()
+	bless := func(extension string, timestamp time.Time, cavs ...security.Caveat) {
+		b, err := idp.NewBlessings(testutil.NewPrincipal(), extension, cavs...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		args := []interface{}{nil, nil, extension}
+		for _, cav := range cavs {
+			args = append(args, cav)
+		}
+		if err := a.Audit(ctx, audit.Entry{
+			Method:    "Bless",
+			Arguments: args,
+			Results:   []interface{}{b},
+			Timestamp: timestamp,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expiry, err := security.NewExpiryCaveat(now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newRevocationCaveat := func() security.Caveat {
+		cav, err := revocationManager.NewCaveat(root.PublicKey(), "discharger")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cav
+	}
+	revoked, notRevoked := newRevocationCaveat(), newRevocationCaveat()
+	if err := revocationManager.Revoke(revoked.ThirdPartyDetails().ID()); err != nil {
+		t.Fatal(err)
+	}
+	bless("u:alice@example.com", now.Add(-2*time.Hour), expiry)
+	bless("u:bob@example.com", now.Add(-time.Hour), revoked)
+	bless("o:app:alice@example.com", now, notRevoked)
+
+	l := &blessingAuditLog{reader, revocationManager}
+	testcases := []struct {
+		query identity.BlessingAuditQuery
+		want  []string
+	}{
+		{identity.BlessingAuditQuery{}, []string{"root:o:app:alice@example.com", "root:u:bob@example.com", "root:u:alice@example.com"}},
+		{identity.BlessingAuditQuery{Email: "alice@example.com"}, []string{"root:o:app:alice@example.com", "root:u:alice@example.com"}},
+		{identity.BlessingAuditQuery{Start: now.Add(-time.Hour), End: now}, []string{"root:u:bob@example.com"}},
+		{identity.BlessingAuditQuery{Email: "alice@example.com", End: now}, []string{"root:u:alice@example.com"}},
+		{identity.BlessingAuditQuery{Pattern: "root:u"}, []string{"root:u:bob@example.com", "root:u:alice@example.com"}},
+		{identity.BlessingAuditQuery{CaveatType: "Expiry"}, []string{"root:u:alice@example.com"}},
+		{identity.BlessingAuditQuery{Revocation: identity.RevocationFilterRevoked}, []string{"root:u:bob@example.com"}},
+		{identity.BlessingAuditQuery{CaveatType: "Revocation", Revocation: identity.RevocationFilterNotRevoked}, []string{"root:o:app:alice@example.com"}},
+	}
+	for _, tc := range testcases {
+		var got []string
+		if err := l.query(ctx, root, tc.query, func(e identity.BlessingAuditEntry) error {
+			got = append(got, e.Blessings...)
+			if tc.query.Revocation == identity.RevocationFilterRevoked && e.RevocationTime.IsZero() {
+				t.Errorf("%#v: no revocation time in %#v", tc.query, e)
+			}
+			return nil
+		}); err != nil {
+			t.Errorf("%#v: %v", tc.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%#v: got %v, want %v", tc.query, got, tc.want)
+		}
+	}
+
+	err = l.query(ctx, root, identity.BlessingAuditQuery{CaveatType: "Bogus"}, func(identity.BlessingAuditEntry) error { return nil })
+	if verror.ErrorID(err) != verror.ErrBadArg.ID {
+		t.Errorf("got %v, want %v", err, verror.ErrBadArg.ID)
+	}
+}
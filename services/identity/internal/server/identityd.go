@@ -20,10 +20,12 @@ import (
 	"v.io/v23/naming"
 	"v.io/v23/rpc"
 	"v.io/v23/security"
+	"v.io/v23/security/access"
 	"v.io/v23/verror"
 	"v.io/x/ref/lib/security/audit"
 	"v.io/x/ref/lib/signals"
 	"v.io/x/ref/services/discharger"
+	"v.io/x/ref/services/identity"
 	"v.io/x/ref/services/identity/internal/auditor"
 	"v.io/x/ref/services/identity/internal/blesser"
 	"v.io/x/ref/services/identity/internal/caveats"
@@ -32,11 +34,16 @@ import (
 	"v.io/x/ref/services/identity/internal/oauth"
 	"v.io/x/ref/services/identity/internal/revocation"
 	"v.io/x/ref/services/identity/internal/templates"
+	vrevocation "v.io/x/ref/services/revocation"
+	"v.io/x/ref/services/succession"
 )
 
 const (
 	macaroonService   = "macaroon"
 	dischargerService = "discharger"
+	auditLogService   = "auditlog"
+	successorService  = "successor"
+	revocationService = "revocations"
 )
 
 type IdentityServer struct {
@@ -50,13 +57,19 @@ type IdentityServer struct {
 	mountNamePrefix    string
 	dischargerLocation string
 	registeredApps     handlers.RegisteredAppMap
+	auditLogReaders    []security.BlessingPattern
+	dischargeValidity  time.Duration
 }
 
 // NewIdentityServer returns a IdentityServer that:
 // - uses oauthProvider to authenticate users
 // - auditor and blessingLogReader to audit the root principal and read audit logs
 // - revocationManager to store revocation data and grant discharges
-func NewIdentityServer(oauthProvider oauth.OAuthProvider, auditor audit.Auditor, blessingLogReader auditor.BlessingLogReader, revocationManager revocation.RevocationManager, caveatSelector caveats.CaveatSelector, assetsPrefix, mountNamePrefix, dischargerLocation string, registeredApps handlers.RegisteredAppMap) *IdentityServer {
+// - auditLogReaders to authorize queries of the audit logs over RPC, which are
+//   not served if it is empty
+// - dischargeValidity as the validity of discharges, which, beyond 24 hours,
+//   requires verifiers to check revocation snapshots
+func NewIdentityServer(oauthProvider oauth.OAuthProvider, auditor audit.Auditor, blessingLogReader auditor.BlessingLogReader, revocationManager revocation.RevocationManager, caveatSelector caveats.CaveatSelector, assetsPrefix, mountNamePrefix, dischargerLocation string, registeredApps handlers.RegisteredAppMap, auditLogReaders []security.BlessingPattern, dischargeValidity time.Duration) *IdentityServer {
 	return &IdentityServer{
 		oauthProvider:      oauthProvider,
 		auditor:            auditor,
@@ -67,6 +80,8 @@ func NewIdentityServer(oauthProvider oauth.OAuthProvider, auditor audit.Auditor,
 		mountNamePrefix:    mountNamePrefix,
 		dischargerLocation: dischargerLocation,
 		registeredApps:     registeredApps,
+		auditLogReaders:    auditLogReaders,
+		dischargeValidity:  dischargeValidity,
 	}
 }
 
@@ -199,7 +214,21 @@ func appendSuffixTo(objectname []string, suffix string) []string {
 // Starts the Vanadium and HTTP services for blessing, and the Vanadium service for discharging.
 // All Vanadium services are started on the same port.
 func (s *IdentityServer) setupBlessingServices(ctx, oauthCtx *context.T) (rpc.Server, []string, error) {
-	disp := newDispatcher()
+	disp := newDispatcher(s.dischargeValidity)
+	if s.revocationManager != nil {
+		disp.add(revocationService, vrevocation.RevocationListServer(&revocationList{s.revocationManager}), nil)
+	}
+	if len(s.auditLogReaders) > 0 {
+		perms := access.Permissions{}
+		for _, pattern := range s.auditLogReaders {
+			perms.Add(pattern, string(access.Read))
+		}
+		auth, err := access.PermissionsAuthorizer(perms, access.TypicalTagType())
+		if err != nil {
+			return nil, nil, err
+		}
+		disp.add(auditLogService, identity.BlessingAuditLogServer(&blessingAuditLog{s.blessingLogReader, s.revocationManager}), auth)
+	}
 	p := v23.GetPrincipal(ctx)
 	b, _ := p.BlessingStore().Default()
 	blessingNames := security.BlessingNames(p, b)
@@ -239,29 +268,49 @@ func (s *IdentityServer) setupBlessingServices(ctx, oauthCtx *context.T) (rpc.Se
 }
 
 // newDispatcher returns a dispatcher for both the blessing and the discharging
-// service.
-func newDispatcher() *dispatcher {
+// service.  The discharges are valid for dischargeValidity, see
+// dischargerlib.NewSnapshotDischarger.
+func newDispatcher(dischargeValidity time.Duration) *dispatcher {
 	d := &dispatcher{
 		m: map[string]interface{}{
 			macaroonService:   blesser.NewMacaroonBlesserServer(),
-			dischargerService: discharger.DischargerServer(dischargerlib.NewDischarger()),
+			dischargerService: discharger.DischargerServer(dischargerlib.NewSnapshotDischarger(dischargeValidity)),
+			successorService:  succession.SuccessorBlesserServer(succession.NewBlesser(succession.DefaultMaxAge)),
 		},
+		auth: make(map[string]security.Authorizer),
 	}
-	// Set up the glob invoker.
-	var children []string
-	for k, _ := range d.m {
-		children = append(children, k)
-	}
-	d.m[""] = rpc.ChildrenGlobberInvoker(children...)
+	d.setGlobber()
 	return d
 }
 
 type dispatcher struct {
-	m map[string]interface{}
+	m    map[string]interface{}
+	auth map[string]security.Authorizer
+}
+
+// add adds a service authorized by auth to the dispatcher.
+func (d *dispatcher) add(suffix string, service interface{}, auth security.Authorizer) {
+	d.m[suffix] = service
+	d.auth[suffix] = auth
+	d.setGlobber()
+}
+
+// setGlobber sets up the glob invoker.
+func (d *dispatcher) setGlobber() {
+	var children []string
+	for k, _ := range d.m {
+		if k != "" {
+			children = append(children, k)
+		}
+	}
+	d.m[""] = rpc.ChildrenGlobberInvoker(children...)
 }
 
 func (d *dispatcher) Lookup(ctx *context.T, suffix string) (interface{}, security.Authorizer, error) {
 	if invoker := d.m[suffix]; invoker != nil {
+		if auth := d.auth[suffix]; auth != nil {
+			return invoker, auth, nil
+		}
 		return invoker, security.AllowEveryone(), nil
 	}
 	return nil, nil, verror.New(verror.ErrNoExist, ctx, suffix)
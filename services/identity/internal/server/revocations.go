@@ -0,0 +1,30 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"v.io/v23"
+	"v.io/v23/context"
+	"v.io/v23/rpc"
+	"v.io/v23/verror"
+	"v.io/x/ref/services/identity/internal/revocation"
+	vrevocation "v.io/x/ref/services/revocation"
+)
+
+// revocationList implements the revocation.RevocationList service on the
+// revocation manager of an identity server.  The snapshots are signed by the
+// principal of the server, which is also the discharger of the revocation
+// caveats.
+type revocationList struct {
+	revocationManager revocation.RevocationManager
+}
+
+func (l *revocationList) Snapshot(ctx *context.T, _ rpc.ServerCall) (vrevocation.Snapshot, error) {
+	revoked, err := l.revocationManager.RevokedCaveats()
+	if err != nil {
+		return vrevocation.Snapshot{}, verror.New(verror.ErrInternal, ctx, err)
+	}
+	return vrevocation.NewSnapshot(v23.GetPrincipal(ctx), revoked)
+}
@@ -0,0 +1,48 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"v.io/v23"
+	_ "v.io/x/ref/runtime/factories/fake"
+	"v.io/x/ref/services/identity/internal/revocation"
+	"v.io/x/ref/test"
+)
+
+func TestRevocationSnapshot(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+	p := v23.GetPrincipal(ctx)
+	m := revocation.NewMockRevocationManager(ctx)
+	revoked, err := m.NewCaveat(p.PublicKey(), "discharger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.NewCaveat(p.PublicKey(), "discharger"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Revoke(revoked.ThirdPartyDetails().ID()); err != nil {
+		t.Fatal(err)
+	}
+
+	l := &revocationList{m}
+	s, err := l.Snapshot(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := s.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := key.String(), p.PublicKey().String(); got != want {
+		t.Errorf("got discharger %v, want %v", got, want)
+	}
+	if got, want := s.Revoked, []string{revoked.ThirdPartyDetails().ID()}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
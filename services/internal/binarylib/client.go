@@ -113,6 +113,27 @@ func Stat(ctx *context.T, name string) (repository.MediaInfo, error) {
 	return mediaInfo, nil
 }
 
+// Checksum returns a checksum of the content of the binary with the given
+// name, computed from the checksums of its parts as reported by the binary
+// repository.  Binaries uploaded with the same content have the same
+// checksum, which can therefore be used to identify them without downloading
+// them.
+func Checksum(ctx *context.T, name string) (string, error) {
+	client := repository.BinaryClient(name)
+	parts, _, err := client.Stat(ctx)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, part := range parts {
+		if part.Checksum == binary.MissingChecksum {
+			return "", verror.New(verror.ErrNoExist, ctx)
+		}
+		fmt.Fprintf(h, "%s %d\n", part.Checksum, part.Size)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 func download(ctx *context.T, w io.WriteSeeker, von string) (repository.MediaInfo, error) {
 	client := repository.BinaryClient(von)
 	parts, mediaInfo, err := client.Stat(ctx)
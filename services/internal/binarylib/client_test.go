@@ -190,3 +190,39 @@ func TestDownloadUrl(t *testing.T) {
 		t.Fatalf("unexpect output: got %v, want %v", got, want)
 	}
 }
+
+// TestChecksum tests the binary repository client-side library Checksum
+// method.
+func TestChecksum(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+	rg := testutil.NewRandGenerator(t.Logf)
+
+	von, cleanup := setupRepository(t, ctx)
+	defer cleanup()
+	mediaInfo := repository.MediaInfo{Type: "application/octet-stream"}
+	checksumOf := func(data []byte) string {
+		if _, err := Upload(ctx, von, data, mediaInfo); err != nil {
+			t.Fatalf("Upload(%v) failed: %v", von, err)
+		}
+		checksum, err := Checksum(ctx, von)
+		if err != nil {
+			t.Fatalf("Checksum(%v) failed: %v", von, err)
+		}
+		if err := Delete(ctx, von); err != nil {
+			t.Fatalf("Delete(%v) failed: %v", von, err)
+		}
+		return checksum
+	}
+	data := rg.RandomBytes(rg.RandomIntn(10<<20) + 1)
+	first := checksumOf(data)
+	if second := checksumOf(data); first != second {
+		t.Errorf("checksums of the same data differ: %v and %v", first, second)
+	}
+	if other := checksumOf(append(data, 0)); first == other {
+		t.Errorf("checksums of different data are the same: %v", first)
+	}
+	if _, err := Checksum(ctx, von); err == nil {
+		t.Errorf("Checksum(%v) did not fail", von)
+	}
+}
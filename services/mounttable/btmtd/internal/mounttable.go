@@ -21,6 +21,7 @@ import (
 	"v.io/v23/security/access"
 	v23mt "v.io/v23/services/mounttable"
 	"v.io/v23/verror"
+	"v.io/x/ref/lib/serverlabels"
 )
 
 const pkgPath = "v.io/x/ref/services/mounttable/btmtd/internal"
@@ -83,7 +84,7 @@ func (mt *mounttable) Mount(ctx *context.T, call rpc.ServerCall, server string,
 	if naming.Rooted(server) {
 		ep, _ = naming.SplitAddressName(server)
 	}
-	if _, err := naming.ParseEndpoint(ep); err != nil {
+	if _, err := naming.ParseEndpoint(serverlabels.Strip(ep)); err != nil {
 		return verror.New(errMalformedAddress, ctx, ep, server)
 	}
 
@@ -14,12 +14,17 @@ Usage:
 
 The mounttabled flags are:
  -acls=
-   ACL file.  Default is to allow all access.
+   ACL file.  Default is to allow all access.  Per-user limits are read from the
+   file of the same name with a .policy extension, if present.
  -name=
    If provided, causes the mount table to mount itself under this name.  The
    name may be absolute for a remote mount table service (e.g. "/<remote mt
    address>//some/suffix") or could be relative to this process' default mount
    table (e.g. "some/suffix").
+ -neighborhood-global-path=
+   If provided along with -neighborhood-name, the neighborhood is also shared
+   through global discovery under this path in the namespace, for networks
+   without multicast.
  -neighborhood-name=
    If provided, enables sharing with the local neighborhood with the provided
    name.  The address of this mount table will be published to the neighboorhood
@@ -60,6 +65,14 @@ The global flags are:
  -v23.proxy=
    object name of proxy service to use to export services across network
    boundaries
+ -v23.revocation.max-staleness=24h0m0s
+   Maximum age of the revocation snapshots against which discharges past their
+   freshness are validated.
+ -v23.revocation.refresh=1h0m0s
+   Interval at which revocation snapshots are fetched.
+ -v23.revocation.snapshots=
+   Names of the revocation list services to fetch revocation snapshots from,
+   comma separated.
  -v23.tcp.address=
    address to listen on
  -v23.tcp.protocol=wsh
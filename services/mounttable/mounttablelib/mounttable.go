@@ -21,6 +21,7 @@ import (
 	"v.io/v23/security/access"
 	"v.io/v23/services/mounttable"
 	"v.io/v23/verror"
+	"v.io/x/ref/lib/serverlabels"
 	"v.io/x/ref/lib/stats"
 	"v.io/x/ref/lib/timekeeper"
 )
@@ -64,16 +65,19 @@ type persistence interface {
 // mountTable represents a namespace.  One exists per server instance.
 type mountTable struct {
 	sync.Mutex
-	root               *node
-	superUsers         access.AccessList
-	persisting         bool
-	persist            persistence
-	nodeCounter        *stats.Integer
-	serverCounter      *stats.Integer
-	perUserNodeCounter *stats.Map
-	perUserRPCCounter  *stats.Map
-	maxNodesPerUser    int64
-	slm                *serverListManager
+	root                *node
+	superUsers          access.AccessList
+	persisting          bool
+	persist             persistence
+	nodeCounter         *stats.Integer
+	serverCounter       *stats.Integer
+	perUserNodeCounter  *stats.Map
+	perUserMountCounter *stats.Map
+	perUserRPCCounter   *stats.Map
+	policy              *policy
+	policyStats         *policyStats
+	limiter             *rateLimiter
+	slm                 *serverListManager
 }
 
 var _ rpc.Dispatcher = (*mountTable)(nil)
@@ -89,9 +93,11 @@ type mountContext struct {
 // here.  The servers are considered equivalent, i.e., RPCs to a name below this
 // point can be sent to any of these servers.
 type mount struct {
-	servers *serverList
-	mt      bool
-	leaf    bool
+	servers    *serverList
+	mt         bool
+	leaf       bool
+	creator    string
+	maxServers int // the creator's limit on servers, 0 if unlimited
 }
 
 // node is a single point in the tree representing the mount table.
@@ -114,6 +120,7 @@ type callContext struct {
 	rejected     []security.RejectedBlessing // rejected remote blessing names.
 	create       bool                        // true if we are to create traversed nodes.
 	creator      string
+	limits       Limits // the policy limits for the client.
 	ignorePerms  bool
 	ignoreLimits bool
 }
@@ -132,18 +139,31 @@ const templateVar = "%%"
 // persistDir is the directory for persisting Permissions.
 //
 // statsPrefix is the prefix for for exported statistics objects.
+//
+// Per-user limits on nodes, mounts, servers per name and RPC rate are read
+// from a JSON-encoded policy file next to permsFile, i.e. with the extension
+// of permsFile replaced by ".policy".  If there is no such file, only the
+// default limit on the number of nodes per user applies.
 func NewMountTableDispatcher(ctx *context.T, permsFile, persistDir, statsPrefix string) (rpc.Dispatcher, error) {
 	return NewMountTableDispatcherWithClock(ctx, permsFile, persistDir, statsPrefix, timekeeper.RealTime())
 }
 func NewMountTableDispatcherWithClock(ctx *context.T, permsFile, persistDir, statsPrefix string, clock timekeeper.TimeKeeper) (rpc.Dispatcher, error) {
+	policyFile := policyFileFor(permsFile)
+	p, err := readPolicyFile(ctx, policyFile)
+	if err != nil {
+		return nil, verror.New(errInvalidPolicyFile, ctx, policyFile, err)
+	}
 	mt := &mountTable{
-		root:               new(node),
-		nodeCounter:        stats.NewInteger(naming.Join(statsPrefix, "num-nodes")),
-		serverCounter:      stats.NewInteger(naming.Join(statsPrefix, "num-mounted-servers")),
-		perUserNodeCounter: stats.NewMap(naming.Join(statsPrefix, "num-nodes-per-user")),
-		perUserRPCCounter:  stats.NewMap(naming.Join(statsPrefix, "num-rpcs-per-user")),
-		maxNodesPerUser:    defaultMaxNodesPerUser,
-		slm:                newServerListManager(clock),
+		root:                new(node),
+		nodeCounter:         stats.NewInteger(naming.Join(statsPrefix, "num-nodes")),
+		serverCounter:       stats.NewInteger(naming.Join(statsPrefix, "num-mounted-servers")),
+		perUserNodeCounter:  stats.NewMap(naming.Join(statsPrefix, "num-nodes-per-user")),
+		perUserMountCounter: stats.NewMap(naming.Join(statsPrefix, "num-mounts-per-user")),
+		perUserRPCCounter:   stats.NewMap(naming.Join(statsPrefix, "num-rpcs-per-user")),
+		policy:              p,
+		policyStats:         newPolicyStats(statsPrefix),
+		limiter:             newRateLimiter(clock),
+		slm:                 newServerListManager(clock),
 	}
 	mt.root.parent = mt.newNode() // just for its lock
 	if persistDir != "" {
@@ -175,6 +195,7 @@ func (mt *mountTable) deleteNode(parent *node, child string) {
 	}
 	delete(parent.children, child)
 	mt.credit(first)
+	mt.creditMount(first.mount)
 	nodeCount := int64(0)
 	serverCount := int64(0)
 	queue := []*node{first}
@@ -190,6 +211,7 @@ func (mt *mountTable) deleteNode(parent *node, child string) {
 			queue = append(queue, ch)
 			delete(n.children, k)
 			mt.credit(ch)
+			mt.creditMount(ch.mount)
 		}
 		if n != first {
 			n.Unlock()
@@ -435,9 +457,10 @@ func (mt *mountTable) findMountPoint(cc *callContext, elems []string) (*node, []
 }
 
 // Authorize verifies that the client has access to the requested node.
-// Since we do the check at the time of access, we always return OK here.
-func (ms *mountContext) Authorize(*context.T, security.Call) error {
-	return nil
+// Since we do the check at the time of access, we only enforce the client's
+// RPC rate limit here.
+func (ms *mountContext) Authorize(ctx *context.T, call security.Call) error {
+	return ms.mt.checkRate(ctx, call)
 }
 
 // ResolveStep returns the next server in a resolution in the form of a MountEntry.  The name
@@ -509,10 +532,11 @@ func (ms *mountContext) Mount(ctx *context.T, call rpc.ServerCall, server string
 		ttlsecs = 10 * 365 * 24 * 60 * 60 // a really long time
 	}
 
-	// Make sure the server address is reasonable.
-	epString := server
-	if naming.Rooted(server) {
-		epString, _ = naming.SplitAddressName(server)
+	// Make sure the server address is reasonable.  Any labels the server
+	// attached are passed on to clients as is.
+	epString := serverlabels.Strip(server)
+	if naming.Rooted(epString) {
+		epString, _ = naming.SplitAddressName(epString)
 	}
 	_, err := naming.ParseEndpoint(epString)
 	if err != nil {
@@ -533,6 +557,7 @@ func (ms *mountContext) Mount(ctx *context.T, call rpc.ServerCall, server string
 
 	wantMT := hasMTFlag(flags)
 	wantLeaf := hasLeafFlag(flags)
+	wantReplace := hasReplaceFlag(flags)
 	if n.mount != nil {
 		if wantMT != n.mount.mt {
 			return verror.New(errMTDoesntMatch, ctx)
@@ -541,17 +566,37 @@ func (ms *mountContext) Mount(ctx *context.T, call rpc.ServerCall, server string
 			return verror.New(errLeafDoesntMatch, ctx)
 		}
 	}
+	// Obey account limits before changing anything.
+	if err := mt.checkServers(cc, n, server, wantReplace); err != nil {
+		return err
+	}
+	// Replacing a mount of the same creator doesn't change its count.
+	sameCreator := n.mount != nil && n.mount.creator == cc.creator
+	if n.mount == nil || (wantReplace && !sameCreator) {
+		if err := mt.debitMount(cc); err != nil {
+			return err
+		}
+	}
 	// Remove any existing children.
 	for child := range n.children {
 		mt.deleteNode(n, child)
 	}
 
 	nServersBefore := numServers(n)
-	if hasReplaceFlag(flags) {
+	if wantReplace {
+		if !sameCreator {
+			mt.creditMount(n.mount)
+		}
 		n.mount = nil
 	}
 	if n.mount == nil {
-		n.mount = &mount{servers: mt.slm.newServerList(), mt: wantMT, leaf: wantLeaf}
+		n.mount = &mount{
+			servers:    mt.slm.newServerList(),
+			mt:         wantMT,
+			leaf:       wantLeaf,
+			creator:    cc.creator,
+			maxServers: cc.limits.MaxServersPerName,
+		}
 	}
 	n.mount.servers.add(server, time.Duration(ttlsecs)*time.Second)
 	mt.serverCounter.Incr(numServers(n) - nServersBefore)
@@ -622,8 +667,10 @@ func (ms *mountContext) Unmount(ctx *context.T, call rpc.ServerCall, server stri
 	}
 	nServersBefore := numServers(n)
 	if server == "" {
+		mt.creditMount(n.mount)
 		n.mount = nil
 	} else if n.mount != nil && n.mount.servers.remove(server) == 0 {
+		mt.creditMount(n.mount)
 		n.mount = nil
 	}
 	mt.serverCounter.Incr(numServers(n) - nServersBefore)
@@ -902,8 +949,9 @@ func (mt *mountTable) debit(cc *callContext) error {
 	if !ok {
 		return verror.New(errTooManyNodes, cc.ctx)
 	}
-	if count > mt.maxNodesPerUser && !cc.ignoreLimits {
+	if max := cc.limits.MaxNodes; max > 0 && count > max && !cc.ignoreLimits {
 		mt.perUserNodeCounter.Incr(cc.creator, -1)
+		mt.violation(cc.ctx, cc.creator, violationNodes)
 		return verror.New(errTooManyNodes, cc.ctx)
 	}
 	return nil
@@ -926,6 +974,7 @@ func (ms *mountContext) newCallContext(ctx *context.T, call security.Call, creat
 		}
 		cc.rbn, cc.rejected = security.RemoteBlessingNames(ctx, call)
 	}
+	cc.limits = ms.mt.policy.limitsFor(cc.rbn)
 	cc.creator = ms.mt.pickCreator(cc.ctx, cc.call)
 	ms.mt.perUserRPCCounter.Incr(cc.creator, 1)
 	return ms.mt, cc
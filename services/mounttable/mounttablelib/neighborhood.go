@@ -48,10 +48,20 @@ type neighborhood struct {
 
 var _ rpc.Dispatcher = (*neighborhood)(nil)
 
+// neighborLister is implemented by the sources of neighbors that back a
+// neighborhood mount table.
+type neighborLister interface {
+	// neighbor returns the MountedServers for a particular neighbor, or
+	// nil if the neighbor is not known.
+	neighbor(instance string) []naming.MountedServer
+	// neighbors returns all neighbors and their MountedServers.
+	neighbors() map[string][]naming.MountedServer
+}
+
 type neighborhoodService struct {
 	name  string
 	elems []string
-	nh    *neighborhood
+	nh    neighborLister
 }
 
 func getPort(address string) uint16 {
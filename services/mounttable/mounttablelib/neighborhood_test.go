@@ -8,12 +8,15 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
 	"v.io/v23"
 	"v.io/v23/naming"
 	"v.io/v23/options"
+	idiscovery "v.io/x/ref/lib/discovery"
+	"v.io/x/ref/lib/discovery/plugins/mock"
 	_ "v.io/x/ref/runtime/factories/generic"
 	"v.io/x/ref/services/mounttable/mounttablelib"
 	"v.io/x/ref/test"
@@ -111,3 +114,60 @@ L3:
 		boom(t, "Missing address from resolveStep result: %v", a)
 	}
 }
+
+func TestDiscoveryNeighborhood(t *testing.T) {
+	rootCtx, shutdown := test.V23InitWithMounttable()
+	defer shutdown()
+
+	// Both neighborhoods share the same mock plugin, as if they were on the
+	// same network.
+	df, err := idiscovery.NewFactory(rootCtx, mock.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer df.Shutdown()
+
+	mstr := v23.GetNamespace(rootCtx).Roots()[0]
+	newNeighborhood := func(host string, addresses ...string) string {
+		d, err := df.New(rootCtx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nhd, err := mounttablelib.NewDiscoveryNeighborhoodDispatcher(rootCtx, host, addresses, d)
+		if err != nil {
+			boom(t, "Failed to create neighborhood server: %s\n", err)
+		}
+		_, server, err := v23.WithNewDispatchingServer(rootCtx, "", nhd)
+		if err != nil {
+			boom(t, "Failed to create neighborhood: %s", err)
+		}
+		return server.Status().Endpoints[0].Name()
+	}
+	addr1, addr2 := naming.JoinAddressName(mstr, "one"), naming.JoinAddressName(mstr, "two")
+	estr := newNeighborhood("nh1", addr1)
+	newNeighborhood("nh2", addr2)
+
+	// Wait for the neighbor to be discovered.
+	want := []string{"nh1", "nh2"}
+	var got []string
+	for tries := 0; tries < 50; tries++ {
+		got = doGlob(t, rootCtx, estr, "", "*")
+		if sort.Strings(got); reflect.DeepEqual(want, got) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("Unexpected Glob result want: %q, got: %q", want, got)
+	}
+
+	// Make sure we can resolve through the neighborhood.
+	name := naming.JoinAddressName(estr, "nh2/a/b")
+	var entry naming.MountEntry
+	if err := v23.GetClient(rootCtx).Call(rootCtx, name, "ResolveStep", nil, []interface{}{&entry}, options.Preresolved{}); err != nil {
+		boom(t, "ResolveStep: %s", err)
+	}
+	if entry.Name != "a/b" || len(entry.Servers) != 1 || entry.Servers[0].Server != addr2 {
+		t.Errorf("Unexpected ResolveStep result: %v", entry)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mounttablelib
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/discovery"
+	"v.io/v23/naming"
+	"v.io/v23/rpc"
+	"v.io/v23/security"
+	"v.io/v23/services/mounttable"
+	vdltime "v.io/v23/vdlroot/time"
+	"v.io/v23/verror"
+)
+
+const (
+	// NeighborhoodInterfaceName is the interface name under which mount
+	// tables advertise themselves to the neighborhood.
+	NeighborhoodInterfaceName = "v.io/x/ref/services/mounttable/neighborhood"
+	// nhHostAttribute is the attribute of the advertisement that holds the
+	// mount table's name in the neighborhood.
+	nhHostAttribute = "host"
+	// nhNeighborTTL is the deadline given to the servers of discovered
+	// neighbors.  Discovery reports when a neighbor is lost, so this only
+	// bounds how long clients may cache the servers.
+	nhNeighborTTL = 2 * time.Minute
+)
+
+// nhSource identifies an advertisement seen by one of the discovery
+// instances of a discoveryNeighborhood.  The same advertisement may be seen
+// through several instances.
+type nhSource struct {
+	idx int
+	id  discovery.AdId
+}
+
+// discoveryNeighborhood is a neighborhood made of the mount tables found
+// by one or more discovery instances, e.g. the one returned by
+// v23.NewDiscovery, which covers the mdns, BLE and vine plugins, and a global
+// discovery instance for networks without multicast.
+type discoveryNeighborhood struct {
+	host      string
+	addresses []string
+	cancel    func()
+	wg        sync.WaitGroup
+
+	mu  sync.Mutex
+	ads map[nhSource]*discovery.Advertisement // GUARDED_BY(mu)
+}
+
+var _ rpc.Dispatcher = (*discoveryNeighborhood)(nil)
+
+// NewDiscoveryNeighborhoodDispatcher creates a new instance of a dispatcher
+// for a neighborhood service provider built on the given discovery
+// instances.  If host is not empty, the mount table at addresses is
+// advertised under that name through each of them.
+func NewDiscoveryNeighborhoodDispatcher(ctx *context.T, host string, addresses []string, ds ...discovery.T) (rpc.Dispatcher, error) {
+	if strings.Contains(host, "/") {
+		return nil, verror.New(errSlashInHostName, ctx)
+	}
+	if len(host) > 0 && len(addresses) == 0 {
+		return nil, verror.New(errNoUsefulAddresses, ctx)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	nh := &discoveryNeighborhood{
+		host:      host,
+		addresses: addresses,
+		cancel:    cancel,
+		ads:       make(map[nhSource]*discovery.Advertisement),
+	}
+	var adId discovery.AdId
+	if len(host) > 0 {
+		var err error
+		if adId, err = discovery.NewAdId(); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+	query := fmt.Sprintf("v.InterfaceName=%q", NeighborhoodInterfaceName)
+	for i, d := range ds {
+		if len(host) > 0 {
+			// All instances advertise the same id so that scanners
+			// that use several of them see a single neighbor.
+			ad := &discovery.Advertisement{
+				Id:            adId,
+				InterfaceName: NeighborhoodInterfaceName,
+				Addresses:     addresses,
+				Attributes:    discovery.Attributes{nhHostAttribute: host},
+			}
+			done, err := d.Advertise(ctx, ad, nil)
+			if err != nil {
+				nh.Stop()
+				return nil, err
+			}
+			nh.wg.Add(1)
+			go func() {
+				defer nh.wg.Done()
+				<-done
+			}()
+		}
+		updates, err := d.Scan(ctx, query)
+		if err != nil {
+			nh.Stop()
+			return nil, err
+		}
+		nh.wg.Add(1)
+		go nh.scan(ctx, i, updates)
+	}
+	return nh, nil
+}
+
+// scan records the neighbors reported by the idx'th discovery instance.
+func (nh *discoveryNeighborhood) scan(ctx *context.T, idx int, updates <-chan discovery.Update) {
+	defer nh.wg.Done()
+	for u := range updates {
+		src := nhSource{idx, u.Id()}
+		nh.mu.Lock()
+		if u.IsLost() {
+			delete(nh.ads, src)
+		} else {
+			ad := u.Advertisement()
+			nh.ads[src] = &ad
+		}
+		nh.mu.Unlock()
+		ctx.VI(2).Infof("neighborhood update %v: lost %v", src, u.IsLost())
+	}
+}
+
+// Lookup implements rpc.Dispatcher.Lookup.
+func (nh *discoveryNeighborhood) Lookup(ctx *context.T, name string) (interface{}, security.Authorizer, error) {
+	ctx.VI(1).Infof("LookupServer '%s'", name)
+	var elems []string
+	if name != "" {
+		elems = strings.Split(name, "/")
+	}
+	ns := &neighborhoodService{
+		name:  name,
+		elems: elems,
+		nh:    nh,
+	}
+	return mounttable.MountTableServer(ns), nh, nil
+}
+
+func (nh *discoveryNeighborhood) Authorize(*context.T, security.Call) error {
+	// Like the mdns neighborhood, everything in the neighborhood is
+	// visible to everyone.
+	return nil
+}
+
+// Stop stops advertising and scanning.
+func (nh *discoveryNeighborhood) Stop() {
+	nh.cancel()
+	nh.wg.Wait()
+}
+
+// neighbor implements neighborLister.neighbor.
+func (nh *discoveryNeighborhood) neighbor(instance string) []naming.MountedServer {
+	return nh.neighbors()[instance]
+}
+
+// neighbors implements neighborLister.neighbors.
+func (nh *discoveryNeighborhood) neighbors() map[string][]naming.MountedServer {
+	deadline := vdltime.Deadline{Time: time.Now().Add(nhNeighborTTL)}
+	// Use a map per neighbor to dedup any addresses seen.
+	addrMaps := make(map[string]map[string]bool)
+	add := func(host string, addrs []string) {
+		if host == "" || strings.Contains(host, "/") {
+			return
+		}
+		m := addrMaps[host]
+		if m == nil {
+			m = make(map[string]bool)
+			addrMaps[host] = m
+		}
+		for _, addr := range addrs {
+			m[addr] = true
+		}
+	}
+	// Discovery does not report our own advertisements back to us.
+	add(nh.host, nh.addresses)
+	nh.mu.Lock()
+	for _, ad := range nh.ads {
+		add(ad.Attributes[nhHostAttribute], ad.Addresses)
+	}
+	nh.mu.Unlock()
+
+	neighbors := make(map[string][]naming.MountedServer, len(addrMaps))
+	for host, m := range addrMaps {
+		var reply []naming.MountedServer
+		for addr := range m {
+			reply = append(reply, naming.MountedServer{Server: addr, Deadline: deadline})
+		}
+		neighbors[host] = reply
+	}
+	return neighbors
+}
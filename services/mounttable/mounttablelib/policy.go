@@ -0,0 +1,275 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mounttablelib
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/naming"
+	"v.io/v23/security"
+	"v.io/v23/verror"
+	"v.io/x/ref/lib/stats"
+	"v.io/x/ref/lib/timekeeper"
+)
+
+var (
+	errTooManyMounts     = verror.Register(pkgPath+".errTooManyMounts", verror.NoRetry, "{1:}{2:} User has exceeded his mount limit {:_}")
+	errTooManyServers    = verror.Register(pkgPath+".errTooManyServers", verror.NoRetry, "{1:}{2:} too many servers mounted on {3}{:_}")
+	errRateLimited       = verror.Register(pkgPath+".errRateLimited", verror.RetryBackoff, "{1:}{2:} User {3} has exceeded his RPC rate limit{:_}")
+	errInvalidPolicyFile = verror.Register(pkgPath+".errInvalidPolicyFile", verror.NoRetry, "{1:}{2:} policy file {3} invalid {:_}")
+)
+
+// Kinds of policy violations, used as keys in the policy-violations stats map.
+const (
+	violationNodes   = "nodes"
+	violationMounts  = "mounts"
+	violationServers = "servers"
+	violationRPCs    = "rpcs"
+)
+
+// policyFileExt is the extension of the policy file.  The policy file lives
+// next to the permissions file, e.g. the policy for "/etc/mt.perms" is read
+// from "/etc/mt.policy".
+const policyFileExt = ".policy"
+
+// Limits are the resource limits applied to a single user of the mount table.
+// A limit of zero means that the resource is unlimited.
+type Limits struct {
+	// MaxNodes is the maximum number of nodes the user may create.
+	MaxNodes int64
+	// MaxMounts is the maximum number of mount points the user may create.
+	MaxMounts int64
+	// MaxServersPerName is the maximum number of servers that may be
+	// mounted on any mount point the user created.
+	MaxServersPerName int
+	// RPCRate is the sustained number of RPCs per second the user may make.
+	RPCRate float64
+	// RPCBurst is the number of RPCs the user may make in a burst above
+	// RPCRate.  If zero, the burst is max(1, RPCRate).
+	RPCBurst int
+}
+
+// policyRule applies Limits to the users whose blessings match Pattern.
+type policyRule struct {
+	Pattern security.BlessingPattern
+	Limits
+}
+
+// policy is the configured set of per-user limits.  The first rule that
+// matches any of the client's blessing names applies, otherwise the default
+// limits apply.
+type policy struct {
+	def   Limits
+	rules []policyRule
+}
+
+// policyFile is the JSON-encoded form of a policy file, e.g.:
+//
+//	{
+//	  "Default": { "MaxNodes": 1000, "MaxMounts": 100, "RPCRate": 20 },
+//	  "Rules": [
+//	    { "Pattern": "root", "MaxNodes": 0, "RPCRate": 0 },
+//	    { "Pattern": "dev.v.io:u:flooder", "RPCRate": 1, "MaxServersPerName": 2 }
+//	  ]
+//	}
+//
+// Any limit not specified in a rule is inherited from the default.
+type policyFile struct {
+	Default *Limits
+	Rules   []json.RawMessage
+}
+
+func defaultPolicy() *policy {
+	return &policy{def: Limits{MaxNodes: defaultMaxNodesPerUser}}
+}
+
+// policyFileFor returns the name of the policy file that accompanies
+// permsFile.
+func policyFileFor(permsFile string) string {
+	if permsFile == "" {
+		return ""
+	}
+	return strings.TrimSuffix(permsFile, filepath.Ext(permsFile)) + policyFileExt
+}
+
+// readPolicyFile reads and parses the policy file at path.  A missing file
+// results in the default policy.
+func readPolicyFile(ctx *context.T, path string) (*policy, error) {
+	ctx.VI(2).Infof("readPolicyFile(%s)", path)
+	p := defaultPolicy()
+	if path == "" {
+		return p, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return p, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var pf policyFile
+	if err := json.NewDecoder(f).Decode(&pf); err != nil {
+		return nil, err
+	}
+	if pf.Default != nil {
+		p.def = *pf.Default
+	}
+	for _, raw := range pf.Rules {
+		// Start from the default so that unspecified limits are inherited.
+		r := policyRule{Limits: p.def}
+		if err := json.Unmarshal(raw, &r); err != nil {
+			return nil, err
+		}
+		if r.Pattern == "" {
+			return nil, verror.New(errInvalidPolicyFile, ctx, path, "rule without a Pattern")
+		}
+		p.rules = append(p.rules, r)
+	}
+	return p, nil
+}
+
+// limitsFor returns the limits that apply to a client with the given
+// blessing names.
+func (p *policy) limitsFor(rbn []string) Limits {
+	for _, r := range p.rules {
+		if r.Pattern.MatchedBy(rbn...) {
+			return r.Limits
+		}
+	}
+	return p.def
+}
+
+// tokenBucket is the state of a single user's RPC rate limiter.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter enforces per-user RPC rates.
+type rateLimiter struct {
+	sync.Mutex
+	clock   timekeeper.TimeKeeper
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter(clock timekeeper.TimeKeeper) *rateLimiter {
+	return &rateLimiter{clock: clock, buckets: make(map[string]*tokenBucket)}
+}
+
+// allow returns true if user may make another RPC under limits.
+func (rl *rateLimiter) allow(user string, limits Limits) bool {
+	if limits.RPCRate <= 0 {
+		return true
+	}
+	burst := float64(limits.RPCBurst)
+	if burst <= 0 {
+		burst = limits.RPCRate
+		if burst < 1 {
+			burst = 1
+		}
+	}
+	now := rl.clock.Now()
+	rl.Lock()
+	defer rl.Unlock()
+	b, ok := rl.buckets[user]
+	if !ok {
+		b = &tokenBucket{tokens: burst, last: now}
+		rl.buckets[user] = b
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * limits.RPCRate
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// policyStats exports the policy violations.
+type policyStats struct {
+	violations        *stats.Map // keyed by kind of violation
+	perUserViolations *stats.Map // keyed by user
+}
+
+func newPolicyStats(statsPrefix string) *policyStats {
+	return &policyStats{
+		violations:        stats.NewMap(naming.Join(statsPrefix, "policy-violations")),
+		perUserViolations: stats.NewMap(naming.Join(statsPrefix, "policy-violations-per-user")),
+	}
+}
+
+// violation records that user has violated the policy.
+func (mt *mountTable) violation(ctx *context.T, user, kind string) {
+	ctx.VI(1).Infof("policy violation by %q: %s", user, kind)
+	mt.policyStats.violations.Incr(kind, 1)
+	mt.policyStats.perUserViolations.Incr(user, 1)
+}
+
+// checkRate enforces the RPC rate limit of the client making call.
+func (mt *mountTable) checkRate(ctx *context.T, call security.Call) error {
+	var rbn []string
+	if call != nil {
+		rbn, _ = security.RemoteBlessingNames(ctx, call)
+	}
+	limits := mt.policy.limitsFor(rbn)
+	if limits.RPCRate <= 0 {
+		return nil
+	}
+	user := mt.pickCreator(ctx, call)
+	if mt.limiter.allow(user, limits) {
+		return nil
+	}
+	mt.violation(ctx, user, violationRPCs)
+	return verror.New(errRateLimited, ctx, user)
+}
+
+// checkServers verifies that mounting server on n does not exceed the limit
+// on servers per name.  n must be locked.
+func (mt *mountTable) checkServers(cc *callContext, n *node, server string, replace bool) error {
+	if cc.ignoreLimits || replace || n.mount == nil {
+		return nil
+	}
+	// The limit is that of the creator of the mount point, not the caller.
+	max := n.mount.maxServers
+	if max <= 0 || n.mount.servers.contains(server) || n.mount.servers.len() < max {
+		return nil
+	}
+	mt.violation(cc.ctx, cc.creator, violationServers)
+	return verror.New(errTooManyServers, cc.ctx, n.fullName())
+}
+
+// debitMount debits the user for mount point creation.
+func (mt *mountTable) debitMount(cc *callContext) error {
+	count, ok := mt.perUserMountCounter.Incr(cc.creator, 1).(int64)
+	if !ok {
+		return verror.New(errTooManyMounts, cc.ctx)
+	}
+	if max := cc.limits.MaxMounts; max > 0 && count > max && !cc.ignoreLimits {
+		mt.perUserMountCounter.Incr(cc.creator, -1)
+		mt.violation(cc.ctx, cc.creator, violationMounts)
+		return verror.New(errTooManyMounts, cc.ctx)
+	}
+	return nil
+}
+
+// creditMount credits the mount point's creator for its removal.
+func (mt *mountTable) creditMount(m *mount) {
+	if m == nil {
+		return
+	}
+	mt.perUserMountCounter.Incr(m.creator, -1)
+}
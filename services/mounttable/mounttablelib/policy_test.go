@@ -0,0 +1,104 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mounttablelib_test
+
+import (
+	"testing"
+	"time"
+
+	"v.io/v23"
+	"v.io/v23/context"
+	"v.io/v23/naming"
+	"v.io/v23/options"
+	libstats "v.io/x/ref/lib/stats"
+)
+
+func tryMount(ctx *context.T, ep, suffix, service string) error {
+	return tryMountWithFlags(ctx, ep, suffix, service, 0)
+}
+
+func tryMountWithFlags(ctx *context.T, ep, suffix, service string, flags naming.MountFlag) error {
+	name := naming.JoinAddressName(ep, suffix)
+	return v23.GetClient(ctx).Call(ctx, name, "Mount", []interface{}{service, uint32(ttlSecs), flags}, nil, options.Preresolved{})
+}
+
+func TestPolicy(t *testing.T) {
+	rootCtx, aliceCtx, bobCtx, shutdown := initTest()
+	defer shutdown()
+
+	// testdata/policy.policy is picked up because it is next to the perms file.
+	stop, estr, clock := newMT(t, "testdata/policy.perms", "", "testPolicy", rootCtx)
+	defer stop()
+	server := func(s string) string { return naming.JoinAddressName(estr, s) }
+
+	// Alice may create two mount points.
+	if err := tryMount(aliceCtx, estr, "a1", server("s1")); err != nil {
+		t.Fatalf("mount a1: %v", err)
+	}
+	if err := tryMount(aliceCtx, estr, "a2", server("s1")); err != nil {
+		t.Fatalf("mount a2: %v", err)
+	}
+	if err := tryMount(aliceCtx, estr, "a3", server("s1")); err == nil {
+		t.Errorf("mount a3 succeeded, expected mount limit to be hit")
+	}
+	// Replacing her own mount at the limit doesn't change her count.
+	if err := tryMountWithFlags(aliceCtx, estr, "a2", server("s2"), naming.Replace); err != nil {
+		t.Errorf("replace a2: %v", err)
+	}
+
+	// Alice may mount two servers per name.  Refreshing a mount is fine.
+	if err := tryMount(aliceCtx, estr, "a1", server("s2")); err != nil {
+		t.Fatalf("mount a1 s2: %v", err)
+	}
+	if err := tryMount(aliceCtx, estr, "a1", server("s1")); err != nil {
+		t.Fatalf("remount a1 s1: %v", err)
+	}
+	if err := tryMount(aliceCtx, estr, "a1", server("s3")); err == nil {
+		t.Errorf("mount a1 s3 succeeded, expected server limit to be hit")
+	}
+	// The server limit belongs to the mount point, so it applies to root too.
+	if err := tryMount(rootCtx, estr, "a1", server("s3")); err == nil {
+		t.Errorf("mount a1 s3 by root succeeded, expected server limit to be hit")
+	}
+
+	// Bob may only make two RPCs in a burst and then one per second.
+	if err := tryMount(bobCtx, estr, "b", server("s1")); err != nil {
+		t.Fatalf("mount b: %v", err)
+	}
+	if err := tryMount(bobCtx, estr, "b", server("s1")); err != nil {
+		t.Fatalf("mount b: %v", err)
+	}
+	if err := tryMount(bobCtx, estr, "b", server("s1")); err == nil {
+		t.Errorf("mount b succeeded, expected rate limit to be hit")
+	}
+	clock.AdvanceTime(time.Second)
+	if err := tryMount(bobCtx, estr, "b", server("s1")); err != nil {
+		t.Errorf("mount b after waiting: %v", err)
+	}
+
+	testcases := []struct {
+		key      string
+		expected interface{}
+	}{
+		{"num-mounts-per-user/alice", int64(2)},
+		{"num-mounts-per-user/bob", int64(1)},
+		{"policy-violations/mounts", int64(1)},
+		{"policy-violations/servers", int64(2)},
+		{"policy-violations/rpcs", int64(1)},
+		{"policy-violations-per-user/alice", int64(2)},
+		{"policy-violations-per-user/bob", int64(1)},
+		{"policy-violations-per-user/root", int64(1)},
+	}
+	for _, tc := range testcases {
+		name := "testPolicy/" + tc.key
+		got, err := libstats.Value(name)
+		if err != nil {
+			t.Errorf("unexpected error getting map entry for %s: %s", name, err)
+		}
+		if got != tc.expected {
+			t.Errorf("unexpected getting map entry for %s. Got %v, want %v", name, got, tc.expected)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"v.io/v23/naming"
 	vdltime "v.io/v23/vdlroot/time"
 
+	"v.io/x/ref/lib/serverlabels"
 	"v.io/x/ref/lib/timekeeper"
 )
 
@@ -60,16 +61,23 @@ func (sl *serverList) Front() *server {
 	return sl.l.Front().Value.(*server)
 }
 
+// sameServer returns true if a and b are the same server, ignoring any
+// labels.
+func sameServer(a, b string) bool {
+	return a == b || serverlabels.Strip(a) == serverlabels.Strip(b)
+}
+
 // add to the front of the list if not already in the list, otherwise,
-// update the expiration time and move to the front of the list.  That
-// way the most recently refreshed is always first.
+// update the expiration time and labels and move to the front of the list.
+// That way the most recently refreshed is always first.
 func (sl *serverList) add(oa string, ttl time.Duration) {
 	expires := sl.m.clock.Now().Add(ttl)
 	sl.Lock()
 	defer sl.Unlock()
 	for e := sl.l.Front(); e != nil; e = e.Next() {
 		s := e.Value.(*server)
-		if s.oa == oa {
+		if sameServer(s.oa, oa) {
+			s.oa = oa
 			s.expires = expires
 			sl.l.MoveToFront(e)
 			return
@@ -82,13 +90,25 @@ func (sl *serverList) add(oa string, ttl time.Duration) {
 	sl.l.PushFront(s) // innocent until proven guilty
 }
 
+// contains returns true if oa is in the list.
+func (sl *serverList) contains(oa string) bool {
+	sl.Lock()
+	defer sl.Unlock()
+	for e := sl.l.Front(); e != nil; e = e.Next() {
+		if sameServer(e.Value.(*server).oa, oa) {
+			return true
+		}
+	}
+	return false
+}
+
 // remove an element from the list.  Return the number of elements remaining.
 func (sl *serverList) remove(oa string) int {
 	sl.Lock()
 	defer sl.Unlock()
 	for e := sl.l.Front(); e != nil; e = e.Next() {
 		s := e.Value.(*server)
-		if s.oa == oa {
+		if sameServer(s.oa, oa) {
 			sl.l.Remove(e)
 			break
 		}
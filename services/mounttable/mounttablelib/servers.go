@@ -10,9 +10,11 @@ import (
 
 	"v.io/v23"
 	"v.io/v23/context"
+	"v.io/v23/discovery"
 	"v.io/v23/naming"
 	"v.io/v23/options"
 	"v.io/v23/rpc"
+	"v.io/x/ref/lib/discovery/global"
 	"v.io/x/ref/lib/signals"
 )
 
@@ -23,7 +25,7 @@ func Main(opts Opts) error {
 }
 
 func MainWithCtx(ctx *context.T, opts Opts) error {
-	name, stop, err := StartServers(ctx, v23.GetListenSpec(ctx), opts.MountName, opts.NhName, opts.AclFile, opts.PersistDir, "mounttable")
+	name, stop, err := StartServersWithOpts(ctx, v23.GetListenSpec(ctx), opts, "mounttable")
 	if err != nil {
 		return fmt.Errorf("mounttablelib.StartServers failed: %v", err)
 	}
@@ -35,6 +37,21 @@ func MainWithCtx(ctx *context.T, opts Opts) error {
 }
 
 func StartServers(ctx *context.T, listenSpec rpc.ListenSpec, mountName, nhName, permsFile, persistDir, debugPrefix string) (string, func(), error) {
+	opts := Opts{
+		MountName:  mountName,
+		NhName:     nhName,
+		AclFile:    permsFile,
+		PersistDir: persistDir,
+	}
+	return StartServersWithOpts(ctx, listenSpec, opts, debugPrefix)
+}
+
+// StartServersWithOpts starts the mount table and, if opts.NhName is set, the
+// neighborhood mount table.  Unless the mount table listens on the loopback
+// address, the neighborhood is built on v23.NewDiscovery and, if
+// opts.NhGlobalPath is set, on global discovery under that path.
+func StartServersWithOpts(ctx *context.T, listenSpec rpc.ListenSpec, opts Opts, debugPrefix string) (string, func(), error) {
+	mountName, nhName, permsFile, persistDir := opts.MountName, opts.NhName, opts.AclFile, opts.PersistDir
 	var stopFuncs []func()
 	ctx, cancel := context.WithCancel(ctx)
 	stop := func() {
@@ -88,8 +105,24 @@ func StartServers(ctx *context.T, listenSpec rpc.ListenSpec, mountName, nhName,
 		var nh rpc.Dispatcher
 		if host == "127.0.0.1" || host == "localhost" {
 			nh, err = NewLoopbackNeighborhoodDispatcher(nhName, names...)
+			if err != nil {
+				ctx.Errorf("NewLoopbackNeighborhoodDispatcher failed: %v", err)
+				stop()
+				return "", nil, err
+			}
 		} else {
-			nh, err = NewNeighborhoodDispatcher(nhName, names...)
+			ds, err := neighborhoodDiscovery(ctx, opts.NhGlobalPath)
+			if err != nil {
+				ctx.Errorf("neighborhood discovery failed: %v", err)
+				stop()
+				return "", nil, err
+			}
+			if nh, err = NewDiscoveryNeighborhoodDispatcher(ctx, nhName, names, ds...); err != nil {
+				ctx.Errorf("NewDiscoveryNeighborhoodDispatcher failed: %v", err)
+				stop()
+				return "", nil, err
+			}
+			stopFuncs = append(stopFuncs, nh.(*discoveryNeighborhood).Stop)
 		}
 
 		ctx, nhServer, err := v23.WithNewDispatchingServer(ctx, naming.Join(mtName, "nh"), nh, options.ServesMountTable(true))
@@ -104,3 +137,21 @@ func StartServers(ctx *context.T, listenSpec rpc.ListenSpec, mountName, nhName,
 	}
 	return mtName, stop, nil
 }
+
+// neighborhoodDiscovery returns the discovery instances that feed the
+// neighborhood.
+func neighborhoodDiscovery(ctx *context.T, globalPath string) ([]discovery.T, error) {
+	d, err := v23.NewDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ds := []discovery.T{d}
+	if globalPath != "" {
+		gd, err := global.New(ctx, globalPath)
+		if err != nil {
+			return nil, err
+		}
+		ds = append(ds, gd)
+	}
+	return ds, nil
+}
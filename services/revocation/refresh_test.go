@@ -0,0 +1,178 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package revocation_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"v.io/v23"
+	"v.io/v23/context"
+	"v.io/v23/naming"
+	"v.io/v23/rpc"
+	"v.io/v23/security"
+	"v.io/v23/verror"
+	_ "v.io/x/ref/runtime/factories/generic"
+	"v.io/x/ref/services/discharger"
+	"v.io/x/ref/services/revocation"
+	"v.io/x/ref/test"
+	"v.io/x/ref/test/testutil"
+)
+
+// dischargerd issues discharges that are valid for an hour, but only with a
+// snapshot, and serves the snapshots of its revocations.
+type dischargerd struct {
+	mu      sync.Mutex
+	revoked []string
+}
+
+func (d *dischargerd) Discharge(ctx *context.T, call rpc.ServerCall, cav security.Caveat, _ security.DischargeImpetus) (security.Discharge, error) {
+	tp := cav.ThirdPartyDetails()
+	if tp == nil {
+		return security.Discharge{}, discharger.NewErrNotAThirdPartyCaveat(ctx, cav)
+	}
+	p, now := call.Security().LocalPrincipal(), time.Now()
+	expiry, err := security.NewExpiryCaveat(now.Add(time.Hour))
+	if err != nil {
+		return security.Discharge{}, err
+	}
+	snapshot, err := revocation.NewSnapshotCaveat(p.PublicKey(), tp.ID(), now)
+	if err != nil {
+		return security.Discharge{}, err
+	}
+	return p.MintDischarge(cav, expiry, snapshot)
+}
+
+func (d *dischargerd) Snapshot(ctx *context.T, call rpc.ServerCall) (revocation.Snapshot, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return revocation.NewSnapshot(call.Security().LocalPrincipal(), d.revoked)
+}
+
+func (d *dischargerd) revoke(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.revoked = append(d.revoked, id)
+}
+
+type dispatcher struct {
+	d *dischargerd
+}
+
+func (disp dispatcher) Lookup(_ *context.T, suffix string) (interface{}, security.Authorizer, error) {
+	if suffix == "revocations" {
+		return revocation.RevocationListServer(disp.d), security.AllowEveryone(), nil
+	}
+	return discharger.DischargerServer(disp.d), security.AllowEveryone(), nil
+}
+
+type service struct{}
+
+func (service) Ping(*context.T, rpc.ServerCall) error {
+	return nil
+}
+
+// authorizer allows the callers with valid blessings.
+type authorizer struct{}
+
+func (authorizer) Authorize(ctx *context.T, call security.Call) error {
+	if names, rejected := security.RemoteBlessingNames(ctx, call); len(names) == 0 {
+		return verror.New(verror.ErrNoAccess, ctx, fmt.Sprint(rejected))
+	}
+	return nil
+}
+
+func newCtxPrincipal(ctx *context.T) *context.T {
+	ctx, err := v23.WithPrincipal(ctx, testutil.NewPrincipal())
+	if err != nil {
+		panic(err)
+	}
+	return ctx
+}
+
+func TestRefresh(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+
+	var (
+		dischargerCtx, clientCtx, serverCtx = newCtxPrincipal(ctx), newCtxPrincipal(ctx), newCtxPrincipal(ctx)
+		pdischarger                         = v23.GetPrincipal(dischargerCtx)
+		root                                = testutil.NewIDProvider("root")
+	)
+	if err := root.Bless(pdischarger, "discharger"); err != nil {
+		t.Fatal(err)
+	}
+	d := &dischargerd{}
+	_, server, err := v23.WithNewDispatchingServer(dischargerCtx, "", dispatcher{d})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dischargerName := server.Status().Endpoints[0].Name()
+
+	if err := root.Bless(v23.GetPrincipal(serverCtx), "server"); err != nil {
+		t.Fatal(err)
+	}
+	_, server, err = v23.WithNewServer(serverCtx, "", service{}, authorizer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverName := server.Status().Endpoints[0].Name()
+
+	tpc, err := security.NewPublicKeyCaveat(pdischarger.PublicKey(), dischargerName, security.ThirdPartyRequirements{}, security.UnconstrainedUse())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := root.Bless(v23.GetPrincipal(clientCtx), "client", tpc); err != nil {
+		t.Fatal(err)
+	}
+	ping := func() error {
+		return v23.GetClient(clientCtx).Call(clientCtx, serverName, "Ping", nil, nil)
+	}
+	waitFor := func(want bool) {
+		start := time.Now()
+		for err := ping(); (err == nil) != want; err = ping() {
+			if time.Since(start) > 10*time.Second {
+				t.Fatalf("got %v after 10 seconds, want success %v", err, want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// The discharges of the client are long-lived, but the server has no
+	// snapshot to validate them against.
+	if err := ping(); err == nil {
+		t.Fatalf("Ping succeeded without a revocation snapshot")
+	}
+
+	// Once the server fetches the snapshots of the discharger, the caveat of
+	// the client is not revoked.
+	refreshCtx, cancel := context.WithCancel(serverCtx)
+	defer cancel()
+	go revocation.Refresh(refreshCtx, naming.Join(dischargerName, "revocations"), 10*time.Millisecond)
+	waitFor(true)
+
+	// The snapshots are also served by mirrors.
+	_, server, err = v23.WithNewServer(serverCtx, "", revocation.RevocationListServer(revocation.NewMirror(pdischarger.PublicKey())), security.AllowEveryone())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := revocation.RevocationListClient(server.Status().Endpoints[0].Name()).Snapshot(clientCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := s.Verify(); err != nil || key.String() != pdischarger.PublicKey().String() {
+		t.Errorf("got (%v, %v), want (%v, nil)", key, err, pdischarger.PublicKey())
+	}
+
+	// Once the caveat is revoked, the server rejects the same discharges
+	// with the next snapshot.
+	d.revoke(tpc.ThirdPartyDetails().ID())
+	waitFor(false)
+	if err := ping(); verror.ErrorID(err) != verror.ErrNoAccess.ID {
+		t.Errorf("got %v, want %v", err, verror.ErrNoAccess.ID)
+	}
+}
@@ -0,0 +1,513 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file was auto-generated by the vanadium vdl tool.
+// Package: revocation
+
+// Package revocation defines signed snapshots of the revocations made by a
+// discharger, and a caveat that lets verifiers check them in place of fresh
+// discharges.
+package revocation
+
+import (
+	"time"
+	"v.io/v23"
+	"v.io/v23/context"
+	"v.io/v23/i18n"
+	"v.io/v23/rpc"
+	"v.io/v23/security"
+	"v.io/v23/security/access"
+	"v.io/v23/uniqueid"
+	"v.io/v23/vdl"
+	vdltime "v.io/v23/vdlroot/time"
+	"v.io/v23/verror"
+)
+
+var _ = __VDLInit() // Must be first; see __VDLInit comments for details.
+
+//////////////////////////////////////////////////
+// Type definitions
+
+// Snapshot is the list of the third-party caveats revoked by a discharger, as
+// of Issued, signed by the discharger.
+type Snapshot struct {
+	// Discharger is the DER encoding of the public key of the discharger.
+	Discharger []byte
+	// Issued is the time at which the snapshot was made.
+	Issued time.Time
+	// Revoked are the IDs of the revoked third-party caveats.
+	Revoked []string
+	// Signature is the signature of the snapshot by Discharger.
+	Signature security.Signature
+}
+
+func (Snapshot) __VDLReflect(struct {
+	Name string `vdl:"v.io/x/ref/services/revocation.Snapshot"`
+}) {
+}
+
+func (x Snapshot) VDLIsZero() bool {
+	if len(x.Discharger) != 0 {
+		return false
+	}
+	if !x.Issued.IsZero() {
+		return false
+	}
+	if len(x.Revoked) != 0 {
+		return false
+	}
+	if !x.Signature.VDLIsZero() {
+		return false
+	}
+	return true
+}
+
+func (x Snapshot) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_struct_1); err != nil {
+		return err
+	}
+	if len(x.Discharger) != 0 {
+		if err := enc.NextFieldValueBytes(0, __VDLType_list_2, x.Discharger); err != nil {
+			return err
+		}
+	}
+	if !x.Issued.IsZero() {
+		if err := enc.NextField(1); err != nil {
+			return err
+		}
+		var wire vdltime.Time
+		if err := vdltime.TimeFromNative(&wire, x.Issued); err != nil {
+			return err
+		}
+		if err := wire.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
+	if len(x.Revoked) != 0 {
+		if err := enc.NextField(2); err != nil {
+			return err
+		}
+		if err := __VDLWriteAnon_list_1(enc, x.Revoked); err != nil {
+			return err
+		}
+	}
+	if !x.Signature.VDLIsZero() {
+		if err := enc.NextField(3); err != nil {
+			return err
+		}
+		if err := x.Signature.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func __VDLWriteAnon_list_1(enc vdl.Encoder, x []string) error {
+	if err := enc.StartValue(__VDLType_list_4); err != nil {
+		return err
+	}
+	if err := enc.SetLenHint(len(x)); err != nil {
+		return err
+	}
+	for _, elem := range x {
+		if err := enc.NextEntryValueString(vdl.StringType, elem); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextEntry(true); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x *Snapshot) VDLRead(dec vdl.Decoder) error {
+	*x = Snapshot{}
+	if err := dec.StartValue(__VDLType_struct_1); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	for {
+		index, err := dec.NextField()
+		switch {
+		case err != nil:
+			return err
+		case index == -1:
+			return dec.FinishValue()
+		}
+		if decType != __VDLType_struct_1 {
+			index = __VDLType_struct_1.FieldIndexByName(decType.Field(index).Name)
+			if index == -1 {
+				if err := dec.SkipValue(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		switch index {
+		case 0:
+			if err := dec.ReadValueBytes(-1, &x.Discharger); err != nil {
+				return err
+			}
+		case 1:
+			var wire vdltime.Time
+			if err := wire.VDLRead(dec); err != nil {
+				return err
+			}
+			if err := vdltime.TimeToNative(wire, &x.Issued); err != nil {
+				return err
+			}
+		case 2:
+			if err := __VDLReadAnon_list_1(dec, &x.Revoked); err != nil {
+				return err
+			}
+		case 3:
+			if err := x.Signature.VDLRead(dec); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func __VDLReadAnon_list_1(dec vdl.Decoder, x *[]string) error {
+	if err := dec.StartValue(__VDLType_list_4); err != nil {
+		return err
+	}
+	if len := dec.LenHint(); len > 0 {
+		*x = make([]string, 0, len)
+	} else {
+		*x = nil
+	}
+	for {
+		switch done, elem, err := dec.NextEntryValueString(); {
+		case err != nil:
+			return err
+		case done:
+			return dec.FinishValue()
+		default:
+			*x = append(*x, elem)
+		}
+	}
+}
+
+// SnapshotCaveatParam is the parameter of SnapshotCaveat.
+type SnapshotCaveatParam struct {
+	// Discharger is the DER encoding of the public key of the discharger.
+	Discharger []byte
+	// CaveatId is the ID of the third-party caveat that was discharged.
+	CaveatId string
+	// FreshUntil is the time until which the caveat is valid without a
+	// snapshot.
+	FreshUntil time.Time
+}
+
+func (SnapshotCaveatParam) __VDLReflect(struct {
+	Name string `vdl:"v.io/x/ref/services/revocation.SnapshotCaveatParam"`
+}) {
+}
+
+func (x SnapshotCaveatParam) VDLIsZero() bool {
+	if len(x.Discharger) != 0 {
+		return false
+	}
+	if x.CaveatId != "" {
+		return false
+	}
+	if !x.FreshUntil.IsZero() {
+		return false
+	}
+	return true
+}
+
+func (x SnapshotCaveatParam) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_struct_6); err != nil {
+		return err
+	}
+	if len(x.Discharger) != 0 {
+		if err := enc.NextFieldValueBytes(0, __VDLType_list_2, x.Discharger); err != nil {
+			return err
+		}
+	}
+	if x.CaveatId != "" {
+		if err := enc.NextFieldValueString(1, vdl.StringType, x.CaveatId); err != nil {
+			return err
+		}
+	}
+	if !x.FreshUntil.IsZero() {
+		if err := enc.NextField(2); err != nil {
+			return err
+		}
+		var wire vdltime.Time
+		if err := vdltime.TimeFromNative(&wire, x.FreshUntil); err != nil {
+			return err
+		}
+		if err := wire.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x *SnapshotCaveatParam) VDLRead(dec vdl.Decoder) error {
+	*x = SnapshotCaveatParam{}
+	if err := dec.StartValue(__VDLType_struct_6); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	for {
+		index, err := dec.NextField()
+		switch {
+		case err != nil:
+			return err
+		case index == -1:
+			return dec.FinishValue()
+		}
+		if decType != __VDLType_struct_6 {
+			index = __VDLType_struct_6.FieldIndexByName(decType.Field(index).Name)
+			if index == -1 {
+				if err := dec.SkipValue(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		switch index {
+		case 0:
+			if err := dec.ReadValueBytes(-1, &x.Discharger); err != nil {
+				return err
+			}
+		case 1:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				x.CaveatId = value
+			}
+		case 2:
+			var wire vdltime.Time
+			if err := wire.VDLRead(dec); err != nil {
+				return err
+			}
+			if err := vdltime.TimeToNative(wire, &x.FreshUntil); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+//////////////////////////////////////////////////
+// Const definitions
+
+// SnapshotCaveat is added by dischargers to long-lived discharges.  It
+// validates until FreshUntil, and afterwards iff the verifier has a snapshot
+// from the discharger, recent enough, in which the discharged caveat is not
+// revoked.
+var SnapshotCaveat = security.CaveatDescriptor{
+	Id: uniqueid.Id{
+		95,
+		191,
+		171,
+		29,
+		255,
+		194,
+		36,
+		14,
+		1,
+		231,
+		32,
+		2,
+		88,
+		26,
+		128,
+		0,
+	},
+	ParamType: __VDLType_struct_6,
+}
+
+//////////////////////////////////////////////////
+// Error definitions
+
+var (
+
+	// Indicates that the discharged third-party caveat has been revoked.
+	ErrRevoked = verror.Register("v.io/x/ref/services/revocation.Revoked", verror.NoRetry, "{1:}{2:} third-party caveat {3} has been revoked")
+	// Indicates that the verifier has no recent enough snapshot from the
+	// discharger.
+	ErrNoFreshSnapshot = verror.Register("v.io/x/ref/services/revocation.NoFreshSnapshot", verror.NoRetry, "{1:}{2:} no revocation snapshot from {3} is recent enough")
+)
+
+// NewErrRevoked returns an error with the ErrRevoked ID.
+func NewErrRevoked(ctx *context.T, caveatId string) error {
+	return verror.New(ErrRevoked, ctx, caveatId)
+}
+
+// NewErrNoFreshSnapshot returns an error with the ErrNoFreshSnapshot ID.
+func NewErrNoFreshSnapshot(ctx *context.T, discharger string) error {
+	return verror.New(ErrNoFreshSnapshot, ctx, discharger)
+}
+
+//////////////////////////////////////////////////
+// Interface definitions
+
+// RevocationListClientMethods is the client interface
+// containing RevocationList methods.
+//
+// RevocationList is the interface of dischargers that publish snapshots of
+// the revocations they made, and of mirrors of these snapshots.
+type RevocationListClientMethods interface {
+	// Snapshot returns a snapshot of the revocations made by the discharger.
+	Snapshot(*context.T, ...rpc.CallOpt) (Snapshot, error)
+}
+
+// RevocationListClientStub adds universal methods to RevocationListClientMethods.
+type RevocationListClientStub interface {
+	RevocationListClientMethods
+	rpc.UniversalServiceMethods
+}
+
+// RevocationListClient returns a client stub for RevocationList.
+func RevocationListClient(name string) RevocationListClientStub {
+	return implRevocationListClientStub{name}
+}
+
+type implRevocationListClientStub struct {
+	name string
+}
+
+func (c implRevocationListClientStub) Snapshot(ctx *context.T, opts ...rpc.CallOpt) (o0 Snapshot, err error) {
+	err = v23.GetClient(ctx).Call(ctx, c.name, "Snapshot", nil, []interface{}{&o0}, opts...)
+	return
+}
+
+// RevocationListServerMethods is the interface a server writer
+// implements for RevocationList.
+//
+// RevocationList is the interface of dischargers that publish snapshots of
+// the revocations they made, and of mirrors of these snapshots.
+type RevocationListServerMethods interface {
+	// Snapshot returns a snapshot of the revocations made by the discharger.
+	Snapshot(*context.T, rpc.ServerCall) (Snapshot, error)
+}
+
+// RevocationListServerStubMethods is the server interface containing
+// RevocationList methods, as expected by rpc.Server.
+// There is no difference between this interface and RevocationListServerMethods
+// since there are no streaming methods.
+type RevocationListServerStubMethods RevocationListServerMethods
+
+// RevocationListServerStub adds universal methods to RevocationListServerStubMethods.
+type RevocationListServerStub interface {
+	RevocationListServerStubMethods
+	// Describe the RevocationList interfaces.
+	Describe__() []rpc.InterfaceDesc
+}
+
+// RevocationListServer returns a server stub for RevocationList.
+// It converts an implementation of RevocationListServerMethods into
+// an object that may be used by rpc.Server.
+func RevocationListServer(impl RevocationListServerMethods) RevocationListServerStub {
+	stub := implRevocationListServerStub{
+		impl: impl,
+	}
+	// Initialize GlobState; always check the stub itself first, to handle the
+	// case where the user has the Glob method defined in their VDL source.
+	if gs := rpc.NewGlobState(stub); gs != nil {
+		stub.gs = gs
+	} else if gs := rpc.NewGlobState(impl); gs != nil {
+		stub.gs = gs
+	}
+	return stub
+}
+
+type implRevocationListServerStub struct {
+	impl RevocationListServerMethods
+	gs   *rpc.GlobState
+}
+
+func (s implRevocationListServerStub) Snapshot(ctx *context.T, call rpc.ServerCall) (Snapshot, error) {
+	return s.impl.Snapshot(ctx, call)
+}
+
+func (s implRevocationListServerStub) Globber() *rpc.GlobState {
+	return s.gs
+}
+
+func (s implRevocationListServerStub) Describe__() []rpc.InterfaceDesc {
+	return []rpc.InterfaceDesc{RevocationListDesc}
+}
+
+// RevocationListDesc describes the RevocationList interface.
+var RevocationListDesc rpc.InterfaceDesc = descRevocationList
+
+// descRevocationList hides the desc to keep godoc clean.
+var descRevocationList = rpc.InterfaceDesc{
+	Name:    "RevocationList",
+	PkgPath: "v.io/x/ref/services/revocation",
+	Doc:     "// RevocationList is the interface of dischargers that publish snapshots of\n// the revocations they made, and of mirrors of these snapshots.",
+	Methods: []rpc.MethodDesc{
+		{
+			Name: "Snapshot",
+			Doc:  "// Snapshot returns a snapshot of the revocations made by the discharger.",
+			OutArgs: []rpc.ArgDesc{
+				{"", ``}, // Snapshot
+			},
+			Tags: []*vdl.Value{vdl.ValueOf(access.Tag("Read"))},
+		},
+	},
+}
+
+// Hold type definitions in package-level variables, for better performance.
+var (
+	__VDLType_struct_1 *vdl.Type
+	__VDLType_list_2   *vdl.Type
+	__VDLType_struct_3 *vdl.Type
+	__VDLType_list_4   *vdl.Type
+	__VDLType_struct_5 *vdl.Type
+	__VDLType_struct_6 *vdl.Type
+)
+
+var __VDLInitCalled bool
+
+// __VDLInit performs vdl initialization.  It is safe to call multiple times.
+// If you have an init ordering issue, just insert the following line verbatim
+// into your source files in this package, right after the "package foo" clause:
+//
+//    var _ = __VDLInit()
+//
+// The purpose of this function is to ensure that vdl initialization occurs in
+// the right order, and very early in the init sequence.  In particular, vdl
+// registration and package variable initialization needs to occur before
+// functions like vdl.TypeOf will work properly.
+//
+// This function returns a dummy value, so that it can be used to initialize the
+// first var in the file, to take advantage of Go's defined init order.
+func __VDLInit() struct{} {
+	if __VDLInitCalled {
+		return struct{}{}
+	}
+	__VDLInitCalled = true
+
+	// Register types.
+	vdl.Register((*Snapshot)(nil))
+	vdl.Register((*SnapshotCaveatParam)(nil))
+
+	// Initialize type definitions.
+	__VDLType_struct_1 = vdl.TypeOf((*Snapshot)(nil)).Elem()
+	__VDLType_list_2 = vdl.TypeOf((*[]byte)(nil))
+	__VDLType_struct_3 = vdl.TypeOf((*vdltime.Time)(nil)).Elem()
+	__VDLType_list_4 = vdl.TypeOf((*[]string)(nil))
+	__VDLType_struct_5 = vdl.TypeOf((*security.Signature)(nil)).Elem()
+	__VDLType_struct_6 = vdl.TypeOf((*SnapshotCaveatParam)(nil)).Elem()
+
+	// Set error format strings.
+	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrRevoked.ID), "{1:}{2:} third-party caveat {3} has been revoked")
+	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrNoFreshSnapshot.ID), "{1:}{2:} no revocation snapshot from {3} is recent enough")
+
+	return struct{}{}
+}
@@ -0,0 +1,248 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package revocation
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"sync"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/rpc"
+	"v.io/v23/security"
+	"v.io/v23/verror"
+)
+
+const pkgPath = "v.io/x/ref/services/revocation"
+
+var (
+	errBadSnapshot = verror.Register(pkgPath+".errBadSnapshot", verror.NoRetry, "{1:}{2:} invalid revocation snapshot{:_}")
+	errBadCaveat   = verror.Register(pkgPath+".errBadCaveat", verror.NoRetry, "{1:}{2:} invalid snapshot caveat{:_}")
+	errNoSnapshots = verror.Register(pkgPath+".errNoSnapshots", verror.NoRetry, "{1:}{2:} context does not hold revocation snapshots{:_}")
+)
+
+// snapshotPrefix separates the messages signed for snapshots from any other
+// message signed by a discharger.
+const snapshotPrefix = "v.io/x/ref/services/revocation.Snapshot\x00"
+
+// NewSnapshot returns a Snapshot, signed by discharger, stating that the
+// third-party caveats with the given IDs are revoked.
+func NewSnapshot(discharger security.Principal, revoked []string) (Snapshot, error) {
+	der, err := discharger.PublicKey().MarshalBinary()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	s := Snapshot{
+		Discharger: der,
+		Issued:     time.Now(),
+		Revoked:    append([]string(nil), revoked...),
+	}
+	sort.Strings(s.Revoked)
+	if s.Signature, err = discharger.Sign(s.message()); err != nil {
+		return Snapshot{}, err
+	}
+	return s, nil
+}
+
+// message returns the message signed in s.
+func (s Snapshot) message() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(snapshotPrefix)
+	binary.Write(&buf, binary.BigEndian, uint32(len(s.Discharger)))
+	buf.Write(s.Discharger)
+	binary.Write(&buf, binary.BigEndian, s.Issued.UnixNano())
+	binary.Write(&buf, binary.BigEndian, uint32(len(s.Revoked)))
+	for _, id := range s.Revoked {
+		binary.Write(&buf, binary.BigEndian, uint32(len(id)))
+		buf.WriteString(id)
+	}
+	digest := sha256.Sum256(buf.Bytes())
+	return digest[:]
+}
+
+// Verify returns the public key of the discharger of s if its signature is
+// valid, and an error otherwise.
+func (s Snapshot) Verify() (security.PublicKey, error) {
+	key, err := security.UnmarshalPublicKey(s.Discharger)
+	if err != nil {
+		return nil, verror.New(errBadSnapshot, nil, err)
+	}
+	if !bytes.Equal(s.Signature.Purpose, []byte(security.SignatureForMessageSigning)) || !s.Signature.Verify(key, s.message()) {
+		return nil, verror.New(errBadSnapshot, nil, "signature verification failed")
+	}
+	return key, nil
+}
+
+// NewSnapshotCaveat returns a SnapshotCaveat for a discharge, by discharger,
+// of the third-party caveat with the given ID.  The caveat validates without
+// a snapshot until freshUntil.
+func NewSnapshotCaveat(discharger security.PublicKey, caveatID string, freshUntil time.Time) (security.Caveat, error) {
+	der, err := discharger.MarshalBinary()
+	if err != nil {
+		return security.Caveat{}, err
+	}
+	return security.NewCaveat(SnapshotCaveat, SnapshotCaveatParam{
+		Discharger: der,
+		CaveatId:   caveatID,
+		FreshUntil: freshUntil,
+	})
+}
+
+// verifiedSnapshot is a snapshot held by a context.
+type verifiedSnapshot struct {
+	snapshot Snapshot
+	revoked  map[string]bool
+}
+
+// snapshotStore holds the most recent snapshot from each discharger.
+type snapshotStore struct {
+	maxStaleness time.Duration
+
+	mu sync.RWMutex
+	// snapshots maps the DER encoded public key of a discharger to its
+	// most recent snapshot.
+	snapshots map[string]verifiedSnapshot // GUARDED_BY(mu)
+}
+
+type storeKey struct{}
+
+// WithSnapshots returns a context in which SnapshotCaveats are validated
+// against the snapshots added to it by AddSnapshot, Fetch or Refresh, as long
+// as they are at most maxStaleness old.  Discharges issued with a
+// SnapshotCaveat are thus accepted, after their FreshUntil time, for at most
+// maxStaleness after the revocation of the discharged caveat.
+//
+// The runtime returns such a context, with the snapshots fetched from the
+// services named by the --v23.revocation.snapshots flag.
+func WithSnapshots(ctx *context.T, maxStaleness time.Duration) *context.T {
+	return context.WithValue(ctx, storeKey{}, &snapshotStore{
+		maxStaleness: maxStaleness,
+		snapshots:    make(map[string]verifiedSnapshot),
+	})
+}
+
+func getStore(ctx *context.T) *snapshotStore {
+	st, _ := ctx.Value(storeKey{}).(*snapshotStore)
+	return st
+}
+
+// AddSnapshot verifies s and, unless ctx already holds a more recent snapshot
+// from the same discharger, validates SnapshotCaveats against it in ctx, which
+// must have been returned by WithSnapshots.
+func AddSnapshot(ctx *context.T, s Snapshot) error {
+	st := getStore(ctx)
+	if st == nil {
+		return verror.New(errNoSnapshots, ctx)
+	}
+	if _, err := s.Verify(); err != nil {
+		return err
+	}
+	revoked := make(map[string]bool, len(s.Revoked))
+	for _, id := range s.Revoked {
+		revoked[id] = true
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if cur, ok := st.snapshots[string(s.Discharger)]; ok && cur.snapshot.Issued.After(s.Issued) {
+		return nil
+	}
+	st.snapshots[string(s.Discharger)] = verifiedSnapshot{snapshot: s, revoked: revoked}
+	return nil
+}
+
+// freshSnapshot returns the snapshot held by ctx from the discharger, if it is
+// at most as old as allowed.
+func freshSnapshot(ctx *context.T, discharger []byte) (verifiedSnapshot, bool) {
+	st := getStore(ctx)
+	if st == nil {
+		return verifiedSnapshot{}, false
+	}
+	st.mu.RLock()
+	s, ok := st.snapshots[string(discharger)]
+	st.mu.RUnlock()
+	if !ok || time.Since(s.snapshot.Issued) > st.maxStaleness {
+		return verifiedSnapshot{}, false
+	}
+	return s, true
+}
+
+// Fetch gets a snapshot from the RevocationList service at name and adds it
+// to ctx with AddSnapshot.
+func Fetch(ctx *context.T, name string) error {
+	s, err := RevocationListClient(name).Snapshot(ctx)
+	if err != nil {
+		return err
+	}
+	return AddSnapshot(ctx, s)
+}
+
+// Refresh calls Fetch for name every interval until ctx is canceled.  Errors
+// are logged; the snapshots already held remain in use until they are too
+// stale.
+func Refresh(ctx *context.T, name string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := Fetch(ctx, name); err != nil {
+			ctx.Errorf("failed to fetch revocation snapshot from %v: %v", name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// NewMirror returns a RevocationList service that serves the most recent
+// snapshot from discharger held by the context of the server, so that
+// snapshots can be published in the namespace by servers other than the
+// discharger.  Since snapshots are signed, verifiers need not trust the
+// mirror.
+func NewMirror(discharger security.PublicKey) RevocationListServerMethods {
+	return &mirror{discharger}
+}
+
+type mirror struct {
+	discharger security.PublicKey
+}
+
+func (m *mirror) Snapshot(ctx *context.T, _ rpc.ServerCall) (Snapshot, error) {
+	der, err := m.discharger.MarshalBinary()
+	if err != nil {
+		return Snapshot{}, verror.Convert(verror.ErrInternal, ctx, err)
+	}
+	s, ok := freshSnapshot(ctx, der)
+	if !ok {
+		return Snapshot{}, NewErrNoFreshSnapshot(ctx, m.discharger.String())
+	}
+	return s.snapshot, nil
+}
+
+func validateSnapshotCaveat(ctx *context.T, _ security.Call, param SnapshotCaveatParam) error {
+	now := time.Now()
+	if now.Before(param.FreshUntil) {
+		return nil
+	}
+	s, ok := freshSnapshot(ctx, param.Discharger)
+	if !ok {
+		key, err := security.UnmarshalPublicKey(param.Discharger)
+		if err != nil {
+			return verror.New(errBadCaveat, ctx, err)
+		}
+		return NewErrNoFreshSnapshot(ctx, key.String())
+	}
+	if s.revoked[param.CaveatId] {
+		return NewErrRevoked(ctx, param.CaveatId)
+	}
+	return nil
+}
+
+func init() {
+	security.RegisterCaveatValidator(SnapshotCaveat, validateSnapshotCaveat)
+}
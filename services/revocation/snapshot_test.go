@@ -0,0 +1,73 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package revocation
+
+import (
+	"testing"
+	"time"
+
+	"v.io/v23/verror"
+	_ "v.io/x/ref/runtime/factories/fake"
+	"v.io/x/ref/test"
+	"v.io/x/ref/test/testutil"
+)
+
+func TestSnapshotCaveat(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+	discharger := testutil.NewPrincipal("discharger")
+	der, err := discharger.PublicKey().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSnapshotCaveat(discharger.PublicKey(), "revoked", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddSnapshot(ctx, Snapshot{}); verror.ErrorID(err) != errNoSnapshots.ID {
+		t.Errorf("got %v, want %v", err, errNoSnapshots.ID)
+	}
+	ctx = WithSnapshots(ctx, time.Hour)
+	validate := func(caveatID string, freshUntil time.Time) error {
+		return validateSnapshotCaveat(ctx, nil, SnapshotCaveatParam{Discharger: der, CaveatId: caveatID, FreshUntil: freshUntil})
+	}
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	// Without a snapshot, the caveat only validates until FreshUntil.
+	if err := validate("revoked", future); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+	if err := validate("revoked", past); verror.ErrorID(err) != ErrNoFreshSnapshot.ID {
+		t.Errorf("got %v, want %v", err, ErrNoFreshSnapshot.ID)
+	}
+
+	s, err := NewSnapshot(discharger, []string{"revoked"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := s
+	tampered.Revoked = nil
+	if err := AddSnapshot(ctx, tampered); err == nil {
+		t.Errorf("AddSnapshot of a tampered snapshot succeeded")
+	}
+	if err := AddSnapshot(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	if err := validate("revoked", past); verror.ErrorID(err) != ErrRevoked.ID {
+		t.Errorf("got %v, want %v", err, ErrRevoked.ID)
+	}
+	if err := validate("notrevoked", past); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+
+	// Stale snapshots are ignored.
+	ctx = WithSnapshots(ctx, time.Nanosecond)
+	if err := AddSnapshot(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	if err := validate("notrevoked", past); verror.ErrorID(err) != ErrNoFreshSnapshot.ID {
+		t.Errorf("got %v, want %v", err, ErrNoFreshSnapshot.ID)
+	}
+}
@@ -10,14 +10,235 @@
 package role
 
 import (
+	"time"
 	"v.io/v23"
 	"v.io/v23/context"
+	"v.io/v23/i18n"
 	"v.io/v23/rpc"
 	"v.io/v23/security"
+	"v.io/v23/vdl"
+	vdltime "v.io/v23/vdlroot/time"
+	"v.io/v23/verror"
+	"v.io/x/ref/services/succession"
 )
 
 var _ = __VDLInit() // Must be first; see __VDLInit comments for details.
 
+//////////////////////////////////////////////////
+// Type definitions
+
+// BlessingRequest is a request for the blessings of a role, made with
+// Role.RequestBlessings.
+type BlessingRequest struct {
+	// Id identifies the request.
+	Id string
+	// Requester are the blessing names of the requester that are members of
+	// the role.
+	Requester []string
+	// Reason is the reason for the request given by the requester.
+	Reason string
+	// Duration is the time for which the blessings are requested.  Zero
+	// indicates the maximum allowed by the role.
+	Duration time.Duration
+	// Created is the time at which the request was made.
+	Created time.Time
+	// Approver are the blessing names of the member of the role who approved
+	// the request, if any.
+	Approver []string
+}
+
+func (BlessingRequest) __VDLReflect(struct {
+	Name string `vdl:"v.io/x/ref/services/role.BlessingRequest"`
+}) {
+}
+
+func (x BlessingRequest) VDLIsZero() bool {
+	if x.Id != "" {
+		return false
+	}
+	if len(x.Requester) != 0 {
+		return false
+	}
+	if x.Reason != "" {
+		return false
+	}
+	if x.Duration != 0 {
+		return false
+	}
+	if !x.Created.IsZero() {
+		return false
+	}
+	if len(x.Approver) != 0 {
+		return false
+	}
+	return true
+}
+
+func (x BlessingRequest) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_struct_1); err != nil {
+		return err
+	}
+	if x.Id != "" {
+		if err := enc.NextFieldValueString(0, vdl.StringType, x.Id); err != nil {
+			return err
+		}
+	}
+	if len(x.Requester) != 0 {
+		if err := enc.NextField(1); err != nil {
+			return err
+		}
+		if err := __VDLWriteAnon_list_1(enc, x.Requester); err != nil {
+			return err
+		}
+	}
+	if x.Reason != "" {
+		if err := enc.NextFieldValueString(2, vdl.StringType, x.Reason); err != nil {
+			return err
+		}
+	}
+	if x.Duration != 0 {
+		if err := enc.NextField(3); err != nil {
+			return err
+		}
+		var wire vdltime.Duration
+		if err := vdltime.DurationFromNative(&wire, x.Duration); err != nil {
+			return err
+		}
+		if err := wire.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
+	if !x.Created.IsZero() {
+		if err := enc.NextField(4); err != nil {
+			return err
+		}
+		var wire vdltime.Time
+		if err := vdltime.TimeFromNative(&wire, x.Created); err != nil {
+			return err
+		}
+		if err := wire.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
+	if len(x.Approver) != 0 {
+		if err := enc.NextField(5); err != nil {
+			return err
+		}
+		if err := __VDLWriteAnon_list_1(enc, x.Approver); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func __VDLWriteAnon_list_1(enc vdl.Encoder, x []string) error {
+	if err := enc.StartValue(__VDLType_list_2); err != nil {
+		return err
+	}
+	if err := enc.SetLenHint(len(x)); err != nil {
+		return err
+	}
+	for _, elem := range x {
+		if err := enc.NextEntryValueString(vdl.StringType, elem); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextEntry(true); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x *BlessingRequest) VDLRead(dec vdl.Decoder) error {
+	*x = BlessingRequest{}
+	if err := dec.StartValue(__VDLType_struct_1); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	for {
+		index, err := dec.NextField()
+		switch {
+		case err != nil:
+			return err
+		case index == -1:
+			return dec.FinishValue()
+		}
+		if decType != __VDLType_struct_1 {
+			index = __VDLType_struct_1.FieldIndexByName(decType.Field(index).Name)
+			if index == -1 {
+				if err := dec.SkipValue(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		switch index {
+		case 0:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				x.Id = value
+			}
+		case 1:
+			if err := __VDLReadAnon_list_1(dec, &x.Requester); err != nil {
+				return err
+			}
+		case 2:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				x.Reason = value
+			}
+		case 3:
+			var wire vdltime.Duration
+			if err := wire.VDLRead(dec); err != nil {
+				return err
+			}
+			if err := vdltime.DurationToNative(wire, &x.Duration); err != nil {
+				return err
+			}
+		case 4:
+			var wire vdltime.Time
+			if err := wire.VDLRead(dec); err != nil {
+				return err
+			}
+			if err := vdltime.TimeToNative(wire, &x.Created); err != nil {
+				return err
+			}
+		case 5:
+			if err := __VDLReadAnon_list_1(dec, &x.Approver); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func __VDLReadAnon_list_1(dec vdl.Decoder, x *[]string) error {
+	if err := dec.StartValue(__VDLType_list_2); err != nil {
+		return err
+	}
+	if len := dec.LenHint(); len > 0 {
+		*x = make([]string, 0, len)
+	} else {
+		*x = nil
+	}
+	for {
+		switch done, elem, err := dec.NextEntryValueString(); {
+		case err != nil:
+			return err
+		case done:
+			return dec.FinishValue()
+		default:
+			*x = append(*x, elem)
+		}
+	}
+}
+
 //////////////////////////////////////////////////
 // Const definitions
 
@@ -25,6 +246,35 @@ var _ = __VDLInit() // Must be first; see __VDLInit comments for details.
 // blessings that end in this suffix.
 const RoleSuffix = "_role"
 
+//////////////////////////////////////////////////
+// Error definitions
+
+var (
+
+	// Indicates that the blessings of the role must be requested with
+	// RequestBlessings and approved.
+	ErrApprovalRequired = verror.Register("v.io/x/ref/services/role.ApprovalRequired", verror.NoRetry, "{1:}{2:} the blessings of this role must be requested and approved")
+	// Indicates that the request has not been approved yet.
+	ErrNotApproved = verror.Register("v.io/x/ref/services/role.NotApproved", verror.NoRetry, "{1:}{2:} request {3} has not been approved yet")
+	// Indicates that the request does not exist, or has expired.
+	ErrUnknownRequest = verror.Register("v.io/x/ref/services/role.UnknownRequest", verror.NoRetry, "{1:}{2:} unknown or expired request {3}")
+)
+
+// NewErrApprovalRequired returns an error with the ErrApprovalRequired ID.
+func NewErrApprovalRequired(ctx *context.T) error {
+	return verror.New(ErrApprovalRequired, ctx)
+}
+
+// NewErrNotApproved returns an error with the ErrNotApproved ID.
+func NewErrNotApproved(ctx *context.T, id string) error {
+	return verror.New(ErrNotApproved, ctx, id)
+}
+
+// NewErrUnknownRequest returns an error with the ErrUnknownRequest ID.
+func NewErrUnknownRequest(ctx *context.T, id string) error {
+	return verror.New(ErrUnknownRequest, ctx, id)
+}
+
 //////////////////////////////////////////////////
 // Interface definitions
 
@@ -40,8 +290,35 @@ const RoleSuffix = "_role"
 // In order to avoid granting role blessings to all delegates of a principal,
 // the role server requires that each authorized blessing presented by the
 // client have the string "_role" as suffix.
+//
+// Roles may require that their blessings be requested with RequestBlessings,
+// and that each request be approved by another member of the role before the
+// blessings are issued by SeekApprovedBlessings.
+//
+// The role server also re-issues the role blessings of a principal to the
+// successor of its key.
 type RoleClientMethods interface {
+	// SuccessorBlesser is the interface of blessers that re-issue the blessings
+	// they granted to a principal when the principal's key is rotated.
+	succession.SuccessorBlesserClientMethods
+	// SeekBlessings returns the blessings of the role.  It fails with
+	// ApprovalRequired if the role requires approval.
 	SeekBlessings(*context.T, ...rpc.CallOpt) (security.Blessings, error)
+	// RequestBlessings requests the blessings of the role for the given
+	// reason and duration, and returns the id of the request.  The
+	// blessings are obtained with SeekApprovedBlessings once the request
+	// is approved, which it is immediately if the role does not require
+	// approval.
+	RequestBlessings(_ *context.T, Reason string, Duration time.Duration, _ ...rpc.CallOpt) (string, error)
+	// ListRequests returns the outstanding requests for the role.
+	ListRequests(*context.T, ...rpc.CallOpt) ([]BlessingRequest, error)
+	// ApproveRequest approves the request with the given id.  A request can
+	// not be approved by its requester.
+	ApproveRequest(_ *context.T, Id string, _ ...rpc.CallOpt) error
+	// SeekApprovedBlessings returns the blessings of the role for the
+	// approved request, made by the caller, with the given id.  The
+	// request can only be used once.
+	SeekApprovedBlessings(_ *context.T, Id string, _ ...rpc.CallOpt) (security.Blessings, error)
 }
 
 // RoleClientStub adds universal methods to RoleClientMethods.
@@ -52,11 +329,13 @@ type RoleClientStub interface {
 
 // RoleClient returns a client stub for Role.
 func RoleClient(name string) RoleClientStub {
-	return implRoleClientStub{name}
+	return implRoleClientStub{name, succession.SuccessorBlesserClient(name)}
 }
 
 type implRoleClientStub struct {
 	name string
+
+	succession.SuccessorBlesserClientStub
 }
 
 func (c implRoleClientStub) SeekBlessings(ctx *context.T, opts ...rpc.CallOpt) (o0 security.Blessings, err error) {
@@ -64,6 +343,26 @@ func (c implRoleClientStub) SeekBlessings(ctx *context.T, opts ...rpc.CallOpt) (
 	return
 }
 
+func (c implRoleClientStub) RequestBlessings(ctx *context.T, i0 string, i1 time.Duration, opts ...rpc.CallOpt) (o0 string, err error) {
+	err = v23.GetClient(ctx).Call(ctx, c.name, "RequestBlessings", []interface{}{i0, i1}, []interface{}{&o0}, opts...)
+	return
+}
+
+func (c implRoleClientStub) ListRequests(ctx *context.T, opts ...rpc.CallOpt) (o0 []BlessingRequest, err error) {
+	err = v23.GetClient(ctx).Call(ctx, c.name, "ListRequests", nil, []interface{}{&o0}, opts...)
+	return
+}
+
+func (c implRoleClientStub) ApproveRequest(ctx *context.T, i0 string, opts ...rpc.CallOpt) (err error) {
+	err = v23.GetClient(ctx).Call(ctx, c.name, "ApproveRequest", []interface{}{i0}, nil, opts...)
+	return
+}
+
+func (c implRoleClientStub) SeekApprovedBlessings(ctx *context.T, i0 string, opts ...rpc.CallOpt) (o0 security.Blessings, err error) {
+	err = v23.GetClient(ctx).Call(ctx, c.name, "SeekApprovedBlessings", []interface{}{i0}, []interface{}{&o0}, opts...)
+	return
+}
+
 // RoleServerMethods is the interface a server writer
 // implements for Role.
 //
@@ -76,8 +375,35 @@ func (c implRoleClientStub) SeekBlessings(ctx *context.T, opts ...rpc.CallOpt) (
 // In order to avoid granting role blessings to all delegates of a principal,
 // the role server requires that each authorized blessing presented by the
 // client have the string "_role" as suffix.
+//
+// Roles may require that their blessings be requested with RequestBlessings,
+// and that each request be approved by another member of the role before the
+// blessings are issued by SeekApprovedBlessings.
+//
+// The role server also re-issues the role blessings of a principal to the
+// successor of its key.
 type RoleServerMethods interface {
+	// SuccessorBlesser is the interface of blessers that re-issue the blessings
+	// they granted to a principal when the principal's key is rotated.
+	succession.SuccessorBlesserServerMethods
+	// SeekBlessings returns the blessings of the role.  It fails with
+	// ApprovalRequired if the role requires approval.
 	SeekBlessings(*context.T, rpc.ServerCall) (security.Blessings, error)
+	// RequestBlessings requests the blessings of the role for the given
+	// reason and duration, and returns the id of the request.  The
+	// blessings are obtained with SeekApprovedBlessings once the request
+	// is approved, which it is immediately if the role does not require
+	// approval.
+	RequestBlessings(_ *context.T, _ rpc.ServerCall, Reason string, Duration time.Duration) (string, error)
+	// ListRequests returns the outstanding requests for the role.
+	ListRequests(*context.T, rpc.ServerCall) ([]BlessingRequest, error)
+	// ApproveRequest approves the request with the given id.  A request can
+	// not be approved by its requester.
+	ApproveRequest(_ *context.T, _ rpc.ServerCall, Id string) error
+	// SeekApprovedBlessings returns the blessings of the role for the
+	// approved request, made by the caller, with the given id.  The
+	// request can only be used once.
+	SeekApprovedBlessings(_ *context.T, _ rpc.ServerCall, Id string) (security.Blessings, error)
 }
 
 // RoleServerStubMethods is the server interface containing
@@ -98,7 +424,8 @@ type RoleServerStub interface {
 // an object that may be used by rpc.Server.
 func RoleServer(impl RoleServerMethods) RoleServerStub {
 	stub := implRoleServerStub{
-		impl: impl,
+		impl:                       impl,
+		SuccessorBlesserServerStub: succession.SuccessorBlesserServer(impl),
 	}
 	// Initialize GlobState; always check the stub itself first, to handle the
 	// case where the user has the Glob method defined in their VDL source.
@@ -112,19 +439,36 @@ func RoleServer(impl RoleServerMethods) RoleServerStub {
 
 type implRoleServerStub struct {
 	impl RoleServerMethods
-	gs   *rpc.GlobState
+	succession.SuccessorBlesserServerStub
+	gs *rpc.GlobState
 }
 
 func (s implRoleServerStub) SeekBlessings(ctx *context.T, call rpc.ServerCall) (security.Blessings, error) {
 	return s.impl.SeekBlessings(ctx, call)
 }
 
+func (s implRoleServerStub) RequestBlessings(ctx *context.T, call rpc.ServerCall, i0 string, i1 time.Duration) (string, error) {
+	return s.impl.RequestBlessings(ctx, call, i0, i1)
+}
+
+func (s implRoleServerStub) ListRequests(ctx *context.T, call rpc.ServerCall) ([]BlessingRequest, error) {
+	return s.impl.ListRequests(ctx, call)
+}
+
+func (s implRoleServerStub) ApproveRequest(ctx *context.T, call rpc.ServerCall, i0 string) error {
+	return s.impl.ApproveRequest(ctx, call, i0)
+}
+
+func (s implRoleServerStub) SeekApprovedBlessings(ctx *context.T, call rpc.ServerCall, i0 string) (security.Blessings, error) {
+	return s.impl.SeekApprovedBlessings(ctx, call, i0)
+}
+
 func (s implRoleServerStub) Globber() *rpc.GlobState {
 	return s.gs
 }
 
 func (s implRoleServerStub) Describe__() []rpc.InterfaceDesc {
-	return []rpc.InterfaceDesc{RoleDesc}
+	return []rpc.InterfaceDesc{RoleDesc, succession.SuccessorBlesserDesc}
 }
 
 // RoleDesc describes the Role interface.
@@ -134,10 +478,49 @@ var RoleDesc rpc.InterfaceDesc = descRole
 var descRole = rpc.InterfaceDesc{
 	Name:    "Role",
 	PkgPath: "v.io/x/ref/services/role",
-	Doc:     "// Role is an interface to request blessings from a role account server. The\n// returned blessings are bound to the client's public key thereby authorizing\n// the client to acquire the role. The server may tie the returned blessings\n// with the client's presented blessing name in order to maintain audit\n// information in the blessing.\n//\n// In order to avoid granting role blessings to all delegates of a principal,\n// the role server requires that each authorized blessing presented by the\n// client have the string \"_role\" as suffix.",
+	Doc:     "// Role is an interface to request blessings from a role account server. The\n// returned blessings are bound to the client's public key thereby authorizing\n// the client to acquire the role. The server may tie the returned blessings\n// with the client's presented blessing name in order to maintain audit\n// information in the blessing.\n//\n// In order to avoid granting role blessings to all delegates of a principal,\n// the role server requires that each authorized blessing presented by the\n// client have the string \"_role\" as suffix.\n//\n// Roles may require that their blessings be requested with RequestBlessings,\n// and that each request be approved by another member of the role before the\n// blessings are issued by SeekApprovedBlessings.\n//\n// The role server also re-issues the role blessings of a principal to the\n// successor of its key.",
+	Embeds: []rpc.EmbedDesc{
+		{"SuccessorBlesser", "v.io/x/ref/services/succession", "// SuccessorBlesser is the interface of blessers that re-issue the blessings\n// they granted to a principal when the principal's key is rotated."},
+	},
 	Methods: []rpc.MethodDesc{
 		{
 			Name: "SeekBlessings",
+			Doc:  "// SeekBlessings returns the blessings of the role.  It fails with\n// ApprovalRequired if the role requires approval.",
+			OutArgs: []rpc.ArgDesc{
+				{"", ``}, // security.Blessings
+			},
+		},
+		{
+			Name: "RequestBlessings",
+			Doc:  "// RequestBlessings requests the blessings of the role for the given\n// reason and duration, and returns the id of the request.  The\n// blessings are obtained with SeekApprovedBlessings once the request\n// is approved, which it is immediately if the role does not require\n// approval.",
+			InArgs: []rpc.ArgDesc{
+				{"Reason", ``},   // string
+				{"Duration", ``}, // time.Duration
+			},
+			OutArgs: []rpc.ArgDesc{
+				{"", ``}, // string
+			},
+		},
+		{
+			Name: "ListRequests",
+			Doc:  "// ListRequests returns the outstanding requests for the role.",
+			OutArgs: []rpc.ArgDesc{
+				{"", ``}, // []BlessingRequest
+			},
+		},
+		{
+			Name: "ApproveRequest",
+			Doc:  "// ApproveRequest approves the request with the given id.  A request can\n// not be approved by its requester.",
+			InArgs: []rpc.ArgDesc{
+				{"Id", ``}, // string
+			},
+		},
+		{
+			Name: "SeekApprovedBlessings",
+			Doc:  "// SeekApprovedBlessings returns the blessings of the role for the\n// approved request, made by the caller, with the given id.  The\n// request can only be used once.",
+			InArgs: []rpc.ArgDesc{
+				{"Id", ``}, // string
+			},
 			OutArgs: []rpc.ArgDesc{
 				{"", ``}, // security.Blessings
 			},
@@ -145,6 +528,14 @@ var descRole = rpc.InterfaceDesc{
 	},
 }
 
+// Hold type definitions in package-level variables, for better performance.
+var (
+	__VDLType_struct_1 *vdl.Type
+	__VDLType_list_2   *vdl.Type
+	__VDLType_struct_3 *vdl.Type
+	__VDLType_struct_4 *vdl.Type
+)
+
 var __VDLInitCalled bool
 
 // __VDLInit performs vdl initialization.  It is safe to call multiple times.
@@ -166,5 +557,19 @@ func __VDLInit() struct{} {
 	}
 	__VDLInitCalled = true
 
+	// Register types.
+	vdl.Register((*BlessingRequest)(nil))
+
+	// Initialize type definitions.
+	__VDLType_struct_1 = vdl.TypeOf((*BlessingRequest)(nil)).Elem()
+	__VDLType_list_2 = vdl.TypeOf((*[]string)(nil))
+	__VDLType_struct_3 = vdl.TypeOf((*vdltime.Duration)(nil)).Elem()
+	__VDLType_struct_4 = vdl.TypeOf((*vdltime.Time)(nil)).Elem()
+
+	// Set error format strings.
+	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrApprovalRequired.ID), "{1:}{2:} the blessings of this role must be requested and approved")
+	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrNotApproved.ID), "{1:}{2:} request {3} has not been approved yet")
+	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrUnknownRequest.ID), "{1:}{2:} unknown or expired request {3}")
+
 	return struct{}{}
 }
@@ -0,0 +1,157 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"v.io/v23/security"
+
+	"v.io/x/ref/services/role"
+)
+
+// requestTTL is the time during which a blessing request can be approved and
+// redeemed.
+const requestTTL = time.Hour
+
+// pendingRequest is a blessing request that has not been redeemed yet.
+type pendingRequest struct {
+	role.BlessingRequest
+	// The role for which the request was made.
+	role string
+	// The public key of the requester.  Only the requester can redeem the
+	// request.
+	key security.PublicKey
+	// Whether the request was approved.
+	approved bool
+}
+
+// requestStore holds the pending requests of all the roles of a server.
+type requestStore struct {
+	mu       sync.Mutex
+	requests map[string]*pendingRequest // GUARDED_BY(mu)
+}
+
+func newRequestStore() *requestStore {
+	return &requestStore{requests: make(map[string]*pendingRequest)}
+}
+
+func newRequestID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// add stores r, under a new ID, and returns a copy of it.
+func (s *requestStore) add(r *pendingRequest) (role.BlessingRequest, error) {
+	id, err := newRequestID()
+	if err != nil {
+		return role.BlessingRequest{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireLocked()
+	r.Id = id
+	s.requests[id] = r
+	return r.BlessingRequest, nil
+}
+
+// list returns the pending requests for roleName, oldest first.
+func (s *requestStore) list(roleName string) []role.BlessingRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireLocked()
+	var ret []role.BlessingRequest
+	for _, r := range s.requests {
+		if r.role == roleName {
+			ret = append(ret, r.BlessingRequest)
+		}
+	}
+	sort.Sort(byCreated(ret))
+	return ret
+}
+
+// approve records the approval of the request with the given id for roleName
+// by approver, whose public key is key.  It returns a copy of the request and
+// whether it was approved: a request can not be approved by its requester,
+// i.e. by a principal with the same key, or with the same blessing names or
+// extensions of them, e.g. dev:alice and dev:alice:phone.  Different members
+// of a shared pattern, e.g. dev:alice and dev:bob for the pattern dev, may
+// approve each other's requests.
+func (s *requestStore) approve(roleName, id string, key security.PublicKey, approver []string) (role.BlessingRequest, bool, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireLocked()
+	r, ok := s.requests[id]
+	if !ok || r.role != roleName {
+		return role.BlessingRequest{}, false, false
+	}
+	if sameKey(r.key, key) || overlap(r.Requester, approver) {
+		return r.BlessingRequest, true, false
+	}
+	r.Approver = approver
+	r.approved = true
+	return r.BlessingRequest, true, true
+}
+
+// redeem removes the request with the given id for roleName, made by the
+// principal with the given key, if it was approved.  It returns a copy of the
+// request, whether it exists and whether it was approved.
+func (s *requestStore) redeem(roleName, id string, key security.PublicKey) (role.BlessingRequest, bool, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireLocked()
+	r, ok := s.requests[id]
+	if !ok || r.role != roleName || !sameKey(r.key, key) {
+		return role.BlessingRequest{}, false, false
+	}
+	if !r.approved {
+		return r.BlessingRequest, true, false
+	}
+	delete(s.requests, id)
+	return r.BlessingRequest, true, true
+}
+
+func (s *requestStore) expireLocked() {
+	cutoff := time.Now().Add(-requestTTL)
+	for id, r := range s.requests {
+		if r.Created.Before(cutoff) {
+			delete(s.requests, id)
+		}
+	}
+}
+
+func sameKey(a, b security.PublicKey) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	return a.String() == b.String()
+}
+
+// overlap returns true if a name in a is the same as, or an extension of, a
+// name in b, or vice versa.
+func overlap(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y || strings.HasPrefix(x, y+security.ChainSeparator) || strings.HasPrefix(y, x+security.ChainSeparator) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type byCreated []role.BlessingRequest
+
+func (b byCreated) Len() int           { return len(b) }
+func (b byCreated) Less(i, j int) bool { return b[i].Created.Before(b[j].Created) }
+func (b byCreated) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
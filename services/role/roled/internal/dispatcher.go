@@ -19,6 +19,7 @@ import (
 	"v.io/x/ref/internal/logger"
 	"v.io/x/ref/services/discharger"
 	"v.io/x/ref/services/role"
+	"v.io/x/ref/services/succession"
 )
 
 const requiredSuffix = security.ChainSeparator + role.RoleSuffix
@@ -31,12 +32,22 @@ const requiredSuffix = security.ChainSeparator + role.RoleSuffix
 // service for the third-party caveats attached to the role blessings returned
 // by the role service.
 func NewDispatcher(configRoot, dischargerLocation string) rpc.Dispatcher {
-	return &dispatcher{&serverConfig{configRoot, dischargerLocation}}
+	return &dispatcher{&serverConfig{
+		root:               configRoot,
+		dischargerLocation: dischargerLocation,
+		requests:           newRequestStore(),
+		groups:             newGroupCache(),
+	}}
 }
 
 type serverConfig struct {
 	root               string
 	dischargerLocation string
+	// The blessing requests of all the roles, which outlive the
+	// roleService objects created for each call.
+	requests *requestStore
+	// The cached memberships in the groups of all the roles.
+	groups *groupCache
 }
 
 type dispatcher struct {
@@ -60,12 +71,18 @@ func (d *dispatcher) Lookup(_ *context.T, suffix string) (interface{}, security.
 		logger.Global().Errorf("loadConfig(%q, %q): %v", d.config.root, suffix, err)
 		return nil, nil, verror.Convert(verror.ErrInternal, nil, err)
 	}
-	obj := &roleService{serverConfig: d.config, role: suffix, roleConfig: roleConfig}
-	return role.RoleServer(obj), &authorizer{roleConfig}, nil
+	obj := &roleService{
+		serverConfig: d.config,
+		role:         suffix,
+		roleConfig:   roleConfig,
+		blesser:      succession.NewBlesser(succession.DefaultMaxAge),
+	}
+	return role.RoleServer(obj), &authorizer{d.config, roleConfig}, nil
 }
 
 type authorizer struct {
-	config *Config
+	serverConfig *serverConfig
+	config       *Config
 }
 
 func (a *authorizer) Authorize(ctx *context.T, call security.Call) error {
@@ -79,19 +96,37 @@ func (a *authorizer) Authorize(ctx *context.T, call security.Call) error {
 	}
 	remoteBlessingNames, _ := security.RemoteBlessingNames(ctx, call)
 
-	if hasAccess(a.config, remoteBlessingNames) {
+	if a.serverConfig.hasAccess(ctx, a.config, remoteBlessingNames) {
 		return nil
 	}
 	return verror.New(verror.ErrNoExistOrNoAccess, ctx)
 }
 
-func hasAccess(c *Config, blessingNames []string) bool {
+func (s *serverConfig) hasAccess(ctx *context.T, c *Config, blessingNames []string) bool {
 	for _, pattern := range c.Members {
 		if pattern.MatchedBy(blessingNames...) {
 			return true
 		}
 	}
-	return false
+	return len(s.groupMembers(ctx, c, blessingNames)) > 0
+}
+
+// groupMembers returns the blessing names, among blessingNames, of the members
+// of the groups of the role.
+func (s *serverConfig) groupMembers(ctx *context.T, c *Config, blessingNames []string) []string {
+	var results []string
+	for _, name := range blessingNames {
+		if !isRoleName(name) {
+			continue
+		}
+		for _, group := range c.Groups {
+			if s.groups.isMember(ctx, group, name) {
+				results = append(results, name)
+				break
+			}
+		}
+	}
+	return results
 }
 
 func loadExpandedConfig(fileName string, seenFiles map[string]struct{}) (*Config, error) {
@@ -117,6 +152,7 @@ func loadExpandedConfig(fileName string, seenFiles map[string]struct{}) (*Config
 			continue
 		}
 		c.Members = append(c.Members, ic.Members...)
+		c.Groups = append(c.Groups, ic.Groups...)
 	}
 	c.ImportMembers = nil
 	dedupMembers(c)
@@ -149,4 +185,12 @@ func dedupMembers(c *Config) {
 	for m := range members {
 		c.Members = append(c.Members, m)
 	}
+	groups := make(map[string]struct{})
+	for _, g := range c.Groups {
+		groups[g] = struct{}{}
+	}
+	c.Groups = nil
+	for g := range groups {
+		c.Groups = append(c.Groups, g)
+	}
 }
@@ -19,7 +19,7 @@ import (
 
 func globChildren(ctx *context.T, call rpc.GlobChildrenServerCall, serverConfig *serverConfig, m *glob.Element) error {
 	sCall := call.Security()
-	n := findRoles(ctx, sCall, serverConfig.root)
+	n := findRoles(ctx, sCall, serverConfig)
 	suffix := sCall.Suffix()
 	if len(suffix) > 0 {
 		n = n.find(strings.Split(suffix, "/"), false)
@@ -36,7 +36,8 @@ func globChildren(ctx *context.T, call rpc.GlobChildrenServerCall, serverConfig
 }
 
 // findRoles finds all the roles to which the caller has access.
-func findRoles(ctx *context.T, call security.Call, root string) *node {
+func findRoles(ctx *context.T, call security.Call, serverConfig *serverConfig) *node {
+	root := serverConfig.root
 	blessingNames, _ := security.RemoteBlessingNames(ctx, call)
 	tree := newNode()
 	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
@@ -47,7 +48,7 @@ func findRoles(ctx *context.T, call security.Call, root string) *node {
 		if err != nil {
 			return nil
 		}
-		if !hasAccess(c, blessingNames) {
+		if !serverConfig.hasAccess(ctx, c, blessingNames) {
 			return nil
 		}
 		relPath, err := filepath.Rel(root, path)
@@ -0,0 +1,121 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/security"
+	"v.io/v23/services/groups"
+
+	"v.io/x/ref/services/role"
+)
+
+const (
+	// groupsCacheTTL is the time for which the membership of a blessing
+	// name in a group is cached.
+	groupsCacheTTL = time.Minute
+	// groupsCacheSize is the maximum number of cached memberships.  When it
+	// is reached, the expired memberships are evicted, and then random ones
+	// if none has expired.
+	groupsCacheSize = 10000
+	// groupsTimeout is the timeout of the calls to the groups servers.
+	groupsTimeout = 10 * time.Second
+)
+
+type groupMember struct {
+	group, name string
+}
+
+type membership struct {
+	member bool
+	// The version of the group from which member was computed.
+	version string
+	expires time.Time
+}
+
+// groupCache caches the membership of blessing names in groups, as reported
+// by the Relate method of the groups servers.
+type groupCache struct {
+	mu      sync.Mutex
+	entries map[groupMember]membership // GUARDED_BY(mu)
+}
+
+func newGroupCache() *groupCache {
+	return &groupCache{entries: make(map[groupMember]membership)}
+}
+
+// isMember returns true iff name, a blessing name with a role.RoleSuffix
+// component, is the blessing name of a member of group acting in a role, i.e.
+// a blessing name in the group extended with role.RoleSuffix.  Errors are
+// logged and treated as non-membership.
+func (c *groupCache) isMember(ctx *context.T, group, name string) bool {
+	key := groupMember{group, name}
+	now := time.Now()
+	c.mu.Lock()
+	m, cached := c.entries[key]
+	c.mu.Unlock()
+	if cached && now.Before(m.expires) {
+		return m.member
+	}
+	tctx, cancel := context.WithTimeout(ctx, groupsTimeout)
+	defer cancel()
+	// Membership grants access, so in doubt it is under-approximated.  The
+	// group is not sent again if it still has the cached version.
+	remainder, _, version, err := groups.GroupClient(group).Relate(tctx, map[string]struct{}{name: struct{}{}}, groups.ApproximationTypeUnder, m.version, nil)
+	if err != nil {
+		ctx.Errorf("Relate(%q) on group %q failed: %v", name, group, err)
+		return false
+	}
+	if !cached || version != m.version {
+		m.member = false
+		for r := range remainder {
+			if r == "" || r == role.RoleSuffix || strings.HasPrefix(r, role.RoleSuffix+security.ChainSeparator) {
+				m.member = true
+				break
+			}
+		}
+	}
+	m.version = version
+	m.expires = now.Add(groupsCacheTTL)
+	c.put(key, m, now)
+	return m.member
+}
+
+// put caches m for key, evicting other memberships if the cache is full.
+func (c *groupCache) put(key groupMember, m membership, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= groupsCacheSize {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		// The iteration order of maps is random, so this evicts random
+		// memberships.
+		for k := range c.entries {
+			if len(c.entries) < groupsCacheSize {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = m
+}
+
+// isRoleName returns true iff name can be used to act in a role, i.e. iff it
+// has a role.RoleSuffix component.
+func isRoleName(name string) bool {
+	for _, c := range strings.Split(name, security.ChainSeparator) {
+		if c == role.RoleSuffix {
+			return true
+		}
+	}
+	return false
+}
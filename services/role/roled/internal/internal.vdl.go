@@ -34,7 +34,8 @@ type Config struct {
 	Extend bool
 	// If Audit is true, each use of the role blessing will be reported to
 	// an auditing service and will be usable only if the report was
-	// successful.
+	// successful.  The role blessings obtained with SeekApprovedBlessings
+	// are always audited, with the reason and the approvers of the request.
 	Audit bool
 	// The amount of time for which the role blessing will be valid. It is a
 	// string representation of a time.Duration, e.g. "24h". An empty string
@@ -44,6 +45,22 @@ type Config struct {
 	// communicating with peers that match at least one of these patterns.
 	// If the list is empty, all peers are allowed.
 	Peers []security.BlessingPattern
+	// If RequireApproval is true, the role blessing is only issued for
+	// requests made with RequestBlessings, and approved by another member
+	// of the role, i.e. a member none of whose blessing names match the
+	// same pattern in Members as those of the requester.
+	RequireApproval bool
+	// The maximum duration of the sessions, i.e. of the role blessings
+	// obtained with SeekBlessings or requested with RequestBlessings.  It
+	// is a string representation of a time.Duration, e.g. "1h". An empty
+	// string indicates that sessions are only limited by Expiry.
+	MaxSession string
+	// Object names of groups, served by the groups service in
+	// v.io/x/ref/services/groups.  The members of these groups, with the
+	// suffix _role, are allowed to act on behalf of the role, in addition
+	// to those that match Members.  The groups of the imported roles are
+	// imported too.
+	Groups []string
 }
 
 func (Config) __VDLReflect(struct {
@@ -70,6 +87,15 @@ func (x Config) VDLIsZero() bool {
 	if len(x.Peers) != 0 {
 		return false
 	}
+	if x.RequireApproval {
+		return false
+	}
+	if x.MaxSession != "" {
+		return false
+	}
+	if len(x.Groups) != 0 {
+		return false
+	}
 	return true
 }
 
@@ -116,6 +142,24 @@ func (x Config) VDLWrite(enc vdl.Encoder) error {
 			return err
 		}
 	}
+	if x.RequireApproval {
+		if err := enc.NextFieldValueBool(6, vdl.BoolType, x.RequireApproval); err != nil {
+			return err
+		}
+	}
+	if x.MaxSession != "" {
+		if err := enc.NextFieldValueString(7, vdl.StringType, x.MaxSession); err != nil {
+			return err
+		}
+	}
+	if len(x.Groups) != 0 {
+		if err := enc.NextField(8); err != nil {
+			return err
+		}
+		if err := __VDLWriteAnon_list_1(enc, x.Groups); err != nil {
+			return err
+		}
+	}
 	if err := enc.NextField(-1); err != nil {
 		return err
 	}
@@ -215,6 +259,24 @@ func (x *Config) VDLRead(dec vdl.Decoder) error {
 			if err := __VDLReadAnon_list_2(dec, &x.Peers); err != nil {
 				return err
 			}
+		case 6:
+			switch value, err := dec.ReadValueBool(); {
+			case err != nil:
+				return err
+			default:
+				x.RequireApproval = value
+			}
+		case 7:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				x.MaxSession = value
+			}
+		case 8:
+			if err := __VDLReadAnon_list_1(dec, &x.Groups); err != nil {
+				return err
+			}
 		}
 	}
 }
@@ -15,24 +15,35 @@ import (
 	"v.io/v23/security"
 	"v.io/v23/verror"
 
+	vsecurity "v.io/x/ref/lib/security"
 	"v.io/x/ref/services/role"
+	"v.io/x/ref/services/succession"
 )
 
 var (
 	errNoLocalBlessings = verror.Register("v.io/x/ref/services/role/roled/internal/noLocalBlessings", verror.NoRetry, "{1:}{2:} no local blessings")
+	errReasonRequired   = verror.Register("v.io/x/ref/services/role/roled/internal/reasonRequired", verror.NoRetry, "{1:}{2:} a reason is required to request the blessings of this role")
+	errSessionTooLong   = verror.Register("v.io/x/ref/services/role/roled/internal/sessionTooLong", verror.NoRetry, "{1:}{2:} requested duration {3} exceeds the maximum session length {4}")
+	errSelfApproval     = verror.Register("v.io/x/ref/services/role/roled/internal/selfApproval", verror.NoRetry, "{1:}{2:} request {3} can not be approved by its requester")
 )
 
 type roleService struct {
 	serverConfig *serverConfig
 	role         string
 	roleConfig   *Config
+	// blesser re-issues the role blessings granted to a principal to the
+	// successor of its key.
+	blesser succession.SuccessorBlesserServerMethods
 }
 
 func (i *roleService) SeekBlessings(ctx *context.T, call rpc.ServerCall) (security.Blessings, error) {
 	remoteBlessingNames, _ := security.RemoteBlessingNames(ctx, call.Security())
 	ctx.Infof("%q.SeekBlessings() called by %q", i.role, remoteBlessingNames)
 
-	members := i.filterNonMembers(remoteBlessingNames)
+	if i.roleConfig.RequireApproval {
+		return security.Blessings{}, role.NewErrApprovalRequired(ctx)
+	}
+	members := i.filterNonMembers(ctx, remoteBlessingNames)
 	if len(members) == 0 {
 		// The Authorizer should already have caught that.
 		return security.Blessings{}, verror.New(verror.ErrNoAccess, ctx)
@@ -43,8 +54,161 @@ func (i *roleService) SeekBlessings(ctx *context.T, call rpc.ServerCall) (securi
 	if err != nil {
 		return security.Blessings{}, err
 	}
+	maxSession, err := maxSession(ctx, i.roleConfig)
+	if err != nil {
+		return security.Blessings{}, err
+	}
+	if maxSession > 0 {
+		expiry, err := security.NewExpiryCaveat(time.Now().Add(maxSession))
+		if err != nil {
+			return security.Blessings{}, verror.Convert(verror.ErrInternal, ctx, err)
+		}
+		caveats = append(caveats, expiry)
+	}
+
+	return createBlessings(ctx, call.Security(), i.roleConfig.Audit, v23.GetPrincipal(ctx), extensions, caveats, i.serverConfig.dischargerLocation, nil)
+}
+
+// BlessSuccessor re-issues the role blessings of the predecessor of the caller
+// that SeekBlessings would grant the caller now, i.e. the caller must be a
+// member of the role, and the blessings are only re-issued for its current
+// member names.
+func (i *roleService) BlessSuccessor(ctx *context.T, call rpc.ServerCall, s vsecurity.KeySuccession, blessings security.Blessings) (security.Blessings, error) {
+	remoteBlessingNames, _ := security.RemoteBlessingNames(ctx, call.Security())
+	ctx.Infof("%q.BlessSuccessor(%v) called by %q", i.role, blessings, remoteBlessingNames)
+
+	if i.roleConfig.RequireApproval {
+		return security.Blessings{}, role.NewErrApprovalRequired(ctx)
+	}
+	members := i.filterNonMembers(ctx, remoteBlessingNames)
+	if len(members) == 0 {
+		// The Authorizer should already have caught that.
+		return security.Blessings{}, verror.New(verror.ErrNoAccess, ctx)
+	}
+	allowed := make(map[string]bool)
+	for _, ext := range extensions(i.roleConfig, i.role, members) {
+		allowed[ext] = true
+	}
+	current, err := succession.FilterBlessings(blessings, func(chain []security.Certificate) bool {
+		return allowed[chain[len(chain)-1].Extension]
+	})
+	if err != nil {
+		return security.Blessings{}, verror.Convert(verror.ErrInternal, ctx, err)
+	}
+	if current.IsZero() {
+		return security.Blessings{}, verror.New(vsecurity.ErrNotGrantedByBlesser, ctx, blessings)
+	}
+	return i.blesser.BlessSuccessor(ctx, call, s, current)
+}
+
+func (i *roleService) RequestBlessings(ctx *context.T, call rpc.ServerCall, reason string, duration time.Duration) (string, error) {
+	remoteBlessingNames, _ := security.RemoteBlessingNames(ctx, call.Security())
+	ctx.Infof("%q.RequestBlessings(%q, %v) called by %q", i.role, reason, duration, remoteBlessingNames)
+
+	members := i.filterNonMembers(ctx, remoteBlessingNames)
+	if len(members) == 0 {
+		// The Authorizer should already have caught that.
+		return "", verror.New(verror.ErrNoAccess, ctx)
+	}
+	if i.roleConfig.RequireApproval && reason == "" {
+		return "", verror.New(errReasonRequired, ctx)
+	}
+	if duration < 0 {
+		return "", verror.New(verror.ErrBadArg, ctx, duration)
+	}
+	maxSession, err := maxSession(ctx, i.roleConfig)
+	if err != nil {
+		return "", err
+	}
+	if maxSession > 0 && duration > maxSession {
+		return "", verror.New(errSessionTooLong, ctx, duration, maxSession)
+	}
+	r, err := i.serverConfig.requests.add(&pendingRequest{
+		BlessingRequest: role.BlessingRequest{
+			Requester: members,
+			Reason:    reason,
+			Duration:  duration,
+			Created:   time.Now(),
+		},
+		role:     i.role,
+		key:      call.Security().RemoteBlessings().PublicKey(),
+		approved: !i.roleConfig.RequireApproval,
+	})
+	if err != nil {
+		return "", verror.Convert(verror.ErrInternal, ctx, err)
+	}
+	ctx.Infof("%q: request %v by %q for %v: %q", i.role, r.Id, members, duration, reason)
+	return r.Id, nil
+}
 
-	return createBlessings(ctx, call.Security(), i.roleConfig, v23.GetPrincipal(ctx), extensions, caveats, i.serverConfig.dischargerLocation)
+func (i *roleService) ListRequests(ctx *context.T, call rpc.ServerCall) ([]role.BlessingRequest, error) {
+	return i.serverConfig.requests.list(i.role), nil
+}
+
+func (i *roleService) ApproveRequest(ctx *context.T, call rpc.ServerCall, id string) error {
+	remoteBlessingNames, _ := security.RemoteBlessingNames(ctx, call.Security())
+	ctx.Infof("%q.ApproveRequest(%q) called by %q", i.role, id, remoteBlessingNames)
+
+	members := i.filterNonMembers(ctx, remoteBlessingNames)
+	if len(members) == 0 {
+		// The Authorizer should already have caught that.
+		return verror.New(verror.ErrNoAccess, ctx)
+	}
+	r, exists, approved := i.serverConfig.requests.approve(i.role, id, call.Security().RemoteBlessings().PublicKey(), members)
+	switch {
+	case !exists:
+		return role.NewErrUnknownRequest(ctx, id)
+	case !approved:
+		return verror.New(errSelfApproval, ctx, id)
+	}
+	ctx.Infof("%q: request %v by %q approved by %q", i.role, id, r.Requester, members)
+	return nil
+}
+
+func (i *roleService) SeekApprovedBlessings(ctx *context.T, call rpc.ServerCall, id string) (security.Blessings, error) {
+	remoteBlessingNames, _ := security.RemoteBlessingNames(ctx, call.Security())
+	ctx.Infof("%q.SeekApprovedBlessings(%q) called by %q", i.role, id, remoteBlessingNames)
+
+	members := i.filterNonMembers(ctx, remoteBlessingNames)
+	if len(members) == 0 {
+		// The Authorizer should already have caught that.
+		return security.Blessings{}, verror.New(verror.ErrNoAccess, ctx)
+	}
+	r, exists, approved := i.serverConfig.requests.redeem(i.role, id, call.Security().RemoteBlessings().PublicKey())
+	switch {
+	case !exists:
+		return security.Blessings{}, role.NewErrUnknownRequest(ctx, id)
+	case !approved:
+		return security.Blessings{}, role.NewErrNotApproved(ctx, id)
+	}
+
+	extensions := extensions(i.roleConfig, i.role, members)
+	caveats, err := caveats(ctx, i.roleConfig)
+	if err != nil {
+		return security.Blessings{}, err
+	}
+	duration := r.Duration
+	if duration == 0 {
+		if duration, err = maxSession(ctx, i.roleConfig); err != nil {
+			return security.Blessings{}, err
+		}
+	}
+	if duration > 0 {
+		expiry, err := security.NewExpiryCaveat(time.Now().Add(duration))
+		if err != nil {
+			return security.Blessings{}, verror.Convert(verror.ErrInternal, ctx, err)
+		}
+		caveats = append(caveats, expiry)
+	}
+	// The reason and approver of the request are recorded by the auditing
+	// service on each use of the role blessing, even if the role is not
+	// audited otherwise.
+	auditInfo := []string{"reason:" + r.Reason}
+	for _, a := range r.Approver {
+		auditInfo = append(auditInfo, "approver:"+a)
+	}
+	ctx.Infof("%q: request %v by %q redeemed for %v", i.role, id, members, duration)
+	return createBlessings(ctx, call.Security(), true, v23.GetPrincipal(ctx), extensions, caveats, i.serverConfig.dischargerLocation, auditInfo)
 }
 
 func (i *roleService) GlobChildren__(ctx *context.T, call rpc.GlobChildrenServerCall, m *glob.Element) error {
@@ -53,21 +217,31 @@ func (i *roleService) GlobChildren__(ctx *context.T, call rpc.GlobChildrenServer
 
 // filterNonMembers returns only the blessing names that are authorized members
 // for the role.
-func (i *roleService) filterNonMembers(blessingNames []string) []string {
-	var results []string
+func (i *roleService) filterNonMembers(ctx *context.T, blessingNames []string) []string {
+	var results, others []string
 	for _, name := range blessingNames {
 		// It is not enough to know if the pattern is matched by the
 		// blessings. We need to know exactly which names matched.
 		// These names will be used later to construct the role
 		// blessings.
-		for _, pattern := range i.roleConfig.Members {
-			if pattern.MatchedBy(name) {
-				results = append(results, name)
-				break
-			}
+		if !matchesAny(i.roleConfig.Members, name) {
+			others = append(others, name)
+			continue
 		}
+		results = append(results, name)
 	}
-	return results
+	// The names that don't match any pattern may still be those of members
+	// of the groups of the role.
+	return append(results, i.serverConfig.groupMembers(ctx, i.roleConfig, others)...)
+}
+
+func matchesAny(patterns []security.BlessingPattern, name string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchedBy(name) {
+			return true
+		}
+	}
+	return false
 }
 
 func extensions(config *Config, roleStr string, blessingNames []string) []string {
@@ -108,7 +282,23 @@ func caveats(ctx *context.T, config *Config) ([]security.Caveat, error) {
 	return caveats, nil
 }
 
-func createBlessings(ctx *context.T, call security.Call, config *Config, principal security.Principal, extensions []string, caveats []security.Caveat, dischargerLocation string) (security.Blessings, error) {
+// maxSession returns the maximum duration of the sessions of the role, or zero
+// if it has none.
+func maxSession(ctx *context.T, config *Config) (time.Duration, error) {
+	if config.MaxSession == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(config.MaxSession)
+	if err != nil {
+		return 0, verror.Convert(verror.ErrInternal, ctx, err)
+	}
+	return d, nil
+}
+
+// createBlessings returns the role blessings with the given extensions and
+// caveats.  If audit is true, the blessing names and auditInfo are reported on
+// each use of the blessings.
+func createBlessings(ctx *context.T, call security.Call, audit bool, principal security.Principal, extensions []string, caveats []security.Caveat, dischargerLocation string, auditInfo []string) (security.Blessings, error) {
 	blessWith := call.LocalBlessings()
 	blessWithNames := security.LocalBlessingNames(ctx, call)
 	publicKey := call.RemoteBlessings().PublicKey()
@@ -119,7 +309,7 @@ func createBlessings(ctx *context.T, call security.Call, config *Config, princip
 	var ret security.Blessings
 	for _, ext := range extensions {
 		cav := caveats
-		if config.Audit {
+		if audit {
 			// TODO(rthellend): This third-party caveat will only work with a single
 			// discharger service. We need a way to allow multiple instances of this
 			// service to be interchangeable.
@@ -128,7 +318,7 @@ func createBlessings(ctx *context.T, call security.Call, config *Config, princip
 			for i, n := range blessWithNames {
 				fullNames[i] = n + security.ChainSeparator + ext
 			}
-			loggingCaveat, err := security.NewCaveat(LoggingCaveat, fullNames)
+			loggingCaveat, err := security.NewCaveat(LoggingCaveat, append(fullNames, auditInfo...))
 			if err != nil {
 				return security.Blessings{}, verror.Convert(verror.ErrInternal, ctx, err)
 			}
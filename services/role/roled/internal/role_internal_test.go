@@ -6,12 +6,14 @@ package internal
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 
 	"v.io/v23/security"
 )
@@ -75,3 +77,27 @@ func WriteConfig(t *testing.T, config Config, fileName string) {
 		t.Fatalf("ioutil.WriteFile(%q, %q) failed: %v", fileName, string(mConf), err)
 	}
 }
+
+func TestGroupCacheSize(t *testing.T) {
+	c := newGroupCache()
+	now := time.Now()
+	m := membership{member: true, expires: now.Add(groupsCacheTTL)}
+	for i := 0; i < groupsCacheSize+10; i++ {
+		c.put(groupMember{"group", fmt.Sprintf("user%d", i)}, m, now)
+		if got := len(c.entries); got > groupsCacheSize {
+			t.Fatalf("unexpected cache size after %d insertions. Got %d, expected at most %d", i+1, got, groupsCacheSize)
+		}
+	}
+	// Updating a cached membership doesn't evict anything.
+	for key := range c.entries {
+		c.put(key, m, now)
+		break
+	}
+	if got := len(c.entries); got != groupsCacheSize {
+		t.Errorf("unexpected cache size. Got %d, expected %d", got, groupsCacheSize)
+	}
+	// The last membership is cached.
+	if _, cached := c.entries[groupMember{"group", fmt.Sprintf("user%d", groupsCacheSize+9)}]; !cached {
+		t.Errorf("the last membership is not cached")
+	}
+}
@@ -10,17 +10,22 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"v.io/v23"
 	"v.io/v23/context"
 	"v.io/v23/naming"
 	"v.io/v23/rpc"
 	"v.io/v23/security"
+	"v.io/v23/security/access"
+	"v.io/v23/services/groups"
 	"v.io/v23/verror"
 	vsecurity "v.io/x/ref/lib/security"
 	_ "v.io/x/ref/runtime/factories/roaming"
+	groupslib "v.io/x/ref/services/groups/lib"
 	"v.io/x/ref/services/role"
 	irole "v.io/x/ref/services/role/roled/internal"
+	"v.io/x/ref/services/succession"
 	"v.io/x/ref/test"
 	"v.io/x/ref/test/testutil"
 )
@@ -136,6 +141,299 @@ func TestSeekBlessings(t *testing.T) {
 	}
 }
 
+func TestBlessSuccessor(t *testing.T) {
+	ctx, shutdown := test.V23InitWithMounttable()
+	defer shutdown()
+
+	workdir, err := ioutil.TempDir("", "test-role-server-")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(workdir)
+
+	roleConf := irole.Config{
+		Members: []security.BlessingPattern{"test-blessing:users:user:_role"},
+		Extend:  true,
+	}
+	irole.WriteConfig(t, roleConf, filepath.Join(workdir, "A.conf"))
+
+	var (
+		root = testutil.IDProviderFromPrincipal(v23.GetPrincipal(ctx))
+		// The key of user is rotated to successor.
+		user      = newPrincipalContext(t, ctx, root, "users:user:_role")
+		successor = newPrincipalContext(t, ctx, root, "users:user:_role")
+		other     = newPrincipalContext(t, ctx, root, "users:user:_role")
+		// The key of user is also rotated to principals that are not
+		// the same member of the role.
+		stranger = newPrincipalContext(t, ctx, root, "users:stranger:_role")
+		renamed  = newPrincipalContext(t, ctx, root, "users:renamed:_role")
+	)
+	addr := newRoleServer(t, newPrincipalContext(t, ctx, root, "roles"), workdir)
+	testServerCtx := newPrincipalContext(t, ctx, root, "testserver")
+	if _, _, err := v23.WithNewDispatchingServer(testServerCtx, "test", &testDispatcher{}); err != nil {
+		t.Fatalf("NewDispatchingServer failed: %v", err)
+	}
+
+	c := role.RoleClient(naming.Join(addr, "A"))
+	old, err := c.SeekBlessings(user)
+	if err != nil {
+		t.Fatalf("SeekBlessings failed: %v", err)
+	}
+	s, err := vsecurity.NewKeySuccession(v23.GetPrincipal(user), v23.GetPrincipal(successor).PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.BlessSuccessor(other, s, old); verror.ErrorID(err) != succession.ErrNotSuccessor.ID {
+		t.Errorf("unexpected error ID. Got %#v, expected %#v", verror.ErrorID(err), succession.ErrNotSuccessor.ID)
+	}
+
+	// The successor must be a member of the role, as for SeekBlessings.
+	for _, successor := range []*context.T{stranger, renamed} {
+		s, err := vsecurity.NewKeySuccession(v23.GetPrincipal(user), v23.GetPrincipal(successor).PublicKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.BlessSuccessor(successor, s, old); verror.ErrorID(err) != verror.ErrNoAccess.ID {
+			t.Errorf("unexpected error ID. Got %#v, expected %#v", verror.ErrorID(err), verror.ErrNoAccess.ID)
+		}
+	}
+	// Only the role blessings that SeekBlessings would grant the successor
+	// are re-issued.
+	roleConf.Members = append(roleConf.Members, "test-blessing:users:renamed:_role")
+	irole.WriteConfig(t, roleConf, filepath.Join(workdir, "A.conf"))
+	sRenamed, err := vsecurity.NewKeySuccession(v23.GetPrincipal(user), v23.GetPrincipal(renamed).PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.BlessSuccessor(renamed, sRenamed, old); verror.ErrorID(err) != vsecurity.ErrNotGrantedByBlesser.ID {
+		t.Errorf("unexpected error ID. Got %#v, expected %#v", verror.ErrorID(err), vsecurity.ErrNotGrantedByBlesser.ID)
+	}
+	// Members that were removed from the role are no longer re-issued their
+	// blessings.
+	irole.WriteConfig(t, irole.Config{Members: []security.BlessingPattern{"test-blessing:users:renamed:_role"}, Extend: true}, filepath.Join(workdir, "A.conf"))
+	if _, err := c.BlessSuccessor(successor, s, old); verror.ErrorID(err) != verror.ErrNoAccess.ID {
+		t.Errorf("unexpected error ID. Got %#v, expected %#v", verror.ErrorID(err), verror.ErrNoAccess.ID)
+	}
+	// Nor are the blessings of roles that require approval.
+	irole.WriteConfig(t, irole.Config{Members: roleConf.Members, Extend: true, RequireApproval: true}, filepath.Join(workdir, "A.conf"))
+	if _, err := c.BlessSuccessor(successor, s, old); verror.ErrorID(err) != role.ErrApprovalRequired.ID {
+		t.Errorf("unexpected error ID. Got %#v, expected %#v", verror.ErrorID(err), role.ErrApprovalRequired.ID)
+	}
+	irole.WriteConfig(t, roleConf, filepath.Join(workdir, "A.conf"))
+
+	blessings, err := c.BlessSuccessor(successor, s, old)
+	if err != nil {
+		t.Fatalf("BlessSuccessor failed: %v", err)
+	}
+	v23.GetPrincipal(successor).BlessingStore().Set(blessings, security.AllPrincipals)
+	blessingNames, rejected := callTest(t, successor, "test")
+	if want := []string{"test-blessing:roles:A:test-blessing:users:user"}; !reflect.DeepEqual(blessingNames, want) {
+		t.Errorf("unexpected blessings. Got %q, expected %q", blessingNames, want)
+	}
+	if len(rejected) != 0 {
+		t.Errorf("unexpected rejected blessings: %q", rejected)
+	}
+}
+
+func TestApproval(t *testing.T) {
+	ctx, shutdown := test.V23InitWithMounttable()
+	defer shutdown()
+
+	workdir, err := ioutil.TempDir("", "test-role-server-")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(workdir)
+
+	roleConf := irole.Config{
+		Members: []security.BlessingPattern{
+			"test-blessing:users:user1:_role",
+			"test-blessing:users:user2:_role",
+		},
+		RequireApproval: true,
+		MaxSession:      "1h",
+	}
+	irole.WriteConfig(t, roleConf, filepath.Join(workdir, "A.conf"))
+	// Role B does not require approval, but its sessions are limited too.
+	irole.WriteConfig(t, irole.Config{Members: roleConf.Members, MaxSession: "1h"}, filepath.Join(workdir, "B.conf"))
+	// The members of role C share a single pattern.
+	irole.WriteConfig(t, irole.Config{Members: []security.BlessingPattern{"test-blessing:users"}, RequireApproval: true, MaxSession: "1h"}, filepath.Join(workdir, "C.conf"))
+
+	var (
+		root       = testutil.IDProviderFromPrincipal(v23.GetPrincipal(ctx))
+		user1      = newPrincipalContext(t, ctx, root, "users:user1:_role")
+		user1Phone = newPrincipalContext(t, ctx, root, "users:user1:_role:phone")
+		user2      = newPrincipalContext(t, ctx, root, "users:user2:_role")
+	)
+	addr := newRoleServer(t, newPrincipalContext(t, ctx, root, "roles"), workdir)
+	testServerCtx := newPrincipalContext(t, ctx, root, "testserver")
+	if _, _, err := v23.WithNewDispatchingServer(testServerCtx, "test", &testDispatcher{}); err != nil {
+		t.Fatalf("NewDispatchingServer failed: %v", err)
+	}
+
+	c := role.RoleClient(naming.Join(addr, "A"))
+	if _, err := c.SeekBlessings(user1); verror.ErrorID(err) != role.ErrApprovalRequired.ID {
+		t.Errorf("unexpected error ID. Got %#v, expected %#v", verror.ErrorID(err), role.ErrApprovalRequired.ID)
+	}
+	if _, err := c.RequestBlessings(user1, "", time.Hour); err == nil {
+		t.Errorf("RequestBlessings without a reason succeeded")
+	}
+	if _, err := c.RequestBlessings(user1, "incident", 2*time.Hour); err == nil {
+		t.Errorf("RequestBlessings longer than MaxSession succeeded")
+	}
+	id, err := c.RequestBlessings(user1, "incident", 30*time.Minute)
+	if err != nil {
+		t.Fatalf("RequestBlessings failed: %v", err)
+	}
+	requests, err := c.ListRequests(user2)
+	if err != nil {
+		t.Fatalf("ListRequests failed: %v", err)
+	}
+	if len(requests) != 1 || requests[0].Id != id || requests[0].Reason != "incident" || requests[0].Duration != 30*time.Minute {
+		t.Errorf("unexpected requests: %#v", requests)
+	}
+
+	if _, err := c.SeekApprovedBlessings(user1, id); verror.ErrorID(err) != role.ErrNotApproved.ID {
+		t.Errorf("unexpected error ID. Got %#v, expected %#v", verror.ErrorID(err), role.ErrNotApproved.ID)
+	}
+	if err := c.ApproveRequest(user1, id); err == nil {
+		t.Errorf("ApproveRequest by the requester succeeded")
+	}
+	// Nor can the requester approve the request with another key.
+	if err := c.ApproveRequest(user1Phone, id); err == nil {
+		t.Errorf("ApproveRequest by the requester with another key succeeded")
+	}
+	if err := c.ApproveRequest(user2, id); err != nil {
+		t.Fatalf("ApproveRequest failed: %v", err)
+	}
+	if _, err := c.SeekApprovedBlessings(user2, id); verror.ErrorID(err) != role.ErrUnknownRequest.ID {
+		t.Errorf("unexpected error ID. Got %#v, expected %#v", verror.ErrorID(err), role.ErrUnknownRequest.ID)
+	}
+	blessings, err := c.SeekApprovedBlessings(user1, id)
+	if err != nil {
+		t.Fatalf("SeekApprovedBlessings failed: %v", err)
+	}
+	if _, err := c.SeekApprovedBlessings(user1, id); verror.ErrorID(err) != role.ErrUnknownRequest.ID {
+		t.Errorf("unexpected error ID. Got %#v, expected %#v", verror.ErrorID(err), role.ErrUnknownRequest.ID)
+	}
+	if expiry := blessings.Expiry(); expiry.IsZero() || expiry.After(time.Now().Add(30*time.Minute)) {
+		t.Errorf("unexpected expiry of the blessings: %v", expiry)
+	}
+	// The reason of the request is reported on each use of the blessings,
+	// although role A is not audited.
+	if got := len(blessings.ThirdPartyCaveats()); got != 1 {
+		t.Errorf("unexpected number of third-party caveats. Got %d, expected 1", got)
+	}
+	v23.GetPrincipal(user1).BlessingStore().Set(blessings, security.AllPrincipals)
+	blessingNames, rejected := callTest(t, user1, "test")
+	if want := []string{"test-blessing:roles:A"}; !reflect.DeepEqual(blessingNames, want) {
+		t.Errorf("unexpected blessings. Got %q, expected %q", blessingNames, want)
+	}
+	if len(rejected) != 0 {
+		t.Errorf("unexpected rejected blessings: %q", rejected)
+	}
+
+	blessings, err = role.RoleClient(naming.Join(addr, "B")).SeekBlessings(user2)
+	if err != nil {
+		t.Fatalf("SeekBlessings failed: %v", err)
+	}
+	if expiry := blessings.Expiry(); expiry.IsZero() || expiry.After(time.Now().Add(time.Hour)) {
+		t.Errorf("unexpected expiry of the blessings: %v", expiry)
+	}
+
+	// Distinct members matching the same pattern can approve each other's
+	// requests, but the requester still can't.
+	c = role.RoleClient(naming.Join(addr, "C"))
+	if id, err = c.RequestBlessings(user1, "incident", 30*time.Minute); err != nil {
+		t.Fatalf("RequestBlessings failed: %v", err)
+	}
+	if err := c.ApproveRequest(user1Phone, id); err == nil {
+		t.Errorf("ApproveRequest by the requester with another key succeeded")
+	}
+	if err := c.ApproveRequest(user2, id); err != nil {
+		t.Fatalf("ApproveRequest failed: %v", err)
+	}
+	if _, err := c.SeekApprovedBlessings(user1, id); err != nil {
+		t.Errorf("SeekApprovedBlessings failed: %v", err)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	ctx, shutdown := test.V23InitWithMounttable()
+	defer shutdown()
+
+	workdir, err := ioutil.TempDir("", "test-role-server-")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(workdir)
+
+	root := testutil.IDProviderFromPrincipal(v23.GetPrincipal(ctx))
+	groupsDisp, err := groupslib.NewGroupsDispatcher("", "memstore")
+	if err != nil {
+		t.Fatalf("NewGroupsDispatcher failed: %v", err)
+	}
+	if _, _, err := v23.WithNewDispatchingServer(newPrincipalContext(t, ctx, root, "groups"), "groups", groupsDisp); err != nil {
+		t.Fatalf("NewDispatchingServer failed: %v", err)
+	}
+	perms := access.Permissions{}
+	for _, tag := range access.AllTypicalTags() {
+		perms.Add("test-blessing", string(tag))
+	}
+	if err := groups.GroupClient("groups/admins").Create(ctx, perms, []groups.BlessingPatternChunk{"test-blessing:users:user2"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	roleConf := irole.Config{
+		Members: []security.BlessingPattern{"test-blessing:users:user1"},
+		Groups:  []string{"groups/admins"},
+		Extend:  true,
+	}
+	irole.WriteConfig(t, roleConf, filepath.Join(workdir, "A.conf"))
+
+	var (
+		user1 = newPrincipalContext(t, ctx, root, "users:user1:_role")
+		user2 = newPrincipalContext(t, ctx, root, "users:user2:_role")
+		user3 = newPrincipalContext(t, ctx, root, "users:user3:_role")
+		// Not a role name.
+		user2NoRole = newPrincipalContext(t, ctx, root, "users:user2")
+	)
+	addr := newRoleServer(t, newPrincipalContext(t, ctx, root, "roles"), workdir)
+	testServerCtx := newPrincipalContext(t, ctx, root, "testserver")
+	if _, _, err := v23.WithNewDispatchingServer(testServerCtx, "test", &testDispatcher{}); err != nil {
+		t.Fatalf("NewDispatchingServer failed: %v", err)
+	}
+
+	testcases := []struct {
+		ctx       *context.T
+		errID     verror.ID
+		blessings []string
+	}{
+		{user1, "", []string{"test-blessing:roles:A:test-blessing:users:user1"}},
+		{user2, "", []string{"test-blessing:roles:A:test-blessing:users:user2"}},
+		{user3, verror.ErrNoAccess.ID, nil},
+		{user2NoRole, verror.ErrNoAccess.ID, nil},
+	}
+	c := role.RoleClient(naming.Join(addr, "A"))
+	for i, tc := range testcases {
+		blessings, err := c.SeekBlessings(tc.ctx)
+		if verror.ErrorID(err) != tc.errID {
+			t.Errorf("unexpected error ID for #%d. Got %#v, expected %#v", i, verror.ErrorID(err), tc.errID)
+		}
+		if err != nil {
+			continue
+		}
+		v23.GetPrincipal(tc.ctx).BlessingStore().Set(blessings, security.AllPrincipals)
+		blessingNames, rejected := callTest(t, tc.ctx, "test")
+		if !reflect.DeepEqual(blessingNames, tc.blessings) {
+			t.Errorf("unexpected blessings for #%d. Got %q, expected %q", i, blessingNames, tc.blessings)
+		}
+		if len(rejected) != 0 {
+			t.Errorf("unexpected rejected blessings for #%d: %q", i, rejected)
+		}
+	}
+}
+
 func TestPeerBlessingCaveats(t *testing.T) {
 	ctx, shutdown := test.V23InitWithMounttable()
 	defer shutdown()
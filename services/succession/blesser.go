@@ -0,0 +1,81 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package succession
+
+import (
+	"bytes"
+	"time"
+
+	"v.io/v23"
+	"v.io/v23/context"
+	"v.io/v23/rpc"
+	"v.io/v23/security"
+	vsecurity "v.io/x/ref/lib/security"
+)
+
+// DefaultMaxAge is the age of the oldest key succession accepted by blessers
+// by default.  Key successions are expected to be presented to the blessers
+// right after the key is rotated.
+const DefaultMaxAge = 24 * time.Hour
+
+// NewBlesser returns an implementation of SuccessorBlesser that re-issues the
+// blessings granted by the principal of the server, with
+// vsecurity.BlessSuccessor.  Key successions older than maxAge are rejected,
+// unless maxAge is zero.
+//
+// The caller is authenticated by the key succession, so the service is
+// typically authorized with security.AllowEveryone.
+func NewBlesser(maxAge time.Duration) SuccessorBlesserServerMethods {
+	return &blesser{maxAge}
+}
+
+type blesser struct {
+	maxAge time.Duration
+}
+
+func (b *blesser) BlessSuccessor(ctx *context.T, call rpc.ServerCall, succession vsecurity.KeySuccession, blessings security.Blessings) (security.Blessings, error) {
+	caller := call.Security().RemoteBlessings().PublicKey()
+	if caller == nil {
+		return security.Blessings{}, NewErrNotSuccessor(ctx)
+	}
+	if der, err := caller.MarshalBinary(); err != nil || !bytes.Equal(der, succession.NewPublicKey) {
+		return security.Blessings{}, NewErrNotSuccessor(ctx)
+	}
+	if b.maxAge > 0 && time.Since(succession.Timestamp) > b.maxAge {
+		return security.Blessings{}, NewErrSuccessionTooOld(ctx, succession.Timestamp)
+	}
+	reissued, err := vsecurity.BlessSuccessor(v23.GetPrincipal(ctx), succession, blessings)
+	if err != nil {
+		return security.Blessings{}, err
+	}
+	ctx.Infof("Re-issued %v to the successor of %v", reissued, blessings)
+	return reissued, nil
+}
+
+// FilterBlessings returns the blessings in b whose certificate chains satisfy
+// keep.  It is used by blessers that re-issue only some of the blessings they
+// granted, e.g. those that the successor would still be granted, before
+// calling BlessSuccessor.  If no chain satisfies keep, it returns the zero
+// blessings.
+func FilterBlessings(b security.Blessings, keep func(chain []security.Certificate) bool) (security.Blessings, error) {
+	var wire security.WireBlessings
+	if err := security.WireBlessingsFromNative(&wire, b); err != nil {
+		return security.Blessings{}, err
+	}
+	var chains [][]security.Certificate
+	for _, chain := range wire.CertificateChains {
+		if len(chain) > 0 && keep(chain) {
+			chains = append(chains, chain)
+		}
+	}
+	if len(chains) == 0 {
+		return security.Blessings{}, nil
+	}
+	var filtered security.Blessings
+	if err := security.WireBlessingsToNative(security.WireBlessings{CertificateChains: chains}, &filtered); err != nil {
+		return security.Blessings{}, err
+	}
+	return filtered, nil
+}
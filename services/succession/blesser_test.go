@@ -0,0 +1,114 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package succession_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"v.io/v23"
+	"v.io/v23/context"
+	"v.io/v23/security"
+	"v.io/v23/verror"
+	vsecurity "v.io/x/ref/lib/security"
+	_ "v.io/x/ref/runtime/factories/generic"
+	"v.io/x/ref/services/succession"
+	"v.io/x/ref/test"
+	"v.io/x/ref/test/testutil"
+)
+
+func withPrincipal(t *testing.T, ctx *context.T, p security.Principal) *context.T {
+	ctx, err := v23.WithPrincipal(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx
+}
+
+func TestBlessSuccessor(t *testing.T) {
+	ctx, shutdown := test.V23Init()
+	defer shutdown()
+
+	idp := testutil.NewIDProvider("root")
+	serverP := testutil.NewPrincipal()
+	if err := idp.Bless(serverP, "blesser"); err != nil {
+		t.Fatal(err)
+	}
+	serverCtx := withPrincipal(t, ctx, serverP)
+	_, server, err := v23.WithNewServer(serverCtx, "", succession.SuccessorBlesserServer(succession.NewBlesser(time.Hour)), security.AllowEveryone())
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := server.Status().Endpoints[0].Name()
+
+	// The old principal was blessed by the server.
+	oldP := testutil.NewPrincipal()
+	blesserDefault, _ := serverP.BlessingStore().Default()
+	old, err := serverP.Bless(oldP.PublicKey(), blesserDefault, "alice", security.UnconstrainedUse())
+	if err != nil {
+		t.Fatal(err)
+	}
+	newP := testutil.NewPrincipal()
+	if err := idp.Bless(newP, "self"); err != nil {
+		t.Fatal(err)
+	}
+	s, err := vsecurity.NewKeySuccession(oldP, newP.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := succession.SuccessorBlesserClient(name)
+	b, err := client.BlessSuccessor(withPrincipal(t, ctx, newP), s, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := security.BlessingNames(newP, b), []string{"root:blesser:alice"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Only the successor may use the succession.
+	otherP := testutil.NewPrincipal()
+	if err := idp.Bless(otherP, "other"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.BlessSuccessor(withPrincipal(t, ctx, otherP), s, old); verror.ErrorID(err) != succession.ErrNotSuccessor.ID {
+		t.Errorf("got %v, want %v", err, succession.ErrNotSuccessor.ID)
+	}
+
+	// Old successions are rejected.
+	s.Timestamp = s.Timestamp.Add(-2 * time.Hour)
+	if _, err := client.BlessSuccessor(withPrincipal(t, ctx, newP), s, old); verror.ErrorID(err) != succession.ErrSuccessionTooOld.ID {
+		t.Errorf("got %v, want %v", err, succession.ErrSuccessionTooOld.ID)
+	}
+}
+
+func TestFilterBlessings(t *testing.T) {
+	p := testutil.NewPrincipal()
+	idp := testutil.NewIDProvider("root")
+	if err := idp.Bless(p, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	alice, _ := p.BlessingStore().Default()
+	bob, err := idp.NewBlessings(p, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	union, err := security.UnionOfBlessings(alice, bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extension := func(want string) func([]security.Certificate) bool {
+		return func(chain []security.Certificate) bool {
+			return chain[len(chain)-1].Extension == want
+		}
+	}
+	if got, err := succession.FilterBlessings(union, extension("bob")); err != nil || !got.Equivalent(bob) {
+		t.Errorf("got (%v, %v), want (%v, nil)", got, err, bob)
+	}
+	if got, err := succession.FilterBlessings(union, extension("carol")); err != nil || !got.IsZero() {
+		t.Errorf("got (%v, %v), want zero blessings", got, err)
+	}
+}
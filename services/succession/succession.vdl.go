@@ -0,0 +1,191 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file was auto-generated by the vanadium vdl tool.
+// Package: succession
+
+// Package succession defines an interface for re-issuing blessings to the
+// successor of the public key of a principal.
+package succession
+
+import (
+	"time"
+	"v.io/v23"
+	"v.io/v23/context"
+	"v.io/v23/i18n"
+	"v.io/v23/rpc"
+	"v.io/v23/security"
+	"v.io/v23/verror"
+	vsecurity "v.io/x/ref/lib/security"
+)
+
+var _ = __VDLInit() // Must be first; see __VDLInit comments for details.
+
+//////////////////////////////////////////////////
+// Error definitions
+
+var (
+
+	// Indicates that the caller is not the successor in the key succession.
+	ErrNotSuccessor = verror.Register("v.io/x/ref/services/succession.NotSuccessor", verror.NoRetry, "{1:}{2:} the public key of the caller is not the successor in the key succession")
+	// Indicates that the key succession is older than the blesser accepts.
+	ErrSuccessionTooOld = verror.Register("v.io/x/ref/services/succession.SuccessionTooOld", verror.NoRetry, "{1:}{2:} the key succession made at {3} is too old")
+)
+
+// NewErrNotSuccessor returns an error with the ErrNotSuccessor ID.
+func NewErrNotSuccessor(ctx *context.T) error {
+	return verror.New(ErrNotSuccessor, ctx)
+}
+
+// NewErrSuccessionTooOld returns an error with the ErrSuccessionTooOld ID.
+func NewErrSuccessionTooOld(ctx *context.T, timestamp time.Time) error {
+	return verror.New(ErrSuccessionTooOld, ctx, timestamp)
+}
+
+//////////////////////////////////////////////////
+// Interface definitions
+
+// SuccessorBlesserClientMethods is the client interface
+// containing SuccessorBlesser methods.
+//
+// SuccessorBlesser is the interface of blessers that re-issue the blessings
+// they granted to a principal when the principal's key is rotated.
+type SuccessorBlesserClientMethods interface {
+	// BlessSuccessor is called by the successor in Succession, a statement
+	// that the public key of the caller succeeds the public key of Blessings,
+	// and returns blessings for the caller equivalent to those in Blessings
+	// that were granted by this blesser.
+	BlessSuccessor(_ *context.T, Succession vsecurity.KeySuccession, Blessings security.Blessings, _ ...rpc.CallOpt) (security.Blessings, error)
+}
+
+// SuccessorBlesserClientStub adds universal methods to SuccessorBlesserClientMethods.
+type SuccessorBlesserClientStub interface {
+	SuccessorBlesserClientMethods
+	rpc.UniversalServiceMethods
+}
+
+// SuccessorBlesserClient returns a client stub for SuccessorBlesser.
+func SuccessorBlesserClient(name string) SuccessorBlesserClientStub {
+	return implSuccessorBlesserClientStub{name}
+}
+
+type implSuccessorBlesserClientStub struct {
+	name string
+}
+
+func (c implSuccessorBlesserClientStub) BlessSuccessor(ctx *context.T, i0 vsecurity.KeySuccession, i1 security.Blessings, opts ...rpc.CallOpt) (o0 security.Blessings, err error) {
+	err = v23.GetClient(ctx).Call(ctx, c.name, "BlessSuccessor", []interface{}{i0, i1}, []interface{}{&o0}, opts...)
+	return
+}
+
+// SuccessorBlesserServerMethods is the interface a server writer
+// implements for SuccessorBlesser.
+//
+// SuccessorBlesser is the interface of blessers that re-issue the blessings
+// they granted to a principal when the principal's key is rotated.
+type SuccessorBlesserServerMethods interface {
+	// BlessSuccessor is called by the successor in Succession, a statement
+	// that the public key of the caller succeeds the public key of Blessings,
+	// and returns blessings for the caller equivalent to those in Blessings
+	// that were granted by this blesser.
+	BlessSuccessor(_ *context.T, _ rpc.ServerCall, Succession vsecurity.KeySuccession, Blessings security.Blessings) (security.Blessings, error)
+}
+
+// SuccessorBlesserServerStubMethods is the server interface containing
+// SuccessorBlesser methods, as expected by rpc.Server.
+// There is no difference between this interface and SuccessorBlesserServerMethods
+// since there are no streaming methods.
+type SuccessorBlesserServerStubMethods SuccessorBlesserServerMethods
+
+// SuccessorBlesserServerStub adds universal methods to SuccessorBlesserServerStubMethods.
+type SuccessorBlesserServerStub interface {
+	SuccessorBlesserServerStubMethods
+	// Describe the SuccessorBlesser interfaces.
+	Describe__() []rpc.InterfaceDesc
+}
+
+// SuccessorBlesserServer returns a server stub for SuccessorBlesser.
+// It converts an implementation of SuccessorBlesserServerMethods into
+// an object that may be used by rpc.Server.
+func SuccessorBlesserServer(impl SuccessorBlesserServerMethods) SuccessorBlesserServerStub {
+	stub := implSuccessorBlesserServerStub{
+		impl: impl,
+	}
+	// Initialize GlobState; always check the stub itself first, to handle the
+	// case where the user has the Glob method defined in their VDL source.
+	if gs := rpc.NewGlobState(stub); gs != nil {
+		stub.gs = gs
+	} else if gs := rpc.NewGlobState(impl); gs != nil {
+		stub.gs = gs
+	}
+	return stub
+}
+
+type implSuccessorBlesserServerStub struct {
+	impl SuccessorBlesserServerMethods
+	gs   *rpc.GlobState
+}
+
+func (s implSuccessorBlesserServerStub) BlessSuccessor(ctx *context.T, call rpc.ServerCall, i0 vsecurity.KeySuccession, i1 security.Blessings) (security.Blessings, error) {
+	return s.impl.BlessSuccessor(ctx, call, i0, i1)
+}
+
+func (s implSuccessorBlesserServerStub) Globber() *rpc.GlobState {
+	return s.gs
+}
+
+func (s implSuccessorBlesserServerStub) Describe__() []rpc.InterfaceDesc {
+	return []rpc.InterfaceDesc{SuccessorBlesserDesc}
+}
+
+// SuccessorBlesserDesc describes the SuccessorBlesser interface.
+var SuccessorBlesserDesc rpc.InterfaceDesc = descSuccessorBlesser
+
+// descSuccessorBlesser hides the desc to keep godoc clean.
+var descSuccessorBlesser = rpc.InterfaceDesc{
+	Name:    "SuccessorBlesser",
+	PkgPath: "v.io/x/ref/services/succession",
+	Doc:     "// SuccessorBlesser is the interface of blessers that re-issue the blessings\n// they granted to a principal when the principal's key is rotated.",
+	Methods: []rpc.MethodDesc{
+		{
+			Name: "BlessSuccessor",
+			Doc:  "// BlessSuccessor is called by the successor in Succession, a statement\n// that the public key of the caller succeeds the public key of Blessings,\n// and returns blessings for the caller equivalent to those in Blessings\n// that were granted by this blesser.",
+			InArgs: []rpc.ArgDesc{
+				{"Succession", ``}, // vsecurity.KeySuccession
+				{"Blessings", ``},  // security.Blessings
+			},
+			OutArgs: []rpc.ArgDesc{
+				{"", ``}, // security.Blessings
+			},
+		},
+	},
+}
+
+var __VDLInitCalled bool
+
+// __VDLInit performs vdl initialization.  It is safe to call multiple times.
+// If you have an init ordering issue, just insert the following line verbatim
+// into your source files in this package, right after the "package foo" clause:
+//
+//    var _ = __VDLInit()
+//
+// The purpose of this function is to ensure that vdl initialization occurs in
+// the right order, and very early in the init sequence.  In particular, vdl
+// registration and package variable initialization needs to occur before
+// functions like vdl.TypeOf will work properly.
+//
+// This function returns a dummy value, so that it can be used to initialize the
+// first var in the file, to take advantage of Go's defined init order.
+func __VDLInit() struct{} {
+	if __VDLInitCalled {
+		return struct{}{}
+	}
+	__VDLInitCalled = true
+
+	// Set error format strings.
+	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrNotSuccessor.ID), "{1:}{2:} the public key of the caller is not the successor in the key succession")
+	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrSuccessionTooOld.ID), "{1:}{2:} the key succession made at {3} is too old")
+
+	return struct{}{}
+}